@@ -0,0 +1,188 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hweidner/psync/pkg/treetest"
+)
+
+// Function bench implements the subcommand "psync bench", which copies a
+// source tree several times with different numbers of threads, and with each
+// setting of the engine and the scheduler of package treetest, into temporary
+// destinations, and prints the time and throughput of each run. Each run is
+// done by a separate psync process, so that the runs do not influence each
+// other. Without a source, a synthetic tree is generated as the source, the
+// same as in the benchmarks of package psync.
+func bench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	threadList := fs.String("threads", "1,2,4,8,16,32", "Comma separated list of thread counts to measure")
+	runs := fs.Uint("runs", 1, "Number of runs per thread count, the fastest run is reported")
+	tmp := fs.String("temp", "", "Directory for the temporary destinations (default: system temp directory)")
+	nfiles := fs.Uint("files", 10000, "Number of files of the synthetic tree")
+	settingList := fs.String("settings", "all", "Comma separated list of engine and scheduler settings to measure: "+settingNames())
+	sizeList := fs.String("sizes", treetest.DefaultSizes, "Size distribution of the synthetic tree, as comma separated percent:size pairs")
+	depth := fs.Uint("depth", 3, "Depth of the directories of the synthetic tree")
+	width := fs.Uint("width", 4, "Number of subdirectories per directory of the synthetic tree")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: psync bench [options] [source]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 || *runs == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var counts []uint
+	for _, s := range strings.Split(*threadList, ",") {
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+		if err != nil || n == 0 || n > 1024 {
+			fmt.Fprintf(os.Stderr, "ERROR - invalid thread count %s\n", s)
+			os.Exit(1)
+		}
+		counts = append(counts, uint(n))
+	}
+	settings, err := parseSettings(*settingList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+		os.Exit(1)
+	}
+
+	// generate a synthetic source tree, if none is given
+	source := fs.Arg(0)
+	if source != "" {
+		err := benchTree(source, *tmp, counts, settings, *runs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	sizes, err := treetest.ParseSizes(*sizeList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+		os.Exit(1)
+	}
+	if source, err = ioutil.TempDir(*tmp, "psync-bench-src"); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - cannot create synthetic tree: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Generating synthetic tree %s\n", source)
+	err = treetest.Synthetic(source, int(*nfiles), sizes, int(*depth), int(*width))
+	if err != nil {
+		err = fmt.Errorf("cannot create synthetic tree: %s", err)
+	} else {
+		err = benchTree(source, *tmp, counts, settings, *runs)
+	}
+	os.RemoveAll(source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Function settingNames returns the names of the engine and scheduler
+// settings, separated by commas.
+func settingNames() string {
+	var names []string
+	for _, s := range append(treetest.Engines, treetest.Schedulers...) {
+		names = append(names, s.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Function parseSettings returns the engine and scheduler settings of a comma
+// separated list of names, or all of them for "all".
+func parseSettings(list string) ([]treetest.Setting, error) {
+	all := append(append([]treetest.Setting(nil), treetest.Engines...), treetest.Schedulers...)
+	if list == "all" {
+		return all, nil
+	}
+	var settings []treetest.Setting
+next:
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		for _, s := range all {
+			if s.Name == name {
+				settings = append(settings, s)
+				continue next
+			}
+		}
+		return nil, fmt.Errorf("unknown setting %s, known are %s", name, settingNames())
+	}
+	return settings, nil
+}
+
+// Function benchTree copies the source tree with each of the settings and
+// thread counts, and prints the results.
+func benchTree(source, tmp string, counts []uint, settings []treetest.Setting, runs uint) error {
+	// measure the size of the source tree
+	var files, bytes int64
+	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files++
+			bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot read source %s: %s", source, err)
+	}
+	fmt.Printf("Source %s: %d files, %d bytes\n\n", source, files, bytes)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the psync executable: %s", err)
+	}
+
+	fmt.Printf("%-12s %8s %12s %10s %10s\n", "Setting", "Threads", "Time", "Files/s", "MB/s")
+	for _, s := range settings {
+		for _, n := range counts {
+			best := time.Duration(0)
+			for r := uint(0); r < runs; r++ {
+				d, err := benchRun(exe, source, tmp, n, s.Args(n))
+				if err != nil {
+					return fmt.Errorf("benchmark %s with %d threads failed: %s", s.Name, n, err)
+				}
+				if best == 0 || d < best {
+					best = d
+				}
+			}
+			sec := best.Seconds()
+			fmt.Printf("%-12s %8d %12s %10.0f %10.2f\n", s.Name, n, best.Round(time.Millisecond),
+				float64(files)/sec, float64(bytes)/sec/1e6)
+		}
+	}
+	return nil
+}
+
+// Function benchRun copies the source into a new temporary directory with
+// the given number of threads and flags, removes the copy, and returns the
+// duration of the copy.
+func benchRun(exe, source, tmp string, threads uint, flags []string) (time.Duration, error) {
+	target, err := ioutil.TempDir(tmp, "psync-bench")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(target)
+
+	args := append([]string{"-quiet", "-threads", strconv.Itoa(int(threads))}, flags...)
+	cmd := exec.Command(exe, append(args, source, target)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	begin := time.Now()
+	err = cmd.Run()
+	return time.Since(begin), err
+}
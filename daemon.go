@@ -0,0 +1,90 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hweidner/psync/pkg/daemon"
+)
+
+// Function runDaemon implements "psync --daemon", which exports directory
+// trees as modules over the psync network protocol. Clients access them as
+// host[:port]::module/path. Without a secret, the modules are exported
+// read-only.
+func runDaemon(args []string) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	listen := fs.String("listen", ":"+strconv.Itoa(daemon.DefaultPort), "TCP address to listen on")
+	readOnly := fs.Bool("read-only", false, "Reject all modifications of the modules (implied without a secret)")
+	secretFile := fs.String("secret-file", "", "File with the shared secret of the clients (default: $PSYNC_SECRET)")
+	verbose := fs.Bool("v", false, "Log the connections of clients")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: psync --daemon [options] module=directory ...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	s := &daemon.Server{Modules: make(map[string]string), ReadOnly: *readOnly}
+	for _, m := range fs.Args() {
+		i := strings.Index(m, "=")
+		if i <= 0 || i == len(m)-1 || strings.Contains(m[:i], "/") {
+			fmt.Fprintf(os.Stderr, "ERROR - invalid module %s, use name=directory\n", m)
+			os.Exit(1)
+		}
+		dir, err := filepath.Abs(m[i+1:])
+		if err == nil {
+			var st os.FileInfo
+			if st, err = os.Stat(dir); err == nil && !st.IsDir() {
+				err = fmt.Errorf("%s is not a directory", dir)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR - invalid module %s: %s\n", m, err)
+			os.Exit(1)
+		}
+		s.Modules[m[:i]] = dir
+	}
+
+	if *secretFile != "" {
+		b, err := ioutil.ReadFile(*secretFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR - cannot read secret: %s\n", err)
+			os.Exit(1)
+		}
+		s.Secret = []byte(strings.TrimSpace(string(b)))
+	} else if secret := os.Getenv("PSYNC_SECRET"); secret != "" {
+		s.Secret = []byte(secret)
+	}
+	if *verbose {
+		s.Log = os.Stdout
+	}
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+		os.Exit(1)
+	}
+	if s.Secret == nil {
+		fmt.Fprintln(os.Stderr, "WARNING - no secret given, all clients are accepted, and the modules are read-only")
+	}
+	if *verbose {
+		fmt.Fprintf(os.Stdout, "Listening on %s\n", l.Addr())
+	}
+	if err := s.Serve(l); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+		os.Exit(1)
+	}
+}
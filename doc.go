@@ -15,25 +15,766 @@ systems are usually latency bound, especially with small files.
 Parallel execution can help to utilize the bandwidth better and avoid that
 the latencies sum up, as this is the case in sequential operations.
 
-Currently, psync does only copy directory trees, similar to "cp -r". A "sync"
-mode, similar to "rsync -rl" is planned. See GOALS.md on how psync finally may
-look like. A first version of the sync mode can be found in the branch
-`syncmode`.
+By default, psync copies directory trees, similar to "cp -r". With -sync, it
+works in a "sync" mode similar to "rsync -rl", and copies only files which are
+missing on the destination side or differ in size or modification time.
+Deleting destination entries which do not exist on the source side is not
+supported yet. See GOALS.md on how psync finally may look like.
 
 Usage
 
 psync is invoked as follows:
 
-	psync [-verbose|-quiet] [-threads <num>] [-owner] [-times] [-create] source destination
+	psync [-v|-vv|-vvv|-verbose|-quiet] [-threads <num>] [-data-threads <num>]
+	      [-owner] [-times] [-create] [-stats] [-events <file>] [-progress]
+	      [-stall <dur>] [-shard <tmpl>] [-shard-hash <hash>]
+	      [-shard-manifest <file>] [-filter-from <file>] [-exclude-from <file>]
+	      [-only-type <types>] [-skip-symlinks]
+	      [-sync] [-size-only] [-ignore-times] [-update] [-interactive]
+	      [-existing-links <mode>] [-iops <num>] [-checkpoint <file>] [-resume]
+	      [-keep-atime] [-events-fd <num>]
+	      [-retries <num>] [-retry-delay <dur>] [-max-errors <num>] [-strict]
+	      [-file-progress <MB>] [-errors-to <file>] [-files-from <file>] [-from0]
+	      [-lock] [-lock-timeout <dur>] [-chunk-journal <MB>] [-partial-dir <dir>]
+	      [-temp-dir <dir>] [-nfs-conns <num>] [-file-timeout <dur>] [-since-last]
+	      [-state <file>] [-ssh <command>] [-sftp-conns <num>] [-s3-endpoint <url>]
+	      [-secret-file <file>] [-delta] [-whole-file] [-compress] [-conflicts <mode>]
+	      [-verify] [-verify-after] [-unstable <mode>] [-checksum-choice <hash>]
+	      [-state-db <file>] [-watch] [-interval <dur>] [-link-dest <dir>] [-dedup]
+	      [-two-way] [-resolve <mode>] [-normalize <form>] [-case-collisions <mode>]
+	      [-force] [-no-perms] [-copy-range]
+	      [-chmod <rules>] [-chown <owner>] [-usermap <file>] [-groupmap <file>]
+	      [-fake-super] [-copy-devices] [-numeric-ids] [-rsync-slash]
+	      [-drop-cache <which>] [-preallocate] [-sparse] [-traversal <t>]
+	      [-prefetch <num>] [-order <order>] [-fsync] [-fsync-dir] [-max-queue <num>]
+	      [-spill <num>] [-estimate] [-stats-out <file>] [-audit-log <file>]
+	      [-notify-url <url>] [-notify-cmd <command>] [-pre-cmd <command>]
+	      [-post-cmd <command>] [-file-cmd <command>] [-deterministic]
+	      source [source ...] destination
 
-	-verbose        - verbose mode, prints the current workload to STDOUT
+	-v              - verbose mode, prints created and updated entries to STDOUT
+	-vv             - more verbose mode, prints also skipped entries and metadata operations
+	-vvv            - most verbose mode, prints also the scheduling of the threads
+	-verbose        - same as -vvv
 	-quiet          - quiet mode, suppress warnings
 	-threads <num>  - number of concurrent threads, 1 <= <num> <= 1024, default 16
+	-data-threads <num>
+	                - number of separate threads copying the files, while the threads of
+	                  -threads only read the directories, 0 <= <num> <= 1024, default 0
+	-traversal <t>  - traverse the tree depth-first (dfs, default) or breadth-first (bfs)
+	-max-queue <num>
+	                - maximum number of directories waiting for a worker, default 0 (no limit)
+	-spill <num>    - move parts of the work list to a temporary file beyond <num> directories
+	                  waiting for a worker, default 0 (keep it in memory)
+	-order <order>  - copy the files of each directory by size, small or large files first
+	-deterministic  - copy with a single thread, in the order of the names, for reproducible runs
+	-fsync          - sync each destination file to the storage after copying it
+	-fsync-dir      - sync each destination directory to the storage after creating its entries
+	-prefetch <num> - number of scanners reading directories ahead of the workers, default 0
+	-preallocate    - allocate the blocks of destination files before copying them (Linux only)
+	-copy-range     - copy the content of local files in the kernel, with copy_file_range(2)
+	                  (Linux only)
+	-sparse         - leave blocks of zeros as holes in the destination files (local destinations)
+	-drop-cache <which>
+	                - drop the copied files from the page cache after each file: source,
+	                  dest or both; destination files are written back first (Linux only)
 	-owner          - preserve ownership (user / group)
 	-times          - preserve timestamps (atime / mtime)
 	-create         - create destination directory, if needed (with standard permissions)
-	source          - source directory
-	destination     - destination directory
+	-stats          - print statistics, grouped by top level directory and per thread, at the end
+	-stats-out <file>
+	                - write a JSON summary of the run (counters, durations, errors by
+	                  category, threads, options used) to <file>
+	-events <file>  - write an NDJSON event per completed or failed entry to <file> ('-' for STDOUT)
+	-audit-log <file>
+	                - append an NDJSON record of each change of the destination, with the
+	                  metadata before and after it, to <file>
+	-notify-url <url>
+	                - post the summary of the run as JSON to <url> when it ends or aborts
+	-notify-cmd <command>
+	                - run <command> with the summary of the run as JSON on STDIN when it
+	                  ends or aborts
+	-pre-cmd <command>
+	                - run <command> before the copy, the run fails if it fails
+	-post-cmd <command>
+	                - run <command> after the copy, also if the copy failed
+	-file-cmd <command>
+	                - run <command> for each copied, linked, created or deleted entry
+	-progress       - print progress and a moving average of the throughput every 5 seconds
+	-estimate       - scan the source first, for the percentage done and the time left in
+	                  the progress output
+	-stall <dur>    - warn with the blocked paths when no progress was made for <dur>
+	                  (e.g. 90s, 5m), default 1m, 0 disables stall detection
+	-shard <tmpl>   - shard destination names by a template, for object store destinations.
+	                  Placeholders: {hash}, {hash:n} (first n hex digits), {hash:o:n} (n digits
+	                  at offset o) of the hashed source path, {path}, {dir} and {name}.
+	                  Example: '{hash:2}/{hash:2:2}/{path}'
+	-shard-hash <hash>
+	                - hash function used for sharding: md5 (default), sha1, sha256, fnv
+	-shard-manifest <file>
+	                - NDJSON manifest mapping destination keys to source paths,
+	                  default <destination>/.psync-manifest
+	-filter-from <file>
+	                - read filter rules in rsync syntax (+/- rules, merge and dir-merge
+	                  rules) from <file>, may be repeated
+	-exclude-from <file>
+	                - read exclude patterns in rsync syntax from <file>, may be repeated
+	-only-type <types>
+	                - copy only entries of the given types, like with find -type: f, d, l,
+	                  b, c, p or s, separated by commas (e.g. f,l)
+	-skip-symlinks  - do not copy symbolic links
+	-sync           - sync mode, copy only files which are missing or differ in size or mtime
+	-size-only      - copy only files which are missing or differ in size, implies -sync
+	-ignore-times   - copy all files, also those which look up to date; with -verify, do not
+	                  compare the modification times
+	-update         - keep destination files which are newer than their source files,
+	                  implies -sync
+	-interactive    - ask before overwriting destination files which differ, implies -sync
+	-existing-links <mode>
+	                - handling of existing links on the destination side pointing elsewhere:
+	                  warn (default), skip, or replace (atomically, via a temporary link)
+	-iops <num>     - limit the metadata operations (open, create, stat, readdir, chown, ...)
+	                  to <num> per second, independent of the bandwidth
+	-checkpoint <file>
+	                - checkpoint file listing the unhandled directories when psync is interrupted,
+	                  default <destination>/.psync-checkpoint
+	-resume         - resume an interrupted run from the checkpoint file
+	-keep-atime     - restore the access times of the source directories after reading them
+	-events-fd <num>
+	                - write the event stream to the open file descriptor <num> instead of a file,
+	                  e.g. 'psync -events-fd 3 src dest 3>events.json'
+	-retries <num>  - retry operations failing with transient errors (ESTALE, EIO, ETIMEDOUT, ...)
+	                  <num> times, default 0
+	-retry-delay <dur>
+	                - initial delay between retries, doubled with each attempt, default 1s
+	-max-errors <num>
+	                - abort after more than <num> errors (warnings), after finishing the
+	                  files in progress; 0 means no limit (default)
+	-file-progress <MB>
+	                - report the progress (percentage, throughput, ETA) of files larger than
+	                  <MB> megabytes in verbose or progress mode, default 1024, 0 to disable
+	-errors-to <file>
+	                - write the failed paths to <file>, one per line, followed by the error
+	                  category and message, separated by tabs
+	-files-from <file>
+	                - copy only the paths listed in <file> (- for STDIN), one per line and
+	                  relative to the source directory; directories are copied recursively,
+	                  everything after a tab is ignored, so the report of -errors-to can be used
+	-from0          - the paths of -files-from are separated by NUL characters instead of
+	                  newlines, like the output of find -print0
+	-lock           - take a lease on the destination directory, so that concurrent runs
+	                  from other hosts wait for each other
+	-lock-timeout <dur>
+	                - take over a lease whose heartbeat is older than <dur>, default 2m
+	-chunk-journal <MB>
+	                - copy files larger than <MB> megabytes in chunks, and journal the hashes
+	                  of the chunks in a hidden file next to the destination file; an
+	                  interrupted copy is resumed after the chunks which are verified, default 0 (off)
+	-partial-dir <dir>
+	                - write the files to <dir> in the destination directory while they are copied,
+	                  and move them to their place when they are complete, e.g. .psync-partial
+	-temp-dir <dir> - write the temporary files of delta transfers, clones, replaced links and
+	                  chunk journals to <dir> in the destination directory, e.g. .psync-tmp
+	-strict         - abort the run on the first error, after finishing the files in progress
+	-nfs-conns <num>
+	                - number of TCP connections to an nfs:// source, default 4
+	-file-timeout <dur>
+	                - abandon the copy of a file which made no progress for <dur> (hung NFS
+	                  server, stuck open), report it as failed and move on, default 0 (off);
+	                  the files of the copy are closed, and its partial file is removed
+	-since-last     - skip files which were neither modified nor changed (ctime) since the
+	                  start of the last successful run from the same source, a cheap
+	                  incremental mode for rotating backups; existing destination
+	                  directories are merged
+	-state <file>   - state file recording the last successful run, default
+	                  <destination>/.psync-state; give the same file to runs into
+	                  changing destinations
+	-ssh <command>  - ssh command with options for remote trees, default ssh
+	-sftp-conns <num>
+	                - number of SFTP sessions to a remote tree, default 4
+	-s3-endpoint <url>
+	                - URL of an S3 compatible service (e.g. MinIO) for s3:// trees,
+	                  default AWS
+	-secret-file <file>
+	                - file with the shared secret for host::module trees,
+	                  default $PSYNC_SECRET
+	-delta          - transfer only the changed blocks of changed files to host::module
+	                  destinations (rsync algorithm), the default for these destinations
+	-no-whole-file  - same as -delta
+	-whole-file     - copy changed files as a whole, also to host::module destinations
+	-compress       - compress the traffic to remote trees (ssh -C) and to host::module
+	                  trees (deflate, if the daemon supports it)
+	-conflicts <mode>
+	                - handling of entries in several sources: first (default, keep the entry
+	                  of the first source), last (replace it), or error (keep the first,
+	                  and report an error)
+	-verify         - compare the trees, and report missing, extra and differing entries to STDOUT,
+	                  without copying anything
+	-verify-after   - read each copied file back from the destination, and compare its
+	                  checksum with the source
+	-unstable <mode>
+	                - check whether source files changed while copying them, and skip them
+	                  (skip) or copy them again (retry)
+	-checksum-choice <hash>
+	                - hash function for the checksums of -verify-after and -chunk-journal:
+	                  sha256 (default), sha512, blake3, xxh3, sha1, md5, or crc32c
+	-state-db <file>
+	                - record size, mtime, ctime and inode of the synced entries in <file>, and
+	                  skip the source entries which did not change since, without looking at the
+	                  destination
+	-watch          - keep running after the copy, and replay the changes of a local source
+	                  (inotify) to the destination, until interrupted
+	-interval <dur> - repeat the sync every <dur> (e.g. 15m) until interrupted, copying only
+	                  missing and changed entries after the first sync
+	-link-dest <dir>
+	                - hard-link files which are unchanged in the reference directory <dir>
+	                  (relative to the destination) instead of copying them, may be repeated
+	-dedup          - hard-link files with identical content (by checksum) to the first copy on the
+	                  destination, instead of writing duplicate copies
+	-two-way        - propagate the changes of both trees since the last two-way run to the other
+	                  tree, with a snapshot in <destination>/.psync-twoway
+	-resolve <mode> - handling of entries changed in both trees with -two-way: newer (default),
+	                  rename or skip
+	-normalize <form>
+	                - match names in another Unicode normalization form with the existing
+	                  destination entries (match), and write new names as NFC (nfc) or NFD (nfd)
+	-case-collisions <mode>
+	                - handling of source entries whose names differ only in case, on a
+	                  case-insensitive destination: skip (default) or rename
+	-force          - copy the source even if it lies inside the destination directory
+	-no-perms       - keep the permissions of existing entries, and create new ones with the
+	                  permissions of the source reduced by the umask (same as -perms=false)
+	-chmod <rules>  - change the permissions of the destination entries by rsync style rules,
+	                  e.g. D2775,F664 or Dg+s,ug+w,Fo-w,+X
+	-chown <owner>  - set the owner of the destination entries, user, user:group or :group,
+	                  by name or numeric ID (root only)
+	-usermap <file> - map the user IDs of the source to destination IDs by the rules of the file
+	-groupmap <file>
+	                - map the group IDs of the source to destination IDs by the rules of the file
+	-fake-super     - when not root, keep the owner, device numbers and special bits in the
+	                  attribute user.rsync.%stat (Linux only)
+	-copy-devices   - copy the content of block devices to regular files
+	-numeric-ids    - keep the owner IDs of remote sources, instead of matching the user and
+	                  group names on the destination
+	-rsync-slash    - copy a source directory without a trailing slash into the destination
+	                  as a subdirectory, like rsync
+	source          - source directory or local file, nfs://host/path for the NFS client
+	                  (experimental), remote directory [user@]host:path over SFTP, daemon
+	                  module host::module/path, rsync daemon module
+	                  rsync://[user@]host[:port]/module/path, bucket URL
+	                  (s3://bucket/prefix, gs://bucket/prefix, azblob://account/container/prefix),
+	                  or archive tar:<file> (tar:- for STDIN) or zip:<file>
+	destination     - destination directory, remote directory [user@]host:path over SFTP,
+	                  daemon module host::module/path, bucket URL, or tar:<file>
+	                  for a tar archive (tar:- for STDOUT)
+
+With -state-db <file>, psync records the size, modification time, change time
+and inode number of each entry it synced in a local database (gzip compressed).
+The next run skips the source entries whose record is unchanged, without
+looking at the destination at all, and creates no directories which exist
+already. This saves the round trips to a destination over a slow network,
+which -sync needs for every file. Entries which failed are not recorded, and
+are copied again by the next run. The destination must not be changed by
+others in between, since such changes go unnoticed. The database is tied to the
+source and destination it was written for; it cannot be used with -verify or
+several sources.
+
+With -watch, psync keeps running after the copy as a lightweight live mirror
+of a local source. The copy threads watch each directory with inotify before
+reading it, so that no change is missed. Changed entries are collected until
+no change arrived for a second, and then copied by the copy threads, like with
+-files-from; new directories are copied as a whole, and entries deleted from
+the source, or moved away, are deleted from the destination. Changes of the
+metadata of directories are not replayed. If the kernel lost events, the whole
+tree is copied again. SIGINT or SIGTERM end the watch. Each directory needs an
+inotify watch, so large trees may need a higher fs.inotify.max_user_watches.
+The options -resume, -files-from, -shard and -verify, and several sources
+cannot be combined with -watch.
+
+With -interval <dur>, psync repeats the sync every <dur> in the same process,
+for a near mirror of file systems without inotify, e.g. network file systems.
+The repeated syncs work like -sync, and keep the connections to remote trees,
+the filter rules and the state database of -state-db, which is written after
+each sync. With -since-last, files older than the start of the previous sync
+are skipped. A sync which takes longer than the interval is followed by the
+next one at once. Entries deleted from the source are not deleted from the
+destination. SIGINT or SIGTERM end the run; -interval cannot be combined with
+-watch or -verify.
+
+With -link-dest <dir>, rotating snapshot backups take only the space of the
+changed files, like with "rsync --link-dest": a regular file which has the same
+size, modification time and permissions (and owner, with -owner) in the
+reference directory is hard-linked from there instead of copied. Relative
+reference directories are relative to the destination, e.g.
+"psync -times -link-dest ../2020-05-01 /data /backup/2020-05-02". The option
+can be repeated, the first reference directory with the file is used, and
+missing ones are ignored. Destination files which are hard links are replaced
+instead of overwritten, so that the snapshots never change. The destination
+must be local, and on the same file system as the reference directories.
+
+With -dedup, files with identical content are stored once on the destination:
+a file whose checksum (see -checksum-choice) matches a file copied before in
+the same run is hard-linked to that copy instead of written again. Files are
+only linked if they also agree in permissions, and in owner or modification
+time with -owner or -times. Only files of a size already seen are read twice.
+The destination must be local, and -checksum-choice crc32c or xxh3 is not
+accepted.
+Destination files which are hard links are replaced instead of overwritten.
+
+With -two-way, psync keeps two local trees in sync like Unison: the entries
+of both trees are compared with a snapshot written by the last two-way run
+(<destination>/.psync-twoway), and the entries changed in one tree only are
+copied to or deleted from the other tree, with all copy threads. Without a
+snapshot, e.g. on the first run, the trees are merged. Entries changed in
+both trees are conflicts, which are reported as warnings and resolved with
+-resolve: "newer" keeps the version with the newer modification time, the
+source on a tie; "rename" keeps both, the destination version is renamed to
+<name>.conflict-<time> in both trees; "skip" leaves both versions alone, and
+reports the conflict again on the next run. An entry deleted in one tree and
+changed in the other one is kept. Files changed the same way in both trees are
+no conflict. The metadata of directories is not propagated, and the option
+cannot be combined with the options for incremental or repeated runs.
+
+With -normalize <form>, names which differ only in their Unicode
+normalization are taken as equal. macOS stores names like "café" decomposed
+(NFD), while Linux and SMB servers keep them as written, mostly precomposed
+(NFC). Without the option, a sync from one to the other copies such entries
+again under the second name. With -normalize match, the existing destination
+entry is updated instead, and new entries keep the name of the source; with
+nfc or nfd, new entries are written in this form. The option cannot be
+combined with -verify, -two-way, -link-dest, -dedup, and sharded or archive
+destinations.
+
+psync checks at the start whether the destination is case-insensitive, like
+SMB shares and APFS volumes by default, with a probe file. On such a
+destination, source entries whose names differ only in case (README and
+readme) would overwrite each other. The first one in the sort order is copied,
+the others are skipped with a warning, or with -case-collisions rename copied
+as "readme (2)" etc.
+
+With -verify, nothing is copied. Instead, the source and destination trees are
+walked in parallel, like a fast "diff -r" for network file systems, and each
+entry which is missing in the destination, extra in the destination, or
+differs in type, size, modification time, link target or content is printed
+to STDOUT. Files with a different modification time are reported without
+reading them; with -ignore-times, the times are not compared, and only the
+content of the files is. Filter rules apply to both trees, and the control
+files of psync are ignored. The exit status is 1 if differences were found. The options
+-create, -resume and -shard, several sources and archive destinations cannot be
+used with -verify.
+
+With -verify-after, each copied file is read back from the destination, and
+its checksum is compared with the checksum of the data read from the source
+while copying. A file which differs is removed from the destination, so that
+the next run copies it again, and reported as error of the category
+"mismatch". This doubles the reads on the destination side, but gives
+end-to-end assurance that the data arrived intact, beyond the success of the
+write calls. Files resumed from a chunk journal are read again from the source.
+
+Files which are written to while they are copied, like the files of databases
+or logs, may arrive torn, with parts of the old and the new content. With
+-unstable, the size and modification time of each source file are checked
+again after copying it. If they changed, the copy is removed from the
+destination, so that the next run copies the file again, and reported as
+error of the category "unstable" (-unstable skip). With -unstable retry, the
+file is copied again, up to three times, before it is skipped. Since a write
+within the granularity of the modification time can go unnoticed, this does
+not replace a snapshot of the source, or stopping the writing application.
+
+The checksums of -verify-after and of the chunk journal are SHA-256 hashes by
+default. Other hash functions are selected with -checksum-choice: sha512 and
+blake3 are faster than sha256 on 64-bit CPUs without SHA instructions, and
+blake3 is a modern cryptographic hash. xxh3 (64 bit XXH3) and crc32c are much
+faster still, crc32c using the CRC32 instructions of the CPU, but only detect
+accidental corruption. md5 and sha1 are offered for interoperability. blake3
+and xxh3 are implemented in portable Go within psync, without SIMD or
+parallel hashing, so they are slower than the reference implementations. Chunk
+journals written with another hash function are discarded.
+
+With -partial-dir, the files are written to a directory in the destination
+directory while they are copied, like .psync-partial, with the same path as
+their destination file, and moved to their place when they are complete. So
+an interrupted copy never leaves a half written file where applications might
+read it. The partial files of an interrupted run are kept, and with
+-chunk-journal, the next run resumes them after the chunks which are
+verified. The partial directory is ignored by -verify, and its empty
+directories are removed at the end of a complete run. The partial directory
+must be on the same file system as the destination directory. Delta transfers
+and clones already write to a temporary file next to the destination file.
+-partial-dir is not supported for archives.
+
+Delta transfers, clones, replaced links and chunk journals are written to a
+temporary file next to their destination entry, which is renamed over the
+entry when it is complete. With -temp-dir, the temporary files are written to
+a directory in the destination directory instead, e.g. when the directories of
+the tree are not writable for other names, or when snapshots or quotas should
+not see them. The directory is created if needed, ignored by -verify, and
+removed at the end of a complete run if it is empty. It must be on the same
+file system as the destination directory, since the temporary files are
+renamed.
+
+Several source trees are merged into the destination, like with
+"cp -r src1 src2 dest". They are copied one after the other, each with all
+threads, and directories present in several sources are merged. An entry which
+is in an earlier source, too, is handled as given with -conflicts: the first
+version is kept (first), replaced (last), or kept and reported as error
+(error). The time stamps and owner of merged directories are taken from the
+first source, or from the last with -conflicts last. The options -resume and
+-files-from cannot be used with several sources, and an interrupted run writes
+no checkpoint.
+
+By default, the content of each source directory is copied into the
+destination, regardless of a trailing slash. With the option -rsync-slash, the
+trailing slash makes the difference, like in rsync: "psync -rsync-slash src
+dest" creates the directory dest/src, while "psync -rsync-slash src/ dest"
+copies the content of src into dest. The filter rules and the file list of
+-files-from are then relative to the parent directory of src, so that an
+anchored pattern like /src/tmp matches the directory tmp within src. The time
+stamps, permissions and owner of the destination directory itself are not
+changed in this case. Remote sources are always copied by their content.
+
+A local source which is a file, or a symlink to a file, is copied into the
+destination directory under its name, like "cp file dir/" does. It is handled
+like the only entry of its parent directory, so "psync -sync -times
+/data/report.pdf /backup" copies the file only if it changed, and "psync -verify
+/data/report.pdf /backup" compares it. Files and directories can be mixed as
+sources.
+
+If the destination directory lies inside the source tree (e.g. when copying
+/data to /data/backup), it is excluded from the copy automatically. Copying a
+directory onto itself is refused. A source directory which lies inside the
+destination (e.g. when copying /data/new to /data) is refused as well, unless
+the option -force is given. The paths are compared by device and inode, so that
+symlinks and bind mounts to the same directory are detected.
+
+Symbolic links to directories are copied as links, and not followed. A
+directory which is reached a second time within the source tree, through a
+bind mount of one of its parents or of another directory of the tree, is
+skipped with a warning. So a bind mount loop does not repeat the tree until the
+path length is exceeded, and a subtree mounted twice is copied only once.
+
+The source can be read with the builtin userspace NFSv3 client instead of a
+kernel mount, by giving it as nfs://host/path, where path is an exported
+directory or a subdirectory of it. The client keeps many RPCs in flight over
+several TCP connections, and reads files ahead, which hides the latency of
+high-latency links better than a single kernel mount. It is experimental and
+read-only, NFSv4 is not supported, and the option -keep-atime cannot be used.
+When running as root, a privileged source port is used.
+
+Either the source or the destination can be a directory on a remote host,
+given as [user@]host:path like for scp (relative paths start in the home
+directory), or as sftp://[user@]host[:port]/path. psync runs the ssh command
+with the sftp subsystem, so the SSH configuration, keys and agent of the user
+apply, and keeps many requests in flight over several SFTP sessions. Options
+for ssh are given with -ssh, e.g. -ssh 'ssh -o Compression=yes'. Time stamps are
+transferred in seconds. With a remote destination, the checkpoint and state
+files default to the current directory. Like with rsync, only a colon before
+the first slash makes a path remote, so absolute paths and paths starting with
+"./" are always local. Local paths containing a colon before the first slash
+must be given with a leading "./", e.g. ./backup:2020.
+
+Trees in Amazon S3 or an S3 compatible object store (MinIO, Ceph, ...) are
+given as s3://bucket/prefix, for the source or the destination. Directories are
+key prefixes, and are kept as empty marker objects ending with a slash. Large
+files are uploaded in parts of 8 MiB, four of them in parallel per file. The
+credentials and the region are taken from the AWS environment variables
+(AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION), the
+endpoint of other services from -s3-endpoint or AWS_ENDPOINT_URL. Symbolic
+links, ownership, and the options -lock and -keep-atime are not supported. The
+modification time (-times) is kept in the object metadata; it is used by -sync,
+but files copied from a bucket get the time of the upload.
+
+Google Cloud Storage buckets are given as gs://bucket/prefix, and are accessed
+with the S3 compatible XML API. The credentials are an HMAC key
+(GS_ACCESS_KEY_ID, GS_SECRET_ACCESS_KEY) or an OAuth2 access token
+(GOOGLE_OAUTH_ACCESS_TOKEN, e.g. from 'gcloud auth print-access-token'). Azure
+Blob Storage containers are given as azblob://account/container/prefix, with
+the shared key of the account in AZURE_STORAGE_KEY or a SAS token in
+AZURE_STORAGE_SAS_TOKEN; AZURE_STORAGE_BLOB_ENDPOINT selects another endpoint,
+e.g. an emulator. Both behave like S3 trees, with the same restrictions.
+
+Hosts without SSH access can export directories with "psync --daemon", which
+listens on a TCP port (8730 by default) and speaks its own framed protocol
+(list directory, stat, read and write chunks, set metadata), with many
+requests in flight over several connections. Each exported directory is a
+module; clients give the source or destination as host[:port]::module/path.
+The daemon and its clients share a secret, given with -secret-file or in the
+environment variable PSYNC_SECRET, which is checked with a challenge-response
+handshake. Without a secret, any client is accepted, so the modules are
+exported read-only. The traffic itself is not encrypted, so the daemon is
+meant for trusted networks.
+
+The clients are confined to the modules. Paths whose directories lead out of
+a module by symbolic links are refused, and files are not opened, and their
+permissions, owners and times are not changed, through a symbolic link.
+Symbolic links can only be created with relative targets which stay inside
+the module, so absolute links of the source are not copied to a daemon. On
+Linux, the daemon opens the directories of a path one after the other, each
+relative to its parent and without following links, and accesses the entries
+through /proc/self/fd, so that a client cannot exchange a directory for a
+link while it is used. Other systems lack the means for this, so the modules
+are always exported read-only there.
+
+With -compress, the client asks the daemon to compress the connections after
+the handshake, with deflate at its fastest level. Daemons without support for
+compression are used uncompressed. Compression pays off on links with low
+bandwidth, once their latency is hidden by the parallel transfers, but costs
+CPU time on both sides, and saves nothing for data which is compressed already.
+psync uses deflate instead of zstd or lz4, which would compress faster at a
+similar ratio, because the Go standard library has no implementation of them,
+and psync does not depend on other packages. The compression is negotiated,
+so that faster algorithms can be added later without breaking older daemons.
+
+With -delta, changed files are transferred to a daemon destination with the
+rsync algorithm: the daemon computes checksums of the blocks of the existing
+file, psync scans the source file for these blocks, and sends only the data
+which changed. The daemon builds the new file from the unchanged blocks and
+that data in a temporary file, which replaces the old one. This saves most of
+the traffic for large files which change slightly between runs, at the cost of
+reading both versions completely.
+
+Like with rsync, delta transfers are the default for daemon destinations,
+where the network is expected to be the bottleneck, while files on local and
+other destinations are copied as a whole, since reading both versions costs
+more than writing the new one. -whole-file copies changed files as a whole on
+a fast network to a daemon, too, and -no-whole-file is the same as -delta.
+
+With a destination tar:<file>, psync writes the tree as tar archive, which
+makes it a parallel "tree to tarball" tool for trees on storage with high
+latency. The copy threads read the files in parallel, and their entries are
+merged into the single archive in the order they are completed. The data of
+each file is buffered until the file is complete (in a temporary file for
+files larger than 4 MB), and entries are written when their times and owner
+are set, if -times or -owner are given. Archives named *.gz or *.tgz are
+compressed with gzip. With tar:-, the archive is written to STDOUT, and the
+messages and statistics go to STDERR. The options -lock and -chunk-journal are
+not supported for archives.
+
+The other way round, a source tar:<file> or zip:<file> extracts an archive in
+parallel, e.g. onto a network destination, with the usual options for times
+and owners (zip archives do not record owners). The archive is indexed first,
+and the entries are read by their offsets. Compressed tar archives (*.gz,
+*.tgz) and archives read from STDIN are unpacked to a temporary file for
+this. Sparse files in tar archives are not supported.
+
+Existing rsync daemons can serve as source without installing anything on the
+server, given as rsync://[user@]host[:port]/module/path. psync speaks rsync
+protocol version 29 to them, but unlike rsync, it lists every directory and
+transfers every file over a connection of its own, so that many transfers run
+in parallel. Files are transferred whole, and verified with their MD4
+checksum. For modules with authentication, the password is taken from
+RSYNC_PASSWORD. Without a module, the modules of the daemon are listed.
+Modification times are transferred in seconds, and -keep-atime is not
+supported.
+
+	psync --daemon [-listen <addr>] [-read-only] [-secret-file <file>] [-v] module=dir ...
+
+	-listen <addr>  - TCP address to listen on, default :8730
+	-read-only      - reject all modifications of the modules, the default without secret
+	-secret-file <file>
+	                - file with the shared secret of the clients, default $PSYNC_SECRET
+	-v              - log the connections of clients to STDOUT
+	module=dir      - name and directory of an exported module
+
+When several hosts sync into the same destination (e.g. a shared filer), the
+option '-lock' lets them coordinate. The lease is the directory .psync-lock in
+the destination, which is created atomically and holds the owner (host, pid and
+session token). The owner rewrites it periodically as heartbeat. Other runs wait
+until the lease is released, or take it over when the heartbeat is older than
+the lock timeout, so the clocks of the hosts should be synchronized. Temporary
+files contain the session token, so that concurrent runs never share them.
+
+With -estimate, psync first scans the source trees with all workers, counting
+the files and bytes to copy, and the progress output of -progress then shows
+the percentage done and the time left at the current throughput. The listings
+of the scan are kept in memory and taken by the workers, so the directories
+are not read twice, at the price of about 200 bytes of memory per entry until
+its directory is copied. The option cannot be combined with -verify,
+-two-way, -watch, -resume and -files-from, which do not copy whole trees.
+
+While psync is running, sending the signal SIGUSR1 prints the statistics of
+the copy threads (directories, files and bytes handled, busy and idle time)
+and of the work list (directories waiting, the highest number waiting, and
+the directories handed out) to STDERR. The number of directories waiting is
+also shown by -progress, and returned by Stats().
+
+The errors are classified into categories: vanished (the source entry
+disappeared), permission (permission denied), full (the destination is full),
+conflict (a conflicting destination entry), timeout, mismatch (see
+-verify-after), unstable (see -unstable), io (input/output errors),
+unsupported (entry types and operations not supported, like UNIX special
+files), metadata (the owner, permissions or timestamps could not be changed)
+and other. At the end of a run with errors, their number by category is
+printed to STDERR, like "3000 permission, 1 io", so that a systematic problem
+can be told from a single flaky file at a glance. With -stats, it is part of
+the statistics, and Stats() returns it in the field Categories.
+
+With -stats-out, a summary of the run is written as JSON at its end, also
+when it was interrupted: the sources and destination, the start, end and
+duration, the counters of the run and of each top level directory, the errors
+by category, the statistics of each thread and of the work list, and the
+options of the run. Backup scripts can archive the files and compare the
+runs over time.
+
+With -notify-url, the summary of the run is posted to an HTTP endpoint when
+the run ends, also when it was interrupted or failed, e.g. to a chat or
+alerting service which reports failed mirrors. The summary is the JSON object
+written by -stats-out, with the additional fields "status" (ok, errors,
+interrupted or failed) and "error" (the message of the error which stopped
+the run). The endpoint must answer within 30 seconds with a 2xx status. With
+-notify-cmd, the given command is run instead, with the summary on STDIN and
+the status in the environment variable PSYNC_STATUS. The command is run by
+the shell (/bin/sh -c), so it may contain quoted arguments. A failed
+notification is reported, but does not change the exit status of psync.
+
+With -pre-cmd and -post-cmd, commands are run before and after the copy, e.g.
+to quiesce an application while its data is copied, and to resume it
+afterwards. If the command of -pre-cmd fails, nothing is copied and the run
+fails. The command of -post-cmd is run also when the copy failed or was
+interrupted, with the status (ok, errors, interrupted or failed) in the
+environment variable PSYNC_STATUS, and a failure of the command fails an
+otherwise successful run. With -file-cmd, a command is run for each entry
+which was copied, linked, created or deleted, after its content was written,
+with the action of the event stream (see -events) in PSYNC_ACTION and the
+path relative to the source directory in PSYNC_PATH, e.g. to invalidate a
+cache or to tag the copied files. A failure of the command is counted as error
+of the entry. The copy thread waits for the command, so it should be fast.
+The commands are run by the shell (/bin/sh -c), so they may contain quoted
+arguments, e.g. -post-cmd 'logger "sync done"', and get the source and
+destination in PSYNC_SOURCE and PSYNC_DESTINATION.
+
+With -audit-log, a record of each change of the destination is appended to
+the given file, as newline delimited JSON (NDJSON): the time, the session
+token of the run, the action (create, overwrite, delete, chown, chmod or
+times), the path, and the type, permissions, size, modification time and
+owner of the entry before and after the change. Unlike -events, the records
+describe what was changed rather than what was done, skipped entries are not
+recorded, and the file is never truncated, so it collects the history of all
+runs. Each record is written at once, before the next change is made. The
+log costs an additional stat call per change.
+
+When psync receives SIGINT or SIGTERM, it stops handing out new directories
+to the copy threads, finishes the files in progress, and writes the
+directories left over to the checkpoint file. The same happens when the
+number of errors exceeds the limit given with '-max-errors'. A second
+signal aborts psync immediately. The run can be continued later with
+'-resume', preferably in sync mode.
+
+To find a suitable number of threads and settings for a given storage, the
+subcommand "bench" copies a source tree several times with different numbers
+of threads and with each setting of the engine and the scheduler into
+temporary directories, and prints the time and throughput of each run.
+Without a source, it generates a synthetic tree in the temporary directory
+first, with the given number of files, size distribution and shape, and
+removes it at the end:
+
+	psync bench [-threads <list>] [-settings <list>] [-runs <num>] [-temp <dir>]
+	            [-files <num>] [-sizes <dist>] [-depth <num>] [-width <num>] [source]
+
+	-threads <list> - comma separated list of thread counts, default 1,2,4,8,16,32
+	-settings <list>
+	                - comma separated list of the settings to measure, default all:
+	                  classic, data-threads and copy-range (engines), dfs, bfs, prefetch,
+	                  small-first and large-first (schedulers)
+	-runs <num>     - number of runs per thread count, the fastest one is reported
+	-temp <dir>     - directory for the temporary copies, e.g. on the destination storage
+	-files <num>    - number of files of the synthetic tree, default 10000
+	-sizes <dist>   - size distribution of the synthetic tree, as percent:size pairs with
+	                  the suffixes k, m and g, default 90:4k,9:64k,1:4m
+	-depth <num>    - depth of the directories of the synthetic tree, default 3
+	-width <num>    - number of subdirectories per directory of the synthetic tree, default 4
+	source          - source directory, a synthetic tree is generated if none is given
+
+The engine "classic" copies the files with the copy threads, "data-threads"
+with a separate pool of as many data threads (-data-threads), and
+"copy-range" has the kernel copy them (-copy-range). There is no io_uring
+engine: it would need the raw system calls and their shared memory rings,
+which package syscall does not wrap, and psync takes no dependencies. The
+schedulers are the traversals dfs and bfs (-traversal), the scanners reading
+ahead (-prefetch), and the orders small-first and large-first (-order). The
+same settings are measured by the benchmarks of the package psync on a
+synthetic tree of 2000 files, so that the figures can be reproduced, and
+regressions are caught:
+
+	go test -run NONE -bench . ./pkg/psync
+
+The subcommand "selftest" checks the copy engine end to end. It generates a
+random source tree with directories, regular and sparse files, symbolic
+links, unusual names like names with spaces, newlines or a leading dash,
+various permissions and timestamps, and, when run as root, various owners.
+It copies the tree, and compares the copy with the source like cp -a and
+rsync -a would leave it: type, permission bits, size, content, link target,
+modification time (except of symbolic links) and owner of each entry, and
+no entries missing or left over. A second run in sync mode must find nothing
+to copy. Differences are listed, and the trees are kept for inspection if
+the test fails. A failed test can be repeated with the seed it printed. The
+tree generator and the comparison are in the package
+github.com/hweidner/psync/pkg/treetest, which the tests of the package psync
+run as well (go test ./pkg/psync):
+
+	psync selftest [-seed <num>] [-entries <num>] [-threads <num>] [-temp <dir>]
+
+	-seed <num>    - seed of the random tree, default is the current time
+	-entries <num> - number of entries of the random tree, default 2000
+	-threads <num> - number of threads of the copy, default 16
+	-temp <dir>    - directory for the trees, e.g. on the storage to test
+
+The copy engine is also available as the Go package
+github.com/hweidner/psync/pkg/psync, for programs which want to copy trees
+without running the psync command. The fields of psync.Options correspond to
+the command line options, cancelling the context stops the run like SIGINT
+does, and the counters of the run are returned by Stats():
+
+	s := psync.New(psync.Options{Source: "/data/src", Destination: "/data/dest", Times: true})
+	err := s.Run(ctx)
+	fmt.Println(s.Stats().Files, "files copied")
+
+Errors on single entries do not stop the run. Each one is passed as
+*psync.Error, with the path and the underlying error, to the function in the
+option OnError, and Run returns a *psync.RunError at the end, which matches
+the categories of the errors that occurred with errors.Is(), e.g.
+errors.Is(err, psync.ErrPermission).
+
+Each run keeps its state in its Syncer, so several Syncers can copy in
+parallel in one process. The runs of a single Syncer are executed one after
+the other, and Stats() returns the counters of its current or last run.
+
+All accesses to the source and destination trees go through the
+interface psync.FS, so other storage backends can be plugged in with the
+options SourceFS and DestinationFS. psync.LocalFS returns the backend of a
+local directory.
+
+The content of the files can be transformed while it is copied, e.g.
+compressed or encrypted, by filters in the option Transforms. A filter
+implements the interface psync.Transform: it gets the writer of the
+destination file, with the path and metadata of the source file, and returns
+a writer for the data, whose Close method writes the buffered data. The
+filters are applied in their order, and psync.TransformFunc turns a function
+into a filter:
+
+	gz := psync.TransformFunc(func(w io.Writer, name string, f os.FileInfo) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	})
+	s := psync.New(psync.Options{Source: "/data/src", Destination: "/backup", Transforms: []psync.Transform{gz}})
+
+The names of the files are kept. Since the destination files differ from the
+source files, sync mode compares only the modification times, and delta
+transfers, cloning, -verify, -verify-after, -two-way, -chunk-journal,
+-preallocate, -size-only, -link-dest and -dedup are not available with
+transforms. Empty files are transformed, too.
+
+The package github.com/hweidner/psync/pkg/workpool provides the pattern of
+the copy threads for other tree walks: a pool of workers, which handle the
+items of a work list of arbitrary size, and may submit further items, e.g.
+subdirectories, without blocking. psync runs its copy threads on it, and
+scans the trees of -estimate and two-way runs with it. With workpool.NewWith,
+a long work list is moved out of memory in parts, the pool can be stopped
+with the remaining items passed on, e.g. for a checkpoint, and idle workers
+take side work, like parts of a huge directory.
+
+	pool := workpool.New(16, false, func(id int, item interface{}) { ... pool.Submit(sub) ... })
+	pool.Submit("")
+	pool.Wait()
 
 Example
 
@@ -81,6 +822,123 @@ directory. When subdirectories are discovered, they are created on the destinati
 side. Traversal of the subdirecory is then submitted to other workers and thus done
 in parallel to the current workload.
 
+A single huge directory would leave all but one worker idle. So while more
+than 100 entries of a directory are left, its worker offers them to the idle
+workers, which join in and take the remaining entries one by one from the same
+list. A flat directory with hundreds of thousands of files is thus copied by
+all workers, and its metadata is applied by the worker finishing last.
+
+The directories waiting for a worker are kept in a work list. By default, the
+directory discovered last is handed out first, so the tree is traversed
+depth-first (-traversal dfs), and the work list stays short even for very deep
+and wide trees. With -traversal bfs, the directory discovered first is handed
+out first, so the tree is traversed breadth-first, which gives all workers
+something to do early on wide and shallow trees, at the price of a longer work
+list. The last 100 directories handed out are queued for the workers in the
+order they were handed out, so the strategy shows on larger trees only.
+
+With -max-queue, the number of directories waiting in the work list is
+limited, which bounds the memory on trees with tens of millions of
+directories, where the work list of a breadth-first traversal would grow large.
+While the work list is full, a worker descends into a subdirectory it
+discovered itself, instead of handing it to the other workers, so it never
+waits for room in the work list, which would deadlock once all workers wait.
+The limit is approximate, since several workers may add a directory to the
+work list at the same time.
+
+With -spill, the work list stays in memory up to the given number of
+directories. Beyond, the work pool moves the half of the work list which
+would be handed out last to an append-only temporary file in $TMPDIR, and
+reads the directories back in parts when the work list in memory runs empty.
+So the memory stays flat even on trees with hundreds of millions of
+directories, without changing the order of the traversal much. The file is
+removed right after it is created, so it vanishes when psync ends.
+
+With -data-threads, the work is split between two pools: the workers of
+-threads read the directories and create the subdirectories, and hand the
+files over to a separate pool of data workers, which copy their content. So
+the number of parallel metadata operations, like the stat and mkdir storms on
+NFS, and the number of parallel data streams can be tuned independently, e.g.
+"-threads 64 -data-threads 8" for many small directories on a high latency
+share. The metadata of a directory is applied by the worker finishing its last
+file. The statistics per thread list the data workers after the directory
+workers.
+
+With -prefetch, a small pool of scanners reads the directories ahead, while
+they wait in the work list. The worker which takes a directory then finds its
+entries already listed, instead of waiting for the listing between copying
+files, which hides the latency of reading directories on NFS and remote trees.
+A directory which no scanner got to yet is read by the worker itself, so the
+scanners never slow down the workers. The option has no effect with -watch,
+since watched directories must be read after their watch is added.
+
+With -order, the files of each directory are copied by size instead of in the
+order of the directory: -order small copies the small files first, which
+finishes the long tail of small files early and shows steady progress, while
+-order large starts the large files first, so that they do not end up as the
+last and only transfers of a run. The subdirectories of a directory are then
+handed to the other workers before its files are copied. The order applies
+within each directory; with -data-threads, the files of the directories are
+copied in the order they are read.
+
+With -deterministic, the tree is copied by a single thread in a fixed order:
+the entries of each directory are handled sorted by name (and then by size
+with -order), and each subdirectory is descended into when it is found, like
+cp -r does. So two runs over the same tree perform the same operations in the
+same order, and write the same verbose output, events and manifests, apart
+from timestamps and the progress output, which makes runs comparable when
+debugging. -threads and -prefetch are ignored, and -data-threads, -two-way and
+-watch are not supported.
+
+A large copy reads and writes every file once, which pushes the data of other
+workloads on the host out of the page cache. With -drop-cache source, each
+source file is dropped from the page cache after it was copied, with
+posix_fadvise(POSIX_FADV_DONTNEED). With -drop-cache dest, each destination
+file is written back to the storage with fsync and dropped as well, since
+dirty pages cannot be dropped; this slows down the copy of many small files.
+-drop-cache both does both. The option only affects local trees, and has no
+effect on other platforms than Linux on amd64 and arm64.
+
+With -preallocate, the blocks of each destination file are allocated with
+fallocate(2) before its content is copied, without changing the file size.
+This reduces the fragmentation of large files on ext4 and XFS, and a full
+destination fails the copy of a file at once with "no space left on device",
+instead of after writing most of it. File systems without support for
+fallocate(2), like NFSv3, are written as before.
+
+With -copy-range, the content of each file is copied by the kernel with
+copy_file_range(2), without passing the data through psync. Within a file
+system, the kernel may share the blocks (XFS and Btrfs with reflinks) or
+copy them on the server side (NFSv4.2 and SMB3), which makes large files
+copy much faster. Between file systems, this needs Linux 5.3 or later;
+otherwise, and on other platforms, the files are read and written as before.
+The option only applies to local source and destination trees, and to files
+copied as a whole. Files which psync must see while copying, with -sparse,
+-verify-after, -dedup or transforms, and delta transfers and chunked copies,
+are read and written as before. psync must be built with Go 1.15 or later
+for the option to have an effect.
+
+With -sparse, the data of each file is checked for blocks of 4 KB which are
+all zeros, aligned to the block boundaries of the file. Instead of being
+written, these blocks are skipped, so that they become holes in the
+destination file. Whether the source file has holes or just stores the zeros
+does not matter, so the option also shrinks disk images and database files
+which are logically sparse, but stored dense. The content of the file is the
+same. The option applies to local destinations, and to files copied as a
+whole; delta transfers, clones and chunked copies of -chunk-journal write all
+blocks. It cannot be combined with -preallocate.
+
+By default, the copied data is left in the page cache, and written to the
+storage by the kernel later, so that a crash of the destination host right
+after the run may lose recently copied files. With -fsync, each destination
+file is synced with fsync(2) after its content was copied, before its metadata
+is applied. With -fsync-dir, each destination directory is synced after all its
+entries were created and its metadata was applied, so that the names of the new
+entries survive a crash as well. On journaling file systems like ext4 and XFS,
+syncing the directory also commits the metadata changes of its entries. Both
+options make the copy slower, especially of many small files, and only affect
+local destinations.
+
 Performance values
 
 Here are some performance values comparing psync to cp and rsync when copying
@@ -118,8 +976,36 @@ psync currently can only handle directories, regular files, and symbolic links.
 Other filesystem entries like devices, sockets or named pipes are silently ignored.
 A warning is printed when trying to copy such special files.
 
+With -copy-devices, the content of block devices in the source is copied to
+regular files, like with rsync --copy-devices, e.g. for imaging small devices
+or logical volumes with the throttling, checksums and resume of psync. The
+device is read to its end, since its size is not known from its directory
+entry, and is copied in each run, also in sync mode. Character devices are
+still skipped, since many of them never end.
+
 psync preserves the Unix permissions (rwx) of the copied files and directories,
 but has currently no support for preserving other permission bits (suid, sticky).
+The entries are created accessible by the owner only, and get the permissions of
+the source when they are complete, regardless of the umask. Directories get
+their permissions, owner and timestamps after all entries of their subtree are
+done, since creating entries changes the modification time of a directory;
+the copy threads keep track of the directories whose subdirectories are still
+waiting. When a run is interrupted, the directories whose own entries are done
+get their metadata at its end, and again in the resumed run. The timestamps
+of the destination directory are set again after the control files in it,
+like the partial directory, are removed. The umask of the process is not
+changed. With -no-perms, new entries get the permissions of the source
+reduced by the umask, like with "cp -r", and existing entries keep their
+permissions. Buckets and archives store the permissions of the source directly.
+
+With -chmod, the permissions of the destination entries are changed by a comma
+separated list of rules, in the format of rsync --chmod. A rule starts with D
+to apply only to directories, or F to apply only to other entries, followed by
+an octal mode (e.g. D2775,F664) or by symbolic changes like with chmod(1), e.g.
+Dg+s,ug+w,Fo-w,+X. The rules are applied in order to the permissions of the
+source entry, and may set the setuid, setgid and sticky bits. So a tree can get
+the permissions of its new environment while it is copied. Hard links of
+-link-dest and -dedup are only made between files with the changed permissions.
 
 When using the according options, psync tries to preserve the ownership
 (user/group) and/or the access and modification time stamps. Preserve ownership
@@ -127,15 +1013,105 @@ does only work when psync is running under the root user account. Preserving the
 time stamps does only work for regular files and directories, not for symbolic
 links.
 
-psync does currently implement a simple recursive copy, like "cp -r", and not
-a versatile sync algorithm like rsync. There is no check wether a file already
-exists in the destination, nor its content and timestamps. Existing files on the
-destination side are not deleted when they don't exist on the source side.
+The ownership of entries from a remote source (over SFTP) is matched by the
+names of the user and group: an entry owned by alice on the remote host gets the
+ID of alice on the host running psync, like in rsync. This needs a local
+destination. IDs without a name on either host are kept. With -numeric-ids, the
+IDs of the remote host are kept as they are. Other sources and destinations
+always keep the IDs.
+
+With -chown, all destination entries get the given user and/or group, e.g.
+-chown www-data:www-data, or -chown :staff to set the group only. Names are
+looked up on the host running psync. Given alone, the other ID is left as
+created; together with -owner, it is taken from the source. This is useful to
+migrate data into a directory owned by a service account.
+
+With -usermap and -groupmap, the user and group IDs of the source are
+translated by the rules of a map file, and the ownership is preserved with the
+translated IDs, like with -owner. Each line of the file holds a source and a
+destination, separated by white space. The source is an ID, a user or group
+name, a range of IDs like 1000-1999, or * for all IDs. The destination is an ID,
+a name, or an offset like +100000, which shifts the IDs of a range. The first
+matching line applies, IDs without a matching line are kept, or matched by
+name for remote sources. Names are looked up on the host running psync. Empty
+lines and lines starting with # are ignored. For example, this file moves the
+IDs of a tree into a user namespaced container, and maps all other IDs to
+nobody:
+
+	0-65535 +100000
+	* nobody
+
+-chown takes precedence over the maps.
+
+With -fake-super, an unprivileged run keeps the metadata which it cannot apply
+in the extended attribute user.rsync.%stat of the destination entries, in the
+format of rsync --fake-super: the mode with the file type and the setuid,
+setgid and sticky bits, the device numbers, and the owner (with -owner, or the
+user running psync). Devices, named pipes and sockets are stored as empty
+regular files. The attributes of the source entries are read as well, so that a
+backup made with -fake-super is restored with the original owners by a run as
+root with -fake-super, or copied to another tree with its metadata. Restoring
+devices, named pipes, sockets and the special bits is not supported yet.
+-fake-super needs a local destination on Linux, with a file system supporting
+user attributes.
+
+By default, psync does a simple recursive copy, like "cp -r". In sync mode
+(-sync), existing directories on the destination side are reused, and regular
+files are only copied if they are missing or differ in size or modification
+time. The file content is not compared. Existing files on the destination side
+are not deleted when they don't exist on the source side.
+
+With -interactive, psync asks on the terminal before it overwrites an existing
+destination file which differs from the source file, showing the size and
+modification time of both. The answer can be yes, no, all (overwrite all
+further files), skip all (keep all further files) or quit, which ends the run
+like SIGINT. Missing files are copied without asking. The copy threads keep
+working while a question waits, but only one question is asked at a time. The
+option implies -sync, and cannot be used with -two-way.
+
+With -size-only, files of the same size count as up to date, regardless of
+their modification times, for destinations where the times are meaningless,
+like some object store gateways, or FAT file systems with their 2 second
+resolution and local time. So only files which are missing or differ in size
+are copied. The option implies -sync.
+
+With -ignore-times, all files are copied, also those which look up to date by
+their size and modification time in sync mode, or by the state database of
+-state-db, whose directories are descended into as well. This repairs
+destination files which are suspected of silent corruption, at the cost of a
+full copy; -delta transfers only the blocks which differ. Files are not
+hard-linked from the reference directories of -link-dest. With -verify, files
+which differ in their modification time only are not reported. The option
+cannot be combined with -size-only and -since-last.
+
+With -update, destination files which are newer than their source files are
+kept, like with rsync --update, so that a refresh from a master copy does not
+undo recent edits on the destination side. A file counts as newer if its
+modification time is later, in seconds; files of the same time are still
+replaced if they differ. Entries other than regular files are not affected.
+The option implies -sync, and cannot be used with -two-way.
+
+With -only-type, only entries of the given types are copied, like with find
+-type: regular files (f), directories (d), symbolic links (l), block and
+character devices (b, c), named pipes (p) and sockets (s), e.g. -only-type l
+to repair only the symbolic links after a partial migration. With
+-skip-symlinks, symbolic links are left out. Directories are always created
+and descended into, to find the entries in them, so -only-type d copies only
+the directory tree. Entries of other types are treated like excluded ones:
+they are not compared by -verify, not deleted by -two-way and -watch, and
+not counted by -estimate.
 
 psync is being developed under Linux (Debian, Ubuntu, CentOS). It should work on
-other distributions, but this has not been tested. It does currently not compile
-for Windows, Darwin (MacOS), NetBSD and FreeBSD (but this should easily be
-fixed).
+other distributions, but this has not been tested. It also runs on Darwin
+(macOS), except for -watch, which needs inotify. On APFS, regular files copied
+within a volume are cloned with clonefile(2) instead of copied, which takes no
+time and no space until the copies are changed. psync compiles for Windows,
+but has not been tested there. Owners, hard links and the other features
+which need the inode data of the files are not available, SIGUSR1 does not
+exist, and daemon modules are exported read-only. Local paths are used in the
+extended-length form (\\?\C:\...), so that trees deeper than 260 characters
+and reserved names like CON or NUL can be copied. psync does currently not
+compile for NetBSD and FreeBSD (but this should easily be fixed).
 
 License
 
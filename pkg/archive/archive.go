@@ -0,0 +1,80 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package archive presents tar and zip archives as directory trees.
+//
+// A Writer writes a tree as tar stream. The tree is written like a file
+// system, by many goroutines at the same time; the entries are merged into
+// the single stream in the order they are completed. The data of a file is
+// buffered until the file is closed, in memory or, for large files, in a
+// temporary file, because the size must be written in front of it. The
+// metadata is written along with the data, so entries may wait for their
+// times and owner to be set.
+//
+// A Reader indexes an archive once, and then serves the entries by their
+// offsets, so that many goroutines can read them at the same time. Archives
+// without random access (compressed tar archives, or Stdin) are unpacked to a
+// temporary file first.
+package archive
+
+import (
+	"os"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Type fileInfo implements os.FileInfo for the entries of an archive. For
+// tar archives, the method Sys returns a *sysstat.Stat with the owner and
+// time stamps, like for local files.
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	atime time.Time
+	uid   int
+	gid   int
+	owner bool // the archive records the owner
+}
+
+// Method Name returns the base name of the entry.
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+// Method Size returns the size of the entry in bytes.
+func (fi *fileInfo) Size() int64 {
+	return fi.size
+}
+
+// Method Mode returns the file mode bits.
+func (fi *fileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+// Method ModTime returns the modification time.
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.mtime
+}
+
+// Method IsDir checks whether the entry is a directory.
+func (fi *fileInfo) IsDir() bool {
+	return fi.mode.IsDir()
+}
+
+// Method Sys returns the owner and time stamps as *sysstat.Stat, or nil if
+// the archive does not record the owner.
+func (fi *fileInfo) Sys() interface{} {
+	if !fi.owner {
+		return nil
+	}
+	return sysstat.New(sysstat.Attr{
+		Uid:   uint32(fi.uid),
+		Gid:   uint32(fi.gid),
+		Size:  fi.size,
+		Atime: fi.atime.UnixNano(),
+		Mtime: fi.mtime.UnixNano(),
+	})
+}
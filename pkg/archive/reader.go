@@ -0,0 +1,472 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxLinks is the number of symbolic links followed by Stat.
+const maxLinks = 40
+
+// errSparse is returned for sparse files in tar archives, whose data is not
+// stored contiguously.
+var errSparse = errors.New("sparse files in tar archives are not supported")
+
+// Type node is an entry of the index of an archive.
+type node struct {
+	fi     fileInfo
+	link   string    // target of a symbolic link
+	off    int64     // offset of the data in a tar archive
+	zf     *zip.File // entry of a zip archive
+	sparse bool      // sparse file in a tar archive
+	hard   string    // target of a hard link, resolved after indexing
+}
+
+// Type Reader is an archive opened for reading. The names are relative to
+// the root of the archive, with a leading "/" ("" for the root itself).
+type Reader struct {
+	name  string
+	f     *os.File // tar archive, or unpacked copy
+	tmp   bool     // f is a temporary file, removed on Close
+	zr    *zip.Reader
+	nodes map[string]*node
+	dirs  map[string][]string // names of the entries of each directory
+}
+
+// Function Open opens an archive and reads its index: a tar archive, which
+// is compressed with gzip if it is named *.gz or *.tgz, or a zip archive if
+// zip is set. The name "-" reads a tar archive from Stdin.
+func Open(name string, isZip bool) (*Reader, error) {
+	r := &Reader{name: name, nodes: make(map[string]*node), dirs: make(map[string][]string)}
+	var err error
+	if isZip {
+		err = r.indexZip()
+	} else {
+		err = r.indexTar()
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	// add the directories not stored in the archive, and list the entries
+	// of each directory
+	var mtime time.Time
+	if st, err := os.Stat(name); err == nil {
+		mtime = st.ModTime()
+	}
+	names := make([]string, 0, len(r.nodes))
+	for n := range r.nodes {
+		names = append(names, n)
+	}
+	for _, n := range names {
+		for d := path.Dir(n); ; d = path.Dir(d) {
+			if d == "/" {
+				d = ""
+			}
+			if _, ok := r.nodes[d]; !ok {
+				r.nodes[d] = &node{fi: fileInfo{name: path.Base(d), mode: os.ModeDir | 0755, mtime: mtime}}
+			}
+			if d == "" {
+				break
+			}
+		}
+	}
+	if _, ok := r.nodes[""]; !ok {
+		r.nodes[""] = &node{fi: fileInfo{name: "/", mode: os.ModeDir | 0755, mtime: mtime}}
+	}
+	for n, nd := range r.nodes {
+		if n == "" {
+			continue
+		}
+		if nd.hard != "" {
+			r.resolveHardLink(nd)
+		}
+		d := path.Dir(n)
+		if d == "/" {
+			d = ""
+		}
+		r.dirs[d] = append(r.dirs[d], n)
+	}
+	for _, list := range r.dirs {
+		sort.Strings(list)
+	}
+	return r, nil
+}
+
+// Function entryName returns the name of an archive entry in the tree, with
+// a leading "/", or "" for the root.
+func entryName(name string) string {
+	n := path.Clean("/" + name)
+	if n == "/" {
+		return ""
+	}
+	return n
+}
+
+// Method indexTar reads the headers of a tar archive, and records the
+// offsets of the data.
+func (r *Reader) indexTar() error {
+	var err error
+	if r.name == "-" {
+		err = r.unpack(os.Stdin, false)
+	} else if strings.HasSuffix(r.name, ".gz") || strings.HasSuffix(r.name, ".tgz") {
+		var f *os.File
+		if f, err = os.Open(r.name); err == nil {
+			err = r.unpack(f, true)
+			f.Close()
+		}
+	} else {
+		r.f, err = os.Open(r.name)
+	}
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r.f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// the tar reader does not read ahead, so the data starts at the
+		// current offset
+		off, err := r.f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		name := entryName(hdr.Name)
+		if name == "" {
+			continue
+		}
+		fi := hdr.FileInfo()
+		nd := &node{
+			fi: fileInfo{
+				name:  path.Base(name),
+				size:  hdr.Size,
+				mode:  fi.Mode(),
+				mtime: hdr.ModTime,
+				atime: hdr.AccessTime,
+				uid:   hdr.Uid,
+				gid:   hdr.Gid,
+				owner: true,
+			},
+			off: off,
+		}
+		if nd.fi.atime.IsZero() {
+			nd.fi.atime = hdr.ModTime
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			nd.link = hdr.Linkname
+		case tar.TypeLink:
+			nd.hard = entryName(hdr.Linkname)
+		case tar.TypeGNUSparse:
+			nd.sparse = true
+		}
+		for k := range hdr.PAXRecords {
+			if strings.HasPrefix(k, "GNU.sparse.") {
+				nd.sparse = true
+			}
+		}
+		r.nodes[name] = nd
+	}
+}
+
+// Method unpack copies a tar archive without random access to a temporary
+// file, decompressing it if needed.
+func (r *Reader) unpack(in io.Reader, gz bool) error {
+	if gz {
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		in = zr
+	}
+	f, err := ioutil.TempFile("", "psync-archive-")
+	if err != nil {
+		return err
+	}
+	r.f, r.tmp = f, true
+	if _, err = io.Copy(f, in); err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	return err
+}
+
+// Method indexZip reads the central directory of a zip archive.
+func (r *Reader) indexZip() error {
+	f, err := os.Open(r.name)
+	if err != nil {
+		return err
+	}
+	r.f = f
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if r.zr, err = zip.NewReader(f, st.Size()); err != nil {
+		return err
+	}
+	for _, zf := range r.zr.File {
+		name := entryName(zf.Name)
+		if name == "" {
+			continue
+		}
+		nd := &node{
+			fi: fileInfo{
+				name:  path.Base(name),
+				size:  int64(zf.UncompressedSize64),
+				mode:  zf.Mode(),
+				mtime: zf.Modified,
+			},
+			zf: zf,
+		}
+		if nd.fi.mtime.IsZero() {
+			nd.fi.mtime = zf.ModTime()
+		}
+		nd.fi.atime = nd.fi.mtime
+		if nd.fi.mode&os.ModeSymlink != 0 {
+			// the target of a symbolic link is stored as data
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			b, err := ioutil.ReadAll(io.LimitReader(rc, 4096))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			nd.link = string(b)
+			nd.fi.size = int64(len(b))
+		}
+		r.nodes[name] = nd
+	}
+	return nil
+}
+
+// Method resolveHardLink takes the data of a hard link from its target.
+func (r *Reader) resolveHardLink(nd *node) {
+	t, ok := r.nodes[nd.hard]
+	for i := 0; ok && t.hard != "" && i < maxLinks; i++ {
+		t, ok = r.nodes[t.hard]
+	}
+	if !ok || !t.fi.mode.IsRegular() {
+		// dangling hard link, it is read as empty file
+		nd.hard = ""
+		return
+	}
+	nd.fi.size, nd.off, nd.zf, nd.sparse = t.fi.size, t.off, t.zf, t.sparse
+	nd.hard = ""
+}
+
+// Method path returns the archive name of an entry, for error messages.
+func (r *Reader) path(name string) string {
+	return r.name + ":" + name
+}
+
+// Method lookup returns the node of a name.
+func (r *Reader) lookup(op, name string) (*node, error) {
+	nd, ok := r.nodes[entryName(name)]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: r.path(name), Err: syscall.ENOENT}
+	}
+	return nd, nil
+}
+
+// Method ReadDir returns the entries of a directory, sorted by name.
+func (r *Reader) ReadDir(name string) ([]os.FileInfo, error) {
+	nd, err := r.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !nd.fi.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: r.path(name), Err: syscall.ENOTDIR}
+	}
+	names := r.dirs[entryName(name)]
+	list := make([]os.FileInfo, len(names))
+	for i, n := range names {
+		fi := r.nodes[n].fi
+		list[i] = &fi
+	}
+	return list, nil
+}
+
+// Method Lstat returns the attributes of an entry.
+func (r *Reader) Lstat(name string) (os.FileInfo, error) {
+	nd, err := r.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	fi := nd.fi
+	return &fi, nil
+}
+
+// Method Stat returns the attributes of an entry, following symbolic links
+// within the archive.
+func (r *Reader) Stat(name string) (os.FileInfo, error) {
+	n := entryName(name)
+	for i := 0; i < maxLinks; i++ {
+		nd, ok := r.nodes[n]
+		if !ok {
+			return nil, &os.PathError{Op: "stat", Path: r.path(name), Err: syscall.ENOENT}
+		}
+		if nd.fi.mode&os.ModeSymlink == 0 {
+			fi := nd.fi
+			return &fi, nil
+		}
+		if path.IsAbs(nd.link) {
+			n = entryName(nd.link)
+		} else {
+			n = entryName(path.Join(path.Dir(n), nd.link))
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: r.path(name), Err: syscall.ELOOP}
+}
+
+// Method Readlink returns the target of a symbolic link.
+func (r *Reader) Readlink(name string) (string, error) {
+	nd, err := r.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if nd.fi.mode&os.ModeSymlink == 0 {
+		return "", &os.PathError{Op: "readlink", Path: r.path(name), Err: syscall.EINVAL}
+	}
+	return nd.link, nil
+}
+
+// Method Open opens a regular file for reading.
+func (r *Reader) Open(name string) (*Entry, error) {
+	nd, err := r.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case nd.fi.mode.IsDir():
+		err = syscall.EISDIR
+	case !nd.fi.mode.IsRegular():
+		err = syscall.EINVAL
+	case nd.sparse:
+		err = errSparse
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: r.path(name), Err: err}
+	}
+	e := &Entry{name: r.path(name)}
+	switch {
+	case nd.zf == nil:
+		e.rd = io.NewSectionReader(r.f, nd.off, nd.fi.size)
+	case nd.zf.Method == zip.Store:
+		// stored data is read directly, with random access
+		off, err := nd.zf.DataOffset()
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: r.path(name), Err: err}
+		}
+		e.rd = io.NewSectionReader(r.f, off, nd.fi.size)
+	default:
+		rc, err := nd.zf.Open()
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: r.path(name), Err: err}
+		}
+		e.rc = rc
+	}
+	return e, nil
+}
+
+// Method Close closes the archive, and removes the temporary copy.
+func (r *Reader) Close() error {
+	if r.f != nil {
+		r.f.Close()
+		if r.tmp {
+			os.Remove(r.f.Name())
+		}
+	}
+	return nil
+}
+
+// Type Entry is a regular file of an archive, opened for reading. Entries of
+// tar archives and stored entries of zip archives can be read at any offset,
+// compressed entries of zip archives only sequentially.
+type Entry struct {
+	name string
+	rd   *io.SectionReader // data with random access
+	rc   io.ReadCloser     // compressed data, if rd is nil
+	off  int64
+}
+
+// Method Read reads data of the entry.
+func (e *Entry) Read(b []byte) (int, error) {
+	var n int
+	var err error
+	if e.rd != nil {
+		n, err = e.rd.Read(b)
+	} else {
+		n, err = e.rc.Read(b)
+	}
+	e.off += int64(n)
+	if err != nil && err != io.EOF {
+		err = &os.PathError{Op: "read", Path: e.name, Err: err}
+	}
+	return n, err
+}
+
+// Method Seek sets the offset of the next Read. Compressed entries can only
+// skip forward.
+func (e *Entry) Seek(offset int64, whence int) (int64, error) {
+	if e.rd != nil {
+		n, err := e.rd.Seek(offset, whence)
+		e.off = n
+		return n, err
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += e.off
+	default:
+		return e.off, &os.PathError{Op: "seek", Path: e.name, Err: errors.New("unsupported whence")}
+	}
+	if offset < e.off {
+		return e.off, &os.PathError{Op: "seek", Path: e.name, Err: syscall.ESPIPE}
+	}
+	if _, err := io.CopyN(ioutil.Discard, e, offset-e.off); err != nil {
+		return e.off, err
+	}
+	return offset, nil
+}
+
+// Method Write is not supported.
+func (e *Entry) Write(b []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: e.name, Err: syscall.EROFS}
+}
+
+// Method Truncate is not supported.
+func (e *Entry) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: e.name, Err: syscall.EROFS}
+}
+
+// Method Close closes the entry.
+func (e *Entry) Close() error {
+	if e.rc != nil {
+		return e.rc.Close()
+	}
+	return nil
+}
@@ -0,0 +1,452 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Type Options configures an archive writer.
+type Options struct {
+	Times  bool  // entries wait for Chtimes (files and directories)
+	Owner  bool  // entries wait for Chown or Lchown
+	Buffer int64 // data of a file kept in memory, default 4 MiB
+}
+
+// Type Writer writes a tar archive. The names are relative to the root of
+// the archive, with a leading "/" ("" for the root itself).
+type Writer struct {
+	name string
+	opts Options
+	out  io.Closer // output file, nil for Stdout
+	bw   *bufio.Writer
+	zw   *gzip.Writer // nil without compression
+	tw   *tar.Writer
+
+	wmu sync.Mutex // serializes the writing of entries, protects err
+	err error      // first write error
+
+	mu      sync.Mutex // protects pending, dirs and closed
+	pending map[string]*entry
+	dirs    map[string]bool // directories created
+	closed  bool
+}
+
+// Type entry is an entry of the archive, which is not written yet.
+type entry struct {
+	hdr   tar.Header
+	file  *File // open file, nil after Close
+	data  *File // closed file holding the data, nil for other entries
+	times bool  // waiting for Chtimes
+	owner bool  // waiting for Chown or Lchown
+}
+
+// Method ready checks whether the entry is complete.
+func (e *entry) ready() bool {
+	return e.file == nil && !e.times && !e.owner
+}
+
+// Function Create creates the archive file, "-" for Stdout. Archives with the
+// suffix .gz or .tgz are compressed with gzip.
+func Create(name string, opts Options) (*Writer, error) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 4 << 20
+	}
+	w := &Writer{name: name, opts: opts, pending: make(map[string]*entry), dirs: make(map[string]bool)}
+	var out io.Writer = os.Stdout
+	if name != "-" {
+		f, err := os.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		w.out, out = f, f
+	}
+	w.bw = bufio.NewWriterSize(out, 1<<20)
+	if strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		w.zw = gzip.NewWriter(w.bw)
+		w.tw = tar.NewWriter(w.zw)
+	} else {
+		w.tw = tar.NewWriter(w.bw)
+	}
+	return w, nil
+}
+
+// Function tarName returns the name of an entry in the archive.
+func tarName(name string, dir bool) string {
+	n := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if dir {
+		n += "/"
+	}
+	return n
+}
+
+// Method add adds an entry, which replaces an unwritten entry of the same
+// name (e.g. of a failed copy). The entry is written if it is complete.
+func (w *Writer) add(name string, e *entry) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return os.ErrClosed
+	}
+	if old, ok := w.pending[name]; ok && old.data != nil {
+		old.data.discard()
+	}
+	if !e.ready() {
+		w.pending[name] = e
+		w.mu.Unlock()
+		return nil
+	}
+	delete(w.pending, name)
+	w.mu.Unlock()
+	return w.write(e)
+}
+
+// Method update changes an unwritten entry, and writes it if it is complete.
+// Changes of the root directory are ignored.
+func (w *Writer) update(op, name string, f func(e *entry)) error {
+	if name == "" || name == "/" {
+		return nil
+	}
+	w.mu.Lock()
+	e, ok := w.pending[name]
+	if !ok {
+		w.mu.Unlock()
+		return &os.PathError{Op: op, Path: w.name + ":" + name, Err: syscall.ENOENT}
+	}
+	f(e)
+	if !e.ready() {
+		w.mu.Unlock()
+		return nil
+	}
+	delete(w.pending, name)
+	w.mu.Unlock()
+	return w.write(e)
+}
+
+// Method write writes an entry to the archive. After a write error, the
+// archive is broken, and all further writes fail.
+func (w *Writer) write(e *entry) error {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	if e.data != nil {
+		defer e.data.discard()
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if e.data != nil {
+		e.hdr.Size = e.data.size
+	}
+	err := w.tw.WriteHeader(&e.hdr)
+	if err == nil && e.data != nil {
+		var rd io.Reader = &e.data.buf
+		if e.data.tmp != nil {
+			if _, err = e.data.tmp.Seek(0, io.SeekStart); err == nil {
+				rd = e.data.tmp
+			}
+		}
+		if err == nil {
+			_, err = io.Copy(w.tw, rd)
+		}
+	}
+	if err != nil {
+		w.err = &os.PathError{Op: "write", Path: w.name, Err: err}
+	}
+	return w.err
+}
+
+// Method newEntry returns an entry with the header fields common to all
+// types.
+func (w *Writer) newEntry(name string, typ byte, perm os.FileMode) *entry {
+	return &entry{
+		hdr: tar.Header{
+			Typeflag: typ,
+			Name:     tarName(name, typ == tar.TypeDir),
+			Mode:     int64(perm.Perm()),
+			ModTime:  time.Now().Truncate(time.Second),
+		},
+		times: w.opts.Times && typ != tar.TypeSymlink,
+		owner: w.opts.Owner,
+	}
+}
+
+// Method ReadDir returns an empty root directory. Entries written cannot be
+// read back.
+func (w *Writer) ReadDir(name string) ([]os.FileInfo, error) {
+	if name != "" {
+		return nil, &os.PathError{Op: "readdir", Path: w.name + ":" + name, Err: syscall.ENOENT}
+	}
+	return nil, nil
+}
+
+// Method Lstat returns the attributes of the root directory. Other entries
+// do not exist.
+func (w *Writer) Lstat(name string) (os.FileInfo, error) {
+	if name != "" {
+		return nil, &os.PathError{Op: "lstat", Path: w.name + ":" + name, Err: syscall.ENOENT}
+	}
+	h := tar.Header{Typeflag: tar.TypeDir, Name: "/", Mode: 0755, ModTime: time.Now()}
+	return h.FileInfo(), nil
+}
+
+// Method Stat is the same as Lstat.
+func (w *Writer) Stat(name string) (os.FileInfo, error) {
+	return w.Lstat(name)
+}
+
+// Method Readlink fails, since entries cannot be read back.
+func (w *Writer) Readlink(name string) (string, error) {
+	return "", &os.PathError{Op: "readlink", Path: w.name + ":" + name, Err: syscall.ENOENT}
+}
+
+// Method OpenFile creates a regular file. Files cannot be opened for reading.
+func (w *Writer) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 || flag&os.O_CREATE == 0 || flag&os.O_APPEND != 0 {
+		return nil, &os.PathError{Op: "open", Path: w.name + ":" + name, Err: syscall.ENOENT}
+	}
+	e := w.newEntry(name, tar.TypeReg, perm)
+	f := &File{w: w, e: e, name: name, limit: w.opts.Buffer}
+	e.file = f
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, os.ErrClosed
+	}
+	if old, ok := w.pending[name]; ok && old.data != nil {
+		old.data.discard()
+	}
+	w.pending[name] = e
+	return f, nil
+}
+
+// Method Mkdir adds a directory. The root directory is not written.
+func (w *Writer) Mkdir(name string, perm os.FileMode) error {
+	if name == "" || name == "/" {
+		return nil
+	}
+	w.mu.Lock()
+	w.dirs[name] = true
+	w.mu.Unlock()
+	return w.add(name, w.newEntry(name, tar.TypeDir, perm))
+}
+
+// Method MkdirAll adds a directory, and its parents which were not added
+// before. The parents are written without waiting for their metadata.
+func (w *Writer) MkdirAll(name string, perm os.FileMode) error {
+	if name == "" || name == "/" {
+		return nil
+	}
+	w.mu.Lock()
+	var missing []string
+	for d := path.Clean(name); d != "/" && d != "." && !w.dirs[d]; d = path.Dir(d) {
+		missing = append(missing, d)
+		w.dirs[d] = true
+	}
+	w.mu.Unlock()
+	for i := len(missing) - 1; i >= 0; i-- {
+		e := w.newEntry(missing[i], tar.TypeDir, perm)
+		e.times, e.owner = false, false
+		if err := w.add(missing[i], e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Method Symlink adds the symbolic link newname pointing to oldname.
+func (w *Writer) Symlink(oldname, newname string) error {
+	e := w.newEntry(newname, tar.TypeSymlink, 0777)
+	e.hdr.Linkname = oldname
+	return w.add(newname, e)
+}
+
+// Method Rename is not supported.
+func (w *Writer) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: w.name + ":" + oldname, New: w.name + ":" + newname, Err: syscall.ENOTSUP}
+}
+
+// Method Remove is not supported.
+func (w *Writer) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: w.name + ":" + name, Err: syscall.ENOTSUP}
+}
+
+// Method Chown sets the owner of an entry.
+func (w *Writer) Chown(name string, uid, gid int) error {
+	return w.update("chown", name, func(e *entry) {
+		e.hdr.Uid, e.hdr.Gid = uid, gid
+		e.owner = false
+	})
+}
+
+// Method Lchown is the same as Chown.
+func (w *Writer) Lchown(name string, uid, gid int) error {
+	return w.update("lchown", name, func(e *entry) {
+		e.hdr.Uid, e.hdr.Gid = uid, gid
+		e.owner = false
+	})
+}
+
+// Method Chtimes sets the modification time of an entry. The access time is
+// not stored.
+func (w *Writer) Chtimes(name string, atime, mtime time.Time) error {
+	return w.update("chtimes", name, func(e *entry) {
+		e.hdr.ModTime = mtime
+		e.times = false
+	})
+}
+
+// Method Close writes the entries still waiting for their metadata, in the
+// order of their names, and completes the archive. Entries of files which
+// were not closed are dropped. Close can be called more than once.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		w.wmu.Lock()
+		defer w.wmu.Unlock()
+		return w.err
+	}
+	w.closed = true
+	names := make([]string, 0, len(w.pending))
+	for name := range w.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var rest []*entry
+	for _, name := range names {
+		if e := w.pending[name]; e.file == nil {
+			rest = append(rest, e)
+		}
+	}
+	w.pending = nil
+	w.mu.Unlock()
+
+	for _, e := range rest {
+		w.write(e)
+	}
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+	err := w.tw.Close()
+	if w.zw != nil && err == nil {
+		err = w.zw.Close()
+	}
+	if err == nil {
+		err = w.bw.Flush()
+	}
+	if w.out != nil {
+		if cerr := w.out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil && w.err == nil {
+		w.err = &os.PathError{Op: "write", Path: w.name, Err: err}
+	}
+	return w.err
+}
+
+// Type File is a regular file written to the archive. The data is buffered
+// until the file is closed.
+type File struct {
+	w     *Writer
+	e     *entry
+	name  string
+	buf   bytes.Buffer
+	tmp   *os.File // temporary file, once the data exceeds the limit
+	limit int64
+	size  int64
+}
+
+// Method Write appends data to the file.
+func (f *File) Write(b []byte) (int, error) {
+	if f.tmp == nil && f.size+int64(len(b)) > f.limit {
+		tmp, err := ioutil.TempFile("", "psync-archive-")
+		if err == nil {
+			_, err = tmp.Write(f.buf.Bytes())
+			if err != nil {
+				tmp.Close()
+				os.Remove(tmp.Name())
+			}
+		}
+		if err != nil {
+			return 0, &os.PathError{Op: "write", Path: f.w.name + ":" + f.name, Err: err}
+		}
+		f.tmp = tmp
+		f.buf = bytes.Buffer{}
+	}
+	var n int
+	var err error
+	if f.tmp != nil {
+		n, err = f.tmp.Write(b)
+	} else {
+		n, err = f.buf.Write(b)
+	}
+	f.size += int64(n)
+	if err != nil {
+		return n, &os.PathError{Op: "write", Path: f.w.name + ":" + f.name, Err: err}
+	}
+	return n, nil
+}
+
+// Method Read is not supported.
+func (f *File) Read(b []byte) (int, error) {
+	return 0, &os.PathError{Op: "read", Path: f.w.name + ":" + f.name, Err: syscall.EBADF}
+}
+
+// Method Seek returns the size written so far. The file cannot be
+// repositioned.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if (whence == io.SeekCurrent && offset == 0) || (whence == io.SeekStart && offset == f.size) {
+		return f.size, nil
+	}
+	return f.size, &os.PathError{Op: "seek", Path: f.w.name + ":" + f.name, Err: syscall.ESPIPE}
+}
+
+// Method Truncate is not supported.
+func (f *File) Truncate(size int64) error {
+	if size == f.size {
+		return nil
+	}
+	return &os.PathError{Op: "truncate", Path: f.w.name + ":" + f.name, Err: syscall.EINVAL}
+}
+
+// Method Close completes the file. Its entry is written, unless it waits for
+// its metadata.
+func (f *File) Close() error {
+	replaced := false
+	err := f.w.update("close", f.name, func(e *entry) {
+		if e == f.e {
+			e.file, e.data = nil, f
+		} else {
+			replaced = true
+		}
+	})
+	if replaced || err != nil {
+		// the file was opened again, or the archive is closed
+		f.discard()
+	}
+	return err
+}
+
+// Method discard drops the buffered data.
+func (f *File) discard() {
+	f.buf = bytes.Buffer{}
+	if f.tmp != nil {
+		f.tmp.Close()
+		os.Remove(f.tmp.Name())
+		f.tmp = nil
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package azblob is a minimal client for Azure Blob Storage, which presents a
+// container and blob name prefix as directory tree. Directories are name
+// prefixes, and are created as empty marker blobs with a trailing slash, so
+// that empty directories survive. Large files are uploaded as block blobs,
+// with several blocks sent in parallel. Requests are authorized with the
+// shared key of the storage account, or with a SAS token.
+package azblob
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// apiVersion is the version of the Blob service REST API used.
+const apiVersion = "2020-10-02"
+
+// Type Options configures an Azure Blob client. Empty fields are taken from
+// the environment variables given in parentheses.
+type Options struct {
+	Endpoint  string // URL of the blob service (AZURE_STORAGE_BLOB_ENDPOINT), default https://<account>.blob.core.windows.net
+	Key       string // shared key of the account (AZURE_STORAGE_KEY)
+	SAS       string // SAS token (AZURE_STORAGE_SAS_TOKEN), used if no key is given
+	BlockSize int64  // size of the blocks of large uploads, default 8 MiB
+	Uploads   int    // number of blocks uploaded in parallel per file, default 4
+	Conns     int    // maximum number of idle HTTP connections, default 64
+}
+
+// Type Client is a container and name prefix of a storage account. Names are
+// relative to the prefix, with a leading "/" ("" for the prefix itself).
+type Client struct {
+	account   string
+	container string
+	prefix    string
+	key       []byte
+	opts      Options
+	base      *url.URL
+	hc        *http.Client
+}
+
+// Function New returns a client for the container of the storage account.
+// The prefix is the blob name prefix the names are relative to.
+func New(account, container, prefix string, opts Options) (*Client, error) {
+	env := func(v *string, name string) {
+		if *v == "" {
+			*v = os.Getenv(name)
+		}
+	}
+	env(&opts.Endpoint, "AZURE_STORAGE_BLOB_ENDPOINT")
+	env(&opts.Key, "AZURE_STORAGE_KEY")
+	env(&opts.SAS, "AZURE_STORAGE_SAS_TOKEN")
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = 8 << 20
+	}
+	if opts.Uploads <= 0 {
+		opts.Uploads = 4
+	}
+	if opts.Conns <= 0 {
+		opts.Conns = 64
+	}
+	if account == "" || container == "" {
+		return nil, fmt.Errorf("azblob: no account or container given")
+	}
+	if opts.Key == "" && opts.SAS == "" {
+		return nil, fmt.Errorf("azblob: no shared key or SAS token given")
+	}
+	key, err := base64.StdEncoding.DecodeString(opts.Key)
+	if err != nil {
+		return nil, fmt.Errorf("azblob: invalid shared key: %s", err)
+	}
+	base, err := url.Parse(opts.Endpoint)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("azblob: invalid endpoint %s", opts.Endpoint)
+	}
+
+	return &Client{
+		account:   account,
+		container: container,
+		prefix:    strings.Trim(prefix, "/"),
+		key:       key,
+		opts:      opts,
+		base:      base,
+		hc: &http.Client{Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+			MaxIdleConns:          opts.Conns,
+			MaxIdleConnsPerHost:   opts.Conns,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: 2 * time.Minute,
+		}},
+	}, nil
+}
+
+// Method Close releases the idle connections of the client.
+func (cl *Client) Close() error {
+	if t, ok := cl.hc.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Method blob returns the blob name of a name.
+func (cl *Client) blob(name string) string {
+	return strings.TrimPrefix(path.Join(cl.prefix, name), "/")
+}
+
+// Method url returns the azblob:// URL of a name, for error messages.
+func (cl *Client) url(name string) string {
+	return "azblob://" + cl.account + "/" + cl.container + "/" + cl.blob(name)
+}
+
+// Type Error is an error response of the blob service.
+type Error struct {
+	Status  int    // HTTP status code
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// Method Error returns the error message.
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("azblob: HTTP status %d", e.Status)
+	}
+	return fmt.Sprintf("azblob: %s: %s", e.Code, strings.SplitN(e.Message, "\n", 2)[0])
+}
+
+// Function errno maps an error response to the corresponding system error,
+// so that os.IsNotExist and friends work.
+func errno(err error) error {
+	if e, ok := err.(*Error); ok {
+		switch e.Status {
+		case http.StatusNotFound:
+			return syscall.ENOENT
+		case http.StatusForbidden:
+			return syscall.EACCES
+		case http.StatusConflict, http.StatusPreconditionFailed:
+			return syscall.EEXIST
+		}
+	}
+	return err
+}
+
+// Method sign authorizes a request with the shared key of the account.
+func (cl *Client) sign(req *http.Request, length int) {
+	h := req.Header
+	var canonHeaders []string
+	for k, v := range h {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			canonHeaders = append(canonHeaders, k+":"+strings.TrimSpace(strings.Join(v, ",")))
+		}
+	}
+	sort.Strings(canonHeaders)
+
+	resource := "/" + cl.account + req.URL.EscapedPath()
+	q := req.URL.Query()
+	var params []string
+	for k, v := range q {
+		vs := append([]string{}, v...)
+		sort.Strings(vs)
+		params = append(params, strings.ToLower(k)+":"+strings.Join(vs, ","))
+	}
+	sort.Strings(params)
+	for _, p := range params {
+		resource += "\n" + p
+	}
+
+	contentLength := ""
+	if length > 0 {
+		contentLength = strconv.Itoa(length)
+	}
+	toSign := strings.Join([]string{
+		req.Method,
+		h.Get("Content-Encoding"),
+		h.Get("Content-Language"),
+		contentLength,
+		h.Get("Content-Md5"),
+		h.Get("Content-Type"),
+		"", // date, x-ms-date is used
+		h.Get("If-Modified-Since"),
+		h.Get("If-Match"),
+		h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"),
+		h.Get("Range"),
+	}, "\n") + "\n" + strings.Join(canonHeaders, "\n") + "\n" + resource
+
+	mac := hmac.New(sha256.New, cl.key)
+	mac.Write([]byte(toSign))
+	h.Set("Authorization", "SharedKey "+cl.account+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// Method request sends an authorized request for a blob, and returns the
+// response, which has a successful status. The caller closes the body.
+func (cl *Client) request(method, blob string, query url.Values, hdr http.Header, body []byte) (*http.Response, error) {
+	u := *cl.base
+	u.Path = strings.TrimSuffix(cl.base.Path, "/") + "/" + cl.container
+	if blob != "" {
+		u.Path += "/" + blob
+	}
+	u.RawPath = ""
+	if query == nil {
+		query = url.Values{}
+	}
+	if len(cl.key) == 0 {
+		sas, _ := url.ParseQuery(strings.TrimPrefix(cl.opts.SAS, "?"))
+		for k, v := range sas {
+			query[k] = v
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u.String(), rd)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", apiVersion)
+	if len(cl.key) > 0 {
+		cl.sign(req, len(body))
+	}
+
+	resp, err := cl.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		e := &Error{Status: resp.StatusCode}
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		xml.Unmarshal(b, e)
+		if e.Code == "" {
+			e.Code = resp.Header.Get("X-Ms-Error-Code")
+		}
+		return nil, e
+	}
+	return resp, nil
+}
+
+// Method do sends a request, and decodes the XML response into v, if it is
+// not nil.
+func (cl *Client) do(method, blob string, query url.Values, hdr http.Header, body []byte, v interface{}) (http.Header, error) {
+	resp, err := cl.request(method, blob, query, hdr, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		if err := xml.Unmarshal(b, v); err != nil {
+			return nil, fmt.Errorf("azblob: invalid response: %s", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// Type listResult is the response of List Blobs.
+type listResult struct {
+	Blobs struct {
+		Blob []struct {
+			Name       string `xml:"Name"`
+			Properties struct {
+				LastModified  string `xml:"Last-Modified"`
+				ContentLength int64  `xml:"Content-Length"`
+			} `xml:"Properties"`
+		} `xml:"Blob"`
+		BlobPrefix []struct {
+			Name string `xml:"Name"`
+		} `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// Method list lists the blobs with the given prefix, up to the next slash.
+// It stops after max entries, if max is positive.
+func (cl *Client) list(prefix string, max int) ([]os.FileInfo, error) {
+	q := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}, "delimiter": {"/"}}
+	if max > 0 {
+		q.Set("maxresults", strconv.Itoa(max))
+	}
+	var list []os.FileInfo
+	for {
+		var r listResult
+		if _, err := cl.do("GET", "", q, nil, nil, &r); err != nil {
+			return nil, err
+		}
+		for _, p := range r.Blobs.BlobPrefix {
+			name := strings.TrimSuffix(strings.TrimPrefix(p.Name, prefix), "/")
+			if name != "" {
+				list = append(list, &fileInfo{name: name, dir: true})
+			}
+		}
+		for _, b := range r.Blobs.Blob {
+			name := strings.TrimPrefix(b.Name, prefix)
+			if name == "" {
+				// the directory marker
+				continue
+			}
+			mtime, _ := http.ParseTime(b.Properties.LastModified)
+			list = append(list, &fileInfo{name: name, size: b.Properties.ContentLength, mtime: mtime})
+		}
+		if r.NextMarker == "" || (max > 0 && len(list) >= max) {
+			break
+		}
+		q.Set("marker", r.NextMarker)
+	}
+	return list, nil
+}
+
+// Method ReadDir lists a directory, and returns the entries sorted by name.
+// The modification times are those of the uploads; the file times kept in the
+// metadata are only returned by Stat and Lstat.
+func (cl *Client) ReadDir(name string) ([]os.FileInfo, error) {
+	prefix := cl.blob(name)
+	if prefix != "" {
+		prefix += "/"
+	}
+	list, err := cl.list(prefix, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: cl.url(name), Err: errno(err)}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+// Method head returns the attributes of a blob.
+func (cl *Client) head(blob string) (*fileInfo, error) {
+	hdr, err := cl.do("HEAD", blob, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	fi := &fileInfo{name: path.Base(blob), mode: 0644}
+	fi.size, _ = strconv.ParseInt(hdr.Get("Content-Length"), 10, 64)
+	fi.mtime, _ = http.ParseTime(hdr.Get("Last-Modified"))
+	if s, err := strconv.ParseInt(hdr.Get("X-Ms-Meta-Mtime"), 10, 64); err == nil {
+		fi.mtime = time.Unix(s, 0)
+	}
+	if m, err := strconv.ParseUint(hdr.Get("X-Ms-Meta-Mode"), 8, 32); err == nil {
+		fi.mode = os.FileMode(m).Perm()
+	}
+	return fi, nil
+}
+
+// Method Lstat returns the attributes of a file or directory. A directory
+// exists, if its marker blob or any blob below it exists.
+func (cl *Client) Lstat(name string) (os.FileInfo, error) {
+	blob := cl.blob(name)
+	if name == "" || blob == "" {
+		return &fileInfo{name: path.Base("/" + blob), dir: true}, nil
+	}
+	fi, err := cl.head(blob)
+	if err == nil {
+		return fi, nil
+	}
+	if errno(err) == syscall.ENOENT {
+		if fi, err = cl.head(blob + "/"); err == nil {
+			fi.name, fi.dir = path.Base(blob), true
+			return fi, nil
+		}
+	}
+	if errno(err) == syscall.ENOENT {
+		var list []os.FileInfo
+		if list, err = cl.list(blob+"/", 1); err == nil {
+			if len(list) > 0 {
+				return &fileInfo{name: path.Base(blob), dir: true}, nil
+			}
+			err = &Error{Status: http.StatusNotFound}
+		}
+	}
+	return nil, &os.PathError{Op: "lstat", Path: cl.url(name), Err: errno(err)}
+}
+
+// Method Stat is the same as Lstat, since there are no symbolic links.
+func (cl *Client) Stat(name string) (os.FileInfo, error) {
+	return cl.Lstat(name)
+}
+
+// Method Readlink fails, since there are no symbolic links.
+func (cl *Client) Readlink(name string) (string, error) {
+	return "", &os.PathError{Op: "readlink", Path: cl.url(name), Err: syscall.EINVAL}
+}
+
+// Method Symlink fails, since symbolic links cannot be stored.
+func (cl *Client) Symlink(oldname, newname string) error {
+	return &os.PathError{Op: "symlink", Path: cl.url(newname), Err: syscall.ENOTSUP}
+}
+
+// Method Mkdir creates the marker blob of a directory. An existing directory
+// is reported with EEXIST.
+func (cl *Client) Mkdir(name string, perm os.FileMode) error {
+	blob := cl.blob(name)
+	if blob == "" {
+		return &os.PathError{Op: "mkdir", Path: cl.url(name), Err: syscall.EEXIST}
+	}
+	hdr := http.Header{
+		"If-None-Match":  {"*"},
+		"X-Ms-Blob-Type": {"BlockBlob"},
+		"X-Ms-Meta-Mode": {strconv.FormatUint(uint64(perm.Perm()), 8)},
+	}
+	if _, err := cl.do("PUT", blob+"/", nil, hdr, []byte{}, nil); err != nil {
+		return &os.PathError{Op: "mkdir", Path: cl.url(name), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method MkdirAll creates the marker blob of a directory, if it does not
+// exist. The parent directories are implied by the name.
+func (cl *Client) MkdirAll(name string, perm os.FileMode) error {
+	if cl.blob(name) == "" {
+		return nil
+	}
+	err := cl.Mkdir(name, perm)
+	if os.IsExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Method Rename copies a blob to the new name, and deletes the old one.
+// Directories cannot be renamed.
+func (cl *Client) Rename(oldname, newname string) error {
+	src := *cl.base
+	src.Path = strings.TrimSuffix(cl.base.Path, "/") + "/" + cl.container + "/" + cl.blob(oldname)
+	if len(cl.key) == 0 {
+		src.RawQuery = strings.TrimPrefix(cl.opts.SAS, "?")
+	}
+	hdr := http.Header{"X-Ms-Copy-Source": {src.String()}}
+	h, err := cl.do("PUT", cl.blob(newname), nil, hdr, nil, nil)
+	for err == nil && h.Get("X-Ms-Copy-Status") == "pending" {
+		// copies within the account are usually synchronous
+		time.Sleep(time.Second)
+		h, err = cl.do("HEAD", cl.blob(newname), nil, nil, nil, nil)
+	}
+	if err == nil && h.Get("X-Ms-Copy-Status") != "" && h.Get("X-Ms-Copy-Status") != "success" {
+		err = fmt.Errorf("azblob: copy %s", h.Get("X-Ms-Copy-Status"))
+	}
+	if err == nil {
+		_, err = cl.do("DELETE", cl.blob(oldname), nil, nil, nil, nil)
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: cl.url(oldname), New: cl.url(newname), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method Remove deletes a blob, or the marker blob of a directory.
+func (cl *Client) Remove(name string) error {
+	blob := cl.blob(name)
+	fi, err := cl.Lstat(name)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		blob += "/"
+	}
+	if _, err := cl.do("DELETE", blob, nil, nil, nil, nil); err != nil {
+		return &os.PathError{Op: "remove", Path: cl.url(name), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method Chown fails, since blobs have no owner.
+func (cl *Client) Chown(name string, uid, gid int) error {
+	return &os.PathError{Op: "chown", Path: cl.url(name), Err: syscall.ENOTSUP}
+}
+
+// Method Lchown fails, since blobs have no owner.
+func (cl *Client) Lchown(name string, uid, gid int) error {
+	return &os.PathError{Op: "lchown", Path: cl.url(name), Err: syscall.ENOTSUP}
+}
+
+// Method Chtimes stores the modification time of a file in its metadata.
+// Directories are ignored.
+func (cl *Client) Chtimes(name string, atime, mtime time.Time) error {
+	blob := cl.blob(name)
+	fi, err := cl.head(blob)
+	if errno(err) == syscall.ENOENT {
+		if fi, err := cl.Lstat(name); err == nil && fi.IsDir() {
+			return nil
+		}
+	}
+	if err == nil {
+		// setting the metadata replaces all of it
+		hdr := http.Header{
+			"X-Ms-Meta-Mtime": {strconv.FormatInt(mtime.Unix(), 10)},
+			"X-Ms-Meta-Mode":  {strconv.FormatUint(uint64(fi.mode), 8)},
+		}
+		_, err = cl.do("PUT", blob, url.Values{"comp": {"metadata"}}, hdr, nil, nil)
+	}
+	if err != nil {
+		return &os.PathError{Op: "chtimes", Path: cl.url(name), Err: errno(err)}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package azblob
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Type fileInfo implements os.FileInfo for blobs and name prefixes.
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	dir   bool
+}
+
+// Method Name returns the base name of the blob.
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+// Method Size returns the size of the blob in bytes.
+func (fi *fileInfo) Size() int64 {
+	return fi.size
+}
+
+// Method Mode returns the permissions kept in the metadata, or 0644 for
+// blobs and 0755 for directories.
+func (fi *fileInfo) Mode() os.FileMode {
+	m := fi.mode
+	if m == 0 {
+		m = 0644
+		if fi.dir {
+			m = 0755
+		}
+	}
+	if fi.dir {
+		m |= os.ModeDir
+	}
+	return m
+}
+
+// Method ModTime returns the modification time kept in the metadata, or the
+// time of the upload.
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.mtime
+}
+
+// Method IsDir checks whether the entry is a directory (name prefix).
+func (fi *fileInfo) IsDir() bool {
+	return fi.dir
+}
+
+// Method Sys returns nil, there are no system specific attributes.
+func (fi *fileInfo) Sys() interface{} {
+	return nil
+}
+
+// Type File is a blob opened for reading or writing. Reading streams the
+// blob, seeking restarts the download at the new offset. Writing buffers the
+// data, and uploads it in blocks of BlockSize bytes, several in parallel;
+// small blobs are uploaded with a single request on Close. Written blobs can
+// neither be read nor seeked.
+type File struct {
+	cl    *Client
+	name  string
+	blob  string
+	write bool
+	off   int64 // offset of the next byte read or written
+
+	// reading
+	body io.ReadCloser
+
+	// writing
+	perm   os.FileMode
+	buf    []byte
+	blocks []string // ids of the uploaded blocks
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex // protects err
+	err    error
+}
+
+// Method Open opens a blob for reading.
+func (cl *Client) Open(name string) (*File, error) {
+	return &File{cl: cl, name: name, blob: cl.blob(name)}, nil
+}
+
+// Method OpenFile opens a blob for reading or writing. Writing replaces the
+// blob, appending is not supported.
+func (cl *Client) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return cl.Open(name)
+	}
+	if flag&(os.O_RDWR|os.O_APPEND) != 0 {
+		return nil, &os.PathError{Op: "open", Path: cl.url(name), Err: syscall.ENOTSUP}
+	}
+	return &File{
+		cl:    cl,
+		name:  name,
+		blob:  cl.blob(name),
+		write: true,
+		perm:  perm.Perm(),
+		sem:   make(chan struct{}, cl.opts.Uploads),
+	}, nil
+}
+
+// Method Read reads from the object, starting the download if needed.
+func (f *File) Read(b []byte) (int, error) {
+	if f.write {
+		return 0, &os.PathError{Op: "read", Path: f.cl.url(f.name), Err: syscall.EBADF}
+	}
+	if f.body == nil {
+		var hdr http.Header
+		if f.off > 0 {
+			hdr = http.Header{"X-Ms-Range": {"bytes=" + strconv.FormatInt(f.off, 10) + "-"}}
+		}
+		resp, err := f.cl.request("GET", f.blob, nil, hdr, nil)
+		if e, ok := err.(*Error); ok && e.Status == http.StatusRequestedRangeNotSatisfiable {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, &os.PathError{Op: "read", Path: f.cl.url(f.name), Err: errno(err)}
+		}
+		f.body = resp.Body
+	}
+	n, err := f.body.Read(b)
+	f.off += int64(n)
+	if err != nil && err != io.EOF {
+		err = &os.PathError{Op: "read", Path: f.cl.url(f.name), Err: err}
+	}
+	return n, err
+}
+
+// Method Write buffers the data, and starts the upload of full blocks.
+func (f *File) Write(b []byte) (int, error) {
+	if !f.write {
+		return 0, &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: syscall.EBADF}
+	}
+	if err := f.error(); err != nil {
+		return 0, err
+	}
+	f.buf = append(f.buf, b...)
+	f.off += int64(len(b))
+	for int64(len(f.buf)) >= f.cl.opts.BlockSize {
+		f.sendBlock(f.buf[:f.cl.opts.BlockSize])
+		f.buf = append([]byte{}, f.buf[f.cl.opts.BlockSize:]...)
+	}
+	return len(b), nil
+}
+
+// Method error returns the first error of the block uploads.
+func (f *File) error() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Method sendBlock starts the upload of a block in the background. The block
+// ids have the same length, as required.
+func (f *File) sendBlock(data []byte) {
+	id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%010d", len(f.blocks))))
+	f.blocks = append(f.blocks, id)
+
+	f.sem <- struct{}{}
+	f.wg.Add(1)
+	go func(id string, data []byte) {
+		defer func() {
+			<-f.sem
+			f.wg.Done()
+		}()
+		q := url.Values{"comp": {"block"}, "blockid": {id}}
+		if _, err := f.cl.do("PUT", f.blob, q, nil, data, nil); err != nil {
+			f.mu.Lock()
+			if f.err == nil {
+				f.err = &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+			}
+			f.mu.Unlock()
+		}
+	}(id, append([]byte{}, data...))
+}
+
+// Method Seek sets the offset for the next Read. Written objects only
+// support seeking to the current offset.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	default:
+		return f.off, &os.PathError{Op: "seek", Path: f.cl.url(f.name), Err: errors.New("unsupported whence")}
+	}
+	if offset == f.off {
+		return offset, nil
+	}
+	if f.write || offset < 0 {
+		return f.off, &os.PathError{Op: "seek", Path: f.cl.url(f.name), Err: syscall.EINVAL}
+	}
+	if f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.off = offset
+	return offset, nil
+}
+
+// Method Truncate is only supported for the size already written.
+func (f *File) Truncate(size int64) error {
+	if !f.write || size != f.off {
+		return &os.PathError{Op: "truncate", Path: f.cl.url(f.name), Err: syscall.ENOTSUP}
+	}
+	return nil
+}
+
+// Method Close finishes the download or upload.
+func (f *File) Close() error {
+	if !f.write {
+		if f.body != nil {
+			f.body.Close()
+			f.body = nil
+		}
+		return nil
+	}
+
+	// small blobs are uploaded at once
+	hdr := http.Header{"X-Ms-Meta-Mode": {strconv.FormatUint(uint64(f.perm), 8)}}
+	if len(f.blocks) == 0 {
+		hdr.Set("X-Ms-Blob-Type", "BlockBlob")
+		if _, err := f.cl.do("PUT", f.blob, nil, hdr, f.buf, nil); err != nil {
+			return &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+		}
+		return nil
+	}
+
+	// upload the last block, and commit the block list
+	if len(f.buf) > 0 {
+		f.sendBlock(f.buf)
+	}
+	f.wg.Wait()
+	f.buf = nil
+	if err := f.error(); err != nil {
+		return err
+	}
+	body, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{Latest: f.blocks})
+	if _, err := f.cl.do("PUT", f.blob, url.Values{"comp": {"blocklist"}}, hdr, body, nil); err != nil {
+		return &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+	}
+	return nil
+}
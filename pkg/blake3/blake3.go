@@ -0,0 +1,272 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package blake3 implements the BLAKE3 cryptographic hash function in its
+// default hashing mode, with an output of 32 bytes. The input is split into
+// chunks of 1 KiB, whose chaining values are merged in a binary tree.
+//
+// The implementation is a portable port of the reference implementation. It
+// neither hashes the chunks in parallel nor uses SIMD instructions, so it is
+// slower than the optimized implementations, but still faster than SHA-256
+// on most CPUs without SHA extensions.
+package blake3
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Size is the size of a BLAKE3 checksum in bytes.
+const Size = 32
+
+// BlockSize is the size of the blocks compressed at once.
+const BlockSize = 64
+
+const (
+	chunkLen = 1024 // bytes per chunk, the leaves of the tree
+
+	chunkStart = 1 << 0
+	chunkEnd   = 1 << 1
+	parent     = 1 << 2
+	root       = 1 << 3
+)
+
+// iv is the initialization vector of BLAKE3, the same as of SHA-256.
+var iv = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A, 0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+// schedule holds the order of the message words in each round. The words
+// are permuted by 2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8
+// between the rounds.
+var schedule = [7][16]uint8{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8},
+	{3, 4, 10, 12, 13, 2, 7, 14, 6, 5, 9, 0, 11, 15, 8, 1},
+	{10, 7, 12, 9, 14, 3, 13, 15, 4, 0, 11, 2, 5, 8, 1, 6},
+	{12, 13, 9, 11, 15, 10, 14, 8, 7, 2, 5, 3, 0, 1, 6, 4},
+	{9, 14, 11, 5, 8, 12, 15, 1, 13, 3, 0, 10, 2, 6, 4, 7},
+	{11, 15, 5, 0, 1, 9, 8, 6, 14, 10, 2, 12, 3, 4, 7, 13},
+}
+
+// Function g is the quarter round, mixing a column or diagonal of the state
+// with two message words.
+func g(a, b, c, d, x, y uint32) (uint32, uint32, uint32, uint32) {
+	a += b + x
+	d = bits.RotateLeft32(d^a, -16)
+	c += d
+	b = bits.RotateLeft32(b^c, -12)
+	a += b + y
+	d = bits.RotateLeft32(d^a, -8)
+	c += d
+	b = bits.RotateLeft32(b^c, -7)
+	return a, b, c, d
+}
+
+// Function compress is the compression function. It returns the whole state,
+// whose first eight words are the new chaining value.
+func compress(cv *[8]uint32, m *[16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	v0, v1, v2, v3, v4, v5, v6, v7 := cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7]
+	v8, v9, v10, v11 := iv[0], iv[1], iv[2], iv[3]
+	v12, v13, v14, v15 := uint32(counter), uint32(counter>>32), blockLen, flags
+	for r := range schedule {
+		s := &schedule[r]
+		v0, v4, v8, v12 = g(v0, v4, v8, v12, m[s[0]], m[s[1]])
+		v1, v5, v9, v13 = g(v1, v5, v9, v13, m[s[2]], m[s[3]])
+		v2, v6, v10, v14 = g(v2, v6, v10, v14, m[s[4]], m[s[5]])
+		v3, v7, v11, v15 = g(v3, v7, v11, v15, m[s[6]], m[s[7]])
+		v0, v5, v10, v15 = g(v0, v5, v10, v15, m[s[8]], m[s[9]])
+		v1, v6, v11, v12 = g(v1, v6, v11, v12, m[s[10]], m[s[11]])
+		v2, v7, v8, v13 = g(v2, v7, v8, v13, m[s[12]], m[s[13]])
+		v3, v4, v9, v14 = g(v3, v4, v9, v14, m[s[14]], m[s[15]])
+	}
+	return [16]uint32{
+		v0 ^ v8, v1 ^ v9, v2 ^ v10, v3 ^ v11, v4 ^ v12, v5 ^ v13, v6 ^ v14, v7 ^ v15,
+		v8 ^ cv[0], v9 ^ cv[1], v10 ^ cv[2], v11 ^ cv[3], v12 ^ cv[4], v13 ^ cv[5], v14 ^ cv[6], v15 ^ cv[7],
+	}
+}
+
+// Function words converts a block of bytes into little endian words.
+func words(b *[BlockSize]byte) (w [16]uint32) {
+	for i := range w {
+		w[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return w
+}
+
+// Type output is a node of the tree before its compression, which yields
+// either its chaining value, or the checksum for the root node.
+type output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+// Method chainingValue compresses an inner node.
+func (o *output) chainingValue() (cv [8]uint32) {
+	s := compress(&o.cv, &o.block, o.counter, o.blockLen, o.flags)
+	copy(cv[:], s[:8])
+	return cv
+}
+
+// Method rootBytes compresses the root node, and appends the checksum to b.
+func (o *output) rootBytes(b []byte) []byte {
+	s := compress(&o.cv, &o.block, 0, o.blockLen, o.flags|root)
+	var out [Size]byte
+	for i := 0; i < 8; i++ {
+		binary.LittleEndian.PutUint32(out[4*i:], s[i])
+	}
+	return append(b, out[:]...)
+}
+
+// Function parentOutput returns the parent node of two chaining values.
+func parentOutput(left, right [8]uint32) output {
+	o := output{cv: iv, blockLen: BlockSize, flags: parent}
+	copy(o.block[:8], left[:])
+	copy(o.block[8:], right[:])
+	return o
+}
+
+// Type chunkState is the state of the chunk being hashed.
+type chunkState struct {
+	cv         [8]uint32
+	counter    uint64
+	block      [BlockSize]byte
+	blockLen   int
+	compressed int // blocks compressed in the chunk
+}
+
+// Function newChunkState returns the state of the chunk with the given index.
+func newChunkState(counter uint64) chunkState {
+	return chunkState{cv: iv, counter: counter}
+}
+
+// Method len returns the number of bytes of the chunk hashed so far.
+func (c *chunkState) len() int {
+	return BlockSize*c.compressed + c.blockLen
+}
+
+// Method startFlag returns the flag of the first block of the chunk.
+func (c *chunkState) startFlag() uint32 {
+	if c.compressed == 0 {
+		return chunkStart
+	}
+	return 0
+}
+
+// Method update adds input to the chunk. The last block is compressed only
+// when more input follows, since it gets the flag of the chunk end.
+func (c *chunkState) update(p []byte) {
+	for len(p) > 0 {
+		if c.blockLen == BlockSize {
+			w := words(&c.block)
+			s := compress(&c.cv, &w, c.counter, BlockSize, c.startFlag())
+			copy(c.cv[:], s[:8])
+			c.compressed++
+			c.block = [BlockSize]byte{}
+			c.blockLen = 0
+		}
+		n := copy(c.block[c.blockLen:], p)
+		c.blockLen += n
+		p = p[n:]
+	}
+}
+
+// Method output returns the node of the chunk.
+func (c *chunkState) output() output {
+	return output{
+		cv:       c.cv,
+		block:    words(&c.block),
+		counter:  c.counter,
+		blockLen: uint32(c.blockLen),
+		flags:    c.startFlag() | chunkEnd,
+	}
+}
+
+// Type digest is the state of a streaming BLAKE3 hash. The chaining values of
+// the complete subtrees are kept on a stack, which holds at most one value per
+// level of the tree.
+type digest struct {
+	chunk chunkState
+	stack [][8]uint32
+}
+
+// Function New returns a new hash.Hash computing the BLAKE3 checksum.
+func New() hash.Hash {
+	d := new(digest)
+	d.Reset()
+	return d
+}
+
+// Function Sum256 returns the BLAKE3 checksum of the data.
+func Sum256(b []byte) (sum [Size]byte) {
+	d := digest{chunk: newChunkState(0)}
+	d.Write(b)
+	copy(sum[:], d.Sum(nil))
+	return sum
+}
+
+// Method Reset resets the hash to its initial state.
+func (d *digest) Reset() {
+	d.chunk = newChunkState(0)
+	d.stack = d.stack[:0]
+}
+
+// Method Size returns the number of bytes Sum will append.
+func (d *digest) Size() int {
+	return Size
+}
+
+// Method BlockSize returns the size of the blocks compressed at once.
+func (d *digest) BlockSize() int {
+	return BlockSize
+}
+
+// Method Write adds data to the hash. It never returns an error.
+func (d *digest) Write(p []byte) (int, error) {
+	size := len(p)
+	for len(p) > 0 {
+		if d.chunk.len() == chunkLen {
+			cv := d.chunk.output()
+			d.addChunk(cv.chainingValue(), d.chunk.counter+1)
+			d.chunk = newChunkState(d.chunk.counter + 1)
+		}
+		n := chunkLen - d.chunk.len()
+		if n > len(p) {
+			n = len(p)
+		}
+		d.chunk.update(p[:n])
+		p = p[n:]
+	}
+	return size, nil
+}
+
+// Method addChunk pushes the chaining value of a complete chunk to the stack,
+// after merging it with the complete subtrees of the same size. The number of
+// trailing zero bits of the number of chunks is the number of subtrees to
+// merge.
+func (d *digest) addChunk(cv [8]uint32, chunks uint64) {
+	for chunks&1 == 0 {
+		top := len(d.stack) - 1
+		p := parentOutput(d.stack[top], cv)
+		cv = p.chainingValue()
+		d.stack = d.stack[:top]
+		chunks >>= 1
+	}
+	d.stack = append(d.stack, cv)
+}
+
+// Method Sum appends the checksum of the data written so far to b. The last
+// chunk is merged with the subtrees on the stack, which are all incomplete
+// now, from the right to the left.
+func (d *digest) Sum(b []byte) []byte {
+	o := d.chunk.output()
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		o = parentOutput(d.stack[i], o.chainingValue())
+	}
+	return o.rootBytes(b)
+}
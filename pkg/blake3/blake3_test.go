@@ -0,0 +1,91 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package blake3
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// input returns the input of the official test vectors: the bytes 0 to 250,
+// repeated.
+func input(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range []struct {
+		in  []byte
+		sum string
+	}{
+		{nil, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{input(1), "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213"},
+		{[]byte("abc"), "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"},
+		{input(1024), "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7"},
+		{input(1025), "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444"},
+	} {
+		sum := Sum256(v.in)
+		if got := hex.EncodeToString(sum[:]); got != v.sum {
+			t.Errorf("Sum256(%q) = %s, want %s", v.in, got, v.sum)
+		}
+	}
+}
+
+// treeSum computes the checksum by the recursive definition of the tree: the
+// left subtree holds the largest power of two of chunks, which leaves at
+// least one byte for the right subtree.
+func treeSum(b []byte) []byte {
+	o := treeNode(b, 0)
+	return o.rootBytes(nil)
+}
+
+func treeNode(b []byte, counter uint64) output {
+	if len(b) <= chunkLen {
+		c := newChunkState(counter)
+		c.update(b)
+		return c.output()
+	}
+	left := chunkLen
+	for 2*left < len(b) {
+		left *= 2
+	}
+	l := treeNode(b[:left], counter)
+	r := treeNode(b[left:], counter+uint64(left/chunkLen))
+	return parentOutput(l.chainingValue(), r.chainingValue())
+}
+
+func TestTree(t *testing.T) {
+	for _, n := range []int{0, 1, 63, 64, 65, 1023, 1024, 1025, 2048, 2049, 3072, 3073,
+		4096, 4097, 5120, 5121, 8192, 8193, 16384, 31744, 102400} {
+		b := input(n)
+		want := hex.EncodeToString(treeSum(b))
+		for _, step := range []int{1, 63, 64, 1000, 1024, 4096, n + 1} {
+			h := New()
+			for i := 0; i < n; i += step {
+				j := i + step
+				if j > n {
+					j = n
+				}
+				h.Write(b[i:j])
+			}
+			if got := hex.EncodeToString(h.Sum(nil)); got != want {
+				t.Errorf("length %d, writes of %d bytes: got %s, want %s", n, step, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	buf := input(1 << 20)
+	b.SetBytes(int64(len(buf)))
+	h := New()
+	for i := 0; i < b.N; i++ {
+		h.Write(buf)
+	}
+}
@@ -0,0 +1,425 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/delta"
+)
+
+// errClosed is returned for requests on a closed or broken connection.
+var errClosed = errors.New("daemon: connection closed")
+
+// Type reply is the response to a request, or the error of the connection.
+type reply struct {
+	r   *response
+	err error
+}
+
+// Type conn is a connection to the daemon. Requests are pipelined: many
+// requests can be in flight at the same time, and the responses are matched
+// to the requests by their id.
+type conn struct {
+	c       net.Conn
+	st      *stream
+	wmu     sync.Mutex // serializes the writing of requests
+	mu      sync.Mutex // protects pending and err
+	pending map[uint32]chan reply
+	err     error
+}
+
+// Function dial connects to the daemon, and runs the handshake.
+func dial(addr, module string, opts *Options) (*conn, bool, error) {
+	nc, err := net.DialTimeout("tcp", addr, opts.Timeout)
+	if err != nil {
+		return nil, false, err
+	}
+	c := &conn{c: nc, st: newStream(nc), pending: make(map[uint32]chan reply)}
+
+	if opts.Timeout > 0 {
+		nc.SetDeadline(time.Now().Add(opts.Timeout))
+	}
+	var ch challenge
+	var wel welcome
+	err = c.st.receive(&ch)
+	if err == nil && ch.Version != protocolVersion {
+		err = fmt.Errorf("daemon speaks protocol version %d", ch.Version)
+	}
+	if err == nil {
+		h := hello{Version: protocolVersion, Module: module}
+		if opts.Secret != nil {
+			h.MAC = mac(opts.Secret, ch.Nonce)
+		}
+		if opts.Compress {
+			h.Compress = []string{compressDeflate}
+		}
+		err = c.st.send(h)
+	}
+	if err == nil {
+		err = c.st.receive(&wel)
+	}
+	if err == nil && wel.Err != "" {
+		err = errors.New(wel.Err)
+	}
+	if err == nil && wel.Compress != "" && wel.Compress != compressDeflate {
+		err = fmt.Errorf("daemon chose unknown compression %q", wel.Compress)
+	}
+	if err != nil {
+		nc.Close()
+		return nil, false, err
+	}
+	nc.SetDeadline(time.Time{})
+	if wel.Compress != "" {
+		c.st.compress()
+	}
+	go c.readLoop()
+	return c, wel.ReadOnly, nil
+}
+
+// Method start sends a request, and returns the channel on which the
+// response is delivered.
+func (c *conn) start(rq *request) chan reply {
+	ch := make(chan reply, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		ch <- reply{err: c.err}
+		return ch
+	}
+	c.pending[rq.ID] = ch
+	c.mu.Unlock()
+
+	c.wmu.Lock()
+	err := c.st.send(rq)
+	c.wmu.Unlock()
+	if err != nil {
+		c.fail(err)
+	}
+	return ch
+}
+
+// Method forget drops a pending request, e.g. after a timeout.
+func (c *conn) forget(id uint32) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Method fail closes the connection and delivers the error to all pending
+// requests.
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	for id, ch := range c.pending {
+		ch <- reply{err: err}
+		delete(c.pending, id)
+	}
+	c.c.Close()
+}
+
+// Method readLoop reads the responses and hands them to the waiting
+// requests.
+func (c *conn) readLoop() {
+	for {
+		r := new(response)
+		if err := c.st.receive(r); err != nil {
+			c.fail(err)
+			return
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[r.ID]
+		delete(c.pending, r.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- reply{r: r}
+		}
+	}
+}
+
+// Type call is a request in flight.
+type call struct {
+	cl *Client
+	c  *conn
+	id uint32
+	ch chan reply
+}
+
+// Method wait waits for the response of a request, and returns it with its
+// error. A request without response within the timeout fails with
+// ETIMEDOUT.
+func (ca *call) wait() (*response, error) {
+	var rp reply
+	if ca.cl.opts.Timeout > 0 {
+		t := time.NewTimer(ca.cl.opts.Timeout)
+		select {
+		case rp = <-ca.ch:
+			t.Stop()
+		case <-t.C:
+			ca.c.forget(ca.id)
+			return nil, syscall.ETIMEDOUT
+		}
+	} else {
+		rp = <-ca.ch
+	}
+	if rp.err != nil {
+		return nil, rp.err
+	}
+	return rp.r, decodeError(rp.r)
+}
+
+// Type Options configures a daemon client.
+type Options struct {
+	Secret    []byte        // shared secret, nil for none
+	Conns     int           // number of connections, default 4
+	ReadAhead int           // number of requests in flight per open file, default 8
+	Timeout   time.Duration // timeout of connection setup and requests, 0 for none
+	Compress  bool          // compress the traffic, if the daemon supports it
+}
+
+// chunk is the number of bytes read or written by a single request.
+const chunk = 256 * 1024
+
+// maxCopy is the upper limit for the bytes copied by a single request.
+const maxCopy = 64 << 20
+
+// Type Client is a connection to a module of a psync daemon. The names are
+// relative to the directory given to Dial.
+type Client struct {
+	addr     string
+	module   string
+	root     string
+	opts     Options
+	conns    []*conn
+	readOnly bool
+	next     uint32 // connection for the next request
+	id       uint32 // last request id
+}
+
+// Function Dial connects to the module of the daemon listening on addr,
+// which has the form host[:port]. The root is the directory within the
+// module the names are relative to.
+func Dial(addr, module, root string, opts Options) (*Client, error) {
+	if opts.Conns <= 0 {
+		opts.Conns = 4
+	}
+	if opts.ReadAhead <= 0 {
+		opts.ReadAhead = 8
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(DefaultPort))
+	}
+
+	cl := &Client{addr: addr, module: module, root: path.Clean("/" + root), opts: opts}
+	for i := 0; i < opts.Conns; i++ {
+		c, ro, err := dial(addr, module, &cl.opts)
+		if err != nil {
+			cl.Close()
+			return nil, fmt.Errorf("daemon: cannot connect to module %s on %s: %s", module, addr, err)
+		}
+		cl.conns = append(cl.conns, c)
+		cl.readOnly = ro
+	}
+	return cl, nil
+}
+
+// Method Close closes all connections of the client.
+func (cl *Client) Close() error {
+	for _, c := range cl.conns {
+		c.fail(errClosed)
+	}
+	return nil
+}
+
+// Method ReadOnly checks whether the module is exported read-only.
+func (cl *Client) ReadOnly() bool {
+	return cl.readOnly
+}
+
+// Method path returns the path of a name within the module.
+func (cl *Client) path(name string) string {
+	return path.Join(cl.root, name)
+}
+
+// Method url returns the name as host::module/path, for error messages.
+func (cl *Client) url(name string) string {
+	return cl.addr + "::" + cl.module + cl.path(name)
+}
+
+// Method conn returns the next connection, round robin.
+func (cl *Client) conn() *conn {
+	return cl.conns[atomic.AddUint32(&cl.next, 1)%uint32(len(cl.conns))]
+}
+
+// Method start sends a request over the next connection.
+func (cl *Client) start(rq *request) *call {
+	return cl.startOn(cl.conn(), rq)
+}
+
+// Method startOn sends a request over the given connection. Requests on a
+// file handle must use the connection which opened it.
+func (cl *Client) startOn(c *conn, rq *request) *call {
+	rq.ID = atomic.AddUint32(&cl.id, 1)
+	return &call{cl, c, rq.ID, c.start(rq)}
+}
+
+// Method named sends a request with the name translated to the module path.
+func (cl *Client) named(op string, name string, rq request) (*response, error) {
+	rq.Name = cl.path(name)
+	r, err := cl.start(&rq).wait()
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: cl.url(name), Err: err}
+	}
+	return r, nil
+}
+
+// Method stat requests the attributes of a file.
+func (cl *Client) stat(op string, typ int, name string) (os.FileInfo, error) {
+	r, err := cl.named(op, name, request{Op: typ})
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Infos) != 1 {
+		return nil, &os.PathError{Op: op, Path: cl.url(name), Err: errors.New("daemon: invalid stat response")}
+	}
+	return &fileInfo{r.Infos[0]}, nil
+}
+
+// Method Lstat returns the attributes of a file. Symbolic links are not
+// followed.
+func (cl *Client) Lstat(name string) (os.FileInfo, error) {
+	return cl.stat("lstat", opLstat, name)
+}
+
+// Method Stat returns the attributes of a file, following symbolic links.
+func (cl *Client) Stat(name string) (os.FileInfo, error) {
+	return cl.stat("stat", opStat, name)
+}
+
+// Method Readlink returns the target of a symbolic link.
+func (cl *Client) Readlink(name string) (string, error) {
+	r, err := cl.named("readlink", name, request{Op: opReadlink})
+	if err != nil {
+		return "", err
+	}
+	return r.Target, nil
+}
+
+// Method ReadDir reads a directory, and returns the entries sorted by name,
+// like ioutil.ReadDir.
+func (cl *Client) ReadDir(name string) ([]os.FileInfo, error) {
+	r, err := cl.named("readdir", name, request{Op: opReadDir})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]os.FileInfo, len(r.Infos))
+	for i := range r.Infos {
+		list[i] = &fileInfo{r.Infos[i]}
+	}
+	return list, nil
+}
+
+// Method Open opens a file for reading.
+func (cl *Client) Open(name string) (*File, error) {
+	return cl.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile opens a file with the flags of os.OpenFile. The permissions
+// are applied to new files.
+func (cl *Client) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	c := cl.conn()
+	r, err := cl.startOn(c, &request{Op: opOpen, Name: cl.path(name), Flag: flag, Mode: uint32(perm)}).wait()
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: cl.url(name), Err: err}
+	}
+	return &File{cl: cl, c: c, name: cl.url(name), h: r.Handle}, nil
+}
+
+// Method Signature returns the signature of a file for the delta transfer,
+// computed by the daemon.
+func (cl *Client) Signature(name string, blockSize int) (*delta.Signature, error) {
+	r, err := cl.named("signature", name, request{Op: opSignature, Len: blockSize})
+	if err != nil {
+		return nil, err
+	}
+	if r.Sig == nil || len(r.Sig.Weak) != len(r.Sig.Strong) {
+		return nil, &os.PathError{Op: "signature", Path: cl.url(name), Err: errors.New("daemon: invalid signature response")}
+	}
+	return r.Sig, nil
+}
+
+// Method Mkdir creates a directory.
+func (cl *Client) Mkdir(name string, perm os.FileMode) error {
+	_, err := cl.named("mkdir", name, request{Op: opMkdir, Mode: uint32(perm)})
+	return err
+}
+
+// Method MkdirAll creates a directory and its parents, if they do not exist.
+func (cl *Client) MkdirAll(name string, perm os.FileMode) error {
+	_, err := cl.named("mkdir", name, request{Op: opMkdirAll, Mode: uint32(perm)})
+	return err
+}
+
+// Method Symlink creates the symbolic link newname pointing to oldname. The
+// link target oldname is not relative to the root.
+func (cl *Client) Symlink(oldname, newname string) error {
+	_, err := cl.named("symlink", newname, request{Op: opSymlink, Name2: oldname})
+	return err
+}
+
+// Method Rename renames a file, replacing the target if it exists.
+func (cl *Client) Rename(oldname, newname string) error {
+	rq := &request{Op: opRename, Name: cl.path(oldname), Name2: cl.path(newname)}
+	if _, err := cl.start(rq).wait(); err != nil {
+		return &os.LinkError{Op: "rename", Old: cl.url(oldname), New: cl.url(newname), Err: err}
+	}
+	return nil
+}
+
+// Method Remove removes a file or an empty directory.
+func (cl *Client) Remove(name string) error {
+	_, err := cl.named("remove", name, request{Op: opRemove})
+	return err
+}
+
+// Method Chown changes the owner of a file.
+func (cl *Client) Chown(name string, uid, gid int) error {
+	_, err := cl.named("chown", name, request{Op: opChown, UID: uid, GID: gid})
+	return err
+}
+
+// Method Lchown changes the owner of a file, without following symbolic
+// links.
+func (cl *Client) Lchown(name string, uid, gid int) error {
+	_, err := cl.named("lchown", name, request{Op: opLchown, UID: uid, GID: gid})
+	return err
+}
+
+// Method Chtimes changes the access and modification time of a file.
+func (cl *Client) Chtimes(name string, atime, mtime time.Time) error {
+	_, err := cl.named("chtimes", name, request{Op: opChtimes, Atime: atime.UnixNano(), Mtime: mtime.UnixNano()})
+	return err
+}
+
+// Method Chmod changes the permissions of a file, including the setuid,
+// setgid and sticky bits.
+func (cl *Client) Chmod(name string, mode os.FileMode) error {
+	mode &= os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+	_, err := cl.named("chmod", name, request{Op: opChmod, Mode: uint32(mode)})
+	return err
+}
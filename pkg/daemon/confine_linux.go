@@ -0,0 +1,187 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Modules are walked element by element, each one opened relative to its
+// parent directory without following symbolic links, so they can be exported
+// writable.
+const confinable = true
+
+// oPath is O_PATH, which is missing in package syscall. Its value is the same
+// on all architectures supported by Go.
+const oPath = 0x200000
+
+// maxLinks is the number of symbolic links followed in a path, like the
+// limit of the Linux kernel.
+const maxLinks = 40
+
+// Function checkSystem checks that the entries of the modules can be
+// accessed relative to open directories, by the paths of /proc/self/fd.
+func checkSystem() error {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		return fmt.Errorf("the daemon needs /proc: %s", err)
+	}
+	return nil
+}
+
+// Method path returns the local path of a name, confined to the root of the
+// module, and a function which releases it. The directories of the path are
+// opened one after the other, following symbolic links only inside the
+// module, and the path refers to the last element in the open parent
+// directory, so that no directory can be exchanged for a link in between.
+// The last element is not resolved.
+func (ss *session) path(name string) (string, func(), error) {
+	fd, base, err := ss.walk(name, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return fdPath(fd) + "/" + base, func() { syscall.Close(fd) }, nil
+}
+
+// Method resolved returns the local path of a name like path, for the
+// operations which follow a symbolic link in the last element. The target of
+// the link must lie inside the module as well.
+func (ss *session) resolved(name string) (string, func(), error) {
+	fd, _, err := ss.walk(name, true)
+	if err != nil {
+		return "", nil, err
+	}
+	return fdPath(fd), func() { syscall.Close(fd) }, nil
+}
+
+// Method walk opens the elements of a name from the root of the module. The
+// symbolic links on the way are resolved by walking their targets from the
+// root again, and refused if they lead out of the module. It returns the
+// open parent directory and the last element, or with follow the open last
+// element and an empty name. The descriptors are opened with O_PATH, they
+// only pin the entries.
+func (ss *session) walk(name string, follow bool) (int, string, error) {
+	rest := elements(name)
+	var cur []string // elements of the open directory
+	fd, err := syscall.Open(ss.real, oPath|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, "", err
+	}
+	links := 0
+	for len(rest) > 0 {
+		if len(rest) == 1 && !follow {
+			return fd, rest[0], nil
+		}
+		next, err := syscall.Openat(fd, rest[0], oPath|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			syscall.Close(fd)
+			return -1, "", err
+		}
+		var st syscall.Stat_t
+		if err := syscall.Fstat(next, &st); err != nil {
+			syscall.Close(next)
+			syscall.Close(fd)
+			return -1, "", err
+		}
+
+		switch {
+		case st.Mode&syscall.S_IFMT == syscall.S_IFLNK:
+			// the target is walked from the root again, so its type does
+			// not matter if the link was exchanged in the meantime
+			syscall.Close(next)
+			target, err := os.Readlink(fdPath(fd) + "/" + rest[0])
+			syscall.Close(fd)
+			if err != nil {
+				return -1, "", err
+			}
+			if links++; links > maxLinks {
+				return -1, "", syscall.ELOOP
+			}
+			inner, ok := ss.inside(cur, target)
+			if !ok {
+				return -1, "", syscall.EACCES
+			}
+			rest, cur = append(inner, rest[1:]...), nil
+			if fd, err = syscall.Open(ss.real, oPath|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0); err != nil {
+				return -1, "", err
+			}
+		case st.Mode&syscall.S_IFMT == syscall.S_IFDIR:
+			syscall.Close(fd)
+			fd, cur, rest = next, append(cur, rest[0]), rest[1:]
+		case len(rest) > 1:
+			syscall.Close(next)
+			syscall.Close(fd)
+			return -1, "", syscall.ENOTDIR
+		default:
+			syscall.Close(fd)
+			fd, rest = next, nil
+		}
+	}
+
+	// the root of the module
+	return fd, ".", nil
+}
+
+// Method inside returns the elements of the target of a symbolic link in the
+// directory cur, relative to the root of the module. Absolute targets must
+// point into the module.
+func (ss *session) inside(cur []string, target string) ([]string, bool) {
+	if path.IsAbs(target) {
+		target = path.Clean(target)
+		for _, root := range []string{ss.real, ss.root} {
+			root = strings.TrimSuffix(filepath.ToSlash(root), "/")
+			if target == root || strings.HasPrefix(target, root+"/") {
+				return elements(target[len(root):]), true
+			}
+		}
+		return nil, false
+	}
+	t := path.Join(append(cur, target)...)
+	if t == ".." || strings.HasPrefix(t, "../") {
+		return nil, false
+	}
+	return elements(t), true
+}
+
+// Function elements splits a name into its elements, without leading out of
+// the root by "..".
+func elements(name string) []string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return nil
+	}
+	return strings.Split(name, "/")
+}
+
+// Function fdPath returns the path of an open file descriptor in /proc. The
+// kernel resolves it to the open entry itself, not by its name.
+func fdPath(fd int) string {
+	return "/proc/self/fd/" + strconv.Itoa(fd)
+}
+
+// Function noLink runs an operation which would follow a symbolic link in
+// the last element of p, like chmod and utimes, on the entry itself. Links
+// are refused, since they cannot be changed without AT_SYMLINK_NOFOLLOW. The
+// entry is opened first, so that it cannot be exchanged for a link.
+func noLink(p string, op func(string) error) error {
+	fd, err := syscall.Open(p, oPath|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		return err
+	}
+	if st.Mode&syscall.S_IFMT == syscall.S_IFLNK {
+		return syscall.ELOOP
+	}
+	return op(fdPath(fd))
+}
@@ -0,0 +1,82 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !linux
+// +build !linux
+
+package daemon
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Without the *at system calls, the paths are checked before they are used,
+// so a client could exchange a directory for a symbolic link in between. The
+// modules are exported read-only.
+const confinable = false
+
+// Function checkSystem does nothing, the modules are accessed by their paths.
+func checkSystem() error {
+	return nil
+}
+
+// Method path returns the local path of a name, confined to the root of the
+// module, and a function which releases it. The directories of the path must
+// not lead out of the module by symbolic links, the last element is not
+// resolved.
+func (ss *session) path(name string) (string, func(), error) {
+	p := filepath.Join(ss.root, filepath.FromSlash(path.Clean("/"+name)))
+	if p == ss.root {
+		return p, func() {}, nil
+	}
+	return p, func() {}, ss.confine(filepath.Dir(p))
+}
+
+// Method resolved returns the local path of a name like path, for the
+// operations which follow a symbolic link in the last element. The target of
+// the link must lie inside the module as well.
+func (ss *session) resolved(name string) (string, func(), error) {
+	p := filepath.Join(ss.root, filepath.FromSlash(path.Clean("/"+name)))
+	return p, func() {}, ss.confine(p)
+}
+
+// Method confine checks that a local path, with its symbolic links resolved,
+// lies inside the root of the module. Elements which do not exist yet are
+// skipped, since they are no links; dangling links are refused.
+func (ss *session) confine(p string) error {
+	for {
+		dir, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			if dir != ss.real && !strings.HasPrefix(dir, ss.real+string(filepath.Separator)) {
+				return syscall.EACCES
+			}
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		if _, lerr := os.Lstat(p); lerr == nil {
+			return syscall.EACCES
+		}
+		if len(p) <= len(ss.root) {
+			return err
+		}
+		p = filepath.Dir(p)
+	}
+}
+
+// Function noLink refuses a path whose last element is a symbolic link, for
+// the operations which would follow it, like chmod and utimes. Without
+// AT_SYMLINK_NOFOLLOW, the link itself cannot be changed.
+func noLink(p string, op func(string) error) error {
+	f, err := os.Lstat(p)
+	if err == nil && f.Mode()&os.ModeSymlink != 0 {
+		return syscall.ELOOP
+	}
+	return op(p)
+}
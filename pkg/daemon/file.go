@@ -0,0 +1,208 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package daemon
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Type pendingRead is a read request in flight.
+type pendingRead struct {
+	off int64
+	ca  *call
+}
+
+// Type File is a file opened on the daemon. Reads and writes are pipelined:
+// up to ReadAhead requests are in flight, so that the throughput is not
+// limited by the round trip time of a single request. Errors of writes are
+// reported by a later Write, or by Close.
+type File struct {
+	cl     *Client
+	c      *conn // connection of the handle
+	name   string
+	h      uint32
+	off    int64         // offset of the next byte read or written
+	next   int64         // offset of the next read request
+	reads  []pendingRead // read requests in flight, in offset order
+	buf    []byte        // data received but not yet returned
+	eof    bool          // the daemon reported the end of the file
+	writes []*call       // write requests in flight
+}
+
+// Method fill starts read requests until ReadAhead requests are in flight.
+func (f *File) fill() {
+	for !f.eof && len(f.reads) < f.cl.opts.ReadAhead {
+		rq := &request{Op: opRead, Handle: f.h, Off: f.next, Len: chunk}
+		f.reads = append(f.reads, pendingRead{f.next, f.cl.startOn(f.c, rq)})
+		f.next += chunk
+	}
+}
+
+// Method reset drops the read requests in flight and the buffered data. The
+// responses of the dropped requests are discarded when they arrive.
+func (f *File) reset(off int64) {
+	f.reads = nil
+	f.buf = nil
+	f.off = off
+	f.next = off
+	f.eof = false
+}
+
+// Method Read reads up to len(b) bytes from the file.
+func (f *File) Read(b []byte) (int, error) {
+	if err := f.flush(); err != nil {
+		return 0, err
+	}
+	for len(f.buf) == 0 {
+		if f.eof && len(f.reads) == 0 {
+			return 0, io.EOF
+		}
+		f.fill()
+		p := f.reads[0]
+		f.reads = f.reads[1:]
+
+		r, err := p.ca.wait()
+		if err == nil && len(r.Data) > chunk {
+			err = errors.New("daemon: invalid read response")
+		}
+		if err != nil {
+			f.reset(f.off)
+			return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.buf = r.Data
+		if r.EOF {
+			// the requests behind the end of the file are not needed
+			f.reads = nil
+			f.eof = true
+		} else if len(r.Data) < chunk {
+			// short read, continue after the data received
+			f.reads = nil
+			f.next = p.off + int64(len(r.Data))
+		}
+	}
+	n := copy(b, f.buf)
+	f.buf = f.buf[n:]
+	f.off += int64(n)
+	return n, nil
+}
+
+// Method Write writes b to the file. The data is sent in chunks, without
+// waiting for the responses, unless ReadAhead requests are in flight.
+func (f *File) Write(b []byte) (int, error) {
+	if len(f.reads) > 0 || len(f.buf) > 0 {
+		f.reset(f.off)
+	}
+	n := 0
+	for n < len(b) {
+		m := len(b) - n
+		if m > chunk {
+			m = chunk
+		}
+		if err := f.throttle(); err != nil {
+			return n, err
+		}
+		rq := &request{Op: opWrite, Handle: f.h, Off: f.off, Data: b[n : n+m]}
+		f.writes = append(f.writes, f.cl.startOn(f.c, rq))
+		f.off += int64(m)
+		n += m
+	}
+	return n, nil
+}
+
+// Method CopyFrom writes n bytes of the file basis, starting at offset off,
+// to the file. The data is copied by the daemon, and not transferred. Like
+// Write, it does not wait for the response.
+func (f *File) CopyFrom(basis string, off, n int64) error {
+	if len(f.reads) > 0 || len(f.buf) > 0 {
+		f.reset(f.off)
+	}
+	for n > 0 {
+		m := n
+		if m > maxCopy {
+			m = maxCopy
+		}
+		if err := f.throttle(); err != nil {
+			return err
+		}
+		rq := &request{Op: opCopy, Handle: f.h, Off: f.off, Name: f.cl.path(basis), SrcOff: off, Len: int(m)}
+		f.writes = append(f.writes, f.cl.startOn(f.c, rq))
+		f.off += m
+		off += m
+		n -= m
+	}
+	return nil
+}
+
+// Method throttle waits for the oldest write request, if ReadAhead requests
+// are in flight.
+func (f *File) throttle() error {
+	if len(f.writes) < f.cl.opts.ReadAhead {
+		return nil
+	}
+	ca := f.writes[0]
+	f.writes = f.writes[1:]
+	if _, err := ca.wait(); err != nil {
+		f.flush()
+		return &os.PathError{Op: "write", Path: f.name, Err: err}
+	}
+	return nil
+}
+
+// Method flush waits for the write requests in flight, and returns the first
+// error.
+func (f *File) flush() error {
+	var first error
+	for _, ca := range f.writes {
+		if _, err := ca.wait(); err != nil && first == nil {
+			first = &os.PathError{Op: "write", Path: f.name, Err: err}
+		}
+	}
+	f.writes = nil
+	return first
+}
+
+// Method Seek sets the offset for the next Read or Write. Seeking relative to
+// the end of the file is not supported.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if err := f.flush(); err != nil {
+		return f.off, err
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	default:
+		return f.off, &os.PathError{Op: "seek", Path: f.name, Err: errors.New("unsupported whence")}
+	}
+	if offset < 0 {
+		return f.off, &os.PathError{Op: "seek", Path: f.name, Err: errors.New("negative offset")}
+	}
+	f.reset(offset)
+	return offset, nil
+}
+
+// Method Truncate changes the size of the file.
+func (f *File) Truncate(size int64) error {
+	if err := f.flush(); err != nil {
+		return err
+	}
+	if _, err := f.cl.startOn(f.c, &request{Op: opTruncate, Handle: f.h, Off: size}).wait(); err != nil {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: err}
+	}
+	return nil
+}
+
+// Method Close waits for the pending writes, and closes the file.
+func (f *File) Close() error {
+	err := f.flush()
+	f.reset(f.off)
+	_, cerr := f.cl.startOn(f.c, &request{Op: opClose, Handle: f.h}).wait()
+	if err == nil && cerr != nil {
+		err = &os.PathError{Op: "close", Path: f.name, Err: cerr}
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package daemon
+
+// Windows has no O_NOFOLLOW, the files are opened by their paths. The modules
+// are exported read-only there, so a client cannot plant a symbolic link.
+const oNoFollow = 0
@@ -0,0 +1,293 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package daemon implements the psync network protocol, which gives remote
+// access to directory trees (modules) exported by a psync daemon. The
+// protocol runs over TCP, and consists of gob encoded frames: after a
+// handshake with an optional challenge-response authentication, the client
+// sends requests (list directory, stat, open, read chunk, write chunk, set
+// metadata, ...), each tagged with an id. The server handles the requests of
+// a connection concurrently, and answers them in the order of completion, so
+// that many requests can be in flight. For the delta transfer of changed
+// files, the server computes the signature of the old version of a file, and
+// builds the new version from ranges of the old one and the data sent.
+//
+// The client may ask for compression in the handshake. If the server agrees,
+// both directions of the connection are compressed after the handshake.
+package daemon
+
+import (
+	"bufio"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/delta"
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// protocolVersion is the version of the protocol. Peers with a different
+// version are rejected.
+const protocolVersion = 1
+
+// DefaultPort is the TCP port of the daemon, if none is given.
+const DefaultPort = 8730
+
+// maxChunk is the upper limit for the size of a read or write request.
+const maxChunk = 1 << 20
+
+// Operations of requests
+const (
+	opReadDir = iota + 1
+	opLstat
+	opStat
+	opReadlink
+	opOpen
+	opRead
+	opWrite
+	opTruncate
+	opClose
+	opMkdir
+	opMkdirAll
+	opSymlink
+	opRename
+	opRemove
+	opChown
+	opLchown
+	opChtimes
+	opSignature
+	opCopy
+	opChmod
+)
+
+// Type challenge is the first frame, sent by the server.
+type challenge struct {
+	Version int
+	Nonce   []byte
+}
+
+// Type hello is the answer of the client to the challenge. The MAC is the
+// HMAC-SHA256 of the nonce with the shared secret. Compress lists the
+// compressions the client accepts, in order of preference.
+type hello struct {
+	Version  int
+	Module   string
+	MAC      []byte
+	Compress []string
+}
+
+// Type welcome is the answer of the server to the hello. A non-empty error
+// rejects the client. Compress is the compression chosen by the server, empty
+// for none.
+type welcome struct {
+	Err      string
+	ReadOnly bool
+	Compress string
+}
+
+// Type request is a request of the client. The fields used depend on the
+// operation.
+type request struct {
+	ID     uint32
+	Op     int
+	Name   string
+	Name2  string
+	Handle uint32
+	Off    int64
+	SrcOff int64
+	Len    int
+	Data   []byte
+	Flag   int
+	Mode   uint32
+	UID    int
+	GID    int
+	Atime  int64
+	Mtime  int64
+}
+
+// Type response is the answer to a request. Errors are transported as system
+// error number, or as message.
+type response struct {
+	ID     uint32
+	Errno  int
+	Err    string
+	Infos  []info
+	Data   []byte
+	EOF    bool
+	Handle uint32
+	Target string
+	Sig    *delta.Signature
+}
+
+// Type info holds the attributes of a file. The times are in nanoseconds
+// since the epoch.
+type info struct {
+	Name  string
+	Size  int64
+	Mode  uint32
+	Mtime int64
+	Atime int64
+	Ctime int64
+	UID   uint32
+	GID   uint32
+}
+
+// compressDeflate is the only compression supported: deflate at the fastest
+// level, which keeps up with fast networks. It stands in for zstd and lz4,
+// which are not part of the standard library.
+const compressDeflate = "deflate"
+
+// Type stream is the framing of a connection: gob frames over buffered I/O,
+// optionally compressed.
+type stream struct {
+	r   *bufio.Reader
+	w   *bufio.Writer
+	fw  *flate.Writer // nil without compression
+	enc *gob.Encoder
+	dec *gob.Decoder
+}
+
+// Function newStream returns an uncompressed stream over a connection.
+func newStream(c net.Conn) *stream {
+	s := &stream{r: bufio.NewReaderSize(c, 64*1024), w: bufio.NewWriterSize(c, 64*1024)}
+	s.enc = gob.NewEncoder(s.w)
+	s.dec = gob.NewDecoder(s.r)
+	return s
+}
+
+// Method compress switches the stream to deflate compression in both
+// directions. Both peers switch after the handshake, so that no data is
+// buffered at that time.
+func (s *stream) compress() {
+	s.fw, _ = flate.NewWriter(s.w, flate.BestSpeed)
+	s.enc = gob.NewEncoder(s.fw)
+	s.dec = gob.NewDecoder(bufio.NewReaderSize(flate.NewReader(s.r), 64*1024))
+}
+
+// Method send writes a frame, and flushes it to the connection.
+func (s *stream) send(v interface{}) error {
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	if s.fw != nil {
+		if err := s.fw.Flush(); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+// Method receive reads a frame.
+func (s *stream) receive(v interface{}) error {
+	return s.dec.Decode(v)
+}
+
+// Function mac returns the authentication code of a nonce.
+func mac(secret, nonce []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(nonce)
+	return h.Sum(nil)
+}
+
+// Function encodeError stores an error in a response.
+func encodeError(r *response, err error) {
+	if err == nil {
+		return
+	}
+	e := err
+	switch pe := e.(type) {
+	case *os.PathError:
+		e = pe.Err
+	case *os.LinkError:
+		e = pe.Err
+	case *os.SyscallError:
+		e = pe.Err
+	}
+	if errno, ok := e.(syscall.Errno); ok {
+		r.Errno = int(errno)
+		return
+	}
+	r.Err = e.Error()
+	if r.Err == "" {
+		r.Err = "unknown error"
+	}
+}
+
+// Function decodeError returns the error of a response, nil for success.
+func decodeError(r *response) error {
+	if r.Errno != 0 {
+		return syscall.Errno(r.Errno)
+	}
+	if r.Err != "" {
+		return errors.New(r.Err)
+	}
+	return nil
+}
+
+// Function fileInfoOf converts the attributes of a local file for the wire.
+func fileInfoOf(f os.FileInfo) info {
+	i := info{
+		Name:  f.Name(),
+		Size:  f.Size(),
+		Mode:  uint32(f.Mode()),
+		Mtime: f.ModTime().UnixNano(),
+		Atime: f.ModTime().UnixNano(),
+		Ctime: f.ModTime().UnixNano(),
+	}
+	if st, ok := f.Sys().(*sysstat.Stat); ok {
+		i.Atime, _, i.Ctime = sysstat.Times(st)
+		i.UID, i.GID = st.Uid, st.Gid
+	}
+	return i
+}
+
+// Type fileInfo implements os.FileInfo for remote files. The method Sys
+// returns a *sysstat.Stat with the owner and time stamps, like for local
+// files.
+type fileInfo struct {
+	i info
+}
+
+// Method Name returns the base name of the file.
+func (fi *fileInfo) Name() string {
+	return fi.i.Name
+}
+
+// Method Size returns the size of the file in bytes.
+func (fi *fileInfo) Size() int64 {
+	return fi.i.Size
+}
+
+// Method Mode returns the file mode bits.
+func (fi *fileInfo) Mode() os.FileMode {
+	return os.FileMode(fi.i.Mode)
+}
+
+// Method ModTime returns the modification time.
+func (fi *fileInfo) ModTime() time.Time {
+	return time.Unix(0, fi.i.Mtime)
+}
+
+// Method IsDir checks whether the file is a directory.
+func (fi *fileInfo) IsDir() bool {
+	return fi.Mode().IsDir()
+}
+
+// Method Sys returns the owner and time stamps as *sysstat.Stat.
+func (fi *fileInfo) Sys() interface{} {
+	return sysstat.New(sysstat.Attr{
+		Uid:   fi.i.UID,
+		Gid:   fi.i.GID,
+		Size:  fi.i.Size,
+		Atime: fi.i.Atime,
+		Mtime: fi.i.Mtime,
+		Ctime: fi.i.Ctime,
+	})
+}
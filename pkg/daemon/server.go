@@ -0,0 +1,428 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package daemon
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/delta"
+)
+
+// maxRequests is the number of requests handled concurrently per connection.
+const maxRequests = 64
+
+// Type Server exports directory trees as modules over the psync protocol.
+// Without a secret, any client can connect, so the modules are read-only.
+// The clients are confined to the modules: paths leading out of a module by
+// symbolic links are refused, and symbolic links can only be created with
+// relative targets inside the module. On Linux, the paths are walked relative
+// to the open parent directories, so that a client cannot exchange a
+// directory for a link while it is used. Other systems lack the calls for
+// this, so the modules are always read-only there.
+type Server struct {
+	Modules  map[string]string // module names and their root directories
+	Secret   []byte            // shared secret of the clients, nil for none
+	ReadOnly bool              // reject all modifications, implied by a nil Secret
+	Log      io.Writer         // log of connections, nil for none
+}
+
+// Method ListenAndServe listens on the TCP address, and serves the clients
+// until an error occurs.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Method Serve accepts connections on the listener, and serves each one in a
+// separate goroutine.
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	if err := checkSystem(); err != nil {
+		return err
+	}
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go s.serveConn(c)
+	}
+}
+
+// Method logf writes a line to the log.
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.Log != nil {
+		fmt.Fprintf(s.Log, format+"\n", args...)
+	}
+}
+
+// Type session is the state of a client connection.
+type session struct {
+	s        *Server
+	root     string // root directory of the module
+	real     string // root directory with its symbolic links resolved
+	readOnly bool
+	st       *stream
+	wmu      sync.Mutex // serializes the writing of responses
+	mu       sync.Mutex // protects handles and next
+	handles  map[uint32]*os.File
+	next     uint32
+}
+
+// Method serveConn runs the handshake, and handles the requests of a client.
+func (s *Server) serveConn(c net.Conn) {
+	defer c.Close()
+	peer := c.RemoteAddr().String()
+	st := newStream(c)
+
+	// handshake
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		s.logf("%s: cannot create nonce: %s", peer, err)
+		return
+	}
+	c.SetDeadline(time.Now().Add(30 * time.Second))
+	var h hello
+	err := st.send(challenge{Version: protocolVersion, Nonce: nonce})
+	if err == nil {
+		err = st.receive(&h)
+	}
+	if err != nil {
+		s.logf("%s: handshake failed: %s", peer, err)
+		return
+	}
+	var wel welcome
+	root, ok := s.Modules[h.Module]
+	var dir string
+	if ok {
+		dir, err = filepath.EvalSymlinks(root)
+	}
+	switch {
+	case h.Version != protocolVersion:
+		wel.Err = fmt.Sprintf("protocol version %d is not supported", h.Version)
+	case s.Secret != nil && !hmac.Equal(h.MAC, mac(s.Secret, nonce)):
+		wel.Err = "authentication failed"
+	case !ok:
+		wel.Err = fmt.Sprintf("unknown module %q", h.Module)
+	case err != nil:
+		wel.Err = fmt.Sprintf("module %q is not available", h.Module)
+		s.logf("%s: module %s: %s", peer, h.Module, err)
+	}
+	wel.ReadOnly = s.ReadOnly || s.Secret == nil || !confinable
+	for _, name := range h.Compress {
+		if name == compressDeflate {
+			wel.Compress = name
+			break
+		}
+	}
+	err = st.send(wel)
+	if wel.Err != "" || err != nil {
+		s.logf("%s: rejected: %s%v", peer, wel.Err, err)
+		return
+	}
+	c.SetDeadline(time.Time{})
+	if wel.Compress != "" {
+		st.compress()
+		s.logf("%s: connected to module %s, with %s compression", peer, h.Module, wel.Compress)
+	} else {
+		s.logf("%s: connected to module %s", peer, h.Module)
+	}
+
+	ss := &session{s: s, root: root, real: dir, readOnly: wel.ReadOnly, st: st, handles: make(map[uint32]*os.File)}
+	sem := make(chan struct{}, maxRequests)
+	var wg sync.WaitGroup
+	for {
+		var rq request
+		if err := st.receive(&rq); err != nil {
+			if err != io.EOF {
+				s.logf("%s: %s", peer, err)
+			}
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(rq *request) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			ss.reply(ss.handle(rq))
+		}(&rq)
+	}
+	wg.Wait()
+	for _, f := range ss.handles {
+		f.Close()
+	}
+	s.logf("%s: disconnected", peer)
+}
+
+// Method reply sends a response.
+func (ss *session) reply(r *response) {
+	ss.wmu.Lock()
+	defer ss.wmu.Unlock()
+	ss.st.send(r)
+}
+
+// Function linkTarget checks that the target of a new symbolic link is
+// relative, and does not lead out of the module from the directory of the
+// link.
+func linkTarget(name, target string) error {
+	if path.IsAbs(target) {
+		return syscall.EPERM
+	}
+	t := path.Join(path.Dir(strings.TrimPrefix(path.Clean("/"+name), "/")), target)
+	if t == ".." || strings.HasPrefix(t, "../") {
+		return syscall.EPERM
+	}
+	return nil
+}
+
+// Method file returns the open file of a handle.
+func (ss *session) file(h uint32) (*os.File, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	f, ok := ss.handles[h]
+	if !ok {
+		return nil, syscall.EBADF
+	}
+	return f, nil
+}
+
+// Function modifies checks whether a request changes the module.
+func modifies(rq *request) bool {
+	switch rq.Op {
+	case opReadDir, opLstat, opStat, opReadlink, opRead, opClose, opSignature:
+		return false
+	case opOpen:
+		return rq.Flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0
+	}
+	return true
+}
+
+// Method handle executes a request.
+func (ss *session) handle(rq *request) *response {
+	r := &response{ID: rq.ID}
+	if ss.readOnly && modifies(rq) {
+		r.Errno = int(syscall.EROFS)
+		return r
+	}
+
+	// the local paths of the names, resolved for the operations following
+	// symbolic links
+	var name, name2 string
+	var release, release2 func()
+	var err error
+	switch rq.Op {
+	case opReadDir, opStat:
+		name, release, err = ss.resolved(rq.Name)
+	case opRename:
+		if name, release, err = ss.path(rq.Name); err == nil {
+			name2, release2, err = ss.path(rq.Name2)
+		}
+	case opRead, opWrite, opTruncate, opClose, opMkdirAll:
+	default:
+		name, release, err = ss.path(rq.Name)
+	}
+	if release != nil {
+		defer release()
+	}
+	if release2 != nil {
+		defer release2()
+	}
+	if err != nil {
+		encodeError(r, err)
+		return r
+	}
+
+	switch rq.Op {
+	case opReadDir:
+		var list []os.FileInfo
+		if list, err = ioutil.ReadDir(name); err == nil {
+			r.Infos = make([]info, len(list))
+			for i, f := range list {
+				r.Infos[i] = fileInfoOf(f)
+			}
+		}
+	case opLstat, opStat:
+		var f os.FileInfo
+		if rq.Op == opLstat {
+			f, err = os.Lstat(name)
+		} else {
+			f, err = os.Stat(name)
+		}
+		if err == nil {
+			r.Infos = []info{fileInfoOf(f)}
+			r.Infos[0].Name = filepath.Base(filepath.Join(ss.root, filepath.FromSlash(path.Clean("/"+rq.Name))))
+		}
+	case opReadlink:
+		r.Target, err = os.Readlink(name)
+	case opOpen:
+		var f *os.File
+		if f, err = os.OpenFile(name, rq.Flag|oNoFollow, os.FileMode(rq.Mode)); err == nil {
+			ss.mu.Lock()
+			ss.next++
+			r.Handle = ss.next
+			ss.handles[r.Handle] = f
+			ss.mu.Unlock()
+		}
+	case opRead:
+		var f *os.File
+		if f, err = ss.file(rq.Handle); err == nil {
+			n := rq.Len
+			if n < 0 || n > maxChunk {
+				n = maxChunk
+			}
+			r.Data = make([]byte, n)
+			n, err = f.ReadAt(r.Data, rq.Off)
+			r.Data = r.Data[:n]
+			if err == io.EOF {
+				r.EOF, err = true, nil
+			}
+		}
+	case opWrite:
+		var f *os.File
+		if f, err = ss.file(rq.Handle); err == nil {
+			_, err = f.WriteAt(rq.Data, rq.Off)
+		}
+	case opTruncate:
+		var f *os.File
+		if f, err = ss.file(rq.Handle); err == nil {
+			err = f.Truncate(rq.Off)
+		}
+	case opClose:
+		var f *os.File
+		if f, err = ss.file(rq.Handle); err == nil {
+			ss.mu.Lock()
+			delete(ss.handles, rq.Handle)
+			ss.mu.Unlock()
+			err = f.Close()
+		}
+	case opMkdir:
+		err = os.Mkdir(name, os.FileMode(rq.Mode))
+	case opMkdirAll:
+		err = ss.mkdirAll(rq.Name, os.FileMode(rq.Mode))
+	case opSymlink:
+		if err = linkTarget(rq.Name, rq.Name2); err == nil {
+			err = os.Symlink(rq.Name2, name)
+		}
+	case opRename:
+		err = os.Rename(name, name2)
+	case opRemove:
+		err = os.Remove(name)
+	case opChown, opLchown:
+		err = os.Lchown(name, rq.UID, rq.GID)
+	case opChtimes:
+		err = noLink(name, func(p string) error {
+			return os.Chtimes(p, time.Unix(0, rq.Atime), time.Unix(0, rq.Mtime))
+		})
+	case opChmod:
+		err = noLink(name, func(p string) error {
+			return os.Chmod(p, os.FileMode(rq.Mode))
+		})
+	case opSignature:
+		r.Sig, err = signature(name, rq.Len)
+	case opCopy:
+		var f *os.File
+		if f, err = ss.file(rq.Handle); err == nil {
+			err = copyRange(f, rq.Off, name, rq.SrcOff, int64(rq.Len))
+		}
+	default:
+		err = syscall.ENOSYS
+	}
+	encodeError(r, err)
+	return r
+}
+
+// Method mkdirAll creates a directory with its missing parents, like
+// os.MkdirAll, each one confined to the module.
+func (ss *session) mkdirAll(name string, mode os.FileMode) error {
+	elems := strings.Split(strings.TrimPrefix(path.Clean("/"+name), "/"), "/")
+	for i := range elems {
+		p, release, err := ss.path(strings.Join(elems[:i+1], "/"))
+		if err == nil {
+			err = os.Mkdir(p, mode)
+			release()
+		}
+		if err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	p, release, err := ss.resolved(name)
+	if err != nil {
+		return err
+	}
+	defer release()
+	f, err := os.Stat(p)
+	if err == nil && !f.IsDir() {
+		err = syscall.ENOTDIR
+	}
+	return err
+}
+
+// Function signature computes the signature of a file for the delta transfer.
+func signature(name string, blockSize int) (*delta.Signature, error) {
+	if blockSize < delta.MinBlockSize || blockSize > delta.MaxBlockSize {
+		return nil, syscall.EINVAL
+	}
+	f, err := os.OpenFile(name, os.O_RDONLY|oNoFollow, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return delta.NewSignature(bufio.NewReaderSize(f, 256*1024), blockSize)
+}
+
+// Function copyRange copies n bytes of the file basis, starting at offset
+// srcOff, to the open file f at offset off.
+func copyRange(f *os.File, off int64, basis string, srcOff, n int64) error {
+	if n < 0 {
+		return syscall.EINVAL
+	}
+	bf, err := os.OpenFile(basis, os.O_RDONLY|oNoFollow, 0)
+	if err != nil {
+		return err
+	}
+	defer bf.Close()
+	buf := make([]byte, 256*1024)
+	for n > 0 {
+		b := buf
+		if n < int64(len(b)) {
+			b = b[:n]
+		}
+		m, err := bf.ReadAt(b, srcOff)
+		if m == 0 && err == io.EOF {
+			// the basis changed since its signature was computed
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if _, err := f.WriteAt(b[:m], off); err != nil {
+			return err
+		}
+		off, srcOff, n = off+int64(m), srcOff+int64(m), n-int64(m)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Function testModule creates a module with the directory sub, the link in
+// to it, and the link out to a directory outside of the module, which holds
+// the file secret. It returns the root of the test, and a session on the
+// module.
+func testModule(t *testing.T) (string, *session) {
+	root, err := ioutil.TempDir("", "psync-daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		t.Fatal(err)
+	}
+	module := filepath.Join(root, "module")
+	for _, dir := range []string{filepath.Join(module, "sub"), filepath.Join(root, "outside")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "outside", "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("sub", filepath.Join(module, "in")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../outside", filepath.Join(module, "out")); err != nil {
+		t.Fatal(err)
+	}
+	ss := &session{s: &Server{}, root: module, real: module, handles: make(map[uint32]*os.File)}
+	return root, ss
+}
+
+// Function closeHandles closes the files opened by the requests of a session.
+func closeHandles(ss *session) {
+	for _, f := range ss.handles {
+		f.Close()
+	}
+}
+
+// TestConfine checks that paths leading out of the module by a symbolic link
+// are refused, and that links inside the module are followed.
+func TestConfine(t *testing.T) {
+	root, ss := testModule(t)
+	defer os.RemoveAll(root)
+	defer closeHandles(ss)
+
+	tests := []struct {
+		rq    request
+		errno syscall.Errno
+	}{
+		{request{Op: opReadDir, Name: "out"}, syscall.EACCES},
+		{request{Op: opStat, Name: "out/secret"}, syscall.EACCES},
+		{request{Op: opOpen, Name: "out/secret", Flag: os.O_RDONLY}, syscall.EACCES},
+		{request{Op: opOpen, Name: "out/new", Flag: os.O_WRONLY | os.O_CREATE, Mode: 0644}, syscall.EACCES},
+		{request{Op: opMkdirAll, Name: "out/a/b", Mode: 0755}, syscall.EACCES},
+		{request{Op: opRename, Name: "sub", Name2: "out/sub"}, syscall.EACCES},
+		{request{Op: opChmod, Name: "out", Mode: 0700}, syscall.ELOOP},
+		{request{Op: opOpen, Name: "out", Flag: os.O_RDONLY}, syscall.ELOOP},
+		{request{Op: opLstat, Name: "out"}, 0},
+		{request{Op: opReadDir, Name: "in"}, 0},
+		{request{Op: opOpen, Name: "in/new", Flag: os.O_WRONLY | os.O_CREATE, Mode: 0644}, 0},
+		{request{Op: opMkdirAll, Name: "in/a/b", Mode: 0755}, 0},
+		{request{Op: opStat, Name: "../../outside/secret"}, syscall.ENOENT},
+	}
+	for _, tt := range tests {
+		r := ss.handle(&tt.rq)
+		if syscall.Errno(r.Errno) != tt.errno {
+			t.Errorf("operation %d on %s: error %q, want %q", tt.rq.Op, tt.rq.Name, syscall.Errno(r.Errno), tt.errno)
+		}
+	}
+	for _, name := range []string{"sub/new", "sub/a/b"} {
+		if _, err := os.Lstat(filepath.Join(ss.root, name)); err != nil {
+			t.Errorf("%s was not created: %s", name, err)
+		}
+	}
+	if list, _ := ioutil.ReadDir(filepath.Join(root, "outside")); len(list) != 1 {
+		t.Errorf("%d entries outside of the module, want 1", len(list))
+	}
+}
+
+// TestConfineExchange exchanges a directory of the module for a link out of
+// it, after the path of a new file in it was confined. The file must be
+// created in the directory, not outside of the module.
+func TestConfineExchange(t *testing.T) {
+	if !confinable {
+		t.Skip("modules are read-only on this system")
+	}
+	root, ss := testModule(t)
+	defer os.RemoveAll(root)
+
+	p, release, err := ss.path("sub/escaped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	sub, tmp, out := filepath.Join(ss.root, "sub"), filepath.Join(ss.root, "tmp"), filepath.Join(ss.root, "out")
+	if err := os.Rename(sub, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(out, sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(root, "outside", "escaped")); err == nil {
+		t.Error("file was created outside of the module")
+	}
+	if _, err := os.Lstat(filepath.Join(tmp, "escaped")); err != nil {
+		t.Errorf("file was not created in the directory: %s", err)
+	}
+}
+
+// TestConfineRace exchanges a directory of the module for a link out of it,
+// while files are created in it. No file may be created outside of the
+// module.
+func TestConfineRace(t *testing.T) {
+	if !confinable {
+		t.Skip("modules are read-only on this system")
+	}
+	root, ss := testModule(t)
+	defer os.RemoveAll(root)
+	defer closeHandles(ss)
+
+	sub, tmp, out := filepath.Join(ss.root, "sub"), filepath.Join(ss.root, "tmp"), filepath.Join(ss.root, "out")
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			os.Rename(sub, tmp)
+			os.Rename(out, sub)
+			os.Rename(sub, out)
+			os.Rename(tmp, sub)
+		}
+	}()
+
+	d := time.Second
+	if testing.Short() {
+		d = 200 * time.Millisecond
+	}
+	deadline := time.Now().Add(d)
+	var clients sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			for time.Now().Before(deadline) {
+				r := ss.handle(&request{Op: opOpen, Name: "sub/escaped", Flag: os.O_WRONLY | os.O_CREATE, Mode: 0644})
+				if r.Handle != 0 {
+					ss.handle(&request{Op: opClose, Handle: r.Handle})
+				}
+				ss.handle(&request{Op: opMkdirAll, Name: "sub/dir", Mode: 0755})
+			}
+		}()
+	}
+	clients.Wait()
+	close(stop)
+	wg.Wait()
+
+	for _, name := range []string{"escaped", "dir"} {
+		if _, err := os.Lstat(filepath.Join(root, "outside", name)); err == nil {
+			t.Errorf("%s was created outside of the module", name)
+		}
+	}
+}
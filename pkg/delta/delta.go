@@ -0,0 +1,247 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package delta implements the rsync algorithm. The receiver of a file
+// computes the signature of its old version (the basis): a weak rolling
+// checksum and a strong checksum of each block. The sender scans the new
+// version with the rolling checksum, finds the blocks the receiver has
+// already, and sends only the other data, along with references to the
+// blocks of the basis.
+package delta
+
+import (
+	"crypto/md5"
+	"io"
+	"math"
+)
+
+// Limits of the block size
+const (
+	MinBlockSize = 700
+	MaxBlockSize = 128 * 1024
+)
+
+// maxLiteral is the upper limit for the data of a single literal operation.
+const maxLiteral = 256 * 1024
+
+// Function BlockSize returns the block size for a file of the given size,
+// like rsync: the square root of the size, within the limits.
+func BlockSize(size int64) int {
+	bs := int(math.Sqrt(float64(size))) &^ 7
+	if bs < MinBlockSize {
+		bs = MinBlockSize
+	}
+	if bs > MaxBlockSize {
+		bs = MaxBlockSize
+	}
+	return bs
+}
+
+// Type Signature holds the checksums of the blocks of a basis file. The last
+// block may be shorter than the block size.
+type Signature struct {
+	BlockSize int
+	Size      int64
+	Weak      []uint32
+	Strong    [][md5.Size]byte
+}
+
+// Method blockLen returns the length of a block.
+func (s *Signature) blockLen(i int) int {
+	if rest := s.Size - int64(i)*int64(s.BlockSize); rest < int64(s.BlockSize) {
+		return int(rest)
+	}
+	return s.BlockSize
+}
+
+// Function NewSignature reads a basis file, and returns its signature.
+func NewSignature(r io.Reader, blockSize int) (*Signature, error) {
+	s := &Signature{BlockSize: blockSize}
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			s.Weak = append(s.Weak, weakSum(buf[:n]))
+			s.Strong = append(s.Strong, md5.Sum(buf[:n]))
+			s.Size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Function weakSum returns the rolling checksum of a block.
+func weakSum(b []byte) uint32 {
+	var a, s uint32
+	l := uint32(len(b))
+	for i, c := range b {
+		a += uint32(c)
+		s += (l - uint32(i)) * uint32(c)
+	}
+	return a&0xffff | s<<16
+}
+
+// Type Op is an operation of a delta: either literal data, or a range of
+// Count blocks of the basis file, starting with block Block.
+type Op struct {
+	Data  []byte
+	Block int
+	Count int
+}
+
+// Type matcher finds the blocks of a signature by their checksums.
+type matcher struct {
+	sig   *Signature
+	index map[uint32][]int
+}
+
+// Method find returns the block matching the data, -1 for none. If several
+// blocks match, the block following the previous match is preferred.
+func (m *matcher) find(weak uint32, data []byte, prev int) int {
+	cand, ok := m.index[weak]
+	if !ok {
+		return -1
+	}
+	var strong [md5.Size]byte
+	summed := false
+	found := -1
+	for _, i := range cand {
+		if m.sig.blockLen(i) != len(data) {
+			continue
+		}
+		if !summed {
+			strong, summed = md5.Sum(data), true
+		}
+		if m.sig.Strong[i] != strong {
+			continue
+		}
+		if i == prev+1 {
+			return i
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	return found
+}
+
+// Function Compute reads the new version of a file, and calls emit with the
+// operations which build it from the basis described by the signature. The
+// data of literal operations is only valid during the call.
+func Compute(sig *Signature, r io.Reader, emit func(Op) error) error {
+	m := &matcher{sig: sig, index: make(map[uint32][]int)}
+	for i, w := range sig.Weak {
+		m.index[w] = append(m.index[w], i)
+	}
+	bs := sig.BlockSize
+	var pending Op // coalesced block range, Count 0 for none
+	flushBlocks := func() error {
+		if pending.Count == 0 {
+			return nil
+		}
+		err := emit(pending)
+		pending = Op{}
+		return err
+	}
+	literal := func(b []byte) error {
+		for len(b) > 0 {
+			if err := flushBlocks(); err != nil {
+				return err
+			}
+			n := len(b)
+			if n > maxLiteral {
+				n = maxLiteral
+			}
+			if err := emit(Op{Data: b[:n]}); err != nil {
+				return err
+			}
+			b = b[n:]
+		}
+		return nil
+	}
+
+	// data holds the literal data not sent yet, followed by the window
+	data := make([]byte, 0, 2*maxLiteral+bs)
+	chunk := make([]byte, maxLiteral)
+	var start int    // start of the window in data
+	var eof bool     // the reader is exhausted
+	var rolling bool // a and b hold the checksum of the window
+	var a, b uint32
+	prev := -1
+
+	for {
+		for !eof && len(data)-start < bs {
+			if start >= maxLiteral {
+				if err := literal(data[:start]); err != nil {
+					return err
+				}
+				data = append(data[:0], data[start:]...)
+				start = 0
+			}
+			n, err := r.Read(chunk)
+			data = append(data, chunk[:n]...)
+			if err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		wlen := len(data) - start
+		if wlen > bs {
+			wlen = bs
+		}
+		if wlen == 0 {
+			break
+		}
+		win := data[start : start+wlen]
+		if !rolling {
+			w := weakSum(win)
+			a, b = w&0xffff, w>>16
+			rolling = true
+		}
+
+		if i := m.find(a&0xffff|b<<16, win, prev); i >= 0 {
+			if err := literal(data[:start]); err != nil {
+				return err
+			}
+			if pending.Count > 0 && i == pending.Block+pending.Count {
+				pending.Count++
+			} else {
+				if err := flushBlocks(); err != nil {
+					return err
+				}
+				pending = Op{Block: i, Count: 1}
+			}
+			prev = i
+			data = append(data[:0], data[start+wlen:]...)
+			start = 0
+			rolling = false
+			continue
+		}
+
+		// move the window by one byte
+		out := uint32(data[start])
+		start++
+		switch {
+		case start+bs <= len(data):
+			a = a - out + uint32(data[start+bs-1])
+			b = b - uint32(bs)*out + a
+		case eof:
+			// the window shrinks at the end of the file
+			a -= out
+			b -= uint32(wlen) * out
+		default:
+			// the window is computed anew after reading more data
+			rolling = false
+		}
+	}
+	if err := literal(data); err != nil {
+		return err
+	}
+	return flushBlocks()
+}
@@ -0,0 +1,159 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package nfs
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// File types of NFSv3
+const (
+	typeReg  = 1
+	typeDir  = 2
+	typeBlk  = 3
+	typeChr  = 4
+	typeLnk  = 5
+	typeSock = 6
+	typeFifo = 7
+)
+
+// Type attr holds the file attributes (fattr3) of an NFSv3 file.
+type attr struct {
+	ftype  uint32
+	mode   uint32
+	uid    uint32
+	gid    uint32
+	size   uint64
+	fileid uint64
+	atime  time.Time
+	mtime  time.Time
+	ctime  time.Time
+}
+
+// Function decodeAttr decodes the file attributes (fattr3).
+func decodeAttr(d *decoder) attr {
+	var a attr
+	a.ftype = d.uint32()
+	a.mode = d.uint32()
+	d.uint32() // nlink
+	a.uid = d.uint32()
+	a.gid = d.uint32()
+	a.size = d.uint64()
+	d.uint64() // used
+	d.uint32() // rdev major
+	d.uint32() // rdev minor
+	d.uint64() // fsid
+	a.fileid = d.uint64()
+	a.atime = decodeTime(d)
+	a.mtime = decodeTime(d)
+	a.ctime = decodeTime(d)
+	return a
+}
+
+// Function decodeTime decodes a time stamp (nfstime3).
+func decodeTime(d *decoder) time.Time {
+	sec := d.uint32()
+	nsec := d.uint32()
+	return time.Unix(int64(sec), int64(nsec))
+}
+
+// Function skipPostOpAttr skips optional file attributes (post_op_attr).
+func skipPostOpAttr(d *decoder) {
+	if d.bool() {
+		decodeAttr(d)
+	}
+}
+
+// Type fileInfo implements os.FileInfo for NFS files. The method Sys returns
+// a *sysstat.Stat with the owner and time stamps, like for local files.
+type fileInfo struct {
+	name string
+	a    attr
+}
+
+// Method Name returns the base name of the file.
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+// Method Size returns the size of the file in bytes.
+func (fi *fileInfo) Size() int64 {
+	return int64(fi.a.size)
+}
+
+// Method Mode returns the file mode bits.
+func (fi *fileInfo) Mode() os.FileMode {
+	m := os.FileMode(fi.a.mode & 0777)
+	if fi.a.mode&syscall.S_ISUID != 0 {
+		m |= os.ModeSetuid
+	}
+	if fi.a.mode&syscall.S_ISGID != 0 {
+		m |= os.ModeSetgid
+	}
+	if fi.a.mode&syscall.S_ISVTX != 0 {
+		m |= os.ModeSticky
+	}
+	switch fi.a.ftype {
+	case typeDir:
+		m |= os.ModeDir
+	case typeLnk:
+		m |= os.ModeSymlink
+	case typeBlk:
+		m |= os.ModeDevice
+	case typeChr:
+		m |= os.ModeDevice | os.ModeCharDevice
+	case typeSock:
+		m |= os.ModeSocket
+	case typeFifo:
+		m |= os.ModeNamedPipe
+	}
+	return m
+}
+
+// Method ModTime returns the modification time.
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.a.mtime
+}
+
+// Method IsDir checks whether the file is a directory.
+func (fi *fileInfo) IsDir() bool {
+	return fi.a.ftype == typeDir
+}
+
+// Method Sys returns the attributes as *sysstat.Stat. Only the fields which
+// have the same type on all supported platforms are filled.
+func (fi *fileInfo) Sys() interface{} {
+	a := sysstat.Attr{
+		Ino:   fi.a.fileid,
+		Uid:   fi.a.uid,
+		Gid:   fi.a.gid,
+		Size:  int64(fi.a.size),
+		Mode:  fi.a.mode & 07777,
+		Atime: fi.a.atime.UnixNano(),
+		Mtime: fi.a.mtime.UnixNano(),
+		Ctime: fi.a.ctime.UnixNano(),
+	}
+	switch fi.a.ftype {
+	case typeReg:
+		a.Mode |= syscall.S_IFREG
+	case typeDir:
+		a.Mode |= syscall.S_IFDIR
+	case typeBlk:
+		a.Mode |= syscall.S_IFBLK
+	case typeChr:
+		a.Mode |= syscall.S_IFCHR
+	case typeLnk:
+		a.Mode |= syscall.S_IFLNK
+	case typeSock:
+		a.Mode |= syscall.S_IFSOCK
+	case typeFifo:
+		a.Mode |= syscall.S_IFIFO
+	}
+	return sysstat.New(a)
+}
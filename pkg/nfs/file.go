@@ -0,0 +1,127 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package nfs
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Type pendingRead is a READ call in flight.
+type pendingRead struct {
+	off   uint64
+	count uint32
+	call  *call
+}
+
+// Type File is an NFS file opened for reading. Reads are pipelined: up to
+// ReadAhead READ calls are in flight, so that the throughput is not limited
+// by the round trip time of a single call.
+type File struct {
+	fs    *FS
+	name  string
+	fh    []byte
+	off   uint64        // offset of the next byte returned by Read
+	next  uint64        // offset of the next READ call
+	queue []pendingRead // READ calls in flight, in offset order
+	buf   []byte        // data received but not yet returned
+	eof   bool          // the server reported the end of the file
+}
+
+// Method fill starts READ calls until ReadAhead calls are in flight.
+func (f *File) fill() {
+	for !f.eof && len(f.queue) < f.fs.opts.ReadAhead {
+		var e encoder
+		e.opaque(f.fh)
+		e.uint64(f.next)
+		e.uint32(f.fs.rsize)
+		f.queue = append(f.queue, pendingRead{f.next, f.fs.rsize, f.fs.nfs.start(procRead, e.b)})
+		f.next += uint64(f.fs.rsize)
+	}
+}
+
+// Method reset drops the READ calls in flight and the buffered data. The
+// replies of the dropped calls are discarded when they arrive.
+func (f *File) reset(off uint64) {
+	f.queue = nil
+	f.buf = nil
+	f.off = off
+	f.next = off
+	f.eof = false
+}
+
+// Method Read reads up to len(b) bytes from the file.
+func (f *File) Read(b []byte) (int, error) {
+	for len(f.buf) == 0 {
+		if f.eof && len(f.queue) == 0 {
+			return 0, io.EOF
+		}
+		f.fill()
+		p := f.queue[0]
+		f.queue = f.queue[1:]
+
+		d, err := p.call.wait()
+		if err == nil {
+			if st := d.uint32(); st != 0 {
+				err = nfsError(st)
+			}
+		}
+		if err != nil {
+			f.reset(f.off)
+			return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		skipPostOpAttr(d)
+		count := d.uint32()
+		eof := d.bool()
+		data := d.opaque()
+		if d.err != nil || uint32(len(data)) != count || count > p.count {
+			f.reset(f.off)
+			return 0, &os.PathError{Op: "read", Path: f.name, Err: errors.New("invalid READ reply")}
+		}
+		f.buf = data
+		switch {
+		case eof:
+			// the calls behind the end of the file are not needed
+			f.queue = nil
+			f.eof = true
+		case count < p.count:
+			// short read, continue after the data received
+			f.queue = nil
+			f.next = p.off + uint64(count)
+			if count == 0 {
+				f.eof = true
+			}
+		}
+	}
+	n := copy(b, f.buf)
+	f.buf = f.buf[n:]
+	f.off += uint64(n)
+	return n, nil
+}
+
+// Method Seek sets the offset for the next Read. Seeking relative to the end
+// of the file is not supported.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(f.off)
+	default:
+		return int64(f.off), &os.PathError{Op: "seek", Path: f.name, Err: errors.New("unsupported whence")}
+	}
+	if offset < 0 {
+		return int64(f.off), &os.PathError{Op: "seek", Path: f.name, Err: errors.New("negative offset")}
+	}
+	f.reset(uint64(offset))
+	return offset, nil
+}
+
+// Method Close closes the file. NFSv3 has no open state on the server, so
+// only the pending calls are dropped.
+func (f *File) Close() error {
+	f.reset(f.off)
+	return nil
+}
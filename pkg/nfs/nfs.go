@@ -0,0 +1,364 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package nfs is an experimental userspace NFSv3 client (RFC 1813) for reading
+// directory trees. It talks to the server over several TCP connections, and
+// keeps many RPCs in flight, so that the latency of high-latency links is
+// hidden better than with a single kernel mount. The client is read-only: it
+// supports listing directories, reading attributes, symbolic links and file
+// contents.
+package nfs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RPC programs and procedures
+const (
+	mountProg = 100005
+	mountVers = 3
+	nfsProg   = 100003
+	nfsVers   = 3
+
+	procMnt  = 1
+	procUmnt = 3
+
+	procGetattr     = 1
+	procLookup      = 3
+	procReadlink    = 5
+	procRead        = 6
+	procReaddirplus = 17
+	procFsinfo      = 19
+)
+
+// maxCache is the maximum number of file handles kept in the cache.
+const maxCache = 1 << 17
+
+// Type Options configures an NFS client.
+type Options struct {
+	Conns     int           // number of TCP connections to the NFS server, default 4
+	ReadAhead int           // number of READ calls in flight per open file, default 4
+	Timeout   time.Duration // timeout of connection setup and calls, 0 for none
+}
+
+// Type FS is a mounted NFS export.
+type FS struct {
+	host   string
+	export string
+	opts   Options
+	nfs    *client
+	root   []byte
+	rsize  uint32
+	mu     sync.Mutex        // protects cache
+	cache  map[string][]byte // file handles by path
+}
+
+// Function Mount mounts an exported directory of an NFS server. The export
+// may also be a subdirectory of an exported directory, if the server permits
+// it.
+func Mount(host, export string, opts Options) (*FS, error) {
+	if opts.Conns <= 0 {
+		opts.Conns = 4
+	}
+	if opts.ReadAhead <= 0 {
+		opts.ReadAhead = 4
+	}
+
+	// get the root file handle from the mount daemon
+	port, err := getPort(host, mountProg, mountVers, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	mnt, err := newClient(net.JoinHostPort(host, strconv.Itoa(port)), mountProg, mountVers, 1, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer mnt.close()
+	var e encoder
+	e.string(export)
+	d, err := mnt.call(procMnt, e.b)
+	if err != nil {
+		return nil, err
+	}
+	if st := d.uint32(); st != 0 {
+		return nil, &os.PathError{Op: "mount", Path: host + ":" + export, Err: nfsError(st)}
+	}
+	root := d.opaque()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	// connect to the NFS server
+	port, err = getPort(host, nfsProg, nfsVers, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := newClient(net.JoinHostPort(host, strconv.Itoa(port)), nfsProg, nfsVers, opts.Conns, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	fs := &FS{
+		host:   host,
+		export: export,
+		opts:   opts,
+		nfs:    cl,
+		root:   root,
+		rsize:  64 * 1024,
+		cache:  make(map[string][]byte),
+	}
+
+	// ask for the preferred read size
+	e = encoder{}
+	e.opaque(root)
+	if d, err = cl.call(procFsinfo, e.b); err == nil && d.uint32() == 0 {
+		skipPostOpAttr(d)
+		d.uint32() // rtmax
+		if rtpref := d.uint32(); d.err == nil && rtpref >= 4096 {
+			fs.rsize = rtpref
+		}
+	}
+	return fs, nil
+}
+
+// Method Close unmounts the export and closes the connections.
+func (fs *FS) Close() error {
+	fs.nfs.close()
+	port, err := getPort(fs.host, mountProg, mountVers, fs.opts.Timeout)
+	if err != nil {
+		return err
+	}
+	mnt, err := newClient(net.JoinHostPort(fs.host, strconv.Itoa(port)), mountProg, mountVers, 1, fs.opts.Timeout)
+	if err != nil {
+		return err
+	}
+	defer mnt.close()
+	var e encoder
+	e.string(fs.export)
+	_, err = mnt.call(procUmnt, e.b)
+	return err
+}
+
+// Function clean normalizes a path relative to the export. The root of the
+// export is "".
+func clean(name string) string {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return ""
+	}
+	return name[1:]
+}
+
+// Method remember stores a file handle in the cache. The cache is cleared
+// when it grows too large.
+func (fs *FS) remember(name string, fh []byte) {
+	fs.mu.Lock()
+	if len(fs.cache) >= maxCache {
+		fs.cache = make(map[string][]byte)
+	}
+	fs.cache[name] = fh
+	fs.mu.Unlock()
+}
+
+// Method handle returns the file handle of a path, looking up the path
+// components which are not in the cache. Symbolic links are not followed.
+func (fs *FS) handle(name string) ([]byte, error) {
+	if name == "" {
+		return fs.root, nil
+	}
+	fs.mu.Lock()
+	fh, ok := fs.cache[name]
+	fs.mu.Unlock()
+	if ok {
+		return fh, nil
+	}
+
+	dir, base := "", name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		dir, base = name[:i], name[i+1:]
+	}
+	dfh, err := fs.handle(dir)
+	if err != nil {
+		return nil, err
+	}
+	var e encoder
+	e.opaque(dfh)
+	e.string(base)
+	d, err := fs.nfs.call(procLookup, e.b)
+	if err != nil {
+		return nil, err
+	}
+	if st := d.uint32(); st != 0 {
+		return nil, nfsError(st)
+	}
+	fh = d.opaque()
+	if d.err != nil {
+		return nil, d.err
+	}
+	fs.remember(name, fh)
+	return fh, nil
+}
+
+// Method Lstat returns the attributes of a file. Symbolic links are not
+// followed.
+func (fs *FS) Lstat(name string) (os.FileInfo, error) {
+	name = clean(name)
+	fh, err := fs.handle(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	var e encoder
+	e.opaque(fh)
+	d, err := fs.nfs.call(procGetattr, e.b)
+	if err == nil {
+		if st := d.uint32(); st != 0 {
+			err = nfsError(st)
+		}
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	a := decodeAttr(d)
+	if d.err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: d.err}
+	}
+	return &fileInfo{path.Base("/" + name), a}, nil
+}
+
+// Method Stat returns the attributes of a file. Since symbolic links may
+// point outside of the export, they are not followed, like in Lstat.
+func (fs *FS) Stat(name string) (os.FileInfo, error) {
+	return fs.Lstat(name)
+}
+
+// Method Readlink returns the target of a symbolic link.
+func (fs *FS) Readlink(name string) (string, error) {
+	name = clean(name)
+	fh, err := fs.handle(name)
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	var e encoder
+	e.opaque(fh)
+	d, err := fs.nfs.call(procReadlink, e.b)
+	if err == nil {
+		if st := d.uint32(); st != 0 {
+			err = nfsError(st)
+		}
+	}
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	skipPostOpAttr(d)
+	target := d.string()
+	if d.err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: d.err}
+	}
+	return target, nil
+}
+
+// Method ReadDir reads a directory with READDIRPLUS, and returns the entries
+// sorted by name, like ioutil.ReadDir. The file handles of the entries are
+// cached, so that they need no further lookup.
+func (fs *FS) ReadDir(name string) ([]os.FileInfo, error) {
+	name = clean(name)
+	fh, err := fs.handle(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	var list []os.FileInfo
+	var cookie uint64
+	verf := make([]byte, 8)
+	for {
+		var e encoder
+		e.opaque(fh)
+		e.uint64(cookie)
+		e.fixed(verf)
+		e.uint32(fs.rsize / 2) // dircount
+		e.uint32(fs.rsize)     // maxcount
+		d, err := fs.nfs.call(procReaddirplus, e.b)
+		if err == nil {
+			if st := d.uint32(); st != 0 {
+				err = nfsError(st)
+			}
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		skipPostOpAttr(d)
+		copy(verf, d.fixed(8))
+		for d.bool() {
+			d.uint64() // fileid
+			ename := d.string()
+			cookie = d.uint64()
+			var a attr
+			hasAttr := d.bool()
+			if hasAttr {
+				a = decodeAttr(d)
+			}
+			var efh []byte
+			if d.bool() {
+				efh = d.opaque()
+			}
+			if ename == "." || ename == ".." {
+				continue
+			}
+			ep := path.Join(name, ename)
+			if efh != nil {
+				fs.remember(ep, efh)
+			}
+			if hasAttr {
+				list = append(list, &fileInfo{ename, a})
+			} else if fi, err := fs.Lstat(ep); err == nil {
+				list = append(list, fi)
+			}
+		}
+		eof := d.bool()
+		if d.err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: name, Err: d.err}
+		}
+		if eof {
+			break
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+// Method Open opens a file for reading.
+func (fs *FS) Open(name string) (*File, error) {
+	name = clean(name)
+	fh, err := fs.handle(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &File{fs: fs, name: name, fh: fh}, nil
+}
+
+// Function nfsError translates an NFS status code into an error. The codes
+// below 10000 are the same as the Linux errno values.
+func nfsError(st uint32) error {
+	switch {
+	case st < 10000:
+		return syscall.Errno(st)
+	case st == 10001:
+		return errors.New("NFS3ERR_BADHANDLE")
+	case st == 10004:
+		return syscall.ENOTSUP
+	case st == 10008:
+		return syscall.EAGAIN // NFS3ERR_JUKEBOX, the server is busy
+	default:
+		return fmt.Errorf("NFS error %d", st)
+	}
+}
@@ -0,0 +1,368 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package nfs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ONC RPC constants (RFC 5531)
+const (
+	rpcCall     = 0
+	rpcReply    = 1
+	rpcVersion  = 2
+	authNone    = 0
+	authSys     = 1
+	lastFrag    = 1 << 31
+	maxRecord   = 1 << 24 // upper limit for the size of a reply record
+	portmapProg = 100000
+	portmapVers = 2
+	portmapPort = 111
+	protoTCP    = 6
+)
+
+// errClosed is returned for calls on a closed or broken connection.
+var errClosed = errors.New("nfs: connection closed")
+
+// Type reply is the result of a remote procedure call.
+type reply struct {
+	d   *decoder
+	err error
+}
+
+// Type conn is a TCP connection to an RPC server. Calls are pipelined: many
+// calls can be in flight at the same time, and the replies are matched to the
+// calls by their transaction id.
+type conn struct {
+	c       net.Conn
+	wmu     sync.Mutex // serializes the writing of records
+	mu      sync.Mutex // protects pending and err
+	pending map[uint32]chan reply
+	err     error
+}
+
+// Function dial opens a connection to an RPC server. When running as root,
+// a privileged source port is used, since most NFS servers require it.
+func dial(addr string, timeout time.Duration) (*conn, error) {
+	var c net.Conn
+	var err error
+	if os.Geteuid() == 0 {
+		for port := 1023; port >= 600; port-- {
+			d := net.Dialer{Timeout: timeout, LocalAddr: &net.TCPAddr{Port: port}}
+			c, err = d.Dial("tcp", addr)
+			if err == nil || !isAddrInUse(err) {
+				break
+			}
+		}
+	} else {
+		c, err = net.DialTimeout("tcp", addr, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{c: c, pending: make(map[uint32]chan reply)}
+	go cn.readLoop()
+	return cn, nil
+}
+
+// Function isAddrInUse checks whether a dial failed because the local port
+// is in use.
+func isAddrInUse(err error) bool {
+	if op, ok := err.(*net.OpError); ok {
+		if se, ok := op.Err.(*os.SyscallError); ok {
+			return se.Err == syscall.EADDRINUSE || se.Err == syscall.EADDRNOTAVAIL
+		}
+	}
+	return false
+}
+
+// Method start sends a call record, and returns the channel on which the
+// reply is delivered.
+func (c *conn) start(xid uint32, msg []byte) chan reply {
+	ch := make(chan reply, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		ch <- reply{err: c.err}
+		return ch
+	}
+	c.pending[xid] = ch
+	c.mu.Unlock()
+
+	// record marking: a single fragment per call
+	rec := make([]byte, 4, 4+len(msg))
+	binary.BigEndian.PutUint32(rec, lastFrag|uint32(len(msg)))
+	rec = append(rec, msg...)
+	c.wmu.Lock()
+	_, err := c.c.Write(rec)
+	c.wmu.Unlock()
+	if err != nil {
+		c.fail(err)
+	}
+	return ch
+}
+
+// Method forget drops a pending call, e.g. after a timeout.
+func (c *conn) forget(xid uint32) {
+	c.mu.Lock()
+	delete(c.pending, xid)
+	c.mu.Unlock()
+}
+
+// Method fail closes the connection and delivers the error to all pending
+// calls.
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	for xid, ch := range c.pending {
+		ch <- reply{err: err}
+		delete(c.pending, xid)
+	}
+	c.c.Close()
+}
+
+// Method readLoop reads the reply records and hands them to the waiting
+// calls.
+func (c *conn) readLoop() {
+	r := bufio.NewReader(c.c)
+	for {
+		rec, err := readRecord(r)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		d := &decoder{b: rec}
+		xid := d.uint32()
+		c.mu.Lock()
+		ch, ok := c.pending[xid]
+		delete(c.pending, xid)
+		c.mu.Unlock()
+		if ok {
+			ch <- reply{d: d, err: d.err}
+		}
+	}
+}
+
+// Function readRecord reads a record, which consists of one or more
+// fragments.
+func readRecord(r io.Reader) ([]byte, error) {
+	var rec []byte
+	for {
+		var h [4]byte
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(h[:])
+		size := int(n &^ lastFrag)
+		if len(rec)+size > maxRecord {
+			return nil, fmt.Errorf("nfs: RPC record of %d bytes is too large", len(rec)+size)
+		}
+		frag := make([]byte, size)
+		if _, err := io.ReadFull(r, frag); err != nil {
+			return nil, err
+		}
+		rec = append(rec, frag...)
+		if n&lastFrag != 0 {
+			return rec, nil
+		}
+	}
+}
+
+// Type client calls the procedures of an RPC program over one or more
+// connections. The calls are distributed over the connections round robin.
+type client struct {
+	prog, vers uint32
+	cred       []byte // encoded credentials
+	conns      []*conn
+	next       uint32 // connection for the next call
+	xid        uint32 // last transaction id
+	timeout    time.Duration
+}
+
+// Function newClient connects to an RPC program with the given number of
+// connections.
+func newClient(addr string, prog, vers uint32, conns int, timeout time.Duration) (*client, error) {
+	cl := &client{
+		prog:    prog,
+		vers:    vers,
+		cred:    credentials(),
+		xid:     uint32(time.Now().UnixNano()),
+		timeout: timeout,
+	}
+	if conns < 1 {
+		conns = 1
+	}
+	for i := 0; i < conns; i++ {
+		c, err := dial(addr, timeout)
+		if err != nil {
+			cl.close()
+			return nil, err
+		}
+		cl.conns = append(cl.conns, c)
+	}
+	return cl, nil
+}
+
+// Function credentials encodes AUTH_SYS credentials with the user and group
+// id of the process.
+func credentials() []byte {
+	host, _ := os.Hostname()
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	var e encoder
+	e.uint32(uint32(time.Now().Unix()))
+	e.string(host)
+	e.uint32(uint32(os.Getuid()))
+	e.uint32(uint32(os.Getgid()))
+	groups, _ := os.Getgroups()
+	if len(groups) > 16 {
+		groups = groups[:16]
+	}
+	e.uint32(uint32(len(groups)))
+	for _, g := range groups {
+		e.uint32(uint32(g))
+	}
+	return e.b
+}
+
+// Method close closes all connections of the client.
+func (cl *client) close() {
+	for _, c := range cl.conns {
+		c.fail(errClosed)
+	}
+}
+
+// Type call is a remote procedure call in flight.
+type call struct {
+	cl  *client
+	c   *conn
+	xid uint32
+	ch  chan reply
+}
+
+// Method start sends a call to the procedure proc with the encoded arguments.
+// The result is collected with the wait method, so that many calls can be in
+// flight at the same time.
+func (cl *client) start(proc uint32, args []byte) *call {
+	xid := atomic.AddUint32(&cl.xid, 1)
+	var e encoder
+	e.uint32(xid)
+	e.uint32(rpcCall)
+	e.uint32(rpcVersion)
+	e.uint32(cl.prog)
+	e.uint32(cl.vers)
+	e.uint32(proc)
+	e.uint32(authSys)
+	e.opaque(cl.cred)
+	e.uint32(authNone)
+	e.opaque(nil)
+	e.b = append(e.b, args...)
+
+	c := cl.conns[atomic.AddUint32(&cl.next, 1)%uint32(len(cl.conns))]
+	return &call{cl, c, xid, c.start(xid, e.b)}
+}
+
+// Method wait waits for the reply of a call, and returns a decoder for the
+// procedure results. A call without reply within the timeout fails with
+// ETIMEDOUT.
+func (ca *call) wait() (*decoder, error) {
+	var r reply
+	if ca.cl.timeout > 0 {
+		t := time.NewTimer(ca.cl.timeout)
+		select {
+		case r = <-ca.ch:
+			t.Stop()
+		case <-t.C:
+			ca.c.forget(ca.xid)
+			return nil, syscall.ETIMEDOUT
+		}
+	} else {
+		r = <-ca.ch
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return acceptReply(r.d)
+}
+
+// Method call calls a procedure and waits for the reply.
+func (cl *client) call(proc uint32, args []byte) (*decoder, error) {
+	return cl.start(proc, args).wait()
+}
+
+// Function acceptReply checks the header of a reply (after the transaction
+// id), and returns the decoder positioned at the procedure results.
+func acceptReply(d *decoder) (*decoder, error) {
+	if d.uint32() != rpcReply {
+		return nil, errors.New("nfs: RPC message is not a reply")
+	}
+	if d.uint32() != 0 {
+		// denied: RPC version mismatch or authentication error
+		if d.uint32() == 0 {
+			return nil, errors.New("nfs: RPC version mismatch")
+		}
+		return nil, fmt.Errorf("nfs: RPC authentication error %d", d.uint32())
+	}
+	d.uint32() // verifier flavor
+	d.opaque() // verifier body
+	switch st := d.uint32(); st {
+	case 0:
+		return d, d.err
+	case 1:
+		return nil, errors.New("nfs: RPC program unavailable")
+	case 2:
+		return nil, errors.New("nfs: RPC program version mismatch")
+	case 3:
+		return nil, errors.New("nfs: RPC procedure unavailable")
+	case 4:
+		return nil, errors.New("nfs: RPC garbage arguments")
+	default:
+		return nil, errors.New("nfs: RPC system error " + strconv.Itoa(int(st)))
+	}
+}
+
+// Function getPort asks the portmapper of a host for the TCP port of an RPC
+// program.
+func getPort(host string, prog, vers uint32, timeout time.Duration) (int, error) {
+	cl, err := newClient(net.JoinHostPort(host, strconv.Itoa(portmapPort)), portmapProg, portmapVers, 1, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer cl.close()
+
+	var e encoder
+	e.uint32(prog)
+	e.uint32(vers)
+	e.uint32(protoTCP)
+	e.uint32(0)
+	d, err := cl.call(3, e.b)
+	if err != nil {
+		return 0, err
+	}
+	port := d.uint32()
+	if d.err != nil {
+		return 0, d.err
+	}
+	if port == 0 {
+		return 0, fmt.Errorf("nfs: program %d version %d is not registered on %s", prog, vers, host)
+	}
+	return int(port), nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package nfs
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errShort is returned when a reply is shorter than its XDR encoding requires.
+var errShort = errors.New("nfs: short XDR data")
+
+// Type encoder builds XDR encoded data (RFC 4506).
+type encoder struct {
+	b []byte
+}
+
+// Method uint32 appends an unsigned 32 bit integer.
+func (e *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.b = append(e.b, b[:]...)
+}
+
+// Method uint64 appends an unsigned 64 bit integer (hyper).
+func (e *encoder) uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.b = append(e.b, b[:]...)
+}
+
+// Method fixed appends fixed length opaque data, padded to four bytes.
+func (e *encoder) fixed(b []byte) {
+	e.b = append(e.b, b...)
+	for len(e.b)%4 != 0 {
+		e.b = append(e.b, 0)
+	}
+}
+
+// Method opaque appends variable length opaque data.
+func (e *encoder) opaque(b []byte) {
+	e.uint32(uint32(len(b)))
+	e.fixed(b)
+}
+
+// Method string appends a string.
+func (e *encoder) string(s string) {
+	e.opaque([]byte(s))
+}
+
+// Type decoder reads XDR encoded data. The first error is remembered, and all
+// further reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// Method next returns the next n bytes of the data.
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = errShort
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+// Method uint32 reads an unsigned 32 bit integer.
+func (d *decoder) uint32() uint32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// Method uint64 reads an unsigned 64 bit integer (hyper).
+func (d *decoder) uint64() uint64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// Method bool reads a boolean.
+func (d *decoder) bool() bool {
+	return d.uint32() != 0
+}
+
+// Method fixed reads fixed length opaque data.
+func (d *decoder) fixed(n int) []byte {
+	b := d.next(n)
+	d.next((4 - n%4) % 4)
+	return b
+}
+
+// Method opaque reads variable length opaque data.
+func (d *decoder) opaque() []byte {
+	return d.fixed(int(d.uint32()))
+}
+
+// Method string reads a string.
+func (d *decoder) string() string {
+	return string(d.opaque())
+}
@@ -0,0 +1,49 @@
+#!/usr/bin/env python3
+# Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+# Use of this source code is governed by the GNU General Public License
+# Version 3 that can be found in the LICENSE.txt file.
+#
+# maketables.py writes tables.go from the Unicode Character Database of the
+# Python interpreter:
+#
+#	python3 maketables.py > tables.go && gofmt -w tables.go
+
+import sys
+import unicodedata
+
+decomp, ccc, comp = {}, {}, {}
+for cp in range(0x110000):
+    if 0xAC00 <= cp <= 0xD7A3:
+        continue  # Hangul syllables are decomposed algorithmically
+    ch = chr(cp)
+    c = unicodedata.combining(ch)
+    if c:
+        ccc[cp] = c
+    d = unicodedata.decomposition(ch)
+    if not d or d.startswith("<"):
+        continue
+    parts = [int(x, 16) for x in d.split()]
+    decomp[cp] = parts
+    if len(parts) == 2 and unicodedata.normalize("NFC", chr(parts[0]) + chr(parts[1])) == ch:
+        comp[(parts[0], parts[1])] = cp
+
+out = sys.stdout
+out.write("// Code generated by maketables.py from the Unicode Character Database %s. DO NOT EDIT.\n\n" % unicodedata.unidata_version)
+out.write("package norm\n\n")
+out.write("// UnicodeVersion is the version of the Unicode Character Database of the tables.\n")
+out.write("const UnicodeVersion = \"%s\"\n\n" % unicodedata.unidata_version)
+out.write("// decompositions holds the canonical decomposition of each character, one\n// level deep.\n")
+out.write("var decompositions = map[rune]string{\n")
+for cp in sorted(decomp):
+    out.write("\t0x%04X: \"%s\",\n" % (cp, "".join("\\U%08X" % p for p in decomp[cp])))
+out.write("}\n\n")
+out.write("// combiningClasses holds the canonical combining class of the characters\n// with a class other than 0.\n")
+out.write("var combiningClasses = map[rune]uint8{\n")
+for cp in sorted(ccc):
+    out.write("\t0x%04X: %d,\n" % (cp, ccc[cp]))
+out.write("}\n\n")
+out.write("// compositions maps the pairs of characters which compose to a primary\n// composite, as first<<21 | second.\n")
+out.write("var compositions = map[uint64]rune{\n")
+for (a, b) in sorted(comp):
+    out.write("\t0x%04X<<21 | 0x%04X: 0x%04X,\n" % (a, b, comp[(a, b)]))
+out.write("}\n")
@@ -0,0 +1,142 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package norm implements the Unicode normalization forms NFC and NFD, which
+// are needed to match file names between file systems storing names
+// precomposed (Linux, SMB, most applications) and decomposed (HFS+, some
+// macOS applications). The tables in tables.go are generated from the Unicode
+// Character Database by maketables.py.
+package norm
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Hangul syllables are composed and decomposed algorithmically.
+const (
+	hangulBase   = 0xAC00
+	hangulL      = 0x1100
+	hangulV      = 0x1161
+	hangulT      = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// Function NFD returns the canonical decomposition of a string. Strings which
+// are not valid UTF-8 are returned unchanged.
+func NFD(s string) string {
+	if ascii(s) || !utf8.ValidString(s) {
+		return s
+	}
+	return string(decompose(s))
+}
+
+// Function NFC returns the canonical composition of a string. Strings which
+// are not valid UTF-8 are returned unchanged.
+func NFC(s string) string {
+	if ascii(s) || !utf8.ValidString(s) {
+		return s
+	}
+	return string(compose(decompose(s)))
+}
+
+// Function ascii checks whether a string consists of ASCII characters only,
+// which are not changed by normalization.
+func ascii(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Function decompose decomposes the characters of a string recursively, and
+// puts the combining marks into canonical order.
+func decompose(s string) []rune {
+	out := make([]rune, 0, len(s)+8)
+	for _, r := range s {
+		out = appendDecomposed(out, r)
+	}
+
+	// stable sort of each sequence of combining marks by combining class
+	for i := 0; i < len(out); {
+		if combiningClasses[out[i]] == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(out) && combiningClasses[out[j]] != 0 {
+			j++
+		}
+		marks := out[i:j]
+		sort.SliceStable(marks, func(a, b int) bool {
+			return combiningClasses[marks[a]] < combiningClasses[marks[b]]
+		})
+		i = j
+	}
+	return out
+}
+
+// Function appendDecomposed appends the full canonical decomposition of a
+// character.
+func appendDecomposed(out []rune, r rune) []rune {
+	if r >= hangulBase && r < hangulBase+hangulSCount {
+		i := r - hangulBase
+		out = append(out, hangulL+i/hangulNCount, hangulV+(i%hangulNCount)/hangulTCount)
+		if t := i % hangulTCount; t != 0 {
+			out = append(out, hangulT+t)
+		}
+		return out
+	}
+	d, ok := decompositions[r]
+	if !ok {
+		return append(out, r)
+	}
+	for _, c := range d {
+		out = appendDecomposed(out, c)
+	}
+	return out
+}
+
+// Function compose applies the canonical composition algorithm to a
+// decomposed string: each character is combined with the last starter, if the
+// pair has a primary composite, and no character in between blocks it.
+func compose(in []rune) []rune {
+	out := in[:0]
+	starter := -1
+	var last uint8
+	for _, r := range in {
+		cc := combiningClasses[r]
+		if starter >= 0 && (len(out)-1 == starter || (last != 0 && last < cc)) {
+			if c, ok := composePair(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if cc == 0 {
+			starter = len(out)
+		}
+		out = append(out, r)
+		last = cc
+	}
+	return out
+}
+
+// Function composePair returns the primary composite of two characters.
+func composePair(a, b rune) (rune, bool) {
+	if a >= hangulL && a < hangulL+hangulLCount && b >= hangulV && b < hangulV+hangulVCount {
+		return hangulBase + ((a-hangulL)*hangulVCount+b-hangulV)*hangulTCount, true
+	}
+	if a >= hangulBase && a < hangulBase+hangulSCount && (a-hangulBase)%hangulTCount == 0 &&
+		b > hangulT && b < hangulT+hangulTCount {
+		return a + b - hangulT, true
+	}
+	c, ok := compositions[uint64(a)<<21|uint64(b)]
+	return c, ok
+}
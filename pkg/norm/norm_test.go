@@ -0,0 +1,63 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package norm
+
+import (
+	"testing"
+)
+
+// forms are strings in their NFC and NFD forms.
+var forms = []struct {
+	nfc, nfd string
+}{
+	{"", ""},
+	{"plain.txt", "plain.txt"},
+	{"caf\u00e9", "cafe\u0301"},
+	{"\u00c5ngstr\u00f6m", "A\u030angstro\u0308m"},
+	{"\u1e69", "s\u0323\u0307"},                              // marks in canonical order
+	{"\u1eac", "A\u0323\u0302"},                              // composed in two steps
+	{"\u00f1o\u00f1o", "n\u0303on\u0303o"},                   // several characters
+	{"\ud55c\uae00", "\u1112\u1161\u11ab\u1100\u1173\u11af"}, // Hangul syllables
+	{"\u65e5\u672c", "\u65e5\u672c"},                         // no decomposition
+}
+
+func TestForms(t *testing.T) {
+	for _, f := range forms {
+		if got := NFC(f.nfd); got != f.nfc {
+			t.Errorf("NFC(%+q) = %+q, want %+q", f.nfd, got, f.nfc)
+		}
+		if got := NFC(f.nfc); got != f.nfc {
+			t.Errorf("NFC(%+q) = %+q, want it unchanged", f.nfc, got)
+		}
+		if got := NFD(f.nfc); got != f.nfd {
+			t.Errorf("NFD(%+q) = %+q, want %+q", f.nfc, got, f.nfd)
+		}
+		if got := NFD(f.nfd); got != f.nfd {
+			t.Errorf("NFD(%+q) = %+q, want it unchanged", f.nfd, got)
+		}
+	}
+}
+
+// TestOrder checks that combining marks in the wrong order are sorted by
+// their combining class, and composed with the base character.
+func TestOrder(t *testing.T) {
+	if got, want := NFD("s\u0307\u0323"), "s\u0323\u0307"; got != want {
+		t.Errorf("NFD = %+q, want %+q", got, want)
+	}
+	if got, want := NFC("s\u0307\u0323"), "\u1e69"; got != want {
+		t.Errorf("NFC = %+q, want %+q", got, want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, s := range []string{"caf\xe9", "e\u0301\xff"} {
+		if got := NFC(s); got != s {
+			t.Errorf("NFC(%+q) = %+q, want it unchanged", s, got)
+		}
+		if got := NFD(s); got != s {
+			t.Errorf("NFD(%+q) = %+q, want it unchanged", s, got)
+		}
+	}
+}
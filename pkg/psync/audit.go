@@ -0,0 +1,118 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Type auditRecord is a record of the audit log, a change of an entry of the
+// destination, or of the source in two-way mode. The records are written as
+// newline delimited JSON (NDJSON), one record per line.
+type auditRecord struct {
+	Time    time.Time  `json:"time"`    // time of the change
+	Session string     `json:"session"` // token of the run, see session
+	Action  string     `json:"action"`  // create, overwrite, delete, chown, chmod or times
+	Path    string     `json:"path"`    // path of the changed entry
+	Before  *auditMeta `json:"before"`  // metadata before the change, null if the entry did not exist
+	After   *auditMeta `json:"after"`   // metadata after the change, null if the entry is gone
+}
+
+// Type auditMeta is the metadata of an entry in the audit log.
+type auditMeta struct {
+	Mode  string    `json:"mode"` // type and permissions, like "-rw-r--r--"
+	Size  int64     `json:"size"`
+	Mtime time.Time `json:"mtime"`
+	UID   *uint32   `json:"uid,omitempty"` // not known on all backends
+	GID   *uint32   `json:"gid,omitempty"`
+}
+
+// Method openAudit opens the audit log for appending. Existing records are
+// kept, so the log of several runs can be collected in one file.
+func (e *engine) openAudit(path string) error {
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, os.FileMode(0600))
+	if err != nil {
+		return fmt.Errorf("cannot open audit log %s: %s", path, err)
+	}
+	e.auditFd = fd
+	return nil
+}
+
+// Method closeAudit closes the audit log.
+func (e *engine) closeAudit() {
+	if e.auditFd == nil {
+		return
+	}
+	e.auditMu.Lock()
+	defer e.auditMu.Unlock()
+	if err := e.auditFd.Close(); err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - cannot write audit log: %s\n", err)
+	}
+	e.auditFd = nil
+}
+
+// Method auditBefore returns the metadata of an entry before it is changed,
+// for the audit log. It returns nil if the entry does not exist, or if no
+// audit log is written.
+func (e *engine) auditBefore(fs FS, name string) *auditMeta {
+	if e.auditFd == nil {
+		return nil
+	}
+	e.op()
+	f, err := fs.Lstat(name)
+	if err != nil {
+		return nil
+	}
+	return newAuditMeta(f)
+}
+
+// Function newAuditMeta takes the metadata of an entry for the audit log.
+func newAuditMeta(f os.FileInfo) *auditMeta {
+	m := &auditMeta{Mode: f.Mode().String(), Size: f.Size(), Mtime: f.ModTime()}
+	if stat, ok := f.Sys().(*sysstat.Stat); ok {
+		uid, gid := uint32(stat.Uid), uint32(stat.Gid)
+		m.UID, m.GID = &uid, &gid
+	}
+	return m
+}
+
+// Method audit writes a record of a change of the entry name of the tree
+// fs with the root path root to the audit log. The metadata after the change
+// is read from the tree. The action "write" is logged as create or overwrite,
+// depending on whether the entry existed before. Each record is written at
+// once, so that a crash loses no record of a change which was made.
+func (e *engine) audit(action string, fs FS, root, name string, before *auditMeta) {
+	if e.auditFd == nil {
+		return
+	}
+	r := auditRecord{Time: time.Now(), Session: e.session, Action: action, Path: root + name, Before: before}
+	if r.Action == "write" {
+		r.Action = "overwrite"
+		if before == nil {
+			r.Action = "create"
+		}
+	}
+	if action != "delete" {
+		e.op()
+		if f, err := fs.Lstat(name); err == nil {
+			r.After = newAuditMeta(f)
+		}
+	}
+	b, _ := json.Marshal(&r)
+
+	e.auditMu.Lock()
+	defer e.auditMu.Unlock()
+	if e.auditFd == nil {
+		return
+	}
+	if _, err := e.auditFd.Write(append(b, '\n')); err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - cannot write audit log: %s\n", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hweidner/psync/pkg/psync"
+	"github.com/hweidner/psync/pkg/treetest"
+)
+
+// The synthetic tree of the benchmarks, generated once for all of them
+var (
+	benchOnce  sync.Once
+	benchDir   string
+	benchBytes int64
+	benchErr   error
+)
+
+// benchSource generates the synthetic tree, the same as "psync bench" does
+// by default, but with 2000 files.
+func benchSource(b *testing.B) string {
+	benchOnce.Do(func() {
+		var sizes []treetest.SizeClass
+		if benchDir, benchErr = ioutil.TempDir("", "psync-bench-src"); benchErr != nil {
+			return
+		}
+		if sizes, benchErr = treetest.ParseSizes(treetest.DefaultSizes); benchErr != nil {
+			return
+		}
+		if benchErr = treetest.Synthetic(benchDir, 2000, sizes, 3, 4); benchErr != nil {
+			return
+		}
+		benchErr = filepath.Walk(benchDir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				benchBytes += info.Size()
+			}
+			return err
+		})
+	})
+	if benchErr != nil {
+		b.Fatalf("cannot create synthetic tree: %s", benchErr)
+	}
+	return benchDir
+}
+
+// TestMain removes the synthetic tree after the benchmarks.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// benchSettings copies the synthetic tree with each of the settings, with 16
+// threads, into a new temporary destination per iteration.
+func benchSettings(b *testing.B, settings []treetest.Setting) {
+	source := benchSource(b)
+	for _, s := range settings {
+		b.Run(s.Name, func(b *testing.B) {
+			b.SetBytes(benchBytes)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				target, err := ioutil.TempDir("", "psync-bench")
+				if err != nil {
+					b.Fatal(err)
+				}
+				opts := psync.Options{
+					Source:      source,
+					Destination: target,
+					Threads:     16,
+					Quiet:       true,
+					Stdout:      ioutil.Discard,
+				}
+				s.Apply(&opts)
+				b.StartTimer()
+
+				err = psync.New(opts).Run(context.Background())
+				b.StopTimer()
+				os.RemoveAll(target)
+				if err != nil {
+					b.Fatalf("%s: %s", s.Name, err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// BenchmarkEngine compares the engines copying the data of the files.
+func BenchmarkEngine(b *testing.B) {
+	benchSettings(b, treetest.Engines)
+}
+
+// BenchmarkScheduler compares the orders of the directories and files.
+func BenchmarkScheduler(b *testing.B) {
+	benchSettings(b, treetest.Schedulers)
+}
@@ -0,0 +1,29 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import "sync"
+
+// Variable bufPool holds the copy buffers of BUFSIZE bytes. A buffer is taken
+// for the copy, hash or comparison of a single file, and returned afterwards,
+// so that buffers are not bound to the copy threads. The pointers to the
+// slices avoid an allocation with each return to the pool.
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, BUFSIZE)
+		return &buf
+	},
+}
+
+// Function getBuffer takes a copy buffer from the pool.
+func getBuffer() *[]byte {
+	return bufPool.Get().(*[]byte)
+}
+
+// Function putBuffer returns a copy buffer to the pool. The buffer must not
+// be used afterwards.
+func putBuffer(buf *[]byte) {
+	bufPool.Put(buf)
+}
@@ -0,0 +1,21 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+// Function dropCache drops a copied file from the page cache. A written file
+// is synced first, since dirty pages cannot be dropped. Files of backends
+// without a file descriptor are skipped.
+func dropCache(f interface{}, written bool) error {
+	fd, ok := f.(interface{ Fd() uintptr })
+	if !ok {
+		return nil
+	}
+	if s, ok := f.(interface{ Sync() error }); ok && written {
+		if err := s.Sync(); err != nil {
+			return err
+		}
+	}
+	return fadvise(fd.Fd())
+}
@@ -0,0 +1,84 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hweidner/psync/pkg/norm"
+)
+
+// Method probeCase checks whether the destination is case-insensitive, like
+// SMB shares or APFS and NTFS volumes with the default settings, by creating a
+// probe file and looking it up in upper case. Buckets and archives are not
+// probed, they are case-sensitive.
+func (e *engine) probeCase() bool {
+	switch e.destination.(type) {
+	case s3FS, azFS, archiveFS:
+		return false
+	}
+	name := fmt.Sprintf("/.psync-case-%d", os.Getpid())
+	e.op()
+	wr, err := e.destination.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(0600))
+	if err != nil {
+		return false
+	}
+	wr.Close()
+	e.op()
+	_, err = e.destination.Lstat(strings.ToUpper(name))
+	e.op()
+	e.destination.Remove(name)
+	return err == nil
+}
+
+// Method foldName returns the name under which an entry is found on a
+// case-insensitive destination.
+func (e *engine) foldName(name string) string {
+	if e.normalize != "" {
+		name = norm.NFC(name)
+	}
+	return strings.ToLower(name)
+}
+
+// Method caseCollision checks whether a source entry differs only in case
+// from an earlier entry of the same directory, so that both would end up in
+// the same entry of a case-insensitive destination. The names of the directory
+// seen so far are kept in seen. Colliding entries are skipped with a warning,
+// or copied under another name with -case-collisions rename. It returns true
+// if the entry must be skipped.
+func (e *engine) caseCollision(dir, name string, seen map[string]string) bool {
+	key := e.foldName(name)
+	other, ok := seen[key]
+	if !ok {
+		seen[key] = name
+		return false
+	}
+	if e.caseCollisions != "rename" {
+		e.warning(dir+"/"+name, "%s%s/%s collides with %s on the case-insensitive destination, skipped", e.src, dir, name, other)
+		return true
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// dot files like .Profile
+		base, ext = ext, ""
+	}
+	renamed := name
+	for i := 2; ok; i++ {
+		renamed = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		_, ok = seen[e.foldName(renamed)]
+	}
+	seen[e.foldName(renamed)] = renamed
+	e.destination.(*normFS).rename(dir+"/"+name, renamed)
+	if !e.quiet {
+		fmt.Fprintf(e.stderr, "WARNING - %s%s/%s collides with %s on the case-insensitive destination, copied as %s\n",
+			e.src, dir, name, other, renamed)
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// Type checkpointData is the content of a checkpoint file. It lists the
+// directories which were not yet handled when psync was interrupted.
+type checkpointData struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	Dirs        []string `json:"dirs"`
+}
+
+// Method halt stops the copy threads after the files in progress. The
+// remaining directories are written to the checkpoint.
+func (e *engine) halt() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+	})
+}
+
+// Method stopping checks whether psync was interrupted.
+func (e *engine) stopping() bool {
+	select {
+	case <-e.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Method postpone records a directory job which is not handled due to an
+// interruption, and removes it from the work queue.
+func (e *engine) postpone(j job) {
+	e.pendingMu.Lock()
+	e.pending = append(e.pending, j.dir)
+	e.pendingMu.Unlock()
+	e.wg.Done()
+}
+
+// Method postponePartial records a directory job which was interrupted
+// while its entries were copied. The directory is handled again as a whole.
+func (e *engine) postponePartial(j job) {
+	e.pendingMu.Lock()
+	e.partials[j.dir] = true
+	e.pendingMu.Unlock()
+	e.postpone(j)
+}
+
+// Method writeCheckpoint writes the unhandled directories to the checkpoint
+// file. Subdirectories of partially handled directories are left out, since
+// they are discovered again when the parent directory is resumed.
+func (e *engine) writeCheckpoint() {
+	var dirs []string
+	for _, dir := range e.pending {
+		nested := false
+		for i := 1; i < len(dir); i++ {
+			if dir[i] == '/' && e.partials[dir[:i]] {
+				nested = true
+				break
+			}
+		}
+		if !nested && !(dir != "" && e.partials[""]) {
+			dirs = append(dirs, dir)
+		}
+	}
+	e.pending = dirs
+
+	b, err := json.MarshalIndent(checkpointData{e.src, e.dest, e.pending}, "", "\t")
+	if err == nil {
+		err = ioutil.WriteFile(e.checkpoint, b, os.FileMode(0644))
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - could not write checkpoint %s: %s\n", e.checkpoint, err)
+		return
+	}
+	fmt.Fprintf(e.stderr, "Interrupted with %d directories left. Checkpoint written to %s.\n"+
+		"Use '-resume' to continue.\n", len(e.pending), e.checkpoint)
+}
+
+// Method readCheckpoint reads the directories to resume from the checkpoint
+// file.
+func (e *engine) readCheckpoint() ([]string, error) {
+	var cp checkpointData
+	b, err := ioutil.ReadFile(e.checkpoint)
+	if err == nil {
+		err = json.Unmarshal(b, &cp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint %s: %s", e.checkpoint, err)
+	}
+	if cp.Source != e.src || cp.Destination != e.dest {
+		return nil, fmt.Errorf("checkpoint %s was written for copying %s to %s", e.checkpoint, cp.Source, cp.Destination)
+	}
+	return cp.Dirs, nil
+}
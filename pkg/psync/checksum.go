@@ -0,0 +1,29 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"hash/crc32"
+
+	"github.com/hweidner/psync/pkg/blake3"
+	"github.com/hweidner/psync/pkg/xxh3"
+)
+
+// checksums contains the hash functions which can be used for the checksums
+// of files and chunks. Further hash functions can be registered here.
+var checksums = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"blake3": blake3.New,
+	"xxh3":   func() hash.Hash { return xxh3.New() },
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+	"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+}
@@ -0,0 +1,156 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Type chmodRule is a rule of the -chmod option, like "Dg+s" or "F644". It
+// applies to directories, to other entries, or to both.
+type chmodRule struct {
+	dirs, files bool   // types of entries the rule applies to
+	octal       bool   // the rule sets the permissions to mode
+	mode        uint32 // permissions of an octal rule
+	clauses     []chmodClause
+}
+
+// Type chmodClause is a symbolic change of a rule, like "ug+rw".
+type chmodClause struct {
+	who  uint32 // bits of the users affected
+	op   byte   // '+', '-' or '='
+	what string // permission letters of rwxXst
+}
+
+// Function parseChmod parses the comma separated rules of the -chmod option,
+// in the format of rsync --chmod: each rule starts with an optional D (for
+// directories) or F (for files), followed by an octal mode like 2775, or by
+// symbolic changes like chmod(1), e.g. "D2775,F664" or "Dg+s,ug+w,o-w,+X".
+func parseChmod(s string) ([]chmodRule, error) {
+	var rules []chmodRule
+	for _, item := range strings.Split(s, ",") {
+		r := chmodRule{dirs: true, files: true}
+		text := item
+		switch {
+		case strings.HasPrefix(text, "D"):
+			r.files, text = false, text[1:]
+		case strings.HasPrefix(text, "F"):
+			r.dirs, text = false, text[1:]
+		}
+		if text == "" {
+			return nil, fmt.Errorf("invalid -chmod rule %q", item)
+		}
+		if m, err := strconv.ParseUint(text, 8, 32); err == nil {
+			if m > 07777 {
+				return nil, fmt.Errorf("invalid -chmod rule %q", item)
+			}
+			r.octal, r.mode = true, uint32(m)
+			rules = append(rules, r)
+			continue
+		}
+		for text != "" {
+			var who uint32
+			i := 0
+			for ; i < len(text) && strings.IndexByte("ugoa", text[i]) >= 0; i++ {
+				switch text[i] {
+				case 'u':
+					who |= 04700
+				case 'g':
+					who |= 02070
+				case 'o':
+					who |= 01007
+				case 'a':
+					who |= 07777
+				}
+			}
+			if who == 0 {
+				who = 07777
+			}
+			text = text[i:]
+			if text == "" || strings.IndexByte("+-=", text[0]) < 0 {
+				return nil, fmt.Errorf("invalid -chmod rule %q", item)
+			}
+			// one or more operators for the users, e.g. "u+w-x"
+			for text != "" && strings.IndexByte("+-=", text[0]) >= 0 {
+				j := 1
+				for j < len(text) && strings.IndexByte("rwxXst", text[j]) >= 0 {
+					j++
+				}
+				r.clauses = append(r.clauses, chmodClause{who: who, op: text[0], what: text[1:j]})
+				text = text[j:]
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Method apply changes the permissions of an entry by the rule.
+func (r chmodRule) apply(mode uint32, dir bool) uint32 {
+	if dir && !r.dirs || !dir && !r.files {
+		return mode
+	}
+	if r.octal {
+		return r.mode
+	}
+	for _, c := range r.clauses {
+		var bits uint32
+		for _, l := range c.what {
+			switch l {
+			case 'r':
+				bits |= 0444
+			case 'w':
+				bits |= 0222
+			case 'x':
+				bits |= 0111
+			case 'X':
+				if dir || mode&0111 != 0 {
+					bits |= 0111
+				}
+			case 's':
+				bits |= 06000
+			case 't':
+				bits |= 01000
+			}
+		}
+		bits &= c.who
+		switch c.op {
+		case '+':
+			mode |= bits
+		case '-':
+			mode &^= bits
+		case '=':
+			mode = mode&^c.who | bits
+		}
+	}
+	return mode
+}
+
+// Method destPerm returns the permissions of the destination entry of a
+// source entry, changed by the -chmod rules.
+func (e *engine) destPerm(f os.FileInfo) os.FileMode {
+	perm := f.Mode().Perm()
+	if e.chmodRules == nil {
+		return perm
+	}
+	mode := uint32(perm)
+	for _, r := range e.chmodRules {
+		mode = r.apply(mode, f.IsDir())
+	}
+	perm = os.FileMode(mode & 0777)
+	if mode&04000 != 0 {
+		perm |= os.ModeSetuid
+	}
+	if mode&02000 != 0 {
+		perm |= os.ModeSetgid
+	}
+	if mode&01000 != 0 {
+		perm |= os.ModeSticky
+	}
+	return perm
+}
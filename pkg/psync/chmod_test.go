@@ -0,0 +1,61 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import "testing"
+
+// chmodTests are -chmod rules with the permissions they give to an entry.
+var chmodTests = []struct {
+	rules string
+	mode  uint32
+	dir   bool
+	want  uint32
+}{
+	{"644", 0755, false, 0644},
+	{"D2775,F664", 0700, true, 02775},
+	{"D2775,F664", 0700, false, 0664},
+	{"F600", 0755, true, 0755},
+	{"Dg+s", 0755, true, 02755},
+	{"Dg+s", 0755, false, 0755},
+	{"u+w", 0444, false, 0644},
+	{"go-w", 0666, false, 0644},
+	{"+x", 0644, false, 0755},
+	{"a-x", 0755, true, 0644},
+	{"+X", 0644, false, 0644},
+	{"+X", 0744, false, 0755},
+	{"+X", 0644, true, 0755},
+	{"o=", 0777, false, 0770},
+	{"u=rw,g=r,o=", 0777, false, 0640},
+	{"ug=rwx,o=rx", 0, true, 0775},
+	{"u+w-x", 0555, false, 0655},
+	{"g+rw-x+s", 0611, true, 02661},
+	{"+t", 0777, true, 01777},
+	{"ug+rw,o-w,+X", 0600, true, 0771},
+}
+
+func TestChmod(t *testing.T) {
+	for _, ct := range chmodTests {
+		rules, err := parseChmod(ct.rules)
+		if err != nil {
+			t.Errorf("rules %q: %s", ct.rules, err)
+			continue
+		}
+		mode := ct.mode
+		for _, r := range rules {
+			mode = r.apply(mode, ct.dir)
+		}
+		if mode != ct.want {
+			t.Errorf("rules %q on %04o (dir %t): got %04o, want %04o", ct.rules, ct.mode, ct.dir, mode, ct.want)
+		}
+	}
+}
+
+func TestChmodErrors(t *testing.T) {
+	for _, s := range []string{"", "D", "F,644", "17777", "u", "u+w,", "x+r", "u+wz", "Du*x"} {
+		if _, err := parseChmod(s); err == nil {
+			t.Errorf("rules %q: no error", s)
+		}
+	}
+}
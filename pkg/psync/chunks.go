@@ -0,0 +1,194 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync/atomic"
+)
+
+// Type chunkIndex is the journal of a partially transferred large file. It
+// contains the hashes of the chunks written so far, so that an interrupted copy
+// can be resumed after verifying the chunks on the destination side. The size
+// and modification time identify the version of the source file. Journals
+// without hash function were written with SHA-256.
+type chunkIndex struct {
+	Size   int64    `json:"size"`
+	Mtime  int64    `json:"mtime"`
+	Chunk  int64    `json:"chunk"`
+	Hash   string   `json:"hash,omitempty"`
+	Hashes []string `json:"hashes"`
+}
+
+// Function indexName returns the name of the chunk journal of a destination
+// file. It is stored as a hidden file next to the destination file.
+func indexName(target string) string {
+	return path.Dir(target) + "/." + path.Base(target) + ".psync-chunks"
+}
+
+// Method copyChunked copies a large regular file chunk by chunk, and records
+// the checksum of each chunk in the chunk journal. If a journal of an
+// earlier, interrupted copy of the same source file exists, the chunks already
+// on the destination side are verified against it, and the copy continues
+// after the last good chunk. The journal is removed when the copy is
+// complete. It returns the number of bytes copied, and an *Error if the copy
+// failed.
+func (e *engine) copyChunked(id uint, c *fileCopy, file, target string, f os.FileInfo) (int64, error) {
+	idx := chunkIndex{
+		Size:  f.Size(),
+		Mtime: f.ModTime().Unix(),
+		Chunk: int64(e.chunkSize) * 1000000,
+		Hash:  e.checksum,
+	}
+	journal := indexName(target)
+	idx.Hashes = e.verifyChunks(id, c.buf, file, target, journal, idx)
+	off := int64(len(idx.Hashes)) * idx.Chunk
+
+	// open source file for reading
+	e.op()
+	rd, err := e.source.Open(file)
+	if err == nil {
+		err = c.track(rd, "")
+	}
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("file %s disappeared while copying: %s", e.src+file, err), err}
+	}
+	defer c.close(rd)
+
+	// open destination file for writing, keeping the verified chunks
+	flags := os.O_WRONLY | os.O_CREATE
+	if off == 0 {
+		flags |= os.O_TRUNC
+	}
+	e.op()
+	wr, err := e.destination.OpenFile(target, flags, e.createMode(f))
+	if err == nil {
+		err = c.track(wr, target)
+	}
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, err), err}
+	}
+	if off > 0 {
+		if _, err = rd.Seek(off, io.SeekStart); err == nil {
+			_, err = wr.Seek(off, io.SeekStart)
+		}
+		if err != nil {
+			c.close(wr)
+			return 0, &Error{file, fmt.Sprintf("file %s could not be resumed: %s", e.dest+target, err), err}
+		}
+	}
+	if e.preallocate {
+		if err := allocate(wr, off, f.Size()); err != nil {
+			c.close(wr)
+			return 0, &Error{file, fmt.Sprintf("file %s could not be preallocated: %s", e.dest+target, err), err}
+		}
+	}
+
+	// copy data chunk by chunk
+	atomic.StoreUint64(&c.done, uint64(off))
+	var n int64
+	h := e.checksumNew()
+	for {
+		h.Reset()
+		w := io.MultiWriter(progressWriter{wr, &c.done, &e.copied}, h)
+		var cn int64
+		cn, err = io.CopyBuffer(w, io.LimitReader(rd, idx.Chunk), c.buf)
+		n += cn
+		off += cn
+		if err != nil || cn < idx.Chunk {
+			break
+		}
+		idx.Hashes = append(idx.Hashes, hex.EncodeToString(h.Sum(nil)))
+		if jerr := e.writeIndex(journal, idx); jerr != nil && e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Could not write chunk journal %s: %s\n", id, e.dest+journal, jerr)
+		}
+	}
+	if err == nil {
+		err = wr.Truncate(off)
+	}
+	if err == nil && e.fsyncFiles {
+		err = syncFile(wr)
+	}
+	if err == nil && e.dropDest {
+		err = dropCache(wr, true)
+	}
+	if e.dropSource {
+		dropCache(rd, false)
+	}
+	if cerr := c.close(wr); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, err), err}
+	}
+	e.destination.Remove(journal)
+	if e.verifyAfter {
+		// the chunks copied by earlier runs were not hashed now
+		return n, e.verifyCopy(c.buf, file, target, nil)
+	}
+	return n, nil
+}
+
+// Method verifyChunks reads the chunk journal of a destination file, and
+// checks the chunks on the destination side against the recorded hashes. It
+// returns the hashes of the leading chunks which are still valid.
+func (e *engine) verifyChunks(id uint, buf []byte, file, target, journal string, idx chunkIndex) []string {
+	e.op()
+	b, err := readFile(e.destination, journal)
+	if err != nil {
+		return nil
+	}
+	var old chunkIndex
+	err = json.Unmarshal(b, &old)
+	if old.Hash == "" {
+		old.Hash = "sha256"
+	}
+	if err != nil || old.Size != idx.Size || old.Mtime != idx.Mtime || old.Chunk != idx.Chunk || old.Hash != idx.Hash {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Discarding outdated chunk journal %s\n", id, e.dest+journal)
+		}
+		return nil
+	}
+
+	e.op()
+	fd, err := e.destination.Open(target)
+	if err != nil {
+		return nil
+	}
+	defer fd.Close()
+
+	h := e.checksumNew()
+	var valid []string
+	for _, sum := range old.Hashes {
+		h.Reset()
+		n, err := io.CopyBuffer(h, io.LimitReader(fd, idx.Chunk), buf)
+		if err != nil || n < idx.Chunk || hex.EncodeToString(h.Sum(nil)) != sum {
+			break
+		}
+		valid = append(valid, sum)
+	}
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "[%d] Resuming %s after %d of %d verified chunks\n", id, e.src+file, len(valid), len(old.Hashes))
+	}
+	return valid
+}
+
+// Method writeIndex writes the chunk journal. It is written under a temporary
+// name and renamed, so that an interruption never leaves a partial journal.
+func (e *engine) writeIndex(journal string, idx chunkIndex) error {
+	b, _ := json.Marshal(idx)
+	tmp := e.tempName(journal)
+	e.op()
+	if err := writeFile(e.destination, tmp, b, os.FileMode(0600)); err != nil {
+		return err
+	}
+	e.op()
+	return e.destination.Rename(tmp, journal)
+}
@@ -0,0 +1,47 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+)
+
+// Method cloneData copies a regular file between local trees by cloning it,
+// so that the copy shares the data blocks of the source copy-on-write. The
+// clone is made under a temporary name and renamed, since an existing file is
+// not replaced by cloning. It returns false if the file was not cloned, and
+// must be copied.
+func (e *engine) cloneData(file, target string) bool {
+	if atomic.LoadInt32(&e.cloneOff) != 0 || !e.localTrees() {
+		return false
+	}
+	to := string(e.destination.(localFS)) + target
+	tmp := to + ".psync-clone"
+	if e.tempDir != "" {
+		tmp = string(e.destination.(localFS)) + e.tempName(target)
+	}
+	e.op()
+	err := cloneFile(string(e.source.(localFS))+file, tmp)
+	if err == syscall.EEXIST {
+		// left over from an interrupted run
+		os.Remove(tmp)
+		err = cloneFile(string(e.source.(localFS))+file, tmp)
+	}
+	if err == syscall.ENOTSUP || err == syscall.EXDEV || err == syscall.ENOSYS {
+		atomic.StoreInt32(&e.cloneOff, 1)
+		return false
+	}
+	if err != nil {
+		return false
+	}
+	e.op()
+	if err := os.Rename(tmp, to); err != nil {
+		os.Remove(tmp)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Arguments of the clonefileat(2) system call of Darwin
+const (
+	sysClonefileat = 462    // system call number
+	atFdcwd        = -2     // AT_FDCWD, paths relative to the working directory
+	cloneNofollow  = 0x0001 // CLONE_NOFOLLOW, do not follow a symbolic link
+)
+
+// Function cloneFile clones a file with clonefile(2), which is supported by
+// APFS within a volume.
+func cloneFile(from, to string) error {
+	p1, err := syscall.BytePtrFromString(from)
+	if err != nil {
+		return err
+	}
+	p2, err := syscall.BytePtrFromString(to)
+	if err != nil {
+		return err
+	}
+	fd := atFdcwd
+	_, _, errno := syscall.Syscall6(sysClonefileat, uintptr(fd), uintptr(unsafe.Pointer(p1)),
+		uintptr(fd), uintptr(unsafe.Pointer(p2)), cloneNofollow, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
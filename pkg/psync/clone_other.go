@@ -0,0 +1,15 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !darwin
+// +build !darwin
+
+package psync
+
+import "syscall"
+
+// Function cloneFile clones a file. Cloning is only supported on Darwin.
+func cloneFile(from, to string) error {
+	return syscall.ENOTSUP
+}
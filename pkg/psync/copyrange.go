@@ -0,0 +1,48 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// rangeChunk is the number of bytes copied by one copy_file_range call, so
+// that the progress of large files is counted, and the watchdog sees it.
+const rangeChunk = 8 << 20
+
+// Method kernelCopy copies the content of a local source file to a local
+// destination file in the kernel. The Go runtime copies between two *os.File
+// with copy_file_range(2) on Linux, so that the data is not read into psync,
+// and falls back to reading and writing it where this fails, e.g. between
+// file systems on kernels before 5.3. It returns false if the files are not
+// both local files, or the Go runtime is older than 1.15 and has no ReadFrom
+// for *os.File; then the caller copies the content itself.
+func (e *engine) kernelCopy(c *fileCopy, wr, rd File) (int64, bool, error) {
+	src, ok := rd.(*os.File)
+	if !ok {
+		return 0, false, nil
+	}
+	dst, ok := wr.(*os.File)
+	if !ok {
+		return 0, false, nil
+	}
+	rf, ok := interface{}(dst).(io.ReaderFrom)
+	if !ok {
+		return 0, false, nil
+	}
+
+	var total int64
+	for {
+		n, err := rf.ReadFrom(&io.LimitedReader{R: src, N: rangeChunk})
+		total += n
+		atomic.AddUint64(&e.copied, uint64(n))
+		atomic.AddUint64(&c.done, uint64(n))
+		if err != nil || n == 0 {
+			return total, true, err
+		}
+	}
+}
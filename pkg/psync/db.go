@@ -0,0 +1,164 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Type dbEntry is the record of a synced entry in the state database. It
+// identifies the version of the source entry.
+type dbEntry struct {
+	Size  int64
+	Mtime int64 // modification time in nanoseconds
+	Ctime int64 // change time in nanoseconds, 0 if unknown
+	Ino   uint64
+	Mode  os.FileMode
+}
+
+// Type dbHeader is written at the beginning of the state database, to tie it
+// to the trees it was written for.
+type dbHeader struct {
+	Source      string
+	Destination string
+	Entries     int
+}
+
+// Function newDBEntry returns the record of a source entry.
+func newDBEntry(f os.FileInfo) dbEntry {
+	e := dbEntry{Size: f.Size(), Mtime: f.ModTime().UnixNano(), Mode: f.Mode()}
+	if stat, ok := f.Sys().(*sysstat.Stat); ok {
+		_, _, e.Ctime = sysstat.Times(stat)
+		e.Ino = stat.Ino
+	}
+	if f.IsDir() {
+		// the entries of a directory are checked on their own
+		e.Size, e.Mtime, e.Ctime = 0, 0, 0
+	}
+	return e
+}
+
+// Method readDB reads the state database of the last run. Without a
+// database, e.g. on the first run, all entries are checked as usual.
+func (e *engine) readDB() error {
+	e.dbOld, e.dbNew = make(map[string]dbEntry), make(map[string]dbEntry)
+	fd, err := os.Open(e.stateDB)
+	if os.IsNotExist(err) {
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "No state database %s, checking all entries\n", e.stateDB)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read state database %s: %s", e.stateDB, err)
+	}
+	defer fd.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(fd))
+	var h dbHeader
+	dec := gob.NewDecoder(zr)
+	if err == nil {
+		err = dec.Decode(&h)
+	}
+	for i := 0; err == nil && i < h.Entries; i++ {
+		var name string
+		var ent dbEntry
+		if err = dec.Decode(&name); err == nil {
+			err = dec.Decode(&ent)
+		}
+		e.dbOld[name] = ent
+	}
+	if err != nil {
+		return fmt.Errorf("could not read state database %s: %s", e.stateDB, err)
+	}
+	if h.Source != e.src || h.Destination != e.dest {
+		return fmt.Errorf("state database %s was written for copying %s to %s", e.stateDB, h.Source, h.Destination)
+	}
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Read %d entries from the state database %s\n", len(e.dbOld), e.stateDB)
+	}
+	return nil
+}
+
+// Method synced checks whether a source entry is unchanged since it was
+// synced by the last run. The entry is recorded for the next run then. With
+// -ignore-times, all entries are handled again.
+func (e *engine) synced(file string, f os.FileInfo) bool {
+	if e.stateDB == "" || e.ignoreTimes {
+		return false
+	}
+	ent, ok := e.dbOld[file]
+	if !ok || ent != newDBEntry(f) {
+		return false
+	}
+	e.record(file, f)
+	return true
+}
+
+// Method record remembers a synced entry for the next run.
+func (e *engine) record(file string, f os.FileInfo) {
+	if e.stateDB == "" {
+		return
+	}
+	ent := newDBEntry(f)
+	e.dbMu.Lock()
+	e.dbNew[file] = ent
+	e.dbMu.Unlock()
+}
+
+// Method writeDB writes the state database for the next run. If the run
+// did not visit the whole tree, e.g. when it was interrupted, the entries of
+// the last run which were not visited are kept. The database is written under
+// a temporary name and renamed.
+func (e *engine) writeDB() {
+	e.dbMu.Lock()
+	defer e.dbMu.Unlock()
+	if e.dbDirty {
+		for name, ent := range e.dbOld {
+			if _, ok := e.dbNew[name]; !ok {
+				e.dbNew[name] = ent
+			}
+		}
+	}
+
+	tmp := e.stateDB + ".tmp"
+	fd, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0644))
+	if err == nil {
+		bw := bufio.NewWriter(fd)
+		zw := gzip.NewWriter(bw)
+		enc := gob.NewEncoder(zw)
+		err = enc.Encode(dbHeader{e.src, e.dest, len(e.dbNew)})
+		for name, ent := range e.dbNew {
+			if err != nil {
+				break
+			}
+			if err = enc.Encode(name); err == nil {
+				err = enc.Encode(ent)
+			}
+		}
+		if err == nil {
+			err = zw.Close()
+		}
+		if err == nil {
+			err = bw.Flush()
+		}
+		if cerr := fd.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, e.stateDB)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		fmt.Fprintf(e.stderr, "ERROR - could not write state database %s: %s\n", e.stateDB, err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Type dedupKey identifies the files which can share an inode on the
+// destination side: same content, permissions, and owner or modification time
+// if they are preserved.
+type dedupKey struct {
+	sum      string
+	size     int64
+	perm     os.FileMode
+	uid, gid int
+	mtime    int64
+}
+
+// Method newDedupKey returns the key of a source file with the given
+// checksum.
+func (e *engine) newDedupKey(f os.FileInfo, sum []byte) dedupKey {
+	k := dedupKey{sum: string(sum), size: f.Size(), perm: e.destPerm(f)}
+	if e.owner {
+		k.uid, k.gid, _ = e.destOwner(f)
+	}
+	if e.times {
+		k.mtime = f.ModTime().Unix()
+	}
+	return k
+}
+
+// Method linkDuplicate hard-links a regular file to an identical file copied
+// before, and returns true. The checksum of a file is only computed if a file
+// of the same size was seen before, otherwise it is computed while copying.
+func (e *engine) linkDuplicate(id uint, file string, f os.FileInfo) bool {
+	e.fileSums[id] = nil
+	e.dedupMu.Lock()
+	seen := e.dedupSizes[f.Size()]
+	e.dedupSizes[f.Size()] = true
+	e.dedupMu.Unlock()
+	if !seen {
+		return false
+	}
+
+	begin := time.Now()
+	e.op()
+	rd, err := e.source.Open(file)
+	if err != nil {
+		return false
+	}
+	buf := getBuffer()
+	sum, err := e.hashFile(rd, *buf)
+	putBuffer(buf)
+	rd.Close()
+	if err != nil {
+		return false
+	}
+	e.fileSums[id] = sum
+	e.dedupMu.Lock()
+	first, ok := e.dedupFiles[e.newDedupKey(f, sum)]
+	e.dedupMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	root := e.destination.(localFS)
+	e.op()
+	err = root.Link(string(root)+first, file)
+	if os.IsExist(err) {
+		e.op()
+		d, derr := e.destination.Lstat(file)
+		o, oerr := e.destination.Lstat(first)
+		if derr == nil && oerr == nil && os.SameFile(d, o) {
+			// linked by an earlier run
+			err = nil
+		} else if err = e.destination.Remove(file); err == nil {
+			e.op()
+			err = root.Link(string(root)+first, file)
+		}
+	}
+	if err != nil {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Could not link %s to %s: %s\n", id, e.dest+file, e.dest+first, err)
+		}
+		return false
+	}
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "[%d] Linking %s to the identical %s\n", id, e.dest+file, e.dest+first)
+	}
+	e.countFile(id, file, 0)
+	e.record(file, f)
+	e.emit("link", file, 0, begin, nil)
+	return true
+}
+
+// Method rememberCopy records a copied file, with the checksum computed
+// before or while copying, for linking the later identical files to it.
+func (e *engine) rememberCopy(id uint, file string, f os.FileInfo) {
+	if e.fileSums[id] == nil {
+		return
+	}
+	k := e.newDedupKey(f, e.fileSums[id])
+	e.fileSums[id] = nil
+	e.dedupMu.Lock()
+	if _, ok := e.dedupFiles[k]; !ok {
+		e.dedupFiles[k] = file
+	}
+	e.dedupMu.Unlock()
+}
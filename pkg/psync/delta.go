@@ -0,0 +1,136 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/hweidner/psync/pkg/delta"
+)
+
+// Type deltaFS is implemented by backends which compute the signature of a
+// file on the remote side, for the delta transfer of changed files.
+type deltaFS interface {
+	Signature(name string, blockSize int) (*delta.Signature, error)
+}
+
+// Type deltaFile is implemented by the files of a deltaFS. Method CopyFrom
+// writes a range of another file on the remote side, without transferring
+// the data.
+type deltaFile interface {
+	CopyFrom(basis string, off, n int64) error
+}
+
+// Method deltaBasis returns the signature of the existing destination
+// file, if the file is transferred with the delta algorithm. It returns nil
+// if the file is copied as a whole: without the option -delta, if the
+// destination does not support it, or if there is no regular destination
+// file to build on.
+func (e *engine) deltaBasis(target string) *delta.Signature {
+	dfs, ok := e.destination.(deltaFS)
+	if !e.deltaMode || !ok {
+		return nil
+	}
+	e.op()
+	d, err := e.destination.Lstat(target)
+	if err != nil || !d.Mode().IsRegular() || d.Size() == 0 {
+		return nil
+	}
+	e.op()
+	sig, err := dfs.Signature(target, delta.BlockSize(d.Size()))
+	if err != nil {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "No signature of %s, copying the whole file: %s\n", e.dest+target, err)
+		}
+		return nil
+	}
+	return sig
+}
+
+// Method copyDelta transfers a changed file with the delta algorithm. The
+// source file is compared against the signature of the destination file, and
+// a new destination file is built from the unchanged blocks of the old one,
+// and the data which changed. The new file is written to a temporary file,
+// and renamed over the old one. It returns the size of the file, and an
+// *Error if the transfer failed.
+func (e *engine) copyDelta(id uint, c *fileCopy, file, target string, f os.FileInfo, sig *delta.Signature) (int64, error) {
+	// open source file for reading
+	e.op()
+	rd, err := e.source.Open(file)
+	if err == nil {
+		err = c.track(rd, "")
+	}
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("file %s disappeared while copying: %s", e.src+file, err), err}
+	}
+	defer c.close(rd)
+
+	// open a temporary file, next to the destination file by default
+	tmp := e.tempName(target)
+	e.op()
+	wr, err := e.destination.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, e.createMode(f))
+	if err == nil {
+		err = c.track(wr, tmp)
+	}
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+tmp, err), err}
+	}
+	df, ok := wr.(deltaFile)
+	if !ok {
+		c.close(wr)
+		e.destination.Remove(tmp)
+		err = fmt.Errorf("delta transfer is not supported by %s", e.dest)
+		return 0, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, err), err}
+	}
+
+	// send the changed data, and copy the unchanged blocks
+	var n, sent int64
+	var w io.Writer = progressWriter{ioutil.Discard, &c.done, &e.copied}
+	var h hash.Hash
+	if e.verifyAfter {
+		h = e.checksumNew()
+		w = io.MultiWriter(w, h)
+	}
+	err = delta.Compute(sig, io.TeeReader(rd, w), func(o delta.Op) error {
+		if o.Count == 0 {
+			m, err := wr.Write(o.Data)
+			n += int64(m)
+			sent += int64(m)
+			return err
+		}
+		off := int64(o.Block) * int64(sig.BlockSize)
+		m := int64(o.Count) * int64(sig.BlockSize)
+		if off+m > sig.Size {
+			m = sig.Size - off
+		}
+		n += m
+		return df.CopyFrom(target, off, m)
+	})
+	if cerr := c.close(wr); err == nil {
+		err = cerr
+	}
+	if err == nil && c.abandoned() {
+		err = errAbandoned
+	}
+	if err == nil {
+		e.op()
+		err = e.destination.Rename(tmp, target)
+	}
+	if err != nil {
+		e.destination.Remove(tmp)
+		return n, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, err), err}
+	}
+	if e.verbose >= 2 {
+		fmt.Fprintf(e.stdout, "Delta transfer of %s: %d of %d bytes sent\n", e.src+file, sent, n)
+	}
+	if e.verifyAfter {
+		return n, e.verifyCopy(c.buf, file, target, h.Sum(nil))
+	}
+	return n, nil
+}
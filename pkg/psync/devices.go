@@ -0,0 +1,14 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import "os"
+
+// Method copiedDevice tells whether an entry of the given mode is a block
+// device whose content is copied. Character devices are never copied, since
+// many of them, like /dev/zero, never end.
+func (e *engine) copiedDevice(mode os.FileMode) bool {
+	return e.copyDevices && mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0
+}
@@ -0,0 +1,111 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"strings"
+)
+
+// Type openDir is a directory of the destination whose metadata is not yet
+// applied, because its entries, or the entries of its subdirectories, are not
+// done yet. Creating entries in a directory changes its modification time,
+// and a read-only directory cannot get new entries, so the metadata of a
+// directory is applied only after its whole subtree is complete.
+type openDir struct {
+	pending int         // subdirectories not complete yet, plus one for the directory itself
+	sub     bool        // the directory was submitted by its parent, which waits for it
+	done    bool        // the entries of the directory itself are done
+	info    os.FileInfo // file information of the source directory, may be nil
+}
+
+// Method openSubdir registers a subdirectory submitted to the work list. Its
+// parent directory is completed only after it.
+func (e *engine) openSubdir(dir string) {
+	e.openMu.Lock()
+	defer e.openMu.Unlock()
+	e.openNode(parentDir(dir)).pending++
+	e.openNode(dir).sub = true
+}
+
+// Method openNode returns the open directory of a path, and creates it if
+// needed. The caller must hold openMu.
+func (e *engine) openNode(dir string) *openDir {
+	n := e.openDirs[dir]
+	if n == nil {
+		n = &openDir{pending: 1}
+		e.openDirs[dir] = n
+	}
+	return n
+}
+
+// Function parentDir returns the parent of a directory path.
+func parentDir(dir string) string {
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		return dir[:i]
+	}
+	return ""
+}
+
+// Method completeDir marks the entries of a directory as done. If apply is
+// set, the metadata of the source directory info is applied once its
+// subdirectories are complete, too; it is not set for directories which
+// could not be read. A complete directory completes its parent in turn, when
+// that one waits for it only.
+func (e *engine) completeDir(id uint, dir string, info os.FileInfo, apply bool) {
+	e.openMu.Lock()
+	n := e.openNode(dir)
+	n.done, n.info = apply, info
+	for {
+		n.pending--
+		if n.pending > 0 {
+			break
+		}
+		delete(e.openDirs, dir)
+		e.openMu.Unlock()
+		if n.done {
+			e.dirMeta(id, dir, n.info)
+		}
+		if !n.sub {
+			return
+		}
+		dir = parentDir(dir)
+		e.openMu.Lock()
+		if n = e.openDirs[dir]; n == nil {
+			break
+		}
+	}
+	e.openMu.Unlock()
+}
+
+// Method flushDirs applies the metadata of the directories whose entries
+// are done, but which still wait for subdirectories postponed by an
+// interruption, at the end of a phase. The run resumed later applies it
+// again.
+func (e *engine) flushDirs() {
+	for dir, n := range e.openDirs {
+		if n.done {
+			e.dirMeta(0, dir, n.info)
+		}
+	}
+	e.openDirs = make(map[string]*openDir)
+}
+
+// Method retouchRoot sets the timestamps of the destination directory again
+// at the end of a run, after the control files in it, like the checkpoint or
+// the partial directory, were removed or written.
+func (e *engine) retouchRoot() {
+	if e.shardNew != nil || e.srcName != "" || len(e.sources) > 1 {
+		return
+	}
+	e.op()
+	f, err := e.source.Stat("")
+	if err == nil && e.fakeSource {
+		f = e.fakeEntry("", f)
+	}
+	if err == nil {
+		e.preserveTimes("", "", f, "directory")
+	}
+}
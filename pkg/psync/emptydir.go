@@ -0,0 +1,123 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Function readUmask returns the umask of the process, or -1 if the system
+// does not tell it, like Linux before 4.7 and other systems.
+func readUmask() int {
+	b, err := ioutil.ReadFile("/proc/self/status")
+	if err != nil {
+		return -1
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(line, "Umask:") {
+			m, err := strconv.ParseUint(strings.TrimSpace(line[6:]), 8, 32)
+			if err == nil {
+				return int(m)
+			}
+		}
+	}
+	return -1
+}
+
+// Method peekDir reads a subdirectory which has no subdirectories itself,
+// as told by its link count, before it is created and submitted. Empty
+// directories are completed at once by emptyDir, the entries of the others
+// are handed over with their job, so that they are not read twice. It
+// returns nil if the directory was not read, e.g. while scanners read ahead,
+// or on file systems which do not count the subdirectories.
+func (e *engine) peekDir(dir string, f os.FileInfo) *listing {
+	st, ok := f.Sys().(*sysstat.Stat)
+	if !ok || st.Nlink != 2 || e.pch != nil || e.stopping() {
+		return nil
+	}
+	l := &listing{dir: dir, state: listingScanned, done: make(chan struct{})}
+	close(l.done)
+	if files, ok := e.scanned(dir); ok {
+		l.files = files
+		return l
+	}
+
+	// follow the changes of the directory before it is read, see handleDir
+	if e.watch != nil {
+		e.watch.add(dir)
+	}
+	l.err = e.retry(dir, func() (err error) {
+		e.op()
+		l.files, err = e.source.ReadDir(dir)
+		return
+	})
+	if e.keepAtime {
+		e.restoreAtime(dir, f)
+	}
+	return l
+}
+
+// Method emptyDir creates an empty directory, and applies the metadata of
+// the source directory at once. Unlike other directories, it is neither
+// submitted to the work queue nor kept as an open directory, since it has no
+// entries to wait for. On a local destination, it is created with its final
+// permissions if the umask keeps them, which saves changing them afterwards.
+func (e *engine) emptyDir(id uint, dir string, f os.FileInfo) {
+	perm, final := e.createMode(f), false
+	if _, local := e.destination.(localFS); local && e.perms && e.umask >= 0 {
+		if p := e.destPerm(f); p&^os.ModePerm == 0 && int(p)&e.umask == 0 {
+			perm, final = p, true
+		}
+	}
+	begin := time.Now()
+	err := e.retry(dir, func() error {
+		e.op()
+		return e.destination.Mkdir(dir, perm)
+	})
+	if err != nil && e.mergeDirs() && e.existingDir(dir) {
+		// an existing directory keeps its permissions until they are applied
+		final = false
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Directory %s%s exists\n", id, e.dest, dir)
+		}
+	} else if err != nil {
+		e.warning(dir, "could not create directory %s: %s", e.dest+dir, err)
+		return
+	} else {
+		e.emit("mkdir", dir, 0, begin, nil)
+		e.audit("create", e.destination, e.dest, dir, nil)
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "[%d] Created directory %s%s\n", id, e.dest, dir)
+		}
+	}
+	e.record(dir, f)
+	e.countDir(id, dir)
+
+	// apply the metadata like dirMeta, without the permissions given already
+	if !e.mergedDir(dir) {
+		if e.owner {
+			e.preserveOwner(dir, dir, f, "directory")
+		}
+		if e.perms && !final {
+			e.preservePerms(dir, dir, f, "directory")
+		}
+		if e.times {
+			e.preserveTimes(dir, dir, f, "directory")
+		}
+	}
+	if e.fsyncDirs {
+		e.syncDir(dir)
+	}
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Completed empty directory %s%s\n", id, e.src, dir)
+	}
+}
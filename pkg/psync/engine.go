@@ -0,0 +1,384 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hweidner/psync/pkg/workpool"
+)
+
+// Type engine holds the state of a run: the options, the open trees, the
+// work list and the counters. Each run of a Syncer gets a new engine, so
+// that several runs can be in progress in a process at the same time.
+type engine struct {
+	// Options of the current run, see type Options
+	threads       uint          // number of threads
+	src, dest     string        // source and destination directory
+	verbose       int           // verbosity level (0..3)
+	quiet         bool          // quiet flag
+	times, owner  bool          // preserve timestamps and owner flag
+	create        bool          // create destination directory flag
+	events        string        // path of the event stream
+	eventsFdNum   uint          // file descriptor of the event stream
+	progress      bool          // print progress periodically
+	stall         time.Duration // report stalls after this time without progress
+	shardTemplate string        // template for sharded destination names
+	shardHash     string        // hash function for sharding
+	shardManifest string        // path of the shard manifest
+	filterFrom    []string      // files with rsync filter rules
+	excludeFrom   []string      // files with rsync exclude patterns
+	existingLinks string        // handling of existing links on the destination
+	syncMode      bool          // copy only new and changed entries
+	iops          uint          // maximum number of metadata operations per second
+	checkpoint    string        // path of the checkpoint file
+	resume        bool          // resume from the checkpoint file
+	keepAtime     bool          // restore the access times of source directories
+	retries       uint          // number of retries on transient errors
+	retryDelay    time.Duration // initial delay between retries
+	maxErrors     uint64        // abort after this number of errors
+	nested        string        // destination directory relative to the source, if nested
+	force         bool          // copy a source which lies inside the destination
+	fileProgress  uint          // report the progress of files larger than this (in MB)
+	errorsTo      string        // report file of failed entries
+	filesFrom     string        // file with the list of paths to copy
+	from0         bool          // the paths of the file list are separated by NUL characters
+	preallocate   bool          // allocate the blocks of destination files before copying
+	copyRange     bool          // copy the content of local files in the kernel
+	breadthFirst  bool          // traverse the tree breadth-first instead of depth-first
+	maxQueue      int64         // descend into subdirectories while this many wait, 0 for no limit
+	lock          bool          // take a lease on the destination directory
+	lockTimeout   time.Duration // lease expires after this time without heartbeat
+	chunkSize     uint          // chunk size of the journal for resuming large files (in MB)
+	strict        bool          // abort on the first error
+	nfsConns      uint          // number of connections to an NFS source
+	sshCommand    []string      // ssh command for remote trees
+	sftpConns     uint          // number of SFTP sessions to a remote tree
+	s3Endpoint    string        // URL of an S3 compatible service
+	secretFile    string        // file with the shared secret for psync daemons
+	deltaMode     bool          // transfer only the changed blocks of changed files
+	compress      bool          // compress the traffic to remote trees and daemons
+	fileTimeout   time.Duration // abandon file copies without progress for this time
+	sinceLast     bool          // skip files older than the last successful run
+	stateFile     string        // path of the state file
+	verifyAfter   bool          // compare copied files with the source by their hash
+
+	// Output of the current run
+	stdout io.Writer
+	stderr io.Writer
+
+	// Work queue and Synchronization
+	pool     *workpool.Pool // copy threads with the work list of the current phase
+	wg       sync.WaitGroup // waitgroup for work queue length
+	finished chan struct{}  // closed at the end of a run, stops the background goroutines
+
+	// atimeOnce ensures that a failure to restore access times is reported once.
+	atimeOnce *sync.Once
+
+	// Audit log
+	auditLog string     // path of the audit log, "" if none is written
+	auditMu  sync.Mutex // protects the audit log
+	auditFd  *os.File   // audit log, nil if none is written
+
+	// Page cache handling of -drop-cache. Copying a large tree reads and writes
+	// every file once, and would otherwise push the working set of the other
+	// workloads on the host out of the page cache.
+	dropSource bool // drop the source files from the page cache after copying them
+	dropDest   bool // write back the destination files, and drop them from the page cache
+
+	// Case-insensitive destinations of the current run
+	caseFold       bool   // the destination does not distinguish names by case
+	caseCollisions string // handling of source names differing only in case: skip or rename
+
+	// Interruption handling
+	stop      chan struct{}   // closed when psync is interrupted
+	stopOnce  *sync.Once      // ensures that stop is closed once
+	pending   []string        // directories not handled due to the interruption
+	partials  map[string]bool // directories which were partially handled
+	pendingMu sync.Mutex      // protects pending and partials
+
+	// Checksums of the current run
+	checksum    string           // name of the hash function for checksums
+	checksumNew func() hash.Hash // hash function for checksums
+
+	// chmodRules holds the rules of the -chmod option.
+	chmodRules []chmodRule
+
+	// cloneOff is set when the trees do not support cloning, e.g. when they are
+	// on different volumes. It is accessed atomically.
+	cloneOff int32
+
+	// State database of the current run
+	stateDB string             // path of the state database, "" if not used
+	dbOld   map[string]dbEntry // entries synced by the last run
+	dbNew   map[string]dbEntry // entries synced by the current run
+	dbMu    sync.Mutex         // protects dbNew
+	dbDirty bool               // the run does not visit the whole tree
+
+	// Deduplication of the current run
+	dedupMode  bool                // hard-link identical files on the destination
+	dedupMu    sync.Mutex          // protects dedupSizes and dedupFiles
+	dedupSizes map[int64]bool      // sizes of the files copied so far
+	dedupFiles map[dedupKey]string // first copy of each content
+	fileSums   [][]byte            // checksum of the file copied by each thread, nil if unknown
+
+	// copyDevices selects whether the content of block devices is copied
+	// to regular destination files, like with rsync --copy-devices.
+	copyDevices bool
+
+	// Directories waiting for their subdirectories, by path. Only the paths are
+	// kept, so that spilled directories of the work list take little memory.
+	openMu   sync.Mutex
+	openDirs map[string]*openDir
+
+	// umask is the umask of the process, read from /proc at the start of a run,
+	// or -1 if it is not known. The umask cannot be read without changing it
+	// elsewhere, which would race with other goroutines creating files.
+	umask int
+
+	// Estimate of the run. With -estimate, the source trees are scanned before
+	// copying, so that the progress output can show the percentage done and the
+	// time left. The listings of the scan are kept, and taken by the copy
+	// threads instead of reading the directories again.
+	estimate   bool                       // scan the source trees before copying
+	estFiles   uint64                     // number of entries other than directories found by the scan (atomic)
+	estBytes   uint64                     // size of the regular files found by the scan (atomic)
+	doneFiles  uint64                     // entries other than directories handled so far (atomic)
+	doneBytes  uint64                     // size of the regular files handled so far (atomic)
+	listings   []map[string][]os.FileInfo // listings of the scan by directory, for each source
+	listingsMu sync.Mutex                 // guards listings
+
+	// Event stream
+	eventsMu  sync.Mutex    // protects the event writer
+	eventsBuf *bufio.Writer // buffered event writer, nil if no event stream is requested
+	eventsFd  *os.File      // file the event stream is written to
+	eventsEnc *json.Encoder // JSON encoder writing to eventsBuf
+
+	// Fake-super mode of the current run
+	fakeSuper  bool // store the metadata of destination entries in fakeAttr
+	fakeSource bool // take the metadata of source entries from fakeAttr
+
+	// Filter rules
+	filters  []*rule            // global filter rules
+	dirMerge bool               // filters contain per-directory merge rules
+	dirRules map[string][]*rule // cached filter rules per directory
+	dirMu    sync.Mutex         // protects dirRules
+
+	// Source and destination trees, the local file system by default
+	source      FS
+	destination FS
+
+	// Durability of the destination with -fsync and -fsync-dir. By default, the
+	// copied data is left in the page cache, and written back by the kernel.
+	fsyncFiles bool // sync each destination file after its content is copied
+	fsyncDirs  bool // sync each destination directory after its entries are created
+
+	// Commands run before and after the run, and for each entry
+	preCmd  string // command run before the copy, "" if none
+	postCmd string // command run after the copy, "" if none
+	fileCmd string // command run for each completed entry, "" if none
+
+	// User and group mapping of -usermap and -groupmap
+	userMap, groupMap idMap
+
+	// State of the interactive mode, in which differing destination files are
+	// only overwritten after asking the user
+	interactive bool          // ask before overwriting differing files
+	askMu       sync.Mutex    // serializes the questions of the copy threads
+	askAll      byte          // 'a' to overwrite all further files, 's' to skip them, 0 to ask
+	askTTY      *os.File      // terminal the questions are asked on
+	askIn       *bufio.Reader // buffered answers
+	asking      int32         // a question is waiting for an answer (atomic)
+
+	// interval is the time between the starts of repeated syncs, 0 for a single
+	// sync.
+	interval time.Duration
+
+	// opLimit limits the rate of metadata operations (open, create, stat, ...).
+	// It is nil if the rate is not limited.
+	opLimit *limiter
+
+	// Reference directories of the current run
+	linkDest []string  // reference directories given with -link-dest
+	linkRefs []localFS // existing reference directories
+
+	// Report of failed entries
+	errorsMu  sync.Mutex    // protects the report writer
+	errorsFd  *os.File      // report file
+	errorsBuf *bufio.Writer // buffered report writer, nil if no report is requested
+
+	// Session and lease handling
+	session  string     // random token identifying this run
+	tmpCount uint64     // counter for unique temporary names
+	tempDir  string     // directory in the destination for the temporary files, "" to write them next to their final files
+	lockDir  string     // lock directory in the destination, "" if no lease is taken
+	lockMu   sync.Mutex // protects lockDir against the heartbeat
+
+	// visited holds the directories entered in the current phase.
+	visited *dirSet
+
+	// Source trees of a run with several sources. The sources are copied one
+	// after the other into the destination, each one with all copy threads. The
+	// fields src and source always refer to the source of the current phase.
+	srcs      []string // all source trees, in the order of the command line
+	srcNames  []string // names of the source directories copied with -rsync-slash, or ""
+	srcName   string   // name of the source directory of the current phase, or ""
+	sources   []FS     // backends of the source trees
+	phase     int      // index of the source copied in the current phase
+	conflicts string   // handling of entries in several sources: first, last or error
+
+	// normalize selects the matching of names between the trees: "" for exact
+	// names, "match" for names which are equal after Unicode normalization, "nfc"
+	// and "nfd" to write new names in this form, too.
+	normalize string
+
+	// Notification at the end of a run
+	notifyURL string // URL the summary of the run is posted to, "" if none
+	notifyCmd string // command the summary of the run is passed to, "" if none
+
+	// order selects the order in which the files of a directory are
+	// copied: "small" for the smallest files first, "large" for the largest files
+	// first, or "" for the order of the directory.
+	order string
+
+	// deterministic selects a single copy thread, which descends into
+	// each subdirectory when it finds it, and handles the entries of each
+	// directory sorted by name, so that two runs over the same tree do the same
+	// operations in the same order.
+	deterministic bool
+
+	// Owner of the destination entries
+	sourceOwner        bool // take the owner of the source entries (-owner)
+	chownUID, chownGID int  // owner set by -chown, -1 if not given
+	numericIDs         bool // keep the IDs of remote sources, instead of matching the names
+
+	// Local IDs of the user and group names of remote sources, -1 if unknown
+	namesMu           sync.Mutex
+	userIDs, groupIDs map[string]int
+
+	// partialDir is the name of the directory in the destination root,
+	// where the files are written while they are copied, or "" if they are
+	// written in place.
+	partialDir string
+
+	// perms selects whether the permissions of the source are applied
+	// to the destination entries.
+	perms bool
+
+	// Pool of data threads. With -data-threads, the copy threads only read the
+	// directories and create the subdirectories, and hand the files over to a
+	// separate pool of data threads, so that the number of parallel metadata
+	// operations and the number of parallel data streams can be tuned
+	// independently. The data threads have the ids following the copy threads.
+	dataThreads uint           // number of data threads, 0 if the copy threads copy the files
+	fch         chan fileJob   // file channel - hand files over to the data threads
+	dataWg      sync.WaitGroup // waitgroup for the data threads
+
+	// Pool of scanners. With -prefetch, the directories discovered by the copy
+	// threads are read ahead by a small pool of scanners, while they wait in the
+	// work list, so that the copy thread which takes the directory finds its
+	// entries already listed, instead of waiting for the listing between copying
+	// files. This hides the latency of reading directories on remote file systems.
+	scanners  uint           // number of scanners, 0 if the copy threads read the directories
+	pch       chan *listing  // prefetch channel - hand directories over to the scanners
+	scannerWg sync.WaitGroup // waitgroup for the scanners
+
+	// Live progress of the copy threads
+	copied    uint64      // bytes written so far, updated while copying
+	busy      []string    // current path of each copy thread, "" if idle
+	busySince []time.Time // start time of the current operation of each copy thread
+	busyMu    sync.Mutex  // protects busy, busySince, fileSize and copies
+	fileSize  []int64     // size of the file each copy thread is copying, 0 if none
+	copies    []*fileCopy // copy of the current file of each copy thread, nil if none
+
+	// Sharding of destination names
+	shardNew   func() hash.Hash // hash function used for sharding, nil if sharding is off
+	manifestMu sync.Mutex       // protects the manifest writer
+	manifestFd *os.File         // manifest file
+	manifest   *bufio.Writer    // buffered manifest writer
+
+	// sparse selects whether blocks of zeros are left as holes in the
+	// destination files, instead of being written.
+	sparse bool
+
+	// spillAt is the length of the work list from which the work pool spills
+	// half of it to a temporary file, 0 to keep the work list in memory.
+	spillAt int
+
+	// lastRun is the start time of the last successful run, if files older than
+	// that are skipped.
+	lastRun time.Time
+
+	// Statistics for the whole run, per top level directory and per copy thread
+	total   counters
+	workers []workerStats
+	groups  map[string]*counters
+	groupMu sync.Mutex // protects the groups map
+	start   time.Time  // start time of the copy operation
+	end     time.Time  // end time of the copy operation, zero while it is running
+
+	// Statistics of the work list, kept by the work pool
+	queueLen   int64  // directories waiting in the work list, including the spilled ones
+	queuePeak  int64  // highest number of directories waiting
+	queueTotal uint64 // directories handed out to the copy threads
+
+	// Callback for the errors of the run, see Options.OnError
+	onError   func(*Error)
+	onErrorMu sync.Mutex // serializes the calls of onError
+
+	// Statistics file of -stats-out, and the errors counted by category for it
+	statsOut   string            // path of the statistics file, "" if none is written
+	categoryMu sync.Mutex        // guards categories
+	categories map[string]uint64 // number of errors by category, see categoryName
+
+	// Steal channel. A copy thread handling a huge directory offers its
+	// remaining entries on the steal channel, which idle copy threads take
+	// instead of waiting for the next directory. All threads then take the
+	// entries one by one from the same list, so that a flat directory with
+	// hundreds of thousands of entries is not copied by a single thread.
+	sch chan interface{}
+
+	// update selects whether destination files which are newer than
+	// their source files are kept, like with rsync --update.
+	update bool
+
+	// sizeOnly selects whether files of the same size count as up to
+	// date in sync mode, regardless of their modification times.
+	sizeOnly bool
+
+	// ignoreTimes selects whether all files are copied, even if they
+	// look up to date, to repair destination files which may be corrupted.
+	ignoreTimes bool
+
+	// transforms are the filters applied to the content of the files,
+	// in the order they see the data.
+	transforms []Transform
+
+	// Two-way mode of the current run
+	twoWay       bool   // propagate the changes of both trees
+	resolve      string // handling of conflicting changes: newer, rename or skip
+	snapshotFile string // snapshot of both trees after the last run
+
+	// Selection of the entry types
+	onlyTypes    string // letters of the entry types which are copied, like with find -type, "" for all
+	skipSymlinks bool   // skip symbolic links
+
+	// unstable is the handling of source files which changed while they
+	// were copied: skip or retry, or "" if the files are not checked.
+	unstable string
+
+	// verifyMode compares the trees instead of copying.
+	verifyMode bool
+
+	// Watch mode of the current run
+	watchMode bool     // follow the changes of the source after the copy
+	watch     *watcher // watcher of the source, nil if not watching
+}
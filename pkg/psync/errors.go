@@ -0,0 +1,200 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+)
+
+// Error categories. The errors reported by psync can be checked against them
+// with errors.Is().
+var (
+	ErrVanished        = errors.New("source entry vanished")
+	ErrPermission      = errors.New("permission denied")
+	ErrDestinationFull = errors.New("destination is full")
+	ErrConflict        = errors.New("conflicting destination entry")
+	ErrTimeout         = errors.New("operation timed out")
+	ErrMismatch        = errors.New("checksum mismatch")
+	ErrUnstable        = errors.New("source file changed while copying")
+	ErrIO              = errors.New("input/output error")
+	ErrUnsupported     = errors.New("unsupported entry type or operation")
+	ErrMetadata        = errors.New("metadata could not be changed")
+)
+
+// Type Error is an error which occurred while copying an entry. It carries the
+// path relative to the source directory, the message of the warning, and the
+// underlying error, which can be retrieved with errors.As() or errors.Unwrap().
+type Error struct {
+	Path string // path relative to the source directory
+	Msg  string // message describing the failed operation
+	Err  error  // underlying error, may be nil
+}
+
+// Method Error returns the message of the error.
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// Method Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Method Is reports whether the error belongs to the category target, one of
+// ErrVanished, ErrPermission, ErrDestinationFull, ErrConflict, ErrTimeout,
+// ErrMismatch, ErrUnstable, ErrIO, ErrUnsupported or ErrMetadata.
+func (e *Error) Is(target error) bool {
+	return category(e.Err) == target
+}
+
+// Type RunError is returned by Run when errors occurred on single entries of
+// the tree. It can be checked with errors.Is() against the categories of the
+// errors which occurred, and against ErrInterrupted, if the run was stopped
+// as well. The errors themselves are passed to the OnError function of the
+// options.
+type RunError struct {
+	Errors     uint64            // number of errors
+	Categories map[string]uint64 // number of errors by category, like in Stats
+	Err        error             // ErrInterrupted, if the run was stopped, or nil
+}
+
+// Method Error returns the number of errors by category, like "3 errors: 2
+// permission, 1 io".
+func (e *RunError) Error() string {
+	msg := fmt.Sprintf("%d errors: %s", e.Errors, summarize(e.Categories))
+	if e.Err != nil {
+		msg = e.Err.Error() + ", " + msg
+	}
+	return msg
+}
+
+// Method Unwrap returns ErrInterrupted, if the run was stopped, or nil.
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// Method Is reports whether errors of the category target occurred in the
+// run.
+func (e *RunError) Is(target error) bool {
+	c := category(target)
+	return c != nil && c == target && e.Categories[categoryName(c)] > 0
+}
+
+// Method runError returns the result of Run, given the error of the run:
+// a RunError, if errors occurred on single entries, which wraps
+// ErrInterrupted, if the run was stopped.
+func (e *engine) runError(err error) error {
+	n := atomic.LoadUint64(&e.total.errors)
+	if n == 0 || (err != nil && err != ErrInterrupted) {
+		return err
+	}
+	return &RunError{Errors: n, Categories: e.categoryCounts(), Err: err}
+}
+
+// Function category classifies an error into one of the error categories. It
+// returns nil if the error does not belong to any category.
+func category(err error) error {
+	if e, ok := err.(*Error); ok {
+		err = e.Err
+	}
+	switch err {
+	case nil, ErrVanished, ErrPermission, ErrDestinationFull, ErrConflict, ErrTimeout,
+		ErrMismatch, ErrUnstable, ErrIO, ErrUnsupported, ErrMetadata:
+		return err
+	}
+	if _, ok := err.(*metadataError); ok {
+		return ErrMetadata
+	}
+	switch {
+	case os.IsNotExist(err):
+		return ErrVanished
+	case os.IsPermission(err):
+		return ErrPermission
+	case os.IsExist(err):
+		return ErrConflict
+	}
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	} else if le, ok := err.(*os.LinkError); ok {
+		err = le.Err
+	} else if se, ok := err.(*os.SyscallError); ok {
+		err = se.Err
+	}
+	switch err {
+	case syscall.ENOSPC, syscall.EDQUOT:
+		return ErrDestinationFull
+	case syscall.ENOTEMPTY, syscall.EISDIR, syscall.ENOTDIR:
+		return ErrConflict
+	case syscall.ETIMEDOUT:
+		return ErrTimeout
+	case syscall.EIO:
+		return ErrIO
+	case syscall.ENOTSUP, syscall.ENOSYS:
+		return ErrUnsupported
+	}
+	return nil
+}
+
+// Type metadataError marks the error of a failed change of the owner, the
+// permissions, the timestamps or the fake-super attribute of an entry. Such
+// errors are counted as ErrMetadata, whatever their cause.
+type metadataError struct {
+	err error
+}
+
+// Method Error returns the message of the underlying error.
+func (e *metadataError) Error() string {
+	return e.err.Error()
+}
+
+// Method Unwrap returns the underlying error.
+func (e *metadataError) Unwrap() error {
+	return e.err
+}
+
+// Function newError creates an Error for a warning about the path. The
+// underlying error is taken from the arguments of the warning message.
+func newError(path, msg string, a []interface{}) *Error {
+	e := &Error{Path: path, Msg: msg}
+	for i := len(a) - 1; i >= 0; i-- {
+		if err, ok := a[i].(error); ok {
+			e.Err = err
+			break
+		}
+	}
+	return e
+}
+
+// Function categoryName returns a short name for the category of an error,
+// as used in the event stream.
+func categoryName(err error) string {
+	switch category(err) {
+	case ErrVanished:
+		return "vanished"
+	case ErrPermission:
+		return "permission"
+	case ErrDestinationFull:
+		return "full"
+	case ErrConflict:
+		return "conflict"
+	case ErrTimeout:
+		return "timeout"
+	case ErrMismatch:
+		return "mismatch"
+	case ErrUnstable:
+		return "unstable"
+	case ErrIO:
+		return "io"
+	case ErrUnsupported:
+		return "unsupported"
+	case ErrMetadata:
+		return "metadata"
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/hweidner/psync/pkg/workpool"
+)
+
+// Method estimateSources scans all source trees with a pool of as many
+// workers as copy threads, counting the entries and bytes to copy. Excluded
+// entries are left out. Directories which cannot be read are left to the
+// copy, which reports them.
+func (e *engine) estimateSources() error {
+	begin := time.Now()
+	for _, c := range []*uint64{&e.estFiles, &e.estBytes, &e.doneFiles, &e.doneBytes} {
+		atomic.StoreUint64(c, 0)
+	}
+	e.listings = make([]map[string][]os.FileInfo, len(e.sources))
+	for i := range e.sources {
+		if err := e.selectSource(i); err != nil {
+			return err
+		}
+		e.listings[i] = make(map[string][]os.FileInfo)
+		e.scanSource(e.listings[i])
+		if e.stopping() {
+			break
+		}
+	}
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Estimated %d files with %.1f MB to copy in %s\n",
+			atomic.LoadUint64(&e.estFiles), float64(atomic.LoadUint64(&e.estBytes))/1e6, time.Since(begin).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// Method scanSource scans the source tree of the current phase into the
+// given listings.
+func (e *engine) scanSource(lists map[string][]os.FileInfo) {
+	dirs := e.newDirSet(e.src)
+	e.op()
+	if fi, err := e.source.Stat(""); err == nil {
+		dirs.visit("", fi)
+	}
+
+	var pool *workpool.Pool
+	walk := func(id int, item interface{}) {
+		j := item.(job)
+		if e.stopping() {
+			return
+		}
+		var files []os.FileInfo
+		err := e.retry(j.dir, func() (err error) {
+			e.op()
+			files, err = e.source.ReadDir(j.dir)
+			return
+		})
+		if e.keepAtime && j.info != nil {
+			e.restoreAtime(j.dir, j.info)
+		}
+		if err != nil {
+			return
+		}
+		e.listingsMu.Lock()
+		lists[j.dir] = files
+		e.listingsMu.Unlock()
+
+		var rules []*rule
+		if len(files) > 0 {
+			rules = e.rulesFor(j.dir)
+		}
+		for _, f := range files {
+			name := j.dir + "/" + f.Name()
+			if (f.IsDir() && name == e.nested) || e.excluded(rules, name, f.IsDir()) || !e.selected(f.Mode()) {
+				continue
+			}
+			if !f.IsDir() {
+				atomic.AddUint64(&e.estFiles, 1)
+				if f.Mode().IsRegular() {
+					atomic.AddUint64(&e.estBytes, uint64(f.Size()))
+				}
+			} else if dirs.visit(name, f) {
+				pool.Submit(job{dir: name, info: f})
+			}
+		}
+	}
+	pool = workpool.New(int(e.threads), false, walk)
+	root := job{}
+	if e.keepAtime {
+		e.op()
+		root.info, _ = e.source.Stat("")
+	}
+	pool.Submit(root)
+	pool.Wait()
+}
+
+// Method scanned returns the listing of a directory of the current source
+// found by the scan, and forgets it. It returns false if the directory was
+// not scanned.
+func (e *engine) scanned(dir string) ([]os.FileInfo, bool) {
+	if e.listings == nil {
+		return nil, false
+	}
+	e.listingsMu.Lock()
+	defer e.listingsMu.Unlock()
+	files, ok := e.listings[e.phase][dir]
+	delete(e.listings[e.phase], dir)
+	return files, ok
+}
+
+// Method countHandled counts an entry other than a directory as handled,
+// for the percentage done.
+func (e *engine) countHandled(f os.FileInfo) {
+	if !e.estimate {
+		return
+	}
+	atomic.AddUint64(&e.doneFiles, 1)
+	if f.Mode().IsRegular() {
+		atomic.AddUint64(&e.doneBytes, uint64(f.Size()))
+	}
+}
+
+// Method estimated returns the percentage done and the time left, at the
+// given throughput in bytes per second, for the progress output. While the
+// scan runs, the totals found so far are shown.
+func (e *engine) estimated(rate float64) string {
+	files, bytes := atomic.LoadUint64(&e.doneFiles), atomic.LoadUint64(&e.doneBytes)
+	estFiles, estBytes := atomic.LoadUint64(&e.estFiles), atomic.LoadUint64(&e.estBytes)
+	var pct float64
+	switch {
+	case estBytes > 0:
+		pct = 100 * float64(bytes) / float64(estBytes)
+	case estFiles > 0:
+		pct = 100 * float64(files) / float64(estFiles)
+	default:
+		pct = 100
+	}
+	eta := "unknown"
+	if bytes >= estBytes {
+		eta = "0s"
+	} else if rate > 0 {
+		eta = time.Duration(float64(estBytes-bytes) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	return fmt.Sprintf("%.1f%% of %d files and %.1f MB, ETA %s", pct, estFiles, float64(estBytes)/1e6, eta)
+}
@@ -0,0 +1,107 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Type event describes a completed or failed entry in the event stream. The
+// events are written as newline delimited JSON (NDJSON), one event per line.
+type event struct {
+	Time     time.Time `json:"time"`               // time the entry was finished
+	Action   string    `json:"action"`             // mkdir, copy, link, symlink, relink, delete, differ or error
+	Path     string    `json:"path"`               // path relative to the source directory
+	Size     int64     `json:"size,omitempty"`     // number of bytes copied
+	Duration float64   `json:"duration"`           // duration of the operation in seconds
+	Error    string    `json:"error,omitempty"`    // error message of failed entries
+	Category string    `json:"category,omitempty"` // error category: vanished, permission, full, conflict, timeout, mismatch, unstable, io, unsupported or metadata
+}
+
+// Method openEvents opens the event stream. The path "-" stands for STDOUT.
+func (e *engine) openEvents(path string) error {
+	if path == "-" {
+		e.eventsBuf = bufio.NewWriter(e.stdout)
+	} else {
+		fd, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("cannot create event stream %s: %s", path, err)
+		}
+		e.eventsFd = fd
+		e.eventsBuf = bufio.NewWriter(e.eventsFd)
+	}
+	e.eventsEnc = json.NewEncoder(e.eventsBuf)
+	return nil
+}
+
+// Method openEventsFd opens the event stream on an already open file
+// descriptor, e.g. file descriptor 3 set up by a wrapper script with "3>file"
+// or a pipe. This keeps the events separate from the human readable output on
+// STDOUT and STDERR.
+func (e *engine) openEventsFd(fd uint) error {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if _, err := f.Stat(); err != nil {
+		return fmt.Errorf("file descriptor %d for the event stream is not open: %s", fd, err)
+	}
+	e.eventsFd = f
+	e.eventsBuf = bufio.NewWriter(e.eventsFd)
+	e.eventsEnc = json.NewEncoder(e.eventsBuf)
+	return nil
+}
+
+// Method closeEvents flushes and closes the event stream.
+func (e *engine) closeEvents() {
+	if e.eventsBuf == nil {
+		return
+	}
+	e.eventsMu.Lock()
+	defer e.eventsMu.Unlock()
+	if err := e.eventsBuf.Flush(); err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - cannot write event stream: %s\n", err)
+	}
+	if e.eventsFd != nil {
+		e.eventsFd.Close()
+	}
+}
+
+// Method emit writes an event for the (relative) path to the event stream,
+// if one is requested, and runs the command of -file-cmd for it. The error is
+// nil for completed entries.
+func (e *engine) emit(action, path string, size int64, begin time.Time, err error) {
+	if e.fileCmd != "" {
+		e.fileHook(action, path)
+	}
+	if e.eventsBuf == nil {
+		return
+	}
+	now := time.Now()
+	ev := event{
+		Time:   now,
+		Action: action,
+		Path:   strings.TrimPrefix(path, "/"),
+		Size:   size,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+		ev.Category = categoryName(err)
+	}
+	if ev.Path == "" {
+		ev.Path = "."
+	}
+	if !begin.IsZero() {
+		ev.Duration = now.Sub(begin).Seconds()
+	}
+
+	e.eventsMu.Lock()
+	defer e.eventsMu.Unlock()
+	if err = e.eventsEnc.Encode(&ev); err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - cannot write event stream: %s\n", err)
+	}
+}
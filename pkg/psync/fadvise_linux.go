@@ -0,0 +1,24 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package psync
+
+import "syscall"
+
+// fadvDontneed is the advice POSIX_FADV_DONTNEED of fadvise64(2).
+const fadvDontneed = 4
+
+// Function fadvise advises the kernel that the cached pages of a whole file
+// are not needed anymore.
+func fadvise(fd uintptr) error {
+	_, _, e := syscall.Syscall6(syscall.SYS_FADVISE64, fd, 0, 0, fadvDontneed, 0, 0)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
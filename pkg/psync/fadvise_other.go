@@ -0,0 +1,15 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !linux || (!amd64 && !arm64)
+// +build !linux !amd64,!arm64
+
+package psync
+
+// Function fadvise does nothing. Dropping files from the page cache is only
+// supported on Linux on amd64 and arm64, other platforms differ in the
+// arguments of fadvise64(2).
+func fadvise(fd uintptr) error {
+	return nil
+}
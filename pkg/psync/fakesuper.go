@@ -0,0 +1,177 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// fakeAttr is the extended attribute which holds the metadata of an entry in
+// fake-super mode, in the format of rsync --fake-super: the mode in octal,
+// the major and minor device number, and the owner, e.g. "20644 4,1 0:5".
+const fakeAttr = "user.rsync.%stat"
+
+// Type fakeInfo is the file information of a source entry with the metadata
+// of its fakeAttr.
+type fakeInfo struct {
+	os.FileInfo
+	mode         os.FileMode
+	major, minor uint64
+	uid, gid     int
+}
+
+// Method Mode returns the mode of the fakeAttr.
+func (f *fakeInfo) Mode() os.FileMode {
+	return f.mode
+}
+
+// Method fakeEntry returns the file information of a local source entry
+// with the metadata of its fakeAttr, or f if it has none.
+func (e *engine) fakeEntry(name string, f os.FileInfo) os.FileInfo {
+	l, ok := e.source.(localFS)
+	if !ok || f.Mode()&os.ModeSymlink != 0 {
+		return f
+	}
+	e.op()
+	value, err := getxattr(l.path(name), fakeAttr)
+	if err != nil {
+		return f
+	}
+	var mode uint32
+	fi := &fakeInfo{FileInfo: f}
+	_, err = fmt.Sscanf(string(value), "%o %d,%d %d:%d", &mode, &fi.major, &fi.minor, &fi.uid, &fi.gid)
+	if err != nil {
+		e.warning(name, "invalid attribute %s of %s: %q", fakeAttr, e.src+name, value)
+		return f
+	}
+	fi.mode = fileMode(mode)
+	if fi.mode.IsDir() != f.IsDir() {
+		// a directory cannot be faked by a file, and vice versa
+		return f
+	}
+	return fi
+}
+
+// Method storeFake stores the metadata of a source entry in the fakeAttr
+// of the destination entry. The name is relative to the destination.
+func (e *engine) storeFake(file, name string, f os.FileInfo, ftype string) {
+	uid, gid, _ := e.destOwner(f)
+	if uid < 0 {
+		uid = os.Getuid()
+	}
+	if gid < 0 {
+		gid = os.Getgid()
+	}
+	var major, minor uint64
+	if fi, ok := f.(*fakeInfo); ok {
+		major, minor = fi.major, fi.minor
+	} else if stat, ok := f.Sys().(*sysstat.Stat); ok && f.Mode()&(os.ModeDevice|os.ModeCharDevice) != 0 {
+		major, minor = devSplit(uint64(stat.Rdev))
+	}
+	mode := unixMode(f.Mode()&^os.ModePerm | e.destPerm(f))
+	value := fmt.Sprintf("%o %d,%d %d:%d", mode, major, minor, uid, gid)
+	if e.verbose >= 2 {
+		fmt.Fprintf(e.stdout, "Storing metadata of %s %s as %s\n", ftype, e.dest+name, value)
+	}
+	fs, target := e.destination, name
+	if n, ok := fs.(*normFS); ok {
+		fs, target = n.FS, n.resolve(name)
+	}
+	e.op()
+	if err := setxattr(fs.(localFS).path(target), fakeAttr, []byte(value)); err != nil {
+		e.metaWarning(file, "could not store metadata of %s %s: %s", ftype, e.dest+name, err)
+	}
+}
+
+// Method createFake creates a UNIX special file as an empty regular file,
+// and stores its type and device number in the fakeAttr.
+func (e *engine) createFake(file, target string, f os.FileInfo) bool {
+	e.op()
+	wr, err := e.destination.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0600))
+	if err == nil {
+		err = wr.Close()
+	}
+	if err != nil {
+		e.warning(file, "file %s could not be created: %s", e.dest+target, err)
+		return false
+	}
+	e.storeFake(file, target, f, "special file")
+	return true
+}
+
+// UNIX file type bits of the mode of stat(2)
+const (
+	sIFIFO  = 0010000
+	sIFCHR  = 0020000
+	sIFDIR  = 0040000
+	sIFBLK  = 0060000
+	sIFREG  = 0100000
+	sIFLNK  = 0120000
+	sIFSOCK = 0140000
+	sIFMT   = 0170000
+)
+
+// Function unixMode converts a file mode into the mode of stat(2).
+func unixMode(m os.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	switch {
+	case m&os.ModeDir != 0:
+		mode |= sIFDIR
+	case m&os.ModeSymlink != 0:
+		mode |= sIFLNK
+	case m&os.ModeNamedPipe != 0:
+		mode |= sIFIFO
+	case m&os.ModeSocket != 0:
+		mode |= sIFSOCK
+	case m&os.ModeCharDevice != 0:
+		mode |= sIFCHR
+	case m&os.ModeDevice != 0:
+		mode |= sIFBLK
+	default:
+		mode |= sIFREG
+	}
+	if m&os.ModeSetuid != 0 {
+		mode |= 04000
+	}
+	if m&os.ModeSetgid != 0 {
+		mode |= 02000
+	}
+	if m&os.ModeSticky != 0 {
+		mode |= 01000
+	}
+	return mode
+}
+
+// Function fileMode converts a mode of stat(2) into a file mode.
+func fileMode(mode uint32) os.FileMode {
+	m := os.FileMode(mode & 0777)
+	switch mode & sIFMT {
+	case sIFDIR:
+		m |= os.ModeDir
+	case sIFLNK:
+		m |= os.ModeSymlink
+	case sIFIFO:
+		m |= os.ModeNamedPipe
+	case sIFSOCK:
+		m |= os.ModeSocket
+	case sIFCHR:
+		m |= os.ModeDevice | os.ModeCharDevice
+	case sIFBLK:
+		m |= os.ModeDevice
+	}
+	if mode&04000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if mode&02000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if mode&01000 != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
@@ -0,0 +1,26 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import "syscall"
+
+// fallocKeepSize is the flag FALLOC_FL_KEEP_SIZE of fallocate(2), which
+// allocates the blocks without changing the file size. So a file which
+// shrinks during the copy does not keep the size of the source.
+const fallocKeepSize = 1
+
+// Function allocate allocates the blocks of a destination file from off to
+// size. File systems without support for fallocate(2) are skipped silently.
+func allocate(f interface{}, off, size int64) error {
+	fd, ok := f.(interface{ Fd() uintptr })
+	if !ok || size <= off {
+		return nil
+	}
+	err := syscall.Fallocate(int(fd.Fd()), fallocKeepSize, off, size-off)
+	if err == syscall.EOPNOTSUPP || err == syscall.ENOSYS {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,13 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !linux
+// +build !linux
+
+package psync
+
+// Function allocate does nothing. Preallocation is only supported on Linux.
+func allocate(f interface{}, off, size int64) error {
+	return nil
+}
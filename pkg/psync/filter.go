@@ -0,0 +1,371 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Type rule is a single filter rule in the syntax of rsync. Rules are checked
+// in order, and the first matching rule decides whether an entry is included
+// or excluded. A per-directory merge rule (dir-merge) is a placeholder for the
+// rules read from the merge files of the directories on the current path.
+type rule struct {
+	include bool           // include rule (+) instead of exclude rule (-)
+	negate  bool           // rule matches if the pattern does not match
+	dirOnly bool           // pattern matches directories only
+	anchor  bool           // pattern is anchored at the base directory
+	whole   bool           // pattern is matched against the whole path, not only the name
+	base    string         // directory the rule is relative to ("" for the source directory)
+	re      *regexp.Regexp // compiled pattern
+
+	merge     string  // name of the per-directory merge file
+	mergeMods string  // modifiers applied to the rules read from the merge file
+	noInherit bool    // rules of merge files are not inherited by subdirectories
+	sub       []*rule // rules from the merge files on the current path, deepest first
+}
+
+// Method initFilters reads the filter and exclude files given in the
+// options.
+func (e *engine) initFilters() error {
+	for _, name := range e.filterFrom {
+		rules, err := readRules(name, "", "")
+		if err != nil {
+			return fmt.Errorf("cannot read filter file %s: %s", name, err)
+		}
+		e.filters = appendRules(e.filters, rules)
+	}
+	for _, name := range e.excludeFrom {
+		rules, err := readRules(name, "", "-")
+		if err != nil {
+			return fmt.Errorf("cannot read exclude file %s: %s", name, err)
+		}
+		e.filters = appendRules(e.filters, rules)
+	}
+	for _, r := range e.filters {
+		if r.merge != "" {
+			e.dirMerge = true
+		}
+	}
+	return nil
+}
+
+// Function appendRules appends rules to a rule list. A nil rule stands for the
+// clear rule (!), which removes all previous rules.
+func appendRules(list, rules []*rule) []*rule {
+	for _, r := range rules {
+		if r == nil {
+			list = nil
+		} else {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// Function readRules reads a filter file. Relative patterns are relative to
+// the directory base. If mods is "-" or "+", each line is an exclude or
+// include pattern, unless it starts with an explicit "- " or "+ " prefix, as
+// in rsync's --exclude-from and --include-from files. The word modifier "w"
+// splits lines into several patterns at white space.
+func readRules(name, base, mods string) ([]*rule, error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return parseRules(fd, path.Dir(name), base, mods)
+}
+
+// Function parseRules parses filter rules from a reader. See readRules.
+func parseRules(r io.Reader, dir, base, mods string) ([]*rule, error) {
+	var rules []*rule
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		lines := []string{line}
+		if strings.Contains(mods, "w") {
+			lines = strings.Fields(line)
+		}
+		for _, l := range lines {
+			if strings.ContainsAny(mods, "+-") {
+				if !strings.HasPrefix(l, "+ ") && !strings.HasPrefix(l, "- ") && l != "!" {
+					l = mods[strings.IndexAny(mods, "+-"):][:1] + " " + l
+				}
+			}
+			rs, err := parseRule(l, dir, base)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rs...)
+		}
+	}
+	return rules, sc.Err()
+}
+
+// Function parseRule parses a single filter rule, like "- *.o", "+ /src/",
+// "exclude core", ". other-rules", ": .rsync-filter" or "!". A merge rule
+// returns the rules of the merged file.
+func parseRule(line, dir, base string) ([]*rule, error) {
+	// split rule and modifiers from the pattern
+	var cmd, pat string
+	if i := strings.IndexAny(line, " _"); i >= 0 {
+		cmd, pat = line[:i], line[i+1:]
+	} else {
+		cmd = line
+	}
+	mods := ""
+	long := false
+	for _, name := range []string{"exclude", "include", "dir-merge", "merge", "hide", "show", "protect", "risk", "clear"} {
+		if strings.HasPrefix(cmd, name) {
+			mods = strings.TrimPrefix(cmd[len(name):], ",")
+			cmd = name
+			long = true
+			break
+		}
+	}
+	if !long && len(cmd) > 1 {
+		cmd, mods = cmd[:1], strings.TrimPrefix(cmd[1:], ",")
+	}
+
+	r := &rule{base: base}
+	switch cmd {
+	case "!", "clear":
+		return []*rule{nil}, nil
+	case "-", "exclude", "H", "hide":
+		r.include = false
+	case "+", "include", "S", "show":
+		r.include = true
+	case "P", "protect", "R", "risk":
+		// receiver side rules for deletion, which psync does not do
+		return nil, nil
+	case ".", "merge":
+		name := pat
+		if !path.IsAbs(name) {
+			name = path.Join(dir, name)
+		}
+		return readRules(name, base, mods)
+	case ":", "dir-merge":
+		r.merge = pat
+		r.mergeMods = mods
+		r.noInherit = strings.Contains(mods, "n")
+		rules := []*rule{r}
+		if strings.Contains(mods, "e") {
+			ex, err := parseRule("- "+pat, dir, base)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(ex, rules...)
+		}
+		return rules, nil
+	default:
+		return nil, fmt.Errorf("unknown filter rule: %s", line)
+	}
+
+	for _, m := range mods {
+		switch m {
+		case '!':
+			r.negate = true
+		case '/':
+			r.whole = true
+		default:
+			return nil, fmt.Errorf("unsupported modifier '%c' in filter rule: %s", m, line)
+		}
+	}
+	if pat == "" {
+		return nil, fmt.Errorf("missing pattern in filter rule: %s", line)
+	}
+	if strings.HasSuffix(pat, "/") && !strings.HasSuffix(pat, "***/") {
+		r.dirOnly = true
+		pat = strings.TrimSuffix(pat, "/")
+	}
+	if strings.HasPrefix(pat, "/") {
+		r.anchor = true
+		pat = strings.TrimPrefix(pat, "/")
+	}
+	if strings.Contains(pat, "/") || strings.Contains(pat, "**") {
+		r.whole = true
+	}
+
+	re, err := compileGlob(pat)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern in filter rule %s: %s", line, err)
+	}
+	r.re = re
+	return []*rule{r}, nil
+}
+
+// Function compileGlob translates an rsync wildcard pattern into a regular
+// expression. "*" matches any characters except "/", "**" matches any
+// characters including "/", "?" matches a single character except "/", and
+// "[...]" is a character class. A trailing "/***" matches the directory
+// itself and everything below it.
+func compileGlob(pat string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	suffix := ""
+	if strings.HasSuffix(pat, "/***") {
+		pat = strings.TrimSuffix(pat, "/***")
+		suffix = "(/.*)?"
+	}
+	for i := 0; i < len(pat); i++ {
+		c := pat[i]
+		switch {
+		case c == '\\' && i+1 < len(pat):
+			i++
+			b.WriteString(regexp.QuoteMeta(pat[i : i+1]))
+		case c == '*' && i+1 < len(pat) && pat[i+1] == '*':
+			for i+1 < len(pat) && pat[i+1] == '*' {
+				i++
+			}
+			b.WriteString(".*")
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			j := strings.IndexByte(pat[i+1:], ']')
+			if j < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pat[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += j + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pat[i : i+1]))
+		}
+	}
+	return regexp.Compile("^" + b.String() + suffix + "$")
+}
+
+// Method matches checks whether the rule pattern matches a (relative) path.
+func (r *rule) matches(file string, isDir bool) bool {
+	if !strings.HasPrefix(file, r.base+"/") {
+		return false
+	}
+	if r.dirOnly && !isDir {
+		return r.negate
+	}
+	rel := file[len(r.base)+1:]
+
+	var m bool
+	switch {
+	case r.anchor:
+		m = r.re.MatchString(rel)
+	case r.whole:
+		// match against the end of the path, at a directory boundary
+		m = r.re.MatchString(rel)
+		for i := 0; !m && i < len(rel); i++ {
+			if rel[i] == '/' {
+				m = r.re.MatchString(rel[i+1:])
+			}
+		}
+	default:
+		m = r.re.MatchString(path.Base(rel))
+	}
+	return m != r.negate
+}
+
+// Function check walks through the rule list and returns the decision of the
+// first matching rule.
+func check(rules []*rule, file string, isDir bool) (matched, include bool) {
+	for _, r := range rules {
+		if r.merge != "" {
+			if matched, include = check(r.sub, file, isDir); matched {
+				return
+			}
+			continue
+		}
+		if r.matches(file, isDir) {
+			return true, r.include
+		}
+	}
+	return false, false
+}
+
+// Method excluded checks whether an entry in the directory dir is excluded
+// by the filter rules.
+func (e *engine) excluded(rules []*rule, file string, isDir bool) bool {
+	if e.srcName != "" && strings.LastIndex(file, "/") == 0 && file[1:] != e.srcName {
+		// the other entries of the parent of the source directory
+		return true
+	}
+	matched, include := check(rules, file, isDir)
+	return matched && !include
+}
+
+// Method rulesFor returns the filter rules which apply to the entries of a
+// directory. With per-directory merge rules, the merge files of the directory
+// are read, and their rules take precedence over those inherited from the
+// parent directories.
+func (e *engine) rulesFor(dir string) []*rule {
+	if !e.dirMerge {
+		return e.filters
+	}
+
+	e.dirMu.Lock()
+	rules, ok := e.dirRules[dir]
+	e.dirMu.Unlock()
+	if ok {
+		return rules
+	}
+
+	parent := e.filters
+	if dir != "" {
+		parent = e.rulesFor(dir[:strings.LastIndex(dir, "/")])
+	}
+
+	rules = make([]*rule, len(parent))
+	for i, r := range parent {
+		if r.merge == "" {
+			rules[i] = r
+			continue
+		}
+		nr := *r
+		nr.sub = nil
+		var sub []*rule
+		fd, err := e.source.Open(dir + "/" + r.merge)
+		if err == nil {
+			sub, err = parseRules(fd, e.src+dir, dir, r.mergeMods)
+			fd.Close()
+		}
+		if err != nil && !os.IsNotExist(err) {
+			e.warning(dir, "could not read filter file %s: %s", e.src+dir+"/"+r.merge, err)
+		}
+
+		// rules of this directory come first, followed by the inherited
+		// ones, unless they are cleared
+		inherit := !r.noInherit
+		for _, sr := range sub {
+			if sr == nil {
+				nr.sub = nil
+				inherit = false
+			} else {
+				nr.sub = append(nr.sub, sr)
+			}
+		}
+		if inherit {
+			nr.sub = append(nr.sub, r.sub...)
+		}
+		rules[i] = &nr
+	}
+
+	e.dirMu.Lock()
+	e.dirRules[dir] = rules
+	e.dirMu.Unlock()
+	return rules
+}
@@ -0,0 +1,178 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// filterTests are filter rules with the decision for a path. The decision is
+// "+" for included, "-" for excluded and "" if no rule matches.
+var filterTests = []struct {
+	rules string
+	file  string
+	dir   bool
+	want  string
+}{
+	// names match in every directory
+	{"- *.o", "/a.o", false, "-"},
+	{"- *.o", "/src/a.o", false, "-"},
+	{"- *.o", "/a.c", false, ""},
+	{"- ?.txt", "/a.txt", false, "-"},
+	{"- ?.txt", "/ab.txt", false, ""},
+	{"- [!a]*", "/b", false, "-"},
+	{"- [!a]*", "/a", false, ""},
+	{`- a\*b`, "/a*b", false, "-"},
+	{`- a\*b`, "/axb", false, ""},
+
+	// anchoring at the base directory
+	{"- /build", "/build", true, "-"},
+	{"- /build", "/src/build", true, ""},
+	{"- src/*.o", "/src/a.o", false, "-"},
+	{"- src/*.o", "/lib/src/a.o", false, "-"},
+	{"- src/*.o", "/src/sub/a.o", false, ""},
+	{"- src/*.o", "/mysrc/a.o", false, ""},
+	{"- /src/**", "/src/a/b", false, "-"},
+	{"- /src/**", "/lib/src/a", false, ""},
+	{"- /src/***", "/src", true, "-"},
+	{"- /src/***", "/src/a/b", false, "-"},
+
+	// directories only
+	{"- build/", "/build", true, "-"},
+	{"- build/", "/build", false, ""},
+
+	// modifiers, long names and the order of the rules
+	{"-! *.go", "/a.c", false, "-"},
+	{"-! *.go", "/a.go", false, ""},
+	{"exclude *.o", "/a.o", false, "-"},
+	{"include,! *.go", "/a.c", false, "+"},
+	{"+ *.c\n- *", "/a.c", false, "+"},
+	{"+ *.c\n- *", "/a.h", false, "-"},
+	{"- *\n!\n+ *", "/a", false, "+"},
+	{"- *\nclear", "/a", false, ""},
+	{"P *.o\nrisk *.o", "/a.o", false, ""},
+	{"# comment\n\n; comment\n- a", "/a", false, "-"},
+}
+
+// Function decision returns the decision of the rules for a path, like in
+// filterTests.
+func decision(rules []*rule, file string, isDir bool) string {
+	matched, include := check(rules, file, isDir)
+	switch {
+	case !matched:
+		return ""
+	case include:
+		return "+"
+	}
+	return "-"
+}
+
+func TestFilterRules(t *testing.T) {
+	for _, ft := range filterTests {
+		rules, err := parseRules(strings.NewReader(ft.rules), "", "", "")
+		if err != nil {
+			t.Errorf("rules %q: %s", ft.rules, err)
+			continue
+		}
+		if got := decision(appendRules(nil, rules), ft.file, ft.dir); got != ft.want {
+			t.Errorf("rules %q on %s (dir %t): got %q, want %q", ft.rules, ft.file, ft.dir, got, ft.want)
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, line := range []string{"x foo", "- ", "-z foo", "include,z foo", ". /nonexistent/rules"} {
+		if _, err := parseRules(strings.NewReader(line), "", "", ""); err == nil {
+			t.Errorf("rule %q: no error", line)
+		}
+	}
+}
+
+// TestExcludeFrom checks the lines of exclude and include files, which are
+// patterns unless they carry a rule prefix, and the splitting into words.
+func TestExcludeFrom(t *testing.T) {
+	for _, ft := range []struct {
+		text, mods, file, want string
+	}{
+		{"*.o", "-", "/a.o", "-"},
+		{"+ *.o", "-", "/a.o", "+"},
+		{"*.o", "+", "/a.o", "+"},
+		{"*.o *.a", "-w", "/b.a", "-"},
+		{"*.o *.a", "-", "/b.a", ""},
+	} {
+		rules, err := parseRules(strings.NewReader(ft.text), "", "", ft.mods)
+		if err != nil {
+			t.Errorf("%q with %q: %s", ft.text, ft.mods, err)
+			continue
+		}
+		if got := decision(rules, ft.file, false); got != ft.want {
+			t.Errorf("%q with %q on %s: got %q, want %q", ft.text, ft.mods, ft.file, got, ft.want)
+		}
+	}
+}
+
+// TestDirMerge reads per-directory merge files from a tree. The rules of a
+// merge file are relative to its directory, take precedence over the
+// inherited ones, and are not inherited with the n modifier.
+func TestDirMerge(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for name, text := range map[string]string{
+		".rsync-filter":          "- *.tmp\n- /top",
+		"sub/.rsync-filter":      "+ keep.tmp\n- *.bak\n- /anchored",
+		"sub/deep/.rsync-filter": "",
+		"clear/.rsync-filter":    "!\n- *.log",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, ft := range []struct {
+		merge string
+		dir   string
+		file  string
+		want  string
+	}{
+		{": .rsync-filter", "", "/a.tmp", "-"},
+		{": .rsync-filter", "", "/top", "-"},
+		{": .rsync-filter", "", "/a.bak", ""},
+		{": .rsync-filter", "", "/.rsync-filter", ""},
+		{": .rsync-filter", "/sub", "/sub/a.tmp", "-"},
+		{": .rsync-filter", "/sub", "/sub/keep.tmp", "+"},
+		{": .rsync-filter", "/sub", "/sub/a.bak", "-"},
+		{": .rsync-filter", "/sub", "/sub/top", ""},
+		{": .rsync-filter", "/sub", "/sub/anchored", "-"},
+		{": .rsync-filter", "/sub/deep", "/sub/deep/a.bak", "-"},
+		{": .rsync-filter", "/sub/deep", "/sub/deep/anchored", ""},
+		{": .rsync-filter", "/clear", "/clear/a.tmp", ""},
+		{": .rsync-filter", "/clear", "/clear/a.log", "-"},
+		{":n .rsync-filter", "/sub", "/sub/a.tmp", ""},
+		{":n .rsync-filter", "/sub/deep", "/sub/deep/a.bak", ""},
+		{":e .rsync-filter", "", "/.rsync-filter", "-"},
+		{":e .rsync-filter", "/sub", "/sub/.rsync-filter", "-"},
+		{"dir-merge .rsync-filter", "/sub", "/sub/keep.tmp", "+"},
+	} {
+		rules, err := parseRules(strings.NewReader(ft.merge), "", "", "")
+		if err != nil {
+			t.Fatalf("rule %q: %s", ft.merge, err)
+		}
+		e := &engine{source: localFS(root), filters: rules, dirMerge: true, dirRules: make(map[string][]*rule)}
+		if got := decision(e.rulesFor(ft.dir), ft.file, false); got != ft.want {
+			t.Errorf("%q in %q on %s: got %q, want %q", ft.merge, ft.dir, ft.file, got, ft.want)
+		}
+	}
+}
@@ -0,0 +1,888 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/archive"
+	"github.com/hweidner/psync/pkg/azblob"
+	"github.com/hweidner/psync/pkg/daemon"
+	"github.com/hweidner/psync/pkg/nfs"
+	"github.com/hweidner/psync/pkg/rsync"
+	"github.com/hweidner/psync/pkg/s3"
+	"github.com/hweidner/psync/pkg/sftp"
+)
+
+// Type FS is a file system backend for the source or destination tree. The
+// names are relative to the root of the tree, with a leading "/" ("" for the
+// root itself). The methods behave like their counterparts in package os.
+// Read-only backends return an error for the modifying methods. Files given
+// by path in the options, like the checkpoint or the event stream, are always
+// on the local file system.
+type FS interface {
+	ReadDir(name string) ([]os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	Stat(name string) (os.FileInfo, error)
+	Readlink(name string) (string, error)
+	Open(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Symlink(oldname, newname string) error
+	Rename(oldname, newname string) error
+	Remove(name string) error
+	Chown(name string, uid, gid int) error
+	Lchown(name string, uid, gid int) error
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// Type File is a file opened on a backend.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+	Truncate(size int64) error
+}
+
+// Function LocalFS returns a backend for the tree below the given directory
+// on the local file system (or a kernel mount).
+func LocalFS(root string) FS {
+	return localFS(root)
+}
+
+// Type localFS is a tree on the local file system. The value is the root
+// directory of the tree.
+type localFS string
+
+// Method path returns the local path of an entry of the tree.
+func (l localFS) path(name string) string {
+	return longPath(string(l) + name)
+}
+
+// Method ReadDir reads a local directory.
+func (l localFS) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(l.path(name))
+}
+
+// Method Lstat returns the attributes of a local file.
+func (l localFS) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(l.path(name))
+}
+
+// Method Stat returns the attributes of a local file, following symbolic
+// links.
+func (l localFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(l.path(name))
+}
+
+// Method Readlink returns the target of a local symbolic link.
+func (l localFS) Readlink(name string) (string, error) {
+	return os.Readlink(l.path(name))
+}
+
+// Method Open opens a local file for reading.
+func (l localFS) Open(name string) (File, error) {
+	return os.Open(l.path(name))
+}
+
+// Method OpenFile opens a local file with the given flags.
+func (l localFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return os.OpenFile(l.path(name), flag, perm)
+}
+
+// Method Mkdir creates a local directory.
+func (l localFS) Mkdir(name string, perm os.FileMode) error {
+	return os.Mkdir(l.path(name), perm)
+}
+
+// Method MkdirAll creates a local directory and its parents.
+func (l localFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(l.path(name), perm)
+}
+
+// Method Symlink creates the local symbolic link newname pointing to oldname.
+// The link target oldname is not relative to the root.
+func (l localFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, l.path(newname))
+}
+
+// Method Link creates the local hard link newname to the file oldname. The
+// file oldname is not relative to the root.
+func (l localFS) Link(oldname, newname string) error {
+	return os.Link(longPath(oldname), l.path(newname))
+}
+
+// Method Rename renames a local file.
+func (l localFS) Rename(oldname, newname string) error {
+	return os.Rename(l.path(oldname), l.path(newname))
+}
+
+// Method Remove removes a local file or empty directory.
+func (l localFS) Remove(name string) error {
+	return os.Remove(l.path(name))
+}
+
+// Method Chown changes the owner of a local file.
+func (l localFS) Chown(name string, uid, gid int) error {
+	return os.Chown(l.path(name), uid, gid)
+}
+
+// Method Lchown changes the owner of a local file, without following
+// symbolic links.
+func (l localFS) Lchown(name string, uid, gid int) error {
+	return os.Lchown(l.path(name), uid, gid)
+}
+
+// Method Chtimes changes the access and modification time of a local file.
+func (l localFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(l.path(name), atime, mtime)
+}
+
+// Method Chmod changes the permissions of a local file.
+func (l localFS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(l.path(name), mode)
+}
+
+// Type readOnlyFS implements the modifying methods of FS for read-only
+// backends. They fail with EROFS.
+type readOnlyFS struct{}
+
+// Method Mkdir is not supported by read-only backends.
+func (readOnlyFS) Mkdir(name string, perm os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// Method MkdirAll is not supported by read-only backends.
+func (readOnlyFS) MkdirAll(name string, perm os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// Method Symlink is not supported by read-only backends.
+func (readOnlyFS) Symlink(oldname, newname string) error {
+	return readOnly("symlink", newname)
+}
+
+// Method Rename is not supported by read-only backends.
+func (readOnlyFS) Rename(oldname, newname string) error {
+	return readOnly("rename", oldname)
+}
+
+// Method Remove is not supported by read-only backends.
+func (readOnlyFS) Remove(name string) error {
+	return readOnly("remove", name)
+}
+
+// Method Chown is not supported by read-only backends.
+func (readOnlyFS) Chown(name string, uid, gid int) error {
+	return readOnly("chown", name)
+}
+
+// Method Lchown is not supported by read-only backends.
+func (readOnlyFS) Lchown(name string, uid, gid int) error {
+	return readOnly("lchown", name)
+}
+
+// Method Chtimes is not supported by read-only backends.
+func (readOnlyFS) Chtimes(name string, atime, mtime time.Time) error {
+	return readOnly("chtimes", name)
+}
+
+// Type nfsFS is a source tree read with the userspace NFS client. The client
+// is read-only, so the modifying methods fail with EROFS.
+type nfsFS struct {
+	*nfs.FS
+	readOnlyFS
+}
+
+// Type nfsFile is a file opened with the userspace NFS client.
+type nfsFile struct {
+	*nfs.File
+}
+
+// Method Open opens an NFS file for reading.
+func (n nfsFS) Open(name string) (File, error) {
+	f, err := n.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return nfsFile{f}, nil
+}
+
+// Method OpenFile opens an NFS file. Only reading is supported.
+func (n nfsFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, readOnly("open", name)
+	}
+	return n.Open(name)
+}
+
+// Method Write is not supported by the NFS client.
+func (f nfsFile) Write(b []byte) (int, error) {
+	return 0, readOnly("write", "")
+}
+
+// Method Truncate is not supported by the NFS client.
+func (f nfsFile) Truncate(size int64) error {
+	return readOnly("truncate", "")
+}
+
+// Function readOnly returns the error of a modifying operation on a read-only
+// backend.
+func readOnly(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: syscall.EROFS}
+}
+
+// Type sftpFS is a tree on a remote host, accessed with SFTP over ssh.
+type sftpFS struct {
+	*sftp.Client
+}
+
+// Method Open opens a remote file for reading.
+func (s sftpFS) Open(name string) (File, error) {
+	return s.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile opens a remote file with the given flags.
+func (s sftpFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := s.Client.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Function localTree checks whether a tree is a local path by the rule of
+// rsync: only a colon before the first slash makes a tree remote, so that
+// absolute paths and paths starting with "./" are always local.
+func localTree(tree string) bool {
+	i := strings.Index(tree, ":")
+	return i < 0 || strings.Contains(tree[:i], "/")
+}
+
+// Function remoteTree splits a remote tree, given as sftp://[user@]host[:port]/path
+// or in the scp syntax [user@]host:path, into host, port and path. A path
+// in the scp syntax is relative to the home directory on the host. Local
+// paths containing a colon can be given with a leading "./" (see localTree).
+func remoteTree(tree string) (host string, port int, dir string, ok bool) {
+	if localTree(tree) {
+		return "", 0, "", false
+	}
+	if strings.HasPrefix(tree, "sftp://") {
+		hostPath := strings.TrimPrefix(tree, "sftp://")
+		i := strings.Index(hostPath, "/")
+		if i < 0 {
+			hostPath, i = hostPath+"/", len(hostPath)
+		}
+		host, dir = hostPath[:i], hostPath[i:]
+		if j := strings.LastIndex(host, ":"); j >= 0 {
+			p, err := strconv.Atoi(host[j+1:])
+			if err != nil {
+				return "", 0, "", false
+			}
+			host, port = host[:j], p
+		}
+		return host, port, dir, host != ""
+	}
+	if strings.Contains(tree, "://") {
+		return "", 0, "", false
+	}
+	if _, _, _, ok := daemonTree(tree); ok {
+		return "", 0, "", false
+	}
+	if _, _, ok := archiveTree(tree); ok {
+		return "", 0, "", false
+	}
+	i := strings.Index(tree, ":")
+	if i == 0 {
+		return "", 0, "", false
+	}
+	return tree[:i], 0, tree[i+1:], true
+}
+
+// Method dialTree connects to a remote tree with SFTP.
+func (e *engine) dialTree(tree string) (FS, error) {
+	host, port, dir, ok := remoteTree(tree)
+	if !ok {
+		return nil, fmt.Errorf("invalid remote tree %s, use [user@]host:path or sftp://[user@]host[:port]/path", tree)
+	}
+	cl, err := sftp.Dial(host, dir, sftp.Options{
+		Command:  e.sshCommand,
+		Port:     port,
+		Conns:    int(e.sftpConns),
+		Timeout:  time.Minute,
+		Compress: e.compress,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sftpFS{cl}, nil
+}
+
+// Type daemonFS is a tree in a module of a psync daemon.
+type daemonFS struct {
+	*daemon.Client
+}
+
+// Method Open opens a file of the module for reading.
+func (d daemonFS) Open(name string) (File, error) {
+	return d.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile opens a file of the module with the given flags.
+func (d daemonFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := d.Client.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Function daemonTree splits a tree in a module of a psync daemon, given as
+// host[:port]::module/path, into the address of the daemon, the module and
+// the path within the module. IPv6 addresses are given in brackets.
+func daemonTree(tree string) (addr, module, dir string, ok bool) {
+	if localTree(tree) {
+		return "", "", "", false
+	}
+	start := 0
+	if strings.HasPrefix(tree, "[") {
+		if start = strings.Index(tree, "]"); start < 0 {
+			return "", "", "", false
+		}
+	}
+	i := strings.Index(tree[start:], "::")
+	if i < 0 {
+		return "", "", "", false
+	}
+	addr, module = tree[:start+i], tree[start+i+2:]
+	if addr == "" || strings.Contains(addr, "/") {
+		return "", "", "", false
+	}
+	if j := strings.Index(module, "/"); j >= 0 {
+		module, dir = module[:j], module[j:]
+	}
+	return addr, module, dir, module != ""
+}
+
+// Method daemonSecret returns the shared secret for psync daemons, read
+// from the secret file or the environment variable PSYNC_SECRET.
+func (e *engine) daemonSecret() ([]byte, error) {
+	if e.secretFile != "" {
+		b, err := ioutil.ReadFile(e.secretFile)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimSpace(string(b))), nil
+	}
+	if s := os.Getenv("PSYNC_SECRET"); s != "" {
+		return []byte(s), nil
+	}
+	return nil, nil
+}
+
+// Method dialDaemon connects to a module of a psync daemon.
+func (e *engine) dialDaemon(tree string) (FS, error) {
+	addr, module, dir, ok := daemonTree(tree)
+	if !ok {
+		return nil, fmt.Errorf("invalid daemon tree %s, use host[:port]::module/path", tree)
+	}
+	secret, err := e.daemonSecret()
+	if err != nil {
+		return nil, err
+	}
+	cl, err := daemon.Dial(addr, module, dir, daemon.Options{Secret: secret, Timeout: time.Minute, Compress: e.compress})
+	if err != nil {
+		return nil, err
+	}
+	return daemonFS{cl}, nil
+}
+
+// Type archiveFS is a tar archive written as destination tree.
+type archiveFS struct {
+	*archive.Writer
+}
+
+// Method Open fails, the archive cannot be read back.
+func (a archiveFS) Open(name string) (File, error) {
+	return a.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile creates a file in the archive.
+func (a archiveFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := a.Writer.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Type archiveSource is a tar or zip archive read as source tree. The
+// archive is read-only, so the modifying methods fail with EROFS.
+type archiveSource struct {
+	*archive.Reader
+	readOnlyFS
+	src string // source tree, for the error messages
+}
+
+// Method Open opens a file of the archive for reading.
+func (a archiveSource) Open(name string) (File, error) {
+	f, err := a.Reader.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Method OpenFile opens a file of the archive for reading. Writing is not
+// supported.
+func (a archiveSource) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: a.src + name, Err: syscall.EROFS}
+	}
+	return a.Open(name)
+}
+
+// Function archiveTree splits an archive, given as tar:<file> or zip:<file>,
+// into the format and the file name. The file name "-" stands for STDIN or
+// STDOUT.
+func archiveTree(tree string) (format, name string, ok bool) {
+	if localTree(tree) {
+		return "", "", false
+	}
+	for _, f := range []string{"tar", "zip"} {
+		if strings.HasPrefix(tree, f+":") && len(tree) > len(f)+1 {
+			return f, tree[len(f)+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// Type rsyncFS is a source tree in a module of an rsync daemon. The client
+// is read-only, so the modifying methods fail with EROFS.
+type rsyncFS struct {
+	*rsync.Client
+	readOnlyFS
+}
+
+// Method Open transfers a file from the daemon.
+func (r rsyncFS) Open(name string) (File, error) {
+	f, err := r.Client.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Method OpenFile transfers a file from the daemon. Only reading is
+// supported.
+func (r rsyncFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, readOnly("open", name)
+	}
+	return r.Open(name)
+}
+
+// Function rsyncTree splits a tree in a module of an rsync daemon, given as
+// rsync://[user@]host[:port]/module/path, into the user, the address of the
+// daemon, the module and the path within the module.
+func rsyncTree(tree string) (user, addr, module, dir string, ok bool) {
+	if !strings.HasPrefix(tree, "rsync://") {
+		return "", "", "", "", false
+	}
+	addr = strings.TrimPrefix(tree, "rsync://")
+	if i := strings.Index(addr, "/"); i >= 0 {
+		addr, module = addr[:i], addr[i+1:]
+	}
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		user, addr = addr[:i], addr[i+1:]
+	}
+	if i := strings.Index(module, "/"); i >= 0 {
+		module, dir = module[:i], module[i:]
+	}
+	return user, addr, module, dir, addr != ""
+}
+
+// Function dialRsync connects to a module of an rsync daemon. Without a
+// module, the modules offered by the daemon are listed in the error.
+func dialRsync(tree string) (FS, error) {
+	user, addr, module, dir, ok := rsyncTree(tree)
+	if !ok {
+		return nil, fmt.Errorf("invalid rsync tree %s, use rsync://[user@]host[:port]/module/path", tree)
+	}
+	opts := rsync.Options{User: user, Timeout: time.Minute}
+	if module == "" {
+		mods, err := rsync.ListModules(addr, opts)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, m := range mods {
+			names = append(names, m.Name)
+		}
+		return nil, fmt.Errorf("no module given, the daemon offers: %s", strings.Join(names, ", "))
+	}
+	cl, err := rsync.Dial(addr, module, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rsyncFS{Client: cl}, nil
+}
+
+// Type s3FS is a tree in an S3 bucket, below a key prefix.
+type s3FS struct {
+	*s3.Client
+}
+
+// Method Open opens an object for reading.
+func (s s3FS) Open(name string) (File, error) {
+	return s.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile opens an object for reading or writing.
+func (s s3FS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := s.Client.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Type azFS is a tree in an Azure Blob Storage container, below a name
+// prefix.
+type azFS struct {
+	*azblob.Client
+}
+
+// Method Open opens a blob for reading.
+func (a azFS) Open(name string) (File, error) {
+	return a.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile opens a blob for reading or writing.
+func (a azFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := a.Client.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Function bucketTree splits a tree in an object store, given as
+// s3://bucket/prefix, gs://bucket/prefix or azblob://account/container/prefix,
+// into the scheme, the bucket (or account) and the prefix.
+func bucketTree(tree string) (scheme, bucket, prefix string, ok bool) {
+	i := strings.Index(tree, "://")
+	if i < 0 {
+		return "", "", "", false
+	}
+	scheme = tree[:i]
+	if scheme != "s3" && scheme != "gs" && scheme != "azblob" {
+		return "", "", "", false
+	}
+	bucketPrefix := tree[i+3:]
+	if i := strings.Index(bucketPrefix, "/"); i >= 0 {
+		bucket, prefix = bucketPrefix[:i], bucketPrefix[i+1:]
+	} else {
+		bucket = bucketPrefix
+	}
+	return scheme, bucket, prefix, bucket != ""
+}
+
+// Method openBucket returns the backend for a tree in an object store.
+// Google Cloud Storage is accessed with its S3 compatible XML API.
+func (e *engine) openBucket(tree string) (FS, error) {
+	scheme, bucket, prefix, ok := bucketTree(tree)
+	if !ok {
+		return nil, fmt.Errorf("invalid bucket %s, use s3://bucket/prefix", tree)
+	}
+	switch scheme {
+	case "gs":
+		opts := s3.Options{
+			Endpoint:  "https://storage.googleapis.com",
+			Region:    "auto",
+			AccessKey: os.Getenv("GS_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("GS_SECRET_ACCESS_KEY"),
+			Token:     os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+			Conns:     int(e.threads) * 2,
+		}
+		if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+			opts.Endpoint = "http://" + host
+		}
+		if opts.AccessKey == "" && opts.Token == "" {
+			return nil, errors.New("no credentials, set GS_ACCESS_KEY_ID and GS_SECRET_ACCESS_KEY, or GOOGLE_OAUTH_ACCESS_TOKEN")
+		}
+		cl, err := s3.New(bucket, prefix, opts)
+		if err != nil {
+			return nil, err
+		}
+		return s3FS{cl}, nil
+
+	case "azblob":
+		container := prefix
+		if i := strings.Index(prefix, "/"); i >= 0 {
+			container, prefix = prefix[:i], prefix[i+1:]
+		} else {
+			prefix = ""
+		}
+		cl, err := azblob.New(bucket, container, prefix, azblob.Options{Conns: int(e.threads) * 2})
+		if err != nil {
+			return nil, err
+		}
+		return azFS{cl}, nil
+
+	default:
+		cl, err := s3.New(bucket, prefix, s3.Options{Endpoint: e.s3Endpoint, Conns: int(e.threads) * 2})
+		if err != nil {
+			return nil, err
+		}
+		return s3FS{cl}, nil
+	}
+}
+
+// Function remote checks whether a tree is not on a local file system, so that
+// the control files cannot be placed in it.
+func remote(tree string) bool {
+	if localTree(tree) {
+		return false
+	}
+	_, _, _, ok := remoteTree(tree)
+	_, _, _, ok2 := daemonTree(tree)
+	_, _, ok3 := archiveTree(tree)
+	return ok || ok2 || ok3 || strings.Contains(tree, "://")
+}
+
+// Method openSource mounts the source tree, if it is given as an NFS URL of
+// the form nfs://host/export/path, or connects to it, if it is a remote tree
+// (see remoteTree), a daemon module (see daemonTree), a module of an rsync
+// daemon (see rsyncTree) or an object store (see bucketTree), or opens it, if
+// it is an archive (see archiveTree). Otherwise, the source is read from the
+// local file system, unless another backend was given in the options.
+func (e *engine) openSource() error {
+	if e.source != nil {
+		return nil
+	}
+	if _, _, _, _, ok := rsyncTree(e.src); ok {
+		if e.keepAtime {
+			return errors.New("option -keep-atime is not supported for rsync sources")
+		}
+		fs, err := dialRsync(e.src)
+		if err != nil {
+			return fmt.Errorf("cannot connect to source %s: %s", e.src, err)
+		}
+		e.source = fs
+		return nil
+	}
+	if format, name, ok := archiveTree(e.src); ok {
+		if e.keepAtime {
+			return errors.New("option -keep-atime is not supported for archive sources")
+		}
+		r, err := archive.Open(name, format == "zip")
+		if err != nil {
+			return fmt.Errorf("cannot open source archive %s: %s", name, err)
+		}
+		e.source = archiveSource{Reader: r, src: e.src}
+		return nil
+	}
+	if _, _, _, ok := daemonTree(e.src); ok {
+		fs, err := e.dialDaemon(e.src)
+		if err != nil {
+			return fmt.Errorf("cannot connect to source %s: %s", e.src, err)
+		}
+		e.source = fs
+		return nil
+	}
+	if _, _, _, ok := remoteTree(e.src); ok {
+		fs, err := e.dialTree(e.src)
+		if err != nil {
+			return fmt.Errorf("cannot connect to source %s: %s", e.src, err)
+		}
+		e.source = fs
+		return nil
+	}
+	if _, _, _, ok := bucketTree(e.src); ok {
+		if e.keepAtime {
+			return errors.New("option -keep-atime is not supported for object store sources")
+		}
+		fs, err := e.openBucket(e.src)
+		if err != nil {
+			return fmt.Errorf("cannot open source %s: %s", e.src, err)
+		}
+		e.source = fs
+		return nil
+	}
+	if !strings.HasPrefix(e.src, "nfs://") {
+		e.source = localFS(e.src)
+		return nil
+	}
+	hostPath := strings.TrimPrefix(e.src, "nfs://")
+	i := strings.Index(hostPath, "/")
+	if i <= 0 {
+		return fmt.Errorf("invalid NFS source %s, use nfs://host/path", e.src)
+	}
+	if e.keepAtime {
+		return errors.New("option -keep-atime is not supported for NFS sources")
+	}
+	fs, err := nfs.Mount(hostPath[:i], hostPath[i:], nfs.Options{Conns: int(e.nfsConns), Timeout: time.Minute})
+	if err != nil {
+		return fmt.Errorf("cannot mount NFS source %s: %s", e.src, err)
+	}
+	e.source = nfsFS{FS: fs}
+	return nil
+}
+
+// Method openDestination connects to the destination tree, if it is a
+// remote tree, a daemon module or in an object store, or creates it, if it is
+// a tar archive. Otherwise, the destination is written to the local file
+// system, unless another backend was given in the options.
+func (e *engine) openDestination() error {
+	if e.destination != nil {
+		return nil
+	}
+	if _, _, _, _, ok := rsyncTree(e.dest); ok {
+		return fmt.Errorf("rsync daemons are only supported as source, not as destination %s", e.dest)
+	}
+	if format, name, ok := archiveTree(e.dest); ok {
+		if format != "tar" {
+			return fmt.Errorf("only tar archives are supported as destination, not %s", e.dest)
+		}
+		if e.lock || e.chunkSize > 0 {
+			return errors.New("options -lock and -chunk-journal are not supported for archive destinations")
+		}
+		w, err := archive.Create(name, archive.Options{Times: e.times, Owner: e.owner})
+		if err != nil {
+			return fmt.Errorf("cannot create archive %s: %s", name, err)
+		}
+		e.destination = archiveFS{w}
+		return nil
+	}
+	if _, _, _, ok := daemonTree(e.dest); ok {
+		fs, err := e.dialDaemon(e.dest)
+		if err != nil {
+			return fmt.Errorf("cannot connect to destination %s: %s", e.dest, err)
+		}
+		if fs.(daemonFS).ReadOnly() {
+			fs.(daemonFS).Close()
+			return fmt.Errorf("destination %s is read-only", e.dest)
+		}
+		e.destination = fs
+		return nil
+	}
+	if _, _, _, ok := bucketTree(e.dest); ok {
+		if e.lock {
+			return errors.New("option -lock is not supported for object store destinations")
+		}
+		fs, err := e.openBucket(e.dest)
+		if err != nil {
+			return fmt.Errorf("cannot open destination %s: %s", e.dest, err)
+		}
+		e.destination = fs
+		return nil
+	}
+	if _, _, _, ok := remoteTree(e.dest); !ok {
+		e.destination = localFS(e.dest)
+		return nil
+	}
+	fs, err := e.dialTree(e.dest)
+	if err != nil {
+		return fmt.Errorf("cannot connect to destination %s: %s", e.dest, err)
+	}
+	e.destination = fs
+	return nil
+}
+
+// Method closeTrees unmounts NFS source trees, and disconnects from remote
+// trees and buckets.
+func (e *engine) closeTrees() {
+	d := e.destination
+	if n, ok := d.(*normFS); ok {
+		d = n.FS
+	}
+	trees := append([]FS{d}, e.sources...)
+	if len(e.sources) == 0 {
+		trees = append(trees, e.source)
+	}
+	for _, fs := range trees {
+		switch t := fs.(type) {
+		case nfsFS:
+			t.Close()
+		case sftpFS:
+			t.Close()
+		case s3FS:
+			t.Close()
+		case azFS:
+			t.Close()
+		case daemonFS:
+			t.Close()
+		case archiveFS:
+			t.Close()
+		case archiveSource:
+			t.Close()
+		}
+	}
+}
+
+// Method localTrees checks whether both the source and the destination are
+// on the local file system.
+func (e *engine) localTrees() bool {
+	_, ok := e.source.(localFS)
+	_, ok2 := e.destination.(localFS)
+	return ok && ok2
+}
+
+// Function readFile reads a whole file from a backend.
+func readFile(fs FS, name string) ([]byte, error) {
+	fd, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return ioutil.ReadAll(fd)
+}
+
+// Function writeFile writes a whole file on a backend.
+func writeFile(fs FS, name string, b []byte, perm os.FileMode) error {
+	fd, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = fd.Write(b)
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Function removeAll removes a file or directory tree from a backend.
+func removeAll(fs FS, name string) error {
+	f, err := fs.Lstat(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if f.IsDir() {
+		entries, err := fs.ReadDir(name)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := removeAll(fs, name+"/"+e.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return fs.Remove(name)
+}
@@ -0,0 +1,33 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+// Function syncFile writes a destination file back to the storage. Files of
+// backends without a file descriptor are skipped.
+func syncFile(f interface{}) error {
+	if s, ok := f.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Method syncDir writes a local destination directory back to the storage,
+// so that its entries, including renamed and removed ones, survive a crash.
+// The name is relative to the destination.
+func (e *engine) syncDir(dir string) {
+	if !e.localDest() {
+		return
+	}
+	e.op()
+	fd, err := e.destination.Open(dir)
+	if err != nil {
+		e.warning(dir, "could not sync directory %s: %s", e.dest+dir, err)
+		return
+	}
+	defer fd.Close()
+	if err := syncFile(fd); err != nil {
+		e.warning(dir, "could not sync directory %s: %s", e.dest+dir, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Function shellCommand returns the command line c, run by the shell, so that
+// it may contain quoted arguments, variables and pipes.
+func shellCommand(c string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", c)
+	}
+	return exec.Command("/bin/sh", "-c", c)
+}
+
+// Method runHook runs a hook command by the shell, with the source and
+// destination, and the given variables, in its environment. Its output goes
+// to the output of psync.
+func (e *engine) runHook(c string, env ...string) error {
+	cmd := shellCommand(c)
+	cmd.Stdout, cmd.Stderr = e.stdout, e.stderr
+	cmd.Env = append(os.Environ(), "PSYNC_SOURCE="+e.src, "PSYNC_DESTINATION="+e.dest)
+	cmd.Env = append(cmd.Env, env...)
+	return cmd.Run()
+}
+
+// Method preHook runs the command of -pre-cmd. It fails the run if the
+// command fails, so that nothing is copied from an application which could
+// not be quiesced.
+func (e *engine) preHook() error {
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Running %s before the copy\n", e.preCmd)
+	}
+	if err := e.runHook(e.preCmd); err != nil {
+		return fmt.Errorf("command %s before the copy failed: %s", e.preCmd, err)
+	}
+	return nil
+}
+
+// Method postHook runs the command of -post-cmd after a run which ended with
+// the error err, also if it failed or was interrupted. A failure of the
+// command fails an otherwise successful run.
+func (e *engine) postHook(err error) error {
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Running %s after the copy\n", e.postCmd)
+	}
+	if herr := e.runHook(e.postCmd, "PSYNC_STATUS="+e.runStatus(err)); herr != nil {
+		herr = fmt.Errorf("command %s after the copy failed: %s", e.postCmd, herr)
+		if err == nil {
+			return herr
+		}
+		fmt.Fprintf(e.stderr, "ERROR - %s\n", herr)
+	}
+	return err
+}
+
+// Method fileHook runs the command of -file-cmd for a completed entry, with
+// the action of the event stream and the path relative to the source
+// directory. A failure of the command is counted as error of the entry.
+func (e *engine) fileHook(action, path string) {
+	switch action {
+	case "error", "differ", "conflict":
+		return
+	}
+	rel := strings.TrimPrefix(path, "/")
+	if rel == "" {
+		rel = "."
+	}
+	if err := e.runHook(e.fileCmd, "PSYNC_ACTION="+action, "PSYNC_PATH="+rel); err != nil {
+		e.warning(path, "command %s for %s failed: %s", e.fileCmd, path, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Type idRule maps a source user or group ID, a range of IDs, or all IDs, to
+// a destination ID. With shift, the IDs of the range are moved by to.
+type idRule struct {
+	lo, hi int  // source IDs
+	all    bool // the rule matches all IDs
+	to     int  // destination ID, or offset with shift
+	shift  bool // to is an offset
+}
+
+// Type idMap is a list of mapping rules. The first matching rule applies,
+// IDs without a matching rule are kept.
+type idMap []idRule
+
+// Function readIDMap reads a mapping file of -usermap or -groupmap.
+func readIDMap(name string, group bool) (idMap, error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	m, err := parseIDMap(fd, group)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return m, nil
+}
+
+// Function parseIDMap parses the lines of a mapping file. Each line holds the
+// source and the destination of a mapping, separated by white space:
+//
+//	1000 2000          map ID 1000 to ID 2000
+//	alice bob          map by names, looked up on the local host
+//	1000-1999 5000     map a range of IDs to a single ID
+//	0-65535 +100000    shift a range of IDs, e.g. into a user namespace
+//	* nobody           map all other IDs
+//
+// Empty lines and lines starting with # are ignored.
+func parseIDMap(r io.Reader, group bool) (idMap, error) {
+	var m idMap
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			return nil, fmt.Errorf("line %d: invalid mapping %q", n, line)
+		}
+		var rl idRule
+		var err error
+		switch i := strings.IndexByte(f[0], '-'); {
+		case f[0] == "*":
+			rl.all = true
+		case i > 0:
+			lo, err1 := strconv.ParseUint(f[0][:i], 10, 32)
+			hi, err2 := strconv.ParseUint(f[0][i+1:], 10, 32)
+			if err1 != nil || err2 != nil || lo > hi {
+				return nil, fmt.Errorf("line %d: invalid range %q", n, f[0])
+			}
+			rl.lo, rl.hi = int(lo), int(hi)
+		default:
+			if rl.lo, err = lookupID(f[0], group); err != nil {
+				return nil, fmt.Errorf("line %d: %s", n, err)
+			}
+			rl.hi = rl.lo
+		}
+		if f[1][0] == '+' || f[1][0] == '-' {
+			off, err := strconv.Atoi(f[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid offset %q", n, f[1])
+			}
+			rl.to, rl.shift = off, true
+		} else if rl.to, err = lookupID(f[1], group); err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		m = append(m, rl)
+	}
+	return m, sc.Err()
+}
+
+// Method lookup maps an ID. It returns false if no rule matches, then the ID
+// is kept. Unknown IDs (-1) are kept as well.
+func (m idMap) lookup(id int) (int, bool) {
+	if id < 0 {
+		return id, false
+	}
+	for _, rl := range m {
+		if !rl.all && (id < rl.lo || id > rl.hi) {
+			continue
+		}
+		if rl.shift {
+			return id + rl.to, true
+		}
+		return rl.to, true
+	}
+	return id, false
+}
@@ -0,0 +1,75 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIDMap(t *testing.T) {
+	m, err := parseIDMap(strings.NewReader(`
+# comment
+1000 2000
+1500-1999 3000
+0-999 +100000
+  5000   5001
+* 65534
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range []struct {
+		id, want int
+		ok       bool
+	}{
+		{1000, 2000, true},
+		{1500, 3000, true},
+		{1999, 3000, true},
+		{0, 100000, true},
+		{999, 100999, true},
+		{5000, 5001, true},
+		{1001, 65534, true},
+		{2000, 65534, true},
+		{-1, -1, false},
+	} {
+		if got, ok := m.lookup(it.id); got != it.want || ok != it.ok {
+			t.Errorf("lookup(%d) = %d, %t, want %d, %t", it.id, got, ok, it.want, it.ok)
+		}
+	}
+
+	// without a catch-all rule, the other IDs are kept
+	m, err = parseIDMap(strings.NewReader("10-19 -10\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range []struct {
+		id, want int
+		ok       bool
+	}{
+		{10, 0, true},
+		{19, 9, true},
+		{20, 20, false},
+	} {
+		if got, ok := m.lookup(it.id); got != it.want || ok != it.ok {
+			t.Errorf("lookup(%d) = %d, %t, want %d, %t", it.id, got, ok, it.want, it.ok)
+		}
+	}
+}
+
+func TestIDMapErrors(t *testing.T) {
+	for _, text := range []string{
+		"1000",
+		"1000 2000 3000",
+		"20-10 5",
+		"a-10 5",
+		"10-19 +x",
+		"1000 no-such-user-psync",
+	} {
+		if _, err := parseIDMap(strings.NewReader(text), false); err == nil {
+			t.Errorf("mapping %q: no error", text)
+		}
+	}
+}
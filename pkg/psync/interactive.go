@@ -0,0 +1,84 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Method openTTY opens the terminal for the questions of the interactive
+// mode. STDIN is not used, since it may carry the list of -files-from.
+func (e *engine) openTTY() error {
+	fd, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("option -interactive needs a terminal: %s", err)
+	}
+	e.askTTY, e.askIn, e.askAll = fd, bufio.NewReader(fd), 0
+	return nil
+}
+
+// Method closeTTY closes the terminal of the interactive mode.
+func (e *engine) closeTTY() {
+	if e.askTTY != nil {
+		e.askTTY.Close()
+		e.askTTY = nil
+	}
+}
+
+// Method overwrite checks whether an existing destination file, which
+// differs from the source file, may be overwritten. In interactive mode, the
+// user is asked, unless an earlier answer applies to all files. New files,
+// and entries other than regular files, are copied without asking.
+func (e *engine) overwrite(file, name string, f os.FileInfo) bool {
+	if !e.interactive || !f.Mode().IsRegular() {
+		return true
+	}
+	e.op()
+	d, err := e.destination.Lstat(name)
+	if err != nil {
+		return true
+	}
+
+	e.askMu.Lock()
+	defer e.askMu.Unlock()
+	atomic.StoreInt32(&e.asking, 1)
+	defer atomic.StoreInt32(&e.asking, 0)
+	for e.askAll == 0 && !e.stopping() {
+		fmt.Fprintf(e.askTTY, "Overwrite %s (%d bytes, %s) with %s (%d bytes, %s)?\n"+
+			"[y]es, [n]o, [a]ll, [s]kip all, [q]uit: ",
+			e.dest+name, d.Size(), d.ModTime().Format("2006-01-02 15:04:05"),
+			e.src+file, f.Size(), f.ModTime().Format("2006-01-02 15:04:05"))
+		line, err := e.askIn.ReadString('\n')
+		if err != nil {
+			// no more answers, keep the remaining files
+			fmt.Fprintln(e.askTTY)
+			e.askAll = 's'
+			break
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "a", "all":
+			e.askAll = 'a'
+		case "s", "skip all":
+			e.askAll = 's'
+		case "q", "quit":
+			e.halt()
+		}
+	}
+	return e.askAll == 'a' && !e.stopping()
+}
+
+// Method waiting checks whether a copy thread waits for an answer of the
+// user, which does not count as a stall.
+func (e *engine) waiting() bool {
+	return atomic.LoadInt32(&e.asking) != 0
+}
@@ -0,0 +1,55 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Method repeatSync syncs the sources again each interval, until the run is
+// stopped. The connections to the trees, the filter rules and the state
+// database are kept between the syncs. The repeated syncs copy only entries
+// which are missing or changed, as in sync mode. A sync which takes longer
+// than the interval is followed by the next one immediately.
+func (e *engine) repeatSync(list []string) error {
+	e.syncMode, e.resume = true, false
+	last := e.start
+	for pass := 2; ; pass++ {
+		if e.stateDB != "" {
+			e.writeDB()
+			e.dbOld, e.dbNew = e.dbNew, make(map[string]dbEntry)
+		}
+		next := last.Add(e.interval)
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "Next sync of %s at %s\n", e.src, next.Format(time.RFC3339))
+		}
+		select {
+		case <-e.stop:
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+
+		// files older than the start of the last sync are skipped with
+		// -since-last
+		if e.sinceLast {
+			e.lastRun = last
+		}
+		last = time.Now()
+		files, bytes := atomic.LoadUint64(&e.total.files), atomic.LoadUint64(&e.total.bytes)
+		if err := e.syncSources(nil, list); err != nil {
+			return err
+		}
+		if e.stopping() {
+			return nil
+		}
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "Sync %d of %s finished in %s, %d files with %d bytes copied\n", pass, e.src,
+				time.Since(last).Round(time.Millisecond),
+				atomic.LoadUint64(&e.total.files)-files, atomic.LoadUint64(&e.total.bytes)-bytes)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"sync"
+	"time"
+)
+
+// Type limiter paces operations to a maximum rate. The operations are spread
+// evenly over time, without bursts.
+type limiter struct {
+	mu       sync.Mutex    // protects next
+	interval time.Duration // minimum interval between two operations
+	next     time.Time     // earliest time for the next operation
+}
+
+// Function newLimiter creates a limiter for the given number of operations per
+// second.
+func newLimiter(rate uint) *limiter {
+	return &limiter{interval: time.Second / time.Duration(rate)}
+}
+
+// Method wait blocks until the next operation is allowed. It is safe to call
+// it on a nil limiter.
+func (l *limiter) wait() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	t := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	time.Sleep(t.Sub(now))
+}
+
+// Method op waits until the next metadata operation is allowed by the
+// -iops limit.
+func (e *engine) op() {
+	e.opLimit.wait()
+}
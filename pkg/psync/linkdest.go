@@ -0,0 +1,110 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Method initLinkDest checks the reference directories for hard links.
+// Relative paths are relative to the destination directory, like with rsync.
+// Missing reference directories are ignored, e.g. on the first run of a
+// rotating backup.
+func (e *engine) initLinkDest() error {
+	root, ok := e.destination.(localFS)
+	if !ok {
+		return fmt.Errorf("option -link-dest is only supported for local destinations")
+	}
+	e.linkRefs = nil
+	for _, ref := range e.linkDest {
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(string(root), ref)
+		}
+		if f, err := os.Stat(ref); err != nil || !f.IsDir() {
+			if !e.quiet {
+				fmt.Fprintf(e.stderr, "WARNING - reference directory %s does not exist, ignored\n", ref)
+			}
+			continue
+		}
+		e.linkRefs = append(e.linkRefs, localFS(ref))
+	}
+	return nil
+}
+
+// Method linkUnchanged hard-links a regular file from the first reference
+// directory which has it unchanged, i.e. with the same size, modification
+// time and permissions, and the same owner with -owner. It returns false if
+// the file must be copied.
+func (e *engine) linkUnchanged(id uint, file string, f os.FileInfo) bool {
+	begin := time.Now()
+	for _, ref := range e.linkRefs {
+		e.op()
+		r, err := ref.Lstat(file)
+		if err != nil || !e.sameFile(f, r) {
+			continue
+		}
+		e.op()
+		err = e.destination.(localFS).Link(string(ref)+file, file)
+		if os.IsExist(err) {
+			// replace an outdated file of an earlier run
+			e.op()
+			if d, derr := e.destination.Lstat(file); derr == nil && os.SameFile(d, r) {
+				err = nil
+			} else if e.destination.Remove(file) == nil {
+				e.op()
+				err = e.destination.(localFS).Link(string(ref)+file, file)
+			}
+		}
+		if err != nil {
+			if e.verbose >= 2 {
+				fmt.Fprintf(e.stdout, "[%d] Could not link %s from %s: %s\n", id, e.dest+file, string(ref), err)
+			}
+			break
+		}
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "[%d] Linking %s%s to %s%s\n", id, string(ref), file, e.dest, file)
+		}
+		e.countFile(id, file, 0)
+		e.record(file, f)
+		e.emit("link", file, 0, begin, nil)
+		return true
+	}
+	return false
+}
+
+// Method breakLink removes a destination file which is a hard link, e.g. to
+// an older snapshot or an identical file, so that copying the file does not
+// change the other links.
+func (e *engine) breakLink(file string) {
+	e.op()
+	if d, err := e.destination.Lstat(file); err == nil {
+		if stat, ok := d.Sys().(*sysstat.Stat); ok && stat.Nlink > 1 {
+			e.op()
+			e.destination.Remove(file)
+		}
+	}
+}
+
+// Method sameFile checks whether a file in a reference directory is
+// unchanged against the source file.
+func (e *engine) sameFile(f, r os.FileInfo) bool {
+	if !r.Mode().IsRegular() || r.Size() != f.Size() || r.Mode().Perm() != e.destPerm(f).Perm() ||
+		r.ModTime().Unix() != f.ModTime().Unix() {
+		return false
+	}
+	if e.owner {
+		uid, gid, _ := e.destOwner(f)
+		rs, ok := r.Sys().(*sysstat.Stat)
+		if !ok || uid >= 0 && uint32(uid) != rs.Uid || gid >= 0 && uint32(gid) != rs.Gid {
+			return false
+		}
+	}
+	return true
+}
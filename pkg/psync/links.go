@@ -0,0 +1,71 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Method createLink creates the symbolic link target pointing to link. If
+// the target already exists as a symbolic link pointing elsewhere, it is
+// handled according to the -existing-links mode: "warn" prints a warning,
+// "skip" leaves it alone, and "replace" replaces it atomically by creating the
+// link under a temporary name and renaming it to the target. It returns the
+// action taken ("symlink" or "relink"), or "" if no link was created.
+func (e *engine) createLink(id uint, file, link, target string) string {
+	e.op()
+	err := e.destination.Symlink(link, target)
+	if err == nil {
+		return "symlink"
+	}
+	if !os.IsExist(err) {
+		e.warning(file, "link %s could not be created: %s", e.dest+target, err)
+		return ""
+	}
+
+	e.op()
+	old, lerr := e.destination.Readlink(target)
+	if lerr != nil {
+		// the target exists, but is not a symbolic link
+		e.warning(file, "link %s could not be created: %s", e.dest+target, err)
+		return ""
+	}
+	if old == link {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping link %s, it is up to date\n", id, e.dest+target)
+		}
+		return ""
+	}
+	if e.existingLinks == "warn" {
+		e.warning(file, "link %s could not be created: %s", e.dest+target, err)
+		return ""
+	}
+	if e.existingLinks == "skip" {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping link %s pointing to %s instead of %s\n", id, e.dest+target, old, link)
+		}
+		return ""
+	}
+
+	// replace the link atomically
+	tmp := e.tempName(target)
+	e.op()
+	if err = e.destination.Symlink(link, tmp); err == nil {
+		if err = e.destination.Rename(tmp, target); err != nil {
+			e.destination.Remove(tmp)
+		}
+	}
+	if err != nil {
+		e.warning(file, "link %s could not be replaced: %s", e.dest+target, err)
+		return ""
+	}
+	atomic.AddUint64(&e.total.relinks, 1)
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "[%d] Retargeted link %s from %s to %s\n", id, e.dest+target, old, link)
+	}
+	return "relink"
+}
@@ -0,0 +1,242 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Method openErrors creates the report file of failed entries.
+func (e *engine) openErrors(name string) error {
+	fd, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("cannot create error report %s: %s", name, err)
+	}
+	e.errorsFd = fd
+	e.errorsBuf = bufio.NewWriter(fd)
+	return nil
+}
+
+// Method closeErrors flushes and closes the report file of failed entries.
+func (e *engine) closeErrors() {
+	if e.errorsBuf == nil {
+		return
+	}
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	err := e.errorsBuf.Flush()
+	if err == nil {
+		err = e.errorsFd.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - cannot write error report %s: %s\n", e.errorsTo, err)
+	}
+}
+
+// Method reportError writes a failed entry to the report file, if one is
+// requested. Each line contains the path relative to the source directory,
+// the error category and the error message, separated by tabs. Since
+// -files-from ignores everything after a tab, the report can be used as file
+// list for a follow-up run.
+func (e *engine) reportError(err *Error) {
+	if e.errorsBuf == nil {
+		return
+	}
+	p := strings.TrimPrefix(err.Path, "/")
+	if p == "" {
+		p = "."
+	}
+	cat := categoryName(err)
+	if cat == "" {
+		cat = "other"
+	}
+	msg := strings.Replace(err.Error(), "\n", " ", -1)
+
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	fmt.Fprintf(e.errorsBuf, "%s\t%s\t%s\n", p, cat, msg)
+}
+
+// Method readFileList reads the list of paths given with -files-from. The
+// name "-" stands for STDIN. Paths are relative to the source directory, one
+// per line. Empty lines and lines starting with "#" are ignored, as well as
+// everything after a tab. With -from0, the paths are separated by NUL
+// characters instead, like the output of "find -print0", and taken as they
+// are, so that they may contain newlines, tabs and leading "#".
+func (e *engine) readFileList(name string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		fd, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file list %s: %s", name, err)
+		}
+		defer fd.Close()
+		r = fd
+	}
+
+	var list []string
+	sc := bufio.NewScanner(r)
+	if e.from0 {
+		sc.Split(scanNul)
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		if !e.from0 {
+			line = strings.TrimRight(line, "\r")
+			if i := strings.IndexByte(line, '\t'); i >= 0 {
+				line = line[:i]
+			}
+			if line != "" && line[0] == '#' {
+				continue
+			}
+		}
+		if line == "" {
+			continue
+		}
+		p := path.Clean("/" + line)
+		if p == "/" {
+			p = ""
+		}
+		list = append(list, p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read file list %s: %s", name, err)
+	}
+	return list, nil
+}
+
+// Function scanNul is a split function of bufio.Scanner, which returns the
+// NUL terminated items of the input. The last item may lack the NUL.
+func scanNul(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Method queueFileList hands the paths of the file list to the copy
+// threads. Directories are copied recursively. Other entries are grouped by
+// their parent directory, so that each directory is read by a single copy
+// thread. Missing parent directories are created on the destination side.
+// Entries below a listed directory are skipped, since they are copied with
+// the directory anyway.
+func (e *engine) queueFileList(list []string) {
+	dirs := make(map[string]bool)
+	for _, p := range list {
+		if p == "" {
+			dirs[p] = true
+			continue
+		}
+		if f, err := e.source.Lstat(p); err == nil && f.IsDir() {
+			dirs[p] = true
+		}
+	}
+	below := func(p string) bool {
+		if dirs[""] && p != "" {
+			return true
+		}
+		for i := 1; i < len(p); i++ {
+			if p[i] == '/' && dirs[p[:i]] {
+				return true
+			}
+		}
+		return false
+	}
+
+	queued := make(map[string]bool)
+	names := make(map[string][]string)
+	var order []string
+	for _, p := range list {
+		if below(p) {
+			continue
+		}
+		if dirs[p] {
+			if queued[p] {
+				continue
+			}
+			queued[p] = true
+			if p != "" {
+				e.mkdirs(p)
+			}
+			e.wg.Add(1)
+			e.pool.Submit(job{dir: p})
+			continue
+		}
+		if _, err := e.source.Lstat(p); err != nil {
+			e.warning(p, "file %s could not be read: %s", e.src+p, err)
+			continue
+		}
+		parent := path.Dir(p)
+		if parent == "/" {
+			parent = ""
+		}
+		if _, ok := names[parent]; !ok {
+			order = append(order, parent)
+			e.mkdirs(parent)
+		}
+		names[parent] = append(names[parent], path.Base(p))
+	}
+
+	for _, dir := range order {
+		e.wg.Add(1)
+		e.pool.Submit(job{dir: dir, names: names[dir]})
+	}
+}
+
+// Method mkdirs creates a directory and its parents on the destination
+// side, with the permissions of the corresponding source directories.
+func (e *engine) mkdirs(dir string) {
+	if dir == "" || e.shardNew != nil {
+		return
+	}
+	for i := 1; i <= len(dir); i++ {
+		if i < len(dir) && dir[i] != '/' {
+			continue
+		}
+		perm := os.FileMode(0755)
+		f, err := e.source.Stat(dir[:i])
+		if err == nil {
+			perm = e.createMode(f)
+		}
+		e.op()
+		if err := e.destination.Mkdir(dir[:i], perm); err == nil {
+			if e.perms && f != nil {
+				e.preservePerms(dir[:i], dir[:i], f, "directory")
+			}
+		} else if !os.IsExist(err) {
+			e.warning(dir[:i], "could not create directory %s: %s", e.dest+dir[:i], err)
+			return
+		}
+	}
+}
+
+// Method selectEntries returns the file information of the given entries of
+// a directory, as selected by -files-from.
+func (e *engine) selectEntries(dir string, names []string) []os.FileInfo {
+	var files []os.FileInfo
+	for _, name := range names {
+		var f os.FileInfo
+		err := e.retry(dir+"/"+name, func() (err error) {
+			e.op()
+			f, err = e.source.Lstat(dir + "/" + name)
+			return
+		})
+		if err != nil {
+			e.warning(dir+"/"+name, "file %s could not be read: %s", e.src+dir+"/"+name, err)
+			continue
+		}
+		files = append(files, f)
+	}
+	return files
+}
@@ -0,0 +1,177 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+)
+
+// Type lockOwner describes the holder of the lease on the destination
+// directory. It is stored in the lock directory.
+type lockOwner struct {
+	Host    string    `json:"host"`
+	Pid     int       `json:"pid"`
+	Session string    `json:"session"`
+	Started time.Time `json:"started"`
+}
+
+// Function newSession creates a random session token.
+func newSession() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d%d", os.Getpid(), time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Method tmpName returns a name for a temporary file in the directory dir,
+// derived from the name of the final file. The session token makes it unique
+// across concurrent runs on different hosts.
+func (e *engine) tmpName(dir, name string) string {
+	return fmt.Sprintf("%s/.%s.psync-%s-%d", dir, name, e.session, atomic.AddUint64(&e.tmpCount, 1))
+}
+
+// Method tempName returns a name for a temporary file, which is renamed to
+// the destination entry target when it is complete. With -temp-dir, it is
+// placed in the temporary directory, otherwise next to the entry.
+func (e *engine) tempName(target string) string {
+	if e.tempDir != "" {
+		return e.tmpName("/"+e.tempDir, path.Base(target))
+	}
+	return e.tmpName(path.Dir(target), path.Base(target))
+}
+
+// Method acquireLock takes the lease on the destination directory. The lease
+// is the lock directory .psync-lock, which is created atomically, even on
+// network file systems shared by several hosts. It holds the owner file, which
+// is rewritten periodically as heartbeat. If another run holds the lease, the
+// function waits until it is released, or until its heartbeat is older than
+// the lock timeout. In the latter case, the lease is taken over.
+func (e *engine) acquireLock() error {
+	e.lockDir = "/.psync-lock"
+	host, _ := os.Hostname()
+	owner := lockOwner{host, os.Getpid(), e.session, time.Now()}
+	waiting := false
+	for {
+		e.op()
+		err := e.destination.Mkdir(e.lockDir, os.FileMode(0755))
+		if err == nil {
+			e.writeOwner(owner)
+			go e.heartbeat(owner, e.lockTimeout/4, e.finished)
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create lock directory %s: %s", e.dest+e.lockDir, err)
+		}
+
+		// check the heartbeat of the current owner
+		other, age := e.readOwner()
+		if age > e.lockTimeout {
+			fmt.Fprintf(e.stderr, "WARNING - taking over stale lock of %s (pid %d, session %s), last heartbeat %s ago\n",
+				other.Host, other.Pid, other.Session, age.Round(time.Second))
+			stale := e.lockDir + ".stale-" + e.session
+			if e.destination.Rename(e.lockDir, stale) == nil {
+				removeAll(e.destination, stale)
+			}
+			continue
+		}
+		if !waiting {
+			waiting = true
+			fmt.Fprintf(e.stderr, "Destination %s is locked by %s (pid %d, session %s), waiting.\n",
+				e.dest, other.Host, other.Pid, other.Session)
+		}
+		select {
+		case <-time.After(e.lockTimeout / 4):
+		case <-e.stop:
+			return fmt.Errorf("interrupted while waiting for the lock on %s", e.dest)
+		}
+	}
+}
+
+// Method readOwner reads the owner file of the lock directory, and returns
+// the owner and the age of its last heartbeat. A missing owner file is aged
+// by the time the lock directory exists.
+func (e *engine) readOwner() (lockOwner, time.Duration) {
+	var owner lockOwner
+	name := e.lockDir + "/owner"
+	f, err := e.destination.Stat(name)
+	if err != nil {
+		f, err = e.destination.Stat(e.lockDir)
+		if err != nil {
+			return owner, 0
+		}
+		return owner, time.Since(f.ModTime())
+	}
+	if b, err := readFile(e.destination, name); err == nil {
+		json.Unmarshal(b, &owner)
+	}
+	return owner, time.Since(f.ModTime())
+}
+
+// Method writeOwner writes the owner file in the lock directory. The file is
+// written under a temporary name and renamed, so that other hosts never see a
+// partial file.
+func (e *engine) writeOwner(owner lockOwner) error {
+	b, _ := json.Marshal(owner)
+	tmp := e.tmpName(e.lockDir, "owner")
+	e.op()
+	if err := writeFile(e.destination, tmp, b, os.FileMode(0644)); err != nil {
+		return err
+	}
+	e.op()
+	return e.destination.Rename(tmp, e.lockDir+"/owner")
+}
+
+// Method heartbeat refreshes the owner file periodically. If the lease was
+// taken over by another run, e.g. after a network outage, psync stops after
+// the files in progress. It ends when the done channel is closed.
+func (e *engine) heartbeat(owner lockOwner, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+		e.lockMu.Lock()
+		if e.lockDir == "" {
+			e.lockMu.Unlock()
+			return
+		}
+		if other, _ := e.readOwner(); other.Session != "" && other.Session != e.session {
+			fmt.Fprintf(e.stderr, "ERROR - lock on %s was taken over by %s (pid %d, session %s), stopping.\n",
+				e.dest, other.Host, other.Pid, other.Session)
+			e.lockMu.Unlock()
+			e.halt()
+			return
+		}
+		if err := e.writeOwner(owner); err != nil {
+			fmt.Fprintf(e.stderr, "WARNING - cannot refresh lock %s: %s\n", e.dest+e.lockDir, err)
+		}
+		e.lockMu.Unlock()
+	}
+}
+
+// Method releaseLock removes the lock directory, if this run holds the
+// lease.
+func (e *engine) releaseLock() {
+	e.lockMu.Lock()
+	defer e.lockMu.Unlock()
+	if e.lockDir == "" {
+		return
+	}
+	if owner, _ := e.readOwner(); owner.Session == e.session {
+		removeAll(e.destination, e.lockDir)
+	}
+	e.lockDir = ""
+}
@@ -0,0 +1,14 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !windows
+// +build !windows
+
+package psync
+
+// Function longPath returns a local path. Only Windows limits the length of
+// paths, see longpath_windows.go.
+func longPath(p string) string {
+	return p
+}
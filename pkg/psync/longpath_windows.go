@@ -0,0 +1,29 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Function longPath returns the extended-length form (\\?\C:\dir\file) of a
+// local path. Extended-length paths are not limited to MAX_PATH (260
+// characters), and are taken literally by Windows, so that reserved names
+// like CON, NUL or COM1 and names ending in a dot or space can be copied from
+// other systems. Shares (\\server\share) become \\?\UNC\server\share.
+func longPath(p string) string {
+	if strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
@@ -0,0 +1,74 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"sync"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Type dirKey identifies a directory by device and inode.
+type dirKey struct {
+	dev, ino uint64
+}
+
+// Type dirSet records the directories of a tree which were entered, so that
+// a directory reached a second time through a bind mount is not descended
+// into again. A bind mount of a parent directory would otherwise repeat the
+// tree until the path length is exceeded.
+type dirSet struct {
+	e     *engine // engine of the run, for the warnings
+	mu    sync.Mutex
+	root  string // path of the tree, for messages
+	paths map[dirKey]string
+}
+
+// Method newDirSet returns an empty set of directories of the tree root.
+func (e *engine) newDirSet(root string) *dirSet {
+	return &dirSet{e: e, root: root, paths: make(map[dirKey]string)}
+}
+
+// Function dirKeyOf returns the device and inode of a directory. Trees
+// without inode numbers, like buckets, are not tracked.
+func dirKeyOf(f os.FileInfo) (dirKey, bool) {
+	st, ok := f.Sys().(*sysstat.Stat)
+	if !ok || st == nil || st.Ino == 0 {
+		return dirKey{}, false
+	}
+	return dirKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
+
+// Method visit records a directory of the tree. If it was already entered
+// under another path, it warns and returns false, and the directory must be
+// skipped.
+func (s *dirSet) visit(dir string, f os.FileInfo) bool {
+	key, ok := dirKeyOf(f)
+	if !ok {
+		return true
+	}
+	s.mu.Lock()
+	first, seen := s.paths[key]
+	if !seen {
+		s.paths[key] = dir
+	}
+	s.mu.Unlock()
+	if seen && first != dir {
+		s.e.warning(dir, "directory %s%s is the same as %s%s (bind mount or loop), skipped", s.root, dir, s.root, first)
+		return false
+	}
+	return true
+}
+
+// Method visitRoot starts the set of entered directories of a phase with
+// the top level directory of the source.
+func (e *engine) visitRoot() {
+	e.visited = e.newDirSet(e.src)
+	e.op()
+	if fi, err := e.source.Stat(""); err == nil {
+		e.visited.visit("", fi)
+	}
+}
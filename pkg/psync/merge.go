@@ -0,0 +1,169 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// Method openSources connects to all source trees. A local source which is
+// the destination itself is rejected. A local source which is a file, or a
+// symlink to a file, is copied as the only entry of its parent directory.
+func (e *engine) openSources(first FS) error {
+	e.sources = make([]FS, 0, len(e.srcs))
+	for i := range e.srcs {
+		e.src, e.source, e.nested, e.srcName = e.srcs[i], nil, "", e.srcNames[i]
+		if i == 0 {
+			e.source = first
+		}
+		if err := e.openSource(); err != nil {
+			return err
+		}
+		if l, ok := e.source.(localFS); ok && e.srcName == "" {
+			if f, err := os.Stat(string(l)); err == nil && !f.IsDir() {
+				e.srcs[i], e.srcNames[i] = path.Dir(string(l)), path.Base(string(l))
+				e.src, e.source, e.srcName = e.srcs[i], localFS(e.srcs[i]), e.srcNames[i]
+			}
+		}
+		e.sources = append(e.sources, e.source)
+		if e.localTrees() {
+			if err := e.nestedDest(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Method selectSource makes the i-th source tree the source of the next
+// phase, and resets the state which depends on the source.
+func (e *engine) selectSource(i int) error {
+	e.phase, e.src, e.source, e.nested = i, e.srcs[i], e.sources[i], ""
+	e.srcName = e.srcNames[i]
+	e.dirMu.Lock()
+	e.dirRules = make(map[string][]*rule)
+	e.dirMu.Unlock()
+	if e.localTrees() {
+		if err := e.nestedDest(); err != nil {
+			return err
+		}
+	}
+	if e.nested != "" && e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Excluding destination directory %s from the source tree %s\n", e.dest, e.src)
+	}
+	return nil
+}
+
+// Function splitSource splits a local source directory given without a
+// trailing slash into its parent directory, which becomes the source tree,
+// and its name, like rsync does. The directory itself is then copied into the
+// destination, instead of its content. Sources with a trailing slash, remote
+// trees, and names like "." and "/" are kept.
+func splitSource(tree string) (string, string) {
+	if strings.HasSuffix(tree, "/") || remote(tree) {
+		return tree, ""
+	}
+	name := path.Base(tree)
+	if name == "." || name == ".." || name == "/" {
+		return tree, ""
+	}
+	return path.Dir(tree), name
+}
+
+// Method syncSources copies each source in turn into the destination. With
+// -estimate, all sources are scanned first.
+func (e *engine) syncSources(dirs, list []string) error {
+	if e.estimate {
+		if err := e.estimateSources(); err != nil {
+			return err
+		}
+	}
+	for i := range e.sources {
+		if err := e.selectSource(i); err != nil {
+			return err
+		}
+		e.runPhase(func() { e.queueTree(dirs, list) })
+		if e.stopping() {
+			break
+		}
+	}
+	return nil
+}
+
+// Method runPhase copies the current source into the destination with all
+// copy threads, starting with the jobs submitted by queue. It returns when
+// all directories are handled, or the run was interrupted.
+func (e *engine) runPhase(queue func()) {
+	e.visitRoot()
+	e.startData()
+	e.startScanners()
+	e.startStealing()
+	e.startPool()
+	queue()
+
+	// wait for work queue to get empty, and for the threads to end
+	e.wg.Wait()
+	e.pool.Wait()
+	e.stopData()
+	e.stopScanners()
+	e.flushDirs()
+}
+
+// Method queueTree submits the top level directory, the directories left
+// over from an interrupted run, or the paths of the file list.
+func (e *engine) queueTree(dirs, list []string) {
+	if e.resume {
+		for _, dir := range dirs {
+			e.wg.Add(1)
+			e.pool.Submit(job{dir: dir})
+		}
+	} else if e.filesFrom != "" {
+		e.queueFileList(list)
+	} else {
+		e.wg.Add(1)
+		e.pool.Submit(job{dir: ""})
+	}
+}
+
+// Method earlierSource returns the earlier source tree which contains the
+// given entry, or "" if the entry is only in the current source.
+func (e *engine) earlierSource(file string) string {
+	for i := 0; i < e.phase; i++ {
+		e.op()
+		if _, err := e.sources[i].Lstat(file); err == nil {
+			return e.srcs[i]
+		}
+	}
+	return ""
+}
+
+// Method shadowed checks whether an entry of the current source was already
+// copied from an earlier source, and applies the conflict policy: with
+// "first", the entry is skipped; with "error", it is skipped and reported as
+// error; with "last", it replaces the entry of the earlier source.
+func (e *engine) shadowed(id uint, file string) bool {
+	if e.phase == 0 || e.conflicts == "last" {
+		return false
+	}
+	other := e.earlierSource(file)
+	if other == "" {
+		return false
+	}
+	if e.conflicts == "error" {
+		e.warn(&Error{file, fmt.Sprintf("%s%s conflicts with %s%s, keeping the first version", e.src, file, other, file), ErrConflict})
+	} else if e.verbose >= 2 {
+		fmt.Fprintf(e.stdout, "[%d] Skipping %s%s, already copied from %s\n", id, e.src, file, other)
+	}
+	return true
+}
+
+// Method mergedDir checks whether the metadata of a directory was already
+// set from an earlier source, and must be kept.
+func (e *engine) mergedDir(dir string) bool {
+	return e.phase > 0 && e.conflicts != "last" && e.earlierSource(dir) != ""
+}
@@ -0,0 +1,214 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hweidner/psync/pkg/norm"
+)
+
+// Type normFS wraps the destination tree, so that the names of the source
+// find the existing destination entries with the same name in another Unicode
+// normalization form, e.g. when copying from macOS (decomposed names) to Linux
+// (precomposed names). Otherwise, the entries would be copied again under a
+// second name. The names of each destination directory are read once, when a
+// name with non-ASCII characters is looked up in it. Besides, entries can be
+// renamed, e.g. when their names collide on a case-insensitive destination.
+type normFS struct {
+	FS
+	e       *engine                      // engine of the run, for the rate limit
+	match   bool                         // match names in other normalization forms
+	form    func(string) string          // normalization of new names, nil to keep them
+	mu      sync.Mutex                   // protects names and renames
+	names   map[string]map[string]string // names of the destination directories by their NFC form
+	renames map[string]string            // destination names of renamed source entries, by source path
+}
+
+// Method newNormFS wraps a destination tree for the given normalization, ""
+// for renaming entries only.
+func (e *engine) newNormFS(fs FS, mode string) *normFS {
+	n := &normFS{
+		FS:      fs,
+		e:       e,
+		match:   mode != "",
+		names:   make(map[string]map[string]string),
+		renames: make(map[string]string),
+	}
+	switch mode {
+	case "nfc":
+		n.form = norm.NFC
+	case "nfd":
+		n.form = norm.NFD
+	}
+	return n
+}
+
+// Method rename copies a source entry under another name into the same
+// destination directory.
+func (n *normFS) rename(file, name string) {
+	n.mu.Lock()
+	n.renames[file] = name
+	n.mu.Unlock()
+}
+
+// Method resolve returns the destination path of a source path, with the
+// names of existing and renamed entries.
+func (n *normFS) resolve(name string) string {
+	n.mu.Lock()
+	renamed := len(n.renames) > 0
+	n.mu.Unlock()
+	if !renamed && (!n.match || plainName(name)) {
+		return name
+	}
+	parts := strings.Split(name, "/")
+	dir, file := "", ""
+	for _, p := range parts[1:] {
+		file += "/" + p
+		n.mu.Lock()
+		r, ok := n.renames[file]
+		n.mu.Unlock()
+		if ok {
+			dir += "/" + r
+		} else {
+			dir += "/" + n.lookup(dir, p)
+		}
+	}
+	return dir
+}
+
+// Method lookup returns the name of the entry of a destination directory
+// which matches a source name. A name without match is normalized with the
+// form of new names, and remembered for the entry to be created.
+func (n *normFS) lookup(dir, name string) string {
+	if !n.match || plainName(name) {
+		return name
+	}
+	key := norm.NFC(name)
+	n.mu.Lock()
+	names, ok := n.names[dir]
+	n.mu.Unlock()
+	if !ok {
+		names = make(map[string]string)
+		n.e.op()
+		if files, err := n.FS.ReadDir(dir); err == nil {
+			for _, f := range files {
+				names[norm.NFC(f.Name())] = f.Name()
+			}
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if cached, ok := n.names[dir]; ok {
+		// read by another thread meanwhile
+		names = cached
+	}
+	n.names[dir] = names
+	if actual, ok := names[key]; ok {
+		return actual
+	}
+	if n.form != nil {
+		name = n.form(name)
+	}
+	names[key] = name
+	return name
+}
+
+// Function plainName checks whether a name consists of ASCII characters only,
+// or is not valid UTF-8, so that it is not changed by normalization.
+func plainName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return !utf8.ValidString(s)
+		}
+	}
+	return true
+}
+
+// Method ReadDir reads a destination directory.
+func (n *normFS) ReadDir(name string) ([]os.FileInfo, error) {
+	return n.FS.ReadDir(n.resolve(name))
+}
+
+// Method Lstat returns the attributes of a destination entry.
+func (n *normFS) Lstat(name string) (os.FileInfo, error) {
+	return n.FS.Lstat(n.resolve(name))
+}
+
+// Method Stat returns the attributes of a destination entry, following links.
+func (n *normFS) Stat(name string) (os.FileInfo, error) {
+	return n.FS.Stat(n.resolve(name))
+}
+
+// Method Readlink returns the target of a destination link.
+func (n *normFS) Readlink(name string) (string, error) {
+	return n.FS.Readlink(n.resolve(name))
+}
+
+// Method Open opens a destination file for reading.
+func (n *normFS) Open(name string) (File, error) {
+	return n.FS.Open(n.resolve(name))
+}
+
+// Method OpenFile opens or creates a destination file.
+func (n *normFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return n.FS.OpenFile(n.resolve(name), flag, perm)
+}
+
+// Method Mkdir creates a destination directory.
+func (n *normFS) Mkdir(name string, perm os.FileMode) error {
+	return n.FS.Mkdir(n.resolve(name), perm)
+}
+
+// Method MkdirAll creates a destination directory and its parents.
+func (n *normFS) MkdirAll(name string, perm os.FileMode) error {
+	return n.FS.MkdirAll(n.resolve(name), perm)
+}
+
+// Method Symlink creates a destination link. The link target is not changed.
+func (n *normFS) Symlink(oldname, newname string) error {
+	return n.FS.Symlink(oldname, n.resolve(newname))
+}
+
+// Method Rename renames a destination entry.
+func (n *normFS) Rename(oldname, newname string) error {
+	return n.FS.Rename(n.resolve(oldname), n.resolve(newname))
+}
+
+// Method Remove removes a destination entry.
+func (n *normFS) Remove(name string) error {
+	return n.FS.Remove(n.resolve(name))
+}
+
+// Method Chown changes the owner of a destination entry.
+func (n *normFS) Chown(name string, uid, gid int) error {
+	return n.FS.Chown(n.resolve(name), uid, gid)
+}
+
+// Method Lchown changes the owner of a destination entry, not following links.
+func (n *normFS) Lchown(name string, uid, gid int) error {
+	return n.FS.Lchown(n.resolve(name), uid, gid)
+}
+
+// Method Chtimes changes the time stamps of a destination entry.
+func (n *normFS) Chtimes(name string, atime, mtime time.Time) error {
+	return n.FS.Chtimes(n.resolve(name), atime, mtime)
+}
+
+// Method Chmod changes the permissions of a destination entry, if the wrapped
+// tree supports it.
+func (n *normFS) Chmod(name string, mode os.FileMode) error {
+	c, ok := n.FS.(chmodFS)
+	if !ok {
+		return &os.PathError{Op: "chmod", Path: name, Err: syscall.ENOTSUP}
+	}
+	return c.Chmod(n.resolve(name), mode)
+}
@@ -0,0 +1,52 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNormLookup looks up source names in a destination directory, which
+// holds the entries "café" precomposed (NFC) and "naïve" decomposed (NFD).
+func TestNormLookup(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for _, name := range []string{"caf\u00e9", "nai\u0308ve"} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, nt := range []struct {
+		mode, name, want string
+	}{
+		// existing entries are found in the other form
+		{"nfd", "caf\u00e9", "caf\u00e9"},
+		{"nfd", "cafe\u0301", "caf\u00e9"},
+		{"nfc", "na\u00efve", "nai\u0308ve"},
+		{"match", "cafe\u0301", "caf\u00e9"},
+		// new names are converted, unless they are only matched
+		{"nfc", "u\u0308ber", "\u00fcber"},
+		{"nfd", "\u00fcber", "u\u0308ber"},
+		{"match", "u\u0308ber", "u\u0308ber"},
+		// ASCII and invalid UTF-8 names are taken as they are
+		{"nfc", "plain", "plain"},
+		{"nfc", "caf\xe9", "caf\xe9"},
+		// without normalization, no names are matched
+		{"", "cafe\u0301", "cafe\u0301"},
+	} {
+		e := &engine{}
+		n := e.newNormFS(localFS(root), nt.mode)
+		if got := n.lookup("", nt.name); got != nt.want {
+			t.Errorf("%s lookup of %+q: got %+q, want %+q", nt.mode, nt.name, got, nt.want)
+		}
+	}
+}
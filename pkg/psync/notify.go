@@ -0,0 +1,94 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// notifyTimeout is the time the endpoint of -notify-url has to answer.
+const notifyTimeout = 30 * time.Second
+
+// Type notifyMessage is the message sent at the end of a run: its outcome,
+// and the summary of the run, as written by -stats-out.
+type notifyMessage struct {
+	Status string `json:"status"`          // ok, errors, interrupted or failed
+	Error  string `json:"error,omitempty"` // error which failed or stopped the run
+	statsFile
+}
+
+// Method notify tells the endpoint of -notify-url and the command of
+// -notify-cmd about the outcome of a run, which ended with the error err.
+// Failures of the notification are printed, but do not fail the run.
+func (e *engine) notify(o Options, err error) {
+	if e.end.IsZero() {
+		// the run failed before its end, or before its start
+		e.end = time.Now()
+		if e.start.IsZero() {
+			e.start = e.end
+		}
+	}
+	m := notifyMessage{Status: e.runStatus(err), statsFile: e.newStatsFile(o, err == ErrInterrupted)}
+	if err != nil {
+		m.Error = err.Error()
+	}
+	b, err := json.Marshal(&m)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - could not encode notification: %s\n", err)
+		return
+	}
+
+	if e.notifyURL != "" {
+		if err := e.notifyPost(b); err != nil {
+			fmt.Fprintf(e.stderr, "ERROR - could not notify %s: %s\n", e.notifyURL, err)
+		} else if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "Notified %s of the run (%s)\n", e.notifyURL, m.Status)
+		}
+	}
+	if e.notifyCmd != "" {
+		cmd := shellCommand(e.notifyCmd)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(b), e.stdout, e.stderr
+		cmd.Env = append(os.Environ(), "PSYNC_STATUS="+m.Status)
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(e.stderr, "ERROR - notification command %s failed: %s\n", e.notifyCmd, err)
+		} else if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "Notified %s of the run (%s)\n", e.notifyCmd, m.Status)
+		}
+	}
+}
+
+// Method runStatus returns the outcome of a run which ended with the error
+// err: ok, errors (on single entries), interrupted or failed.
+func (e *engine) runStatus(err error) string {
+	switch {
+	case err == ErrInterrupted:
+		return "interrupted"
+	case err != nil:
+		return "failed"
+	case atomic.LoadUint64(&e.total.errors) > 0:
+		return "errors"
+	}
+	return "ok"
+}
+
+// Method notifyPost posts the message to the endpoint of -notify-url.
+func (e *engine) notifyPost(b []byte) error {
+	c := &http.Client{Timeout: notifyTimeout}
+	resp, err := c.Post(e.notifyURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"sort"
+)
+
+// Function sortNames sorts the entries of a directory by name for
+// -deterministic, before they are sorted by size for -order.
+func sortNames(files []os.FileInfo) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+}
+
+// Method sortEntries sorts the entries of a directory for -order. The
+// subdirectories come first, so that they are handed to the other copy
+// threads before the files are copied. Files of the same size keep the order
+// of the directory.
+func (e *engine) sortEntries(files []os.FileInfo) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if a.IsDir() || b.IsDir() {
+			return a.IsDir() && !b.IsDir()
+		}
+		if e.order == "large" {
+			return a.Size() > b.Size()
+		}
+		return a.Size() < b.Size()
+	})
+}
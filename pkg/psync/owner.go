@@ -0,0 +1,139 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Type ownerNamer is implemented by the file information of remote trees,
+// which know the names of the owner of an entry on the remote host.
+type ownerNamer interface {
+	Owner() (user, group string)
+}
+
+// Function parseChown parses the argument of the -chown option, "user",
+// "user:group" or ":group", with names or numeric IDs. Names are looked up on
+// the local host.
+func parseChown(s string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+	u, g := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		u, g = s[:i], s[i+1:]
+	}
+	if u == "" && g == "" {
+		return -1, -1, fmt.Errorf("invalid -chown argument %q", s)
+	}
+	if u != "" {
+		if uid, err = lookupID(u, false); err != nil {
+			return -1, -1, err
+		}
+	}
+	if g != "" {
+		if gid, err = lookupID(g, true); err != nil {
+			return -1, -1, err
+		}
+	}
+	return uid, gid, nil
+}
+
+// Function lookupID returns the numeric ID of a user or group, given by name
+// or number.
+func lookupID(name string, group bool) (int, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return int(id), nil
+	}
+	var id string
+	if group {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return -1, err
+		}
+		id = g.Gid
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return -1, err
+		}
+		id = u.Uid
+	}
+	return strconv.Atoi(id)
+}
+
+// Method destOwner returns the owner of the destination entry of a source
+// entry, translated by -usermap and -groupmap, or changed by -chown. An ID is -1 if it is not changed. It returns false
+// if neither is changed.
+func (e *engine) destOwner(f os.FileInfo) (uid, gid int, ok bool) {
+	uid, gid = -1, -1
+	if fi, ok := f.(*fakeInfo); ok && e.sourceOwner {
+		uid, gid = fi.uid, fi.gid
+	} else if stat, ok := f.Sys().(*sysstat.Stat); ok && e.sourceOwner {
+		uid, gid = int(stat.Uid), int(stat.Gid)
+	}
+	if e.sourceOwner {
+		var user, group string
+		if n, ok := f.(ownerNamer); ok && !e.numericIDs && e.localDest() {
+			user, group = n.Owner()
+		}
+		uid = e.mapID(e.userMap, uid, user, false)
+		gid = e.mapID(e.groupMap, gid, group, true)
+	}
+	if e.chownUID >= 0 {
+		uid = e.chownUID
+	}
+	if e.chownGID >= 0 {
+		gid = e.chownGID
+	}
+	return uid, gid, uid >= 0 || gid >= 0
+}
+
+// Method mapID translates a user or group ID of the source by the map. If
+// no rule matches, and the name of the ID on the source is known, it is
+// translated into the ID of the same name on the destination host. IDs
+// without a local name are kept, like in rsync.
+func (e *engine) mapID(m idMap, id int, name string, group bool) int {
+	if to, ok := m.lookup(id); ok || name == "" {
+		return to
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		// the server does not know the name either
+		return id
+	}
+	e.namesMu.Lock()
+	defer e.namesMu.Unlock()
+	ids := e.userIDs
+	if group {
+		ids = e.groupIDs
+	}
+	local, ok := ids[name]
+	if !ok {
+		var err error
+		if local, err = lookupID(name, group); err != nil {
+			local = -1
+		}
+		ids[name] = local
+	}
+	if local < 0 {
+		return id
+	}
+	return local
+}
+
+// Method localDest checks whether the destination is on the local host,
+// whose names can be looked up.
+func (e *engine) localDest() bool {
+	fs := e.destination
+	if n, ok := fs.(*normFS); ok {
+		fs = n.FS
+	}
+	_, ok := fs.(localFS)
+	return ok
+}
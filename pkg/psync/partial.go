@@ -0,0 +1,67 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// Method partialName returns the name of the partial file of a destination
+// file, and creates its directory. Within the partial directory, the partial
+// files have the same paths as their destination files, so that the next run
+// finds the partial file of an interrupted copy, with its chunk journal.
+func (e *engine) partialName(target string) (string, error) {
+	name := "/" + e.partialDir + target
+	e.op()
+	return name, e.destination.MkdirAll(path.Dir(name), os.FileMode(0700))
+}
+
+// Method copyPartial copies the content of a regular file to its partial
+// file, and moves the partial file to the destination when it is complete.
+// It returns the number of bytes copied, and an *Error if the copy failed.
+func (e *engine) copyPartial(id uint, c *fileCopy, file, target string, f os.FileInfo) (int64, error) {
+	name, err := e.partialName(target)
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("partial directory of %s could not be created: %s", e.dest+target, err), err}
+	}
+	var n int64
+	if e.chunkSize > 0 && f.Size() > int64(e.chunkSize)*1000000 {
+		n, err = e.copyChunked(id, c, file, name, f)
+	} else {
+		n, err = e.copyData(id, c, file, name, f)
+	}
+	if err != nil {
+		return n, err
+	}
+	if c.abandoned() {
+		// the watchdog removes the partial file
+		return n, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, errAbandoned), ErrTimeout}
+	}
+	e.op()
+	if err := e.destination.Rename(name, target); err != nil {
+		return n, &Error{file, fmt.Sprintf("file %s could not be moved from %s: %s", e.dest+target, e.dest+name, err), err}
+	}
+	return n, nil
+}
+
+// Method removePartialDirs removes the empty directories of the partial
+// directory after a complete run. Directories still holding the partial files
+// of failed copies are kept.
+func (e *engine) removePartialDirs(dir string) {
+	e.op()
+	files, err := e.destination.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			e.removePartialDirs(dir + "/" + f.Name())
+		}
+	}
+	e.op()
+	e.destination.Remove(dir)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+)
+
+// Type chmodFS is implemented by the backends which can change the
+// permissions of an entry. The other backends, like buckets and archives,
+// keep the permissions an entry is created with, regardless of the umask.
+type chmodFS interface {
+	Chmod(name string, mode os.FileMode) error
+}
+
+// Method canChmod checks whether the permissions of destination entries
+// can be changed.
+func (e *engine) canChmod() bool {
+	fs := e.destination
+	if n, ok := fs.(*normFS); ok {
+		fs = n.FS
+	}
+	_, ok := fs.(chmodFS)
+	return ok
+}
+
+// Method createMode returns the permissions a destination entry is created
+// with. If the permissions of the source are applied afterwards, files are
+// created readable and writable by the owner only, and directories
+// accessible by the owner only, so that nobody else can open them in the
+// meantime. Otherwise, the permissions of the source, changed by the -chmod
+// rules, are given, and reduced by the umask of the process.
+func (e *engine) createMode(f os.FileInfo) os.FileMode {
+	switch {
+	case !e.perms || !e.canChmod():
+		return e.destPerm(f)
+	case f.IsDir():
+		return 0700
+	default:
+		return 0600
+	}
+}
+
+// Method preservePerms transfers the permissions from the source to the
+// destination file/directory. The name is relative to the destination.
+func (e *engine) preservePerms(file, name string, f os.FileInfo, ftype string) {
+	c, ok := e.destination.(chmodFS)
+	if !ok || !e.canChmod() {
+		return
+	}
+	perm := e.destPerm(f)
+	if e.verbose >= 2 {
+		fmt.Fprintf(e.stdout, "Changing permissions of %s %s to %#o\n", ftype, e.dest+name, perm)
+	}
+	before := e.auditBefore(e.destination, name)
+	e.op()
+	if err := c.Chmod(name, perm); err != nil {
+		e.metaWarning(file, "could not change permissions of %s %s: %s", ftype, e.dest+name, err)
+	} else {
+		e.audit("chmod", e.destination, e.dest, name, before)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Type dirState tracks a directory whose files are copied by the data
+// threads. The metadata of the destination directory is applied when its
+// last file is done, by the thread which finished it.
+type dirState struct {
+	e       *engine // engine of the run
+	j       job
+	pending int32 // files not yet done, plus one for the reading copy thread
+	partial int32 // some entries were not handled due to an interruption
+}
+
+// Method release marks a file of the directory, or the reading of the
+// directory, as done. The last one finishes the directory.
+func (d *dirState) release(id uint) {
+	if atomic.AddInt32(&d.pending, -1) == 0 {
+		d.e.finishDir(id, d)
+	}
+}
+
+// Type fileJob is a file handed over to the data threads.
+type fileJob struct {
+	file string      // path relative to the source tree
+	info os.FileInfo // file information of the source file
+	dir  *dirState   // directory of the file
+}
+
+// Method startData starts the data threads of a phase, if requested.
+func (e *engine) startData() {
+	if e.dataThreads == 0 {
+		e.fch = nil
+		return
+	}
+	e.fch = make(chan fileJob, 100)
+	e.dataWg.Add(int(e.dataThreads))
+	for i := uint(0); i < e.dataThreads; i++ {
+		go e.copyFiles(e.threads + i)
+	}
+}
+
+// Method stopData waits for the data threads of a phase to end, after all
+// directories are handled.
+func (e *engine) stopData() {
+	if e.fch != nil {
+		close(e.fch)
+		e.dataWg.Wait()
+	}
+}
+
+// Method copyFiles receives files on the file channel and copies them. The
+// data thread ends when the file channel is closed. When psync is
+// interrupted, the files already handed over are still copied, so that the
+// directories read completely need not be resumed.
+func (e *engine) copyFiles(id uint) {
+	defer e.dataWg.Done()
+	for fj := range e.fch {
+		begin := time.Now()
+		e.setBusy(id, e.src+fj.file)
+		e.copyFile(id, fj.file, fj.info)
+		e.setBusy(id, "")
+		e.countBusy(id, time.Since(begin))
+		fj.dir.release(id)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Type listing is the prefetched content of a directory. Whoever claims it
+// first reads the directory: a scanner, or the copy thread taking the
+// directory before a scanner got to it.
+type listing struct {
+	dir   string
+	state int32         // listingFree, listingScanned or listingTaken (atomic)
+	done  chan struct{} // closed when a scanner has read the directory
+	files []os.FileInfo
+	err   error
+}
+
+// States of a listing
+const (
+	listingFree    = iota // not claimed yet
+	listingScanned        // claimed by a scanner
+	listingTaken          // claimed by the copy thread
+)
+
+// Method startScanners starts the scanners of a phase, if requested.
+func (e *engine) startScanners() {
+	if e.scanners == 0 || e.watch != nil || e.estimate {
+		// watched directories must be read after the watch is added, and
+		// the scan of -estimate has listed the directories already
+		e.pch = nil
+		return
+	}
+	e.pch = make(chan *listing, 4*e.scanners)
+	e.scannerWg.Add(int(e.scanners))
+	for i := uint(0); i < e.scanners; i++ {
+		go e.scan()
+	}
+}
+
+// Method stopScanners waits for the scanners of a phase to end, after all
+// directories are handled.
+func (e *engine) stopScanners() {
+	if e.pch != nil {
+		close(e.pch)
+		e.scannerWg.Wait()
+	}
+}
+
+// Method prefetch offers the directory of a job to the scanners. If all
+// scanners are busy, and their queue is full, the copy thread reads the
+// directory itself.
+func (e *engine) prefetch(j job) job {
+	if e.pch == nil {
+		return j
+	}
+	l := &listing{dir: j.dir, done: make(chan struct{})}
+	select {
+	case e.pch <- l:
+		j.list = l
+	default:
+	}
+	return j
+}
+
+// Method scan receives directories on the prefetch channel, and reads those
+// which were not taken by a copy thread yet. The scanner ends when the
+// prefetch channel is closed.
+func (e *engine) scan() {
+	defer e.scannerWg.Done()
+	for l := range e.pch {
+		if e.stopping() || !atomic.CompareAndSwapInt32(&l.state, listingFree, listingScanned) {
+			continue
+		}
+		l.err = e.retry(l.dir, func() (err error) {
+			e.op()
+			l.files, err = e.source.ReadDir(l.dir)
+			return
+		})
+		close(l.done)
+	}
+}
+
+// Method entries returns the prefetched entries of the directory, waiting for
+// a scanner which is reading it. It returns false if no scanner has claimed
+// the directory, which is then read by the copy thread.
+func (l *listing) entries() ([]os.FileInfo, bool, error) {
+	if l == nil || atomic.CompareAndSwapInt32(&l.state, listingFree, listingTaken) {
+		return nil, false, nil
+	}
+	<-l.done
+	return l.files, true, l.err
+}
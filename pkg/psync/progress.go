@@ -0,0 +1,312 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SAMPLES is the number of one second samples used for the moving average
+// of the throughput.
+const SAMPLES = 10
+
+// Type fileCopy is the copy of a file's content by a copy thread. It counts
+// its own progress, and keeps track of the files it opened, so that the
+// watchdog can abort a copy without progress by closing them, while the copy
+// thread moves on to the next file.
+type fileCopy struct {
+	e    *engine // engine of the run
+	buf  []byte  // copy buffer
+	sum  []byte  // checksum of the file, computed before or while copying
+	done uint64  // bytes of the file written so far (atomic)
+
+	mu      sync.Mutex
+	files   map[io.Closer]string // open files, with their destination path, or "" for the source
+	aborted bool                 // the copy was abandoned by the watchdog
+}
+
+// errAbandoned is the error of the operations of an abandoned copy.
+var errAbandoned = errors.New("copy abandoned")
+
+// Method newCopy registers a new copy of a file's content by a copy thread,
+// for the progress of large files.
+func (e *engine) newCopy(id uint, buf []byte) *fileCopy {
+	c := &fileCopy{e: e, buf: buf, sum: e.fileSums[id], files: make(map[io.Closer]string)}
+	e.busyMu.Lock()
+	e.copies[id] = c
+	e.busyMu.Unlock()
+	return c
+}
+
+// Method track registers a file opened by the copy, with its path in the
+// destination if it is written. If the copy was abandoned meanwhile, e.g.
+// after a stuck open, the file is closed and removed again, so that no
+// abandoned copy writes to the destination.
+func (c *fileCopy) track(f io.Closer, target string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.aborted {
+		f.Close()
+		if target != "" {
+			c.e.destination.Remove(target)
+		}
+		return errAbandoned
+	}
+	c.files[f] = target
+	return nil
+}
+
+// Method close closes a file of the copy, unless the watchdog closed it
+// already.
+func (c *fileCopy) close(f io.Closer) error {
+	c.mu.Lock()
+	_, open := c.files[f]
+	delete(c.files, f)
+	c.mu.Unlock()
+	if !open {
+		return errAbandoned
+	}
+	return f.Close()
+}
+
+// Method abandoned checks whether the copy was abandoned by the watchdog.
+func (c *fileCopy) abandoned() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.aborted
+}
+
+// Method abort abandons the copy: its open files are closed, so that the
+// blocked or following reads and writes fail, and the partially written
+// destination files are removed.
+func (c *fileCopy) abort() {
+	c.mu.Lock()
+	files := c.files
+	c.files, c.aborted = nil, true
+	c.mu.Unlock()
+
+	// closing and removing may block as well, e.g. on a hung NFS server
+	go func() {
+		for f, target := range files {
+			f.Close()
+			if target != "" {
+				c.e.destination.Remove(target)
+			}
+		}
+	}()
+}
+
+// Type progressWriter is an io.Writer which counts the bytes written through
+// it, so that the progress of large files is visible while they are copied.
+type progressWriter struct {
+	w      io.Writer
+	done   *uint64 // bytes written to the current file
+	copied *uint64 // bytes written by the run
+}
+
+// Method Write writes to the underlying writer and counts the bytes written.
+func (p progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	atomic.AddUint64(p.copied, uint64(n))
+	atomic.AddUint64(p.done, uint64(n))
+	return n, err
+}
+
+// Method setBusy records the path a copy thread is currently working on.
+// An empty path marks the thread as idle.
+func (e *engine) setBusy(id uint, path string) {
+	e.busyMu.Lock()
+	e.busy[id] = path
+	e.busySince[id] = time.Now()
+	e.busyMu.Unlock()
+}
+
+// Method setFile records the size of the regular file a copy thread is
+// copying. A size of 0 marks the end of the copy.
+func (e *engine) setFile(id uint, size int64) {
+	e.busyMu.Lock()
+	e.fileSize[id] = size
+	if size == 0 {
+		e.copies[id] = nil
+	}
+	e.busyMu.Unlock()
+}
+
+// Method largeFiles returns the progress of the files larger than the
+// -file-progress threshold which are currently copied, with percentage,
+// average throughput and estimated time to completion.
+func (e *engine) largeFiles() []string {
+	if e.fileProgress == 0 {
+		return nil
+	}
+	now := time.Now()
+	var res []string
+
+	e.busyMu.Lock()
+	defer e.busyMu.Unlock()
+	for i, size := range e.fileSize {
+		if size <= int64(e.fileProgress)*1e6 {
+			continue
+		}
+		var done uint64
+		if c := e.copies[i]; c != nil {
+			done = atomic.LoadUint64(&c.done)
+		}
+		d := now.Sub(e.busySince[i])
+		rate := float64(done) / d.Seconds()
+		eta := "unknown"
+		if rate > 0 && uint64(size) >= done {
+			eta = time.Duration(float64(uint64(size)-done) / rate * float64(time.Second)).Round(time.Second).String()
+		}
+		res = append(res, fmt.Sprintf("%s: %.1f%% of %.1f MB, %.2f MB/s, ETA %s",
+			e.busy[i], 100*float64(done)/float64(size), float64(size)/1e6, rate/1e6, eta))
+	}
+	return res
+}
+
+// Method blocked returns a description of the paths the copy threads are
+// currently working on, sorted by the time they are busy with it.
+func (e *engine) blocked() []string {
+	now := time.Now()
+	type entry struct {
+		path string
+		d    time.Duration
+	}
+	var list []entry
+
+	e.busyMu.Lock()
+	for i, p := range e.busy {
+		if p != "" {
+			list = append(list, entry{p, now.Sub(e.busySince[i])})
+		}
+	}
+	e.busyMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].d > list[j].d })
+	res := make([]string, len(list))
+	for i, b := range list {
+		res[i] = fmt.Sprintf("%s (%s)", b.path, b.d.Round(time.Second))
+	}
+	return res
+}
+
+// Method monitor samples the number of copied bytes and entries once per
+// second. It computes a moving average of the throughput, which is printed
+// periodically in progress mode, and warns when no progress was made for
+// the stall timeout. It ends when the done channel is closed.
+func (e *engine) monitor(done <-chan struct{}) {
+	var samples [SAMPLES]uint64
+	var n, lastBytes, lastEntries uint64
+	lastChange := time.Now()
+	lastWarn := time.Time{}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case now = <-ticker.C:
+		case <-done:
+			return
+		}
+		bytes := atomic.LoadUint64(&e.copied)
+		entries := atomic.LoadUint64(&e.total.dirs) + atomic.LoadUint64(&e.total.files) +
+			atomic.LoadUint64(&e.total.links) + atomic.LoadUint64(&e.total.errors)
+
+		// moving average over the last SAMPLES seconds
+		samples[n%SAMPLES] = bytes - lastBytes
+		n++
+		var sum uint64
+		for _, s := range samples {
+			sum += s
+		}
+		cnt := n
+		if cnt > SAMPLES {
+			cnt = SAMPLES
+		}
+		rate := float64(sum) / float64(cnt)
+
+		if bytes != lastBytes || entries != lastEntries || e.waiting() {
+			lastChange = now
+		}
+		lastBytes, lastEntries = bytes, entries
+
+		if e.progress && n%5 == 0 {
+			fmt.Fprintf(e.stderr, "PROGRESS - %d dirs, %d files, %.1f MB copied, %.2f MB/s, %d dirs queued\n",
+				atomic.LoadUint64(&e.total.dirs), atomic.LoadUint64(&e.total.files),
+				float64(bytes)/1e6, rate/1e6, atomic.LoadInt64(&e.queueLen))
+			if e.estimate {
+				fmt.Fprintf(e.stderr, "PROGRESS - %s\n", e.estimated(rate))
+			}
+		}
+		if (e.progress || e.verbose >= 1) && n%5 == 0 {
+			for _, l := range e.largeFiles() {
+				fmt.Fprintf(e.stderr, "PROGRESS - %s\n", l)
+			}
+		}
+
+		// stall detection
+		if e.stall > 0 && !e.quiet && now.Sub(lastChange) >= e.stall && now.Sub(lastWarn) >= e.stall {
+			lastWarn = now
+			fmt.Fprintf(e.stderr, "WARNING - no progress for %s, blocked on:\n\t%s\n",
+				now.Sub(lastChange).Round(time.Second), strings.Join(e.blocked(), "\n\t"))
+		}
+	}
+}
+
+// Method watchdog runs the copy of a file's content. With a file timeout,
+// the copy runs in a separate goroutine, and is abandoned if it makes no
+// progress for the timeout, e.g. on a hung NFS server or a stuck open. The
+// files of the abandoned copy are closed, which makes its reads and writes
+// fail, and its partially written files are removed. The copy thread then
+// moves on, and the abandoned copy returns its buffer to the pool only when
+// it ends. The checksum of the copy is taken over into fileSums only if the
+// copy ended, so that an abandoned copy cannot overwrite the next file's.
+func (e *engine) watchdog(id uint, file string, copy func(c *fileCopy) (int64, error)) (int64, error) {
+	buf := getBuffer()
+	c := e.newCopy(id, *buf)
+	if e.fileTimeout <= 0 {
+		defer putBuffer(buf)
+		n, err := copy(c)
+		e.fileSums[id] = c.sum
+		return n, err
+	}
+
+	type result struct {
+		n   int64
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := copy(c)
+		putBuffer(buf)
+		done <- result{n, err}
+	}()
+
+	ticker := time.NewTicker(e.fileTimeout / 4)
+	defer ticker.Stop()
+	last, since := atomic.LoadUint64(&c.done), time.Now()
+	for {
+		select {
+		case r := <-done:
+			e.fileSums[id] = c.sum
+			return r.n, r.err
+		case now := <-ticker.C:
+			if d := atomic.LoadUint64(&c.done); d != last {
+				last, since = d, now
+			} else if now.Sub(since) >= e.fileTimeout {
+				c.abort()
+				return 0, &Error{file, fmt.Sprintf("copy of file %s abandoned after no progress for %s", e.src+file, e.fileTimeout), ErrTimeout}
+			}
+		}
+	}
+}
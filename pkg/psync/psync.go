@@ -0,0 +1,788 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+	"github.com/hweidner/psync/pkg/workpool"
+)
+
+// BUFSIZE defines the size of the buffer used for copying. It is currently 64kB.
+const BUFSIZE = 64 * 1024
+
+// Type job is a directory in the work queue, together with the file info of
+// the source directory, if it is already known from the listing of its parent.
+type job struct {
+	dir   string
+	info  os.FileInfo
+	names []string // only these entries of the directory, nil for all
+	list  *listing // entries read ahead by a scanner, nil if not prefetched
+}
+
+// Method prepareDestDir checks for the existence of the destination,
+// or creates it if the option Create is set.
+func (e *engine) prepareDestDir() error {
+	if e.create {
+		// create destination directory
+		err := e.destination.MkdirAll("", os.FileMode(0777))
+		if err != nil {
+			return fmt.Errorf("unable to create destination dir %s: %s", e.dest, err)
+		}
+		return nil
+	}
+
+	// test the existence of destination directory prior to syncing
+	stat, err := e.destination.Stat("")
+	if os.IsNotExist(err) {
+		return fmt.Errorf("destination directory %s does not exist: %s.\nUse '-create' to create it.", e.dest, err)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot stat() destination directory %s: %s", e.dest, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("destination %s exists, but is not a directory", e.dest)
+	}
+	return nil
+}
+
+// Method nestedDest checks whether the destination directory lies inside the
+// source tree, e.g. when copying /data to /data/backup. In this case, the
+// destination is remembered relative to the source, so that it is excluded
+// from the traversal instead of being copied into itself. A source inside the
+// destination, which would be overwritten by the copy, is refused without
+// -force. The directories are compared by device and inode, so that trees
+// reached through symbolic links or bind mounts are recognized, too.
+func (e *engine) nestedDest() error {
+	s, err := filepath.Abs(e.src)
+	if err == nil {
+		s, err = filepath.EvalSymlinks(s)
+	}
+	d, derr := filepath.Abs(e.dest)
+	if derr == nil {
+		d, derr = filepath.EvalSymlinks(d)
+	}
+	if err != nil || derr != nil {
+		return nil
+	}
+	sf, err := os.Stat(s)
+	df, derr := os.Stat(d)
+	if err != nil || derr != nil {
+		return nil
+	}
+	if (s == d || os.SameFile(sf, df)) && e.srcName != "" {
+		return fmt.Errorf("source %s would be copied onto itself", filepath.Join(s, e.srcName))
+	}
+	if s == d || os.SameFile(sf, df) {
+		return fmt.Errorf("source and destination directory %s are the same", s)
+	}
+	if rel, ok := below(d, sf); ok {
+		e.nested = rel
+	} else if _, ok := below(s, df); ok && !e.force {
+		return fmt.Errorf("source directory %s lies inside the destination %s, use -force to copy anyway", e.src, e.dest)
+	}
+	return nil
+}
+
+// Function below checks whether the directory with the absolute path p lies
+// below the directory dir. It returns the path of p relative to dir.
+func below(p string, dir os.FileInfo) (string, bool) {
+	for q := p; ; {
+		parent := filepath.Dir(q)
+		if parent == q {
+			return "", false
+		}
+		if f, err := os.Stat(parent); err == nil && os.SameFile(f, dir) {
+			if parent == "/" {
+				return p, true
+			}
+			return p[len(parent):], true
+		}
+		q = parent
+	}
+}
+
+// Method startPool starts the copy threads with the work list of a phase.
+// By default, the work list is treated last-in-first-out, so that the tree is
+// traversed depth-first, which keeps the work list short. With -traversal
+// bfs, it is treated first-in-first-out, so that the tree is traversed
+// breadth-first, which hands the directories of wide and shallow trees to all
+// copy threads early. With -spill, a long work list is moved to disk in parts,
+// see spiller. When psync is interrupted, the work list and all incoming
+// directories are postponed for the checkpoint. While idle, the copy threads
+// help other copy threads with huge directories, see sharedDir.
+func (e *engine) startPool() {
+	o := workpool.Options{
+		Workers: int(e.threads),
+		FIFO:    e.breadthFirst,
+		SpillAt: e.spillAt,
+		Spill:   &spiller{e: e},
+		Stop:    e.stop,
+		Drop:    func(item interface{}) { e.postpone(item.(job)) },
+		Queue:   e.countQueue,
+	}
+	if e.sch != nil {
+		o.Side, o.Helper = e.sch, func(id int, s interface{}) { e.helpDir(uint(id), s.(*sharedDir)) }
+	}
+	e.pool = workpool.NewWith(o, e.copyDir)
+}
+
+// Method copyDir copies the content of a directory, handed out by the work
+// list, from src to dest. Files are copied sequentially. If a subdirectory is
+// discovered, it is created on the destination side, and then inserted into
+// the work list, see submit.
+func (e *engine) copyDir(n int, item interface{}) {
+	id := uint(n)
+	atomic.AddUint64(&e.queueTotal, 1)
+	begin := time.Now()
+	e.handleDir(id, item.(job))
+	e.setBusy(id, "")
+	e.countBusy(id, time.Since(begin))
+}
+
+// Method submit inserts a subdirectory of dir into the work queue. When
+// -max-queue directories wait in the work list already, the copy thread
+// descends into the subdirectory itself instead, like a sequential copy would.
+// This bounds the memory of the work list, and unlike waiting for room in the
+// work queue, it cannot deadlock when all copy threads wait. With
+// -deterministic, the copy thread always descends into the subdirectory.
+func (e *engine) submit(id uint, dir string, j job) {
+	e.wg.Add(1)
+	if e.deterministic {
+		e.handleDir(id, j)
+		e.setBusy(id, e.src+dir)
+		return
+	}
+	if e.maxQueue == 0 || e.pool.Queued() < e.maxQueue {
+		e.pool.Submit(j)
+		return
+	}
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Work list full, descending into %s%s\n", id, e.src, j.dir)
+	}
+	e.handleDir(id, j)
+	e.setBusy(id, e.src+dir)
+}
+
+// Method handleDir handles a directory: it copies the entries, or compares
+// them with -verify. The directory counts as done in the work queue
+// afterwards, or when its last file is copied by another thread.
+func (e *engine) handleDir(id uint, j job) {
+	if e.stopping() {
+		e.postpone(j)
+		return
+	}
+	if e.verifyMode {
+		e.verifyDir(id, j)
+		return
+	}
+	dir := j.dir
+	e.setBusy(id, e.src+dir)
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Handling directory %s%s\n", id, e.src, dir)
+	}
+
+	// follow the changes of the directory from now on
+	if e.watch != nil {
+		e.watch.add(dir)
+	}
+
+	// remember the access time of the source directory
+	if e.keepAtime && j.info == nil {
+		e.op()
+		j.info, _ = e.source.Stat(dir)
+	}
+
+	// read directory content, take the content listed by the scan of
+	// -estimate or read ahead by a scanner, or read the selected entries only
+	var files []os.FileInfo
+	var err error
+	var prefetched bool
+	if j.names != nil {
+		files = e.selectEntries(dir, j.names)
+	} else if files, prefetched = e.scanned(dir); !prefetched {
+		if files, prefetched, err = j.list.entries(); !prefetched {
+			err = e.retry(dir, func() (err error) {
+				e.op()
+				files, err = e.source.ReadDir(dir)
+				return
+			})
+		}
+	}
+	if e.keepAtime && j.info != nil {
+		e.restoreAtime(dir, j.info)
+	}
+	if err != nil {
+		e.warning(dir, "could not read directory %s: %s", e.src+dir, err)
+		e.completeDir(id, dir, nil, false)
+		e.wg.Done()
+		return
+	}
+	e.countDir(id, dir)
+
+	// empty directories need no filter rules, which saves the lookup of
+	// per-directory merge files
+	var rules []*rule
+	if len(files) > 0 {
+		rules = e.rulesFor(dir)
+	}
+	if e.deterministic {
+		sortNames(files)
+	}
+	if e.order != "" {
+		e.sortEntries(files)
+	}
+
+	s := &sharedDir{e: e, d: &dirState{e: e, j: j, pending: 1}, files: files, rules: rules}
+	if e.caseFold {
+		s.seen = make(map[string]string, len(files))
+	}
+	s.work(id, true)
+	s.d.release(id)
+}
+
+// Method handleEntry handles an entry of a directory: it creates and submits
+// subdirectories, and copies the files. It is called by the copy thread which
+// read the directory, and by the idle copy threads helping with it.
+func (e *engine) handleEntry(id uint, s *sharedDir, f os.FileInfo) {
+	dir, rules := s.d.j.dir, s.rules
+	fname := f.Name()
+	if fname == "." || fname == ".." {
+		return
+	}
+	if f.IsDir() && dir+"/"+fname == e.nested {
+		// do not copy the destination into itself
+		return
+	}
+	if e.excluded(rules, dir+"/"+fname, f.IsDir()) {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Excluding %s%s/%s\n", id, e.src, dir, fname)
+		}
+		return
+	}
+	if !e.selected(f.Mode()) {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping %s%s/%s, its type is not selected\n", id, e.src, dir, fname)
+		}
+		return
+	}
+	if !f.IsDir() {
+		// files handed over to the data threads count as handled, too
+		defer e.countHandled(f)
+	}
+	if e.caseFold && s.collision(dir, fname) {
+		return
+	}
+	if f.IsDir() && !e.visited.visit(dir+"/"+fname, f) {
+		return
+	}
+	if e.fakeSource {
+		f = e.fakeEntry(dir+"/"+fname, f)
+	}
+
+	if !f.IsDir() && e.olderThanLastRun(f) {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping %s%s/%s, unchanged since the last run\n", id, e.src, dir, fname)
+		}
+		return
+	}
+
+	if f.IsDir() && (e.shardNew != nil || e.synced(dir+"/"+fname, f)) {
+		// sharded destinations have no directory tree, and directories
+		// synced by the last run exist already
+		e.openSubdir(dir + "/" + fname)
+		e.submit(id, dir, e.prefetch(job{dir: dir + "/" + fname, info: f}))
+	} else if f.IsDir() {
+		// leaf directories are read at once, and empty ones completed
+		list := e.peekDir(dir+"/"+fname, f)
+		if list != nil && list.err == nil && len(list.files) == 0 {
+			e.emptyDir(id, dir+"/"+fname, f)
+			return
+		}
+
+		// create directory on destination side
+		perm := e.createMode(f)
+		begin := time.Now()
+		err := e.retry(dir+"/"+fname, func() error {
+			e.op()
+			return e.destination.Mkdir(dir+"/"+fname, perm)
+		})
+		if err != nil && e.mergeDirs() && e.existingDir(dir+"/"+fname) {
+			// descend into existing directories
+			if e.verbose >= 2 {
+				fmt.Fprintf(e.stdout, "[%d] Directory %s%s/%s exists\n", id, e.dest, dir, fname)
+			}
+		} else if err != nil {
+			e.warning(dir+"/"+fname, "could not create directory %s: %s",
+				e.dest+dir+"/"+fname, err)
+			return
+		} else {
+			e.emit("mkdir", dir+"/"+fname, 0, begin, nil)
+			e.audit("create", e.destination, e.dest, dir+"/"+fname, nil)
+			if e.verbose >= 1 {
+				fmt.Fprintf(e.stdout, "[%d] Created directory %s%s/%s\n", id, e.dest, dir, fname)
+			}
+		}
+
+		// submit directory to work queue, with its entries if read already
+		e.record(dir+"/"+fname, f)
+		e.openSubdir(dir + "/" + fname)
+		if list != nil {
+			e.submit(id, dir, job{dir: dir + "/" + fname, info: f, list: list})
+		} else {
+			e.submit(id, dir, e.prefetch(job{dir: dir + "/" + fname, info: f}))
+		}
+	} else if e.shadowed(id, dir+"/"+fname) {
+		// the entry was copied from an earlier source
+	} else if e.synced(dir+"/"+fname, f) {
+		// skip entries which did not change since the last run
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping %s%s/%s, unchanged since the last run\n", id, e.src, dir, fname)
+		}
+	} else if e.syncMode && f.Mode().IsRegular() && e.unchanged(f, e.destName(dir+"/"+fname)) {
+		// skip files which are up to date in sync mode
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping unchanged file %s%s/%s\n", id, e.src, dir, fname)
+		}
+		e.record(dir+"/"+fname, f)
+	} else if e.update && f.Mode().IsRegular() && e.newer(f, e.destName(dir+"/"+fname)) {
+		// keep files which were changed on the destination side
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Skipping %s%s/%s, the destination file is newer\n", id, e.src, dir, fname)
+		}
+	} else if !e.overwrite(dir+"/"+fname, e.destName(dir+"/"+fname), f) {
+		// keep the differing file, as the user answered
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "[%d] Keeping differing file %s%s/%s\n", id, e.dest, dir, fname)
+		}
+	} else if e.fch != nil {
+		// hand the file over to the data threads
+		atomic.AddInt32(&s.d.pending, 1)
+		e.fch <- fileJob{file: dir + "/" + fname, info: f, dir: s.d}
+	} else {
+		// copy file sequentially
+		e.setBusy(id, e.src+dir+"/"+fname)
+		e.copyFile(id, dir+"/"+fname, f)
+		e.setBusy(id, e.src+dir)
+	}
+}
+
+// Method finishDir completes a directory after all its entries are
+// handled. The metadata of the source directory is applied to the
+// destination directory when its subdirectories are complete, too. An
+// interrupted directory is postponed instead.
+func (e *engine) finishDir(id uint, d *dirState) {
+	dir := d.j.dir
+	if atomic.LoadInt32(&d.partial) != 0 {
+		e.postponePartial(d.j)
+		return
+	}
+	e.completeDir(id, dir, d.j.info, true)
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Finished directory %s%s\n", id, e.src, dir)
+	}
+	e.wg.Done()
+}
+
+// Method dirMeta applies the metadata of a source directory to the
+// destination directory, after its subtree is complete. The file information
+// of the source directory is read if finfo is nil.
+func (e *engine) dirMeta(id uint, dir string, finfo os.FileInfo) {
+	var err error
+	if finfo == nil {
+		e.op()
+		finfo, err = e.source.Stat(dir)
+		if err == nil && e.fakeSource {
+			finfo = e.fakeEntry(dir, finfo)
+		}
+	}
+	if err != nil {
+		e.warning(dir, "could not read fileinfo of directory %s: %s", e.dest+dir, err)
+	} else if e.shardNew == nil && !e.mergedDir(dir) && (dir != "" || e.srcName == "") {
+		// preserve user and group of the destination directory
+		if e.owner {
+			e.preserveOwner(dir, dir, finfo, "directory")
+		}
+		// preserve the permissions after the entries are created
+		if e.perms {
+			e.preservePerms(dir, dir, finfo, "directory")
+		}
+		// setting the timestamps of the destination directory
+		if e.times {
+			e.preserveTimes(dir, dir, finfo, "directory")
+		}
+	}
+	if e.fsyncDirs {
+		e.syncDir(dir)
+	}
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Completed the subtree of directory %s%s\n", id, e.src, dir)
+	}
+}
+
+// Method reportCopy prints the entry copied to the destination in verbose mode.
+// It is called after the entry was created or updated successfully, so that
+// skipped and failed entries are not reported as copied.
+func (e *engine) reportCopy(id uint, file string) {
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "[%d] Copied %s%s to %s%s\n", id, e.src, file, e.dest, file)
+	}
+}
+
+// Method copyFile copies a file from the source to the destination directory.
+func (e *engine) copyFile(id uint, file string, f os.FileInfo) {
+	begin := time.Now()
+	mode := f.Mode()
+	var before *auditMeta
+	if e.auditFd != nil {
+		// destName, since destPath records the file in the shard manifest
+		before = e.auditBefore(e.destination, e.destName(file))
+	}
+	if mode.IsRegular() && (e.linkRefs != nil || e.dedupMode) {
+		if e.linkRefs != nil && !e.ignoreTimes && e.linkUnchanged(id, file, f) {
+			e.audit("write", e.destination, e.dest, file, before)
+			return
+		}
+		if e.dedupMode && e.linkDuplicate(id, file, f) {
+			e.audit("write", e.destination, e.dest, file, before)
+			return
+		}
+		// do not write through a hard link of the destination file
+		e.breakLink(file)
+	}
+	switch {
+
+	case mode&os.ModeSymlink != 0: // symbolic link
+		// read link
+		var link string
+		err := e.retry(file, func() (err error) {
+			e.op()
+			link, err = e.source.Readlink(file)
+			return
+		})
+		if err != nil {
+			e.warning(file, "link %s disappeared while copying %s", e.src+file, err)
+			return
+		}
+
+		// write link to destination
+		target := e.destPath(file)
+		action := e.createLink(id, file, link, target)
+		if action == "" {
+			return
+		}
+		e.countLink(id, file)
+		e.record(file, f)
+		e.emit(action, file, 0, begin, nil)
+		e.audit("write", e.destination, e.dest, target, before)
+		e.reportCopy(id, file)
+
+		// preserve owner of symbolic link
+		if e.owner {
+			e.preserveOwner(file, target, f, "link")
+		}
+		// preserving the timestamps of links seems not be supported in Go
+		// TODO: it should be possible by using the futimesat system call,
+		// see https://github.com/golang/go/issues/3951
+		//if times {
+		//	preserveTimes(file, target, f, "link")
+		//}
+
+	case mode&(os.ModeDevice|os.ModeNamedPipe|os.ModeSocket) != 0 && e.fakeSuper && !e.copiedDevice(mode): // special files
+		// stored as regular files with the metadata in an attribute
+		target := e.destPath(file)
+		if !e.createFake(file, target, f) {
+			return
+		}
+		e.countFile(id, file, 0)
+		e.record(file, f)
+		e.emit("copy", file, 0, begin, nil)
+		e.audit("write", e.destination, e.dest, target, before)
+		e.reportCopy(id, file)
+		if e.times {
+			e.preserveTimes(file, target, f, "special file")
+		}
+
+	case mode&(os.ModeDevice|os.ModeNamedPipe|os.ModeSocket) != 0 && !e.copiedDevice(mode): // special files
+		// TODO: not yet implemented
+		e.warn(&Error{file, fmt.Sprintf("%s: syncing of UNIX special files is not implemented yet.", e.src+file), ErrUnsupported})
+
+	case mode.IsRegular() && f.Size() == 0 && len(e.transforms) == 0: // empty file
+		// fast path, there is no need to open and read the source file
+		target := e.destPath(file)
+		e.op()
+		wr, err := e.destination.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, e.createMode(f))
+		if err == nil {
+			err = wr.Close()
+		}
+		if err != nil {
+			e.warning(file, "file %s could not be created: %s", e.dest+target, err)
+			return
+		}
+		e.countFile(id, file, 0)
+		e.record(file, f)
+		e.emit("copy", file, 0, begin, nil)
+		e.audit("write", e.destination, e.dest, target, before)
+		e.reportCopy(id, file)
+
+		if e.owner {
+			e.preserveOwner(file, target, f, "file")
+		}
+		if e.perms {
+			e.preservePerms(file, target, f, "file")
+		}
+		if e.times {
+			e.preserveTimes(file, target, f, "file")
+		}
+
+	default:
+		// copy regular file, or the content of a block device
+		target := e.destPath(file)
+		var n int64
+		var err error
+		e.setFile(id, f.Size())
+		for attempt := 1; ; attempt++ {
+			err = e.retry(file, func() (err error) {
+				n, err = e.watchdog(id, file, func(c *fileCopy) (int64, error) {
+					// devices have no size, and are read to the end
+					if mode.IsRegular() && len(e.transforms) == 0 {
+						if sig := e.deltaBasis(target); sig != nil {
+							return e.copyDelta(id, c, file, target, f, sig)
+						}
+						if e.cloneData(file, target) {
+							return f.Size(), nil
+						}
+					}
+					if e.partialDir != "" {
+						return e.copyPartial(id, c, file, target, f)
+					}
+					if e.chunkSize > 0 && f.Size() > int64(e.chunkSize)*1000000 {
+						return e.copyChunked(id, c, file, target, f)
+					}
+					return e.copyData(id, c, file, target, f)
+				})
+				return
+			})
+			if err != nil || e.unstable == "" || !mode.IsRegular() {
+				break
+			}
+			// the copy of a file which changed meanwhile may be torn
+			nf, changed := e.changedSource(file, f)
+			if !changed {
+				break
+			}
+			if e.unstable == "skip" || attempt == unstableAttempts {
+				err = e.removeUnstable(file, target)
+				break
+			}
+			if e.verbose >= 2 {
+				fmt.Fprintf(e.stdout, "[%d] Copying %s%s again, it changed while copying\n", id, e.src, file)
+			}
+			f = nf
+		}
+		e.setFile(id, 0)
+		if err != nil {
+			e.warn(err.(*Error))
+			return
+		}
+		e.countFile(id, file, n)
+		e.record(file, f)
+		if e.dedupMode && mode.IsRegular() {
+			e.rememberCopy(id, file, f)
+		}
+		e.emit("copy", file, n, begin, nil)
+		e.audit("write", e.destination, e.dest, target, before)
+		e.reportCopy(id, file)
+
+		if e.owner {
+			e.preserveOwner(file, target, f, "file")
+		}
+		if e.perms {
+			e.preservePerms(file, target, f, "file")
+		}
+		if e.times {
+			e.preserveTimes(file, target, f, "file")
+		}
+
+	}
+}
+
+// Method copyData copies the content of a regular file from the source to
+// the destination. It returns the number of bytes copied, and an *Error if
+// the copy failed.
+func (e *engine) copyData(id uint, c *fileCopy, file, target string, f os.FileInfo) (int64, error) {
+	// open source file for reading
+	e.op()
+	rd, err := e.source.Open(file)
+	if err == nil {
+		err = c.track(rd, "")
+	}
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("file %s disappeared while copying: %s", e.src+file, err), err}
+	}
+	defer c.close(rd)
+
+	// open destination file for writing
+	e.op()
+	wr, err := e.destination.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, e.createMode(f))
+	if err == nil {
+		err = c.track(wr, target)
+	}
+	if err != nil {
+		return 0, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, err), err}
+	}
+	if e.preallocate {
+		if err := allocate(wr, 0, f.Size()); err != nil {
+			c.close(wr)
+			return 0, &Error{file, fmt.Sprintf("file %s could not be preallocated: %s", e.dest+target, err), err}
+		}
+	}
+
+	// copy data, and hash it for the verification
+	var out io.Writer = wr
+	var sw *sparseWriter
+	if e.sparse {
+		sw = &sparseWriter{f: wr}
+		out = sw
+	}
+	var tw *transformWriter
+	if len(e.transforms) > 0 {
+		if tw, err = e.newTransformWriter(out, file, f); err != nil {
+			c.close(wr)
+			return 0, &Error{file, fmt.Sprintf("file %s could not be transformed: %s", e.src+file, err), err}
+		}
+		out = tw
+	}
+	var w io.Writer = progressWriter{out, &c.done, &e.copied}
+	var h hash.Hash
+	if e.verifyAfter || e.dedupMode && c.sum == nil {
+		h = e.checksumNew()
+		w = io.MultiWriter(w, h)
+	}
+	var n int64
+	copied := false
+	if e.copyRange && sw == nil && tw == nil && h == nil {
+		// the data need not pass through psync
+		n, copied, err = e.kernelCopy(c, wr, rd)
+	}
+	if !copied {
+		n, err = io.CopyBuffer(w, rd, c.buf)
+	}
+	if tw != nil {
+		// the filters write their buffered data
+		if cerr := tw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil && sw != nil {
+		// a hole at the end is not written
+		err = wr.Truncate(sw.off)
+		if e.verbose >= 2 && sw.holes > 0 {
+			fmt.Fprintf(e.stdout, "[%d] Left %d bytes of zeros as holes in %s\n", id, sw.holes, e.dest+target)
+		}
+	}
+	if err == nil && e.fsyncFiles {
+		err = syncFile(wr)
+	}
+	if err == nil && e.dropDest {
+		err = dropCache(wr, true)
+	}
+	if e.dropSource {
+		dropCache(rd, false)
+	}
+	if cerr := c.close(wr); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return n, &Error{file, fmt.Sprintf("file %s could not be created: %s", e.dest+target, err), err}
+	}
+	if h != nil && e.dedupMode && c.sum == nil {
+		c.sum = h.Sum(nil)
+	}
+	if e.verifyAfter {
+		return n, e.verifyCopy(c.buf, file, target, h.Sum(nil))
+	}
+	return n, nil
+}
+
+// Method preserveOwner transfers the ownership information from the source to
+// the destination file/directory. The name is relative to the destination.
+func (e *engine) preserveOwner(file, name string, f os.FileInfo, ftype string) {
+	if e.fakeSuper {
+		// symbolic links cannot have user attributes
+		if ftype != "link" {
+			e.storeFake(file, name, f, ftype)
+		}
+		return
+	}
+	if uid, gid, ok := e.destOwner(f); ok {
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "Changing ownership of %s %s to %d:%d\n", ftype, e.dest+name, uid, gid)
+		}
+
+		before := e.auditBefore(e.destination, name)
+		e.op()
+		var err error
+		if ftype == "link" {
+			err = e.destination.Lchown(name, uid, gid)
+		} else {
+			err = e.destination.Chown(name, uid, gid)
+		}
+
+		if err != nil {
+			e.metaWarning(file, "could not change ownership of %s %s: %s", ftype, e.dest+name, err)
+		} else {
+			e.audit("chown", e.destination, e.dest, name, before)
+		}
+	}
+}
+
+// Method preserveTimes transfers the access and modification timestamp from
+// the source to the destination file/directory. The name is relative to the
+// destination.
+func (e *engine) preserveTimes(file, name string, f os.FileInfo, ftype string) {
+	mtime := f.ModTime()
+	atime := accessTime(f)
+	if e.verbose >= 2 {
+		fmt.Fprintf(e.stdout, "Changing timestamps of %s %s to %s\n", ftype, e.dest+name, mtime.Format(time.RFC3339))
+	}
+	before := e.auditBefore(e.destination, name)
+	e.op()
+	err := e.destination.Chtimes(name, atime, mtime)
+	if err != nil {
+		e.metaWarning(file, "could not change timestamps for %s %s: %s", ftype, e.dest+name, err)
+	} else {
+		e.audit("times", e.destination, e.dest, name, before)
+	}
+}
+
+// Function accessTime returns the access time from the file info. If it is
+// not available, the modification time is returned.
+func accessTime(f os.FileInfo) time.Time {
+	if stat, ok := f.Sys().(*sysstat.Stat); ok {
+		atime, _, _ := sysstat.Times(stat)
+		return time.Unix(0, atime)
+	}
+	return f.ModTime()
+}
+
+// Method restoreAtime restores the access time of a source directory after
+// reading it. If this is not permitted, the warning is printed only once.
+func (e *engine) restoreAtime(dir string, f os.FileInfo) {
+	e.op()
+	err := e.source.Chtimes(dir, accessTime(f), f.ModTime())
+	if err != nil {
+		e.atimeOnce.Do(func() {
+			e.warning(dir, "could not restore access time of directory %s: %s", e.src+dir, err)
+		})
+	}
+}
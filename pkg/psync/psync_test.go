@@ -0,0 +1,441 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hweidner/psync/pkg/psync"
+	"github.com/hweidner/psync/pkg/treetest"
+)
+
+// TestRandomTree copies random trees with several numbers of threads, and
+// compares the copies with the sources. The owners are compared only when
+// the test runs as root.
+func TestRandomTree(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Log("not running as root, the owners are not checked")
+	}
+	entries := 1000
+	if testing.Short() {
+		entries = 200
+	}
+	for i, threads := range []uint{1, 4, 16} {
+		seed := int64(i + 1)
+		t.Run(fmt.Sprintf("threads=%d", threads), func(t *testing.T) {
+			root, err := ioutil.TempDir("", "psync-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer treetest.Remove(root)
+
+			src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+			_, diffs, err := treetest.Run(src, dst, seed, entries, threads)
+			if err != nil {
+				t.Fatalf("seed %d: %s", seed, err)
+			}
+			for _, d := range diffs {
+				t.Errorf("seed %d: %s", seed, d)
+			}
+		})
+	}
+}
+
+// TestParallelRuns copies the same random tree with several Syncers at the
+// same time. Each copy must be complete, and each Syncer must count the
+// entries of its own run only.
+func TestParallelRuns(t *testing.T) {
+	entries := 500
+	if testing.Short() {
+		entries = 100
+	}
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+
+	const runs = 4
+	stats := make([]psync.Stats, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src, dst := filepath.Join(root, fmt.Sprintf("src%d", i)), filepath.Join(root, fmt.Sprintf("dst%d", i))
+			st, diffs, err := treetest.Run(src, dst, 1, entries, 4)
+			if err != nil {
+				t.Errorf("run %d: %s", i, err)
+			}
+			for _, d := range diffs {
+				t.Errorf("run %d: %s", i, d)
+			}
+			stats[i] = st
+		}(i)
+	}
+	wg.Wait()
+	for i := 1; i < runs; i++ {
+		a, b := stats[0], stats[i]
+		if a.Dirs != b.Dirs || a.Files != b.Files || a.Links != b.Links || a.Bytes != b.Bytes {
+			t.Errorf("run %d counted %d dirs, %d files, %d links, %d bytes, run 0 %d dirs, %d files, %d links, %d bytes",
+				i, b.Dirs, b.Files, b.Links, b.Bytes, a.Dirs, a.Files, a.Links, a.Bytes)
+		}
+	}
+}
+
+// TestHookQuoting runs a -post-cmd hook with quoted arguments, which must be
+// passed to the command unsplit.
+func TestHookQuoting(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are run by cmd on Windows")
+	}
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src := filepath.Join(root, "src dir")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	s := psync.New(psync.Options{
+		Source:      src,
+		Destination: filepath.Join(root, "dst"),
+		Create:      true,
+		PostCmd:     `printf '<%s>\n' "sync done" "$PSYNC_STATUS" "$PSYNC_SOURCE"`,
+		Stdout:      &out,
+	})
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<sync done>\n<ok>\n<" + src + ">\n"; out.String() != want {
+		t.Errorf("hook printed %q, want %q", out.String(), want)
+	}
+}
+
+// TestNotifyQuoting runs a -notify-cmd command with quoted arguments, which
+// must be passed to the command unsplit.
+func TestNotifyQuoting(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("notification commands are run by cmd on Windows")
+	}
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src := filepath.Join(root, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	s := psync.New(psync.Options{
+		Source:      src,
+		Destination: filepath.Join(root, "dst"),
+		Create:      true,
+		NotifyCmd:   `printf '<%s>\n' "run ended" "$PSYNC_STATUS"`,
+		Stdout:      &out,
+	})
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<run ended>\n<ok>\n"; out.String() != want {
+		t.Errorf("notification command printed %q, want %q", out.String(), want)
+	}
+}
+
+// TestVerifyTimes compares trees whose files differ in their modification
+// time only, which is reported unless the times are ignored.
+func TestVerifyTimes(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	for _, dir := range []string{src, dst} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "file"), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dst, "file"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ignore := range []bool{false, true} {
+		var out bytes.Buffer
+		s := psync.New(psync.Options{
+			Source:      src,
+			Destination: dst,
+			Verify:      true,
+			IgnoreTimes: ignore,
+			Stdout:      &out,
+		})
+		if err := s.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		want := uint64(1)
+		if ignore {
+			want = 0
+		}
+		if d := s.Stats().Diffs; d != want {
+			t.Errorf("ignore times %t: %d differences found, want %d (%q)", ignore, d, want, out.String())
+		}
+	}
+}
+
+// TestShardManifest copies a tree into a sharded destination. The manifest
+// must list each entry once.
+func TestShardManifest(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "dir/c"} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := psync.New(psync.Options{
+		Source:        src,
+		Destination:   dst,
+		Create:        true,
+		ShardTemplate: "{hash:2}/{path}",
+		Stdout:        ioutil.Discard,
+	})
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := os.Open(filepath.Join(dst, ".psync-manifest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	seen := make(map[string]int)
+	sc := bufio.NewScanner(fd)
+	for sc.Scan() {
+		var m struct{ Path string }
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatalf("invalid manifest line %q: %s", sc.Text(), err)
+		}
+		seen[m.Path]++
+	}
+	for _, name := range []string{"a", "b", "dir/c", "link"} {
+		if seen[name] != 1 {
+			t.Errorf("%s is listed %d times in the manifest, want once", name, seen[name])
+		}
+	}
+}
+
+// TestSinceLast runs -since-last twice into the same destination, and then
+// into a new destination with the same state file, like rotating backups.
+// Each run copies the files created after the start of the run before.
+func TestSinceLast(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src, state := filepath.Join(root, "src"), filepath.Join(root, "state")
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := []struct {
+		create string // file created before the run
+		dest   string
+		files  uint64
+		want   []string // files in the destination after the run
+	}{
+		{"dir/a", "dst1", 1, []string{"dir/a"}},
+		{"dir/b", "dst1", 1, []string{"dir/a", "dir/b"}},
+		{"dir/c", "dst2", 1, []string{"dir/c"}},
+	}
+	for i, r := range runs {
+		// the file times must lie after the start of the last run
+		time.Sleep(100 * time.Millisecond)
+		if err := ioutil.WriteFile(filepath.Join(src, r.create), []byte(r.create), 0644); err != nil {
+			t.Fatal(err)
+		}
+		s := psync.New(psync.Options{
+			Source:      src,
+			Destination: filepath.Join(root, r.dest),
+			Create:      true,
+			SinceLast:   true,
+			StateFile:   state,
+			Stdout:      ioutil.Discard,
+		})
+		if err := s.Run(context.Background()); err != nil {
+			t.Fatalf("run %d: %s", i+1, err)
+		}
+		if st := s.Stats(); st.Files != r.files || st.Errors > 0 {
+			t.Errorf("run %d: %d files copied with %d errors, want %d without errors", i+1, st.Files, st.Errors, r.files)
+		}
+		for _, name := range r.want {
+			if _, err := os.Stat(filepath.Join(root, r.dest, name)); err != nil {
+				t.Errorf("run %d: %s", i+1, err)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "dst2", "dir", "a")); err == nil {
+		t.Error("run 3: file older than the last run was copied")
+	}
+}
+
+// TestUpdate refreshes a copy with -update. The destination file changed
+// since the copy must be kept, the changed source file must be copied.
+func TestUpdate(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"dir/edited", "dir/changed"} {
+		p := filepath.Join(src, name)
+		if err := ioutil.WriteFile(p, []byte("master"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := psync.Options{Source: src, Destination: dst, Create: true, Times: true, Stdout: ioutil.Discard}
+	if err := psync.New(opts).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dst, "dir/edited"), []byte("local edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "dir/changed"), []byte("new master"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts.Update = true
+	s := psync.New(opts)
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st := s.Stats(); st.Files != 1 || st.Errors > 0 {
+		t.Errorf("%d files copied with %d errors, want 1 without errors", st.Files, st.Errors)
+	}
+	for name, want := range map[string]string{"dir/edited": "local edit", "dir/changed": "new master"} {
+		if b, _ := ioutil.ReadFile(filepath.Join(dst, name)); string(b) != want {
+			t.Errorf("%s contains %q, want %q", name, b, want)
+		}
+	}
+}
+
+// TestDedupTimeout copies identical files with -dedup and a file timeout, so
+// that the checksums computed while copying are handed over from the
+// goroutines of the watchdog. The identical files must be linked to the
+// first one, and the file of the same size but other content must not.
+func TestDedupTimeout(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := map[string]string{"a": "same", "b": "same", "c": "same", "d": "diff"}
+	for name, c := range content {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := psync.Options{Source: src, Destination: dst, Create: true, Threads: 1, Dedup: true,
+		FileTimeout: time.Minute, Stdout: ioutil.Discard}
+	if err := psync.New(opts).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := make(map[string]os.FileInfo)
+	for name := range content {
+		fi, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		infos[name] = fi
+	}
+	for _, name := range []string{"b", "c"} {
+		if !os.SameFile(infos["a"], infos[name]) {
+			t.Errorf("%s is not linked to the identical a", name)
+		}
+	}
+	if os.SameFile(infos["a"], infos["d"]) {
+		t.Error("d is linked to a, but has another content")
+	}
+}
+
+// TestEngines copies a random tree with each engine of the benchmarks, and
+// compares the copies with the source. A file larger than a chunk of
+// copy_file_range is added, so that -copy-range copies it in several calls.
+func TestEngines(t *testing.T) {
+	root, err := ioutil.TempDir("", "psync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer treetest.Remove(root)
+	src := filepath.Join(root, "src")
+	if err := treetest.Random(src, rand.New(rand.NewSource(1)), 200); err != nil {
+		t.Fatal(err)
+	}
+	large := make([]byte, 9<<20+123)
+	rand.New(rand.NewSource(2)).Read(large)
+	if err := ioutil.WriteFile(filepath.Join(src, "large"), large, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, set := range treetest.Engines {
+		dst := filepath.Join(root, set.Name)
+		opts := psync.Options{Source: src, Destination: dst, Threads: 4, Times: true, Create: true, Stdout: ioutil.Discard}
+		set.Apply(&opts)
+		s := psync.New(opts)
+		if err := s.Run(context.Background()); err != nil {
+			t.Fatalf("%s: %s", set.Name, err)
+		}
+		if st := s.Stats(); st.Errors > 0 {
+			t.Errorf("%s: %d errors", set.Name, st.Errors)
+		}
+		for _, d := range treetest.Compare(src, dst, false) {
+			t.Errorf("%s: %s", set.Name, d)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Function transient checks whether an error is likely to be temporary, as
+// typical for network file systems (stale file handles, I/O errors, timeouts).
+func transient(err error) bool {
+	if e, ok := err.(*Error); ok {
+		err = e.Err
+	}
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	} else if le, ok := err.(*os.LinkError); ok {
+		err = le.Err
+	} else if se, ok := err.(*os.SyscallError); ok {
+		err = se.Err
+	}
+	switch err {
+	case syscall.ESTALE, syscall.EIO, syscall.ETIMEDOUT, syscall.EAGAIN, syscall.EINTR,
+		syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EHOSTUNREACH:
+		return true
+	}
+	return false
+}
+
+// Method retry calls f until it succeeds or fails with a permanent error.
+// Transient errors are retried up to -retries times. The delay between the
+// attempts starts with -retry-delay and doubles with each attempt.
+func (e *engine) retry(path string, f func() error) error {
+	delay := e.retryDelay
+	for attempt := uint(1); ; attempt++ {
+		err := f()
+		if err == nil || attempt > e.retries || !transient(err) {
+			return err
+		}
+		if e.verbose >= 2 {
+			fmt.Fprintf(e.stdout, "Retrying %s%s in %s (attempt %d of %d): %s\n", e.src, path, delay, attempt, e.retries, err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// shardHashes contains the hash functions which can be used for sharding the
+// destination names. Further hash functions can be registered here.
+var shardHashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"fnv":    func() hash.Hash { return fnv.New64a() },
+}
+
+// shardRegexp matches the placeholders of a shard template.
+var shardRegexp = regexp.MustCompile(`\{(hash(?::\d+){0,2}|path|dir|name)\}`)
+
+// Type manifestEntry is a line in the shard manifest, mapping a destination
+// key to the path relative to the source directory.
+type manifestEntry struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+}
+
+// Method initShard checks the shard template and hash function and creates
+// the manifest file.
+func (e *engine) initShard() error {
+	if !shardRegexp.MatchString(e.shardTemplate) {
+		return fmt.Errorf("shard template %s contains no placeholder", e.shardTemplate)
+	}
+	var ok bool
+	e.shardNew, ok = shardHashes[e.shardHash]
+	if !ok {
+		return fmt.Errorf("unknown shard hash function %s", e.shardHash)
+	}
+
+	fd, err := os.Create(e.shardManifest)
+	if err != nil {
+		e.shardNew = nil
+		return fmt.Errorf("cannot create shard manifest %s: %s", e.shardManifest, err)
+	}
+	e.manifestFd = fd
+	e.manifest = bufio.NewWriter(fd)
+	return nil
+}
+
+// Method closeShard flushes and closes the manifest file.
+func (e *engine) closeShard() {
+	if e.manifest == nil {
+		return
+	}
+	e.manifestMu.Lock()
+	defer e.manifestMu.Unlock()
+	err := e.manifest.Flush()
+	if err == nil {
+		err = e.manifestFd.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - cannot write shard manifest %s: %s\n", e.shardManifest, err)
+	}
+}
+
+// Method shardKey expands the shard template for the (relative) path of a
+// file. The placeholder {hash} is replaced by the hex encoded hash of the
+// path, {hash:n} by its first n characters, and {hash:o:n} by n characters
+// starting at offset o. {path}, {dir} and {name} are replaced by the path,
+// its directory and its base name.
+func (e *engine) shardKey(file string) string {
+	file = strings.TrimPrefix(file, "/")
+	h := e.shardNew()
+	h.Write([]byte(file))
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	return shardRegexp.ReplaceAllStringFunc(e.shardTemplate, func(ph string) string {
+		ph = ph[1 : len(ph)-1]
+		switch ph {
+		case "path":
+			return file
+		case "dir":
+			return path.Dir(file)
+		case "name":
+			return path.Base(file)
+		}
+		off, n := 0, len(sum)
+		args := strings.Split(ph, ":")[1:]
+		if len(args) == 2 {
+			off, _ = strconv.Atoi(args[0])
+			args = args[1:]
+		}
+		if len(args) == 1 {
+			n, _ = strconv.Atoi(args[0])
+		}
+		if off > len(sum) {
+			off = len(sum)
+		}
+		if off+n > len(sum) {
+			n = len(sum) - off
+		}
+		return sum[off : off+n]
+	})
+}
+
+// Method destName returns the destination path of a file, relative to the
+// destination directory. Without sharding, the destination tree mirrors the
+// source tree. With sharding, the key is
+// computed from the shard template.
+func (e *engine) destName(file string) string {
+	if e.shardNew == nil {
+		return file
+	}
+	return "/" + e.shardKey(file)
+}
+
+// Method destPath returns the destination path of a file, like destName.
+// With sharding, the parent directories are created, and the mapping is
+// recorded in the manifest.
+func (e *engine) destPath(file string) string {
+	if e.shardNew == nil {
+		return file
+	}
+
+	key := e.shardKey(file)
+	name := "/" + key
+	if err := e.destination.MkdirAll(path.Dir(name), os.FileMode(0777)); err != nil {
+		e.warning(file, "could not create shard directory for %s: %s", e.dest+name, err)
+	}
+
+	e.manifestMu.Lock()
+	defer e.manifestMu.Unlock()
+	b, _ := json.Marshal(manifestEntry{key, strings.TrimPrefix(file, "/")})
+	e.manifest.Write(b)
+	e.manifest.WriteByte('\n')
+	return name
+}
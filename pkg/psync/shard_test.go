@@ -0,0 +1,58 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+)
+
+func TestShardKey(t *testing.T) {
+	sum := md5.Sum([]byte("dir/sub/file.txt"))
+	h := hex.EncodeToString(sum[:])
+	for _, st := range []struct {
+		template, want string
+	}{
+		{"{path}", "dir/sub/file.txt"},
+		{"{dir}/{name}", "dir/sub/file.txt"},
+		{"{name}", "file.txt"},
+		{"{hash}", h},
+		{"{hash:2}/{path}", h[:2] + "/dir/sub/file.txt"},
+		{"{hash:2}/{hash:2:2}/{name}", h[:2] + "/" + h[2:4] + "/file.txt"},
+		{"{hash:30:8}", h[30:]},
+		{"{hash:40:2}x", "x"},
+		{"{hash:64}", h},
+		{"keys/{hash:0:3}.bin", "keys/" + h[:3] + ".bin"},
+		{"{other}/{name}", "{other}/file.txt"},
+	} {
+		e := &engine{shardTemplate: st.template, shardNew: md5.New}
+		if got := e.shardKey("/dir/sub/file.txt"); got != st.want {
+			t.Errorf("template %s: got %s, want %s", st.template, got, st.want)
+		}
+	}
+
+	// a file in the top directory
+	e := &engine{shardTemplate: "{dir}/{name}", shardNew: md5.New}
+	if got := e.shardKey("/file"); got != "./file" {
+		t.Errorf("template {dir}/{name} for /file: got %s, want ./file", got)
+	}
+}
+
+func TestShardErrors(t *testing.T) {
+	for _, st := range []struct {
+		template, hash string
+	}{
+		{"static", "md5"},
+		{"{hash:x}", "md5"},
+		{"{hash}", "crc32"},
+	} {
+		e := &engine{shardTemplate: st.template, shardHash: st.hash}
+		if err := e.initShard(); err == nil {
+			e.closeShard()
+			t.Errorf("template %s with %s: no error", st.template, st.hash)
+		}
+	}
+}
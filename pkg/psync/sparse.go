@@ -0,0 +1,72 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bytes"
+	"io"
+)
+
+// sparseBlock is the size of the blocks which are checked for zeros. It is
+// the block size of most file systems, which cannot have smaller holes.
+const sparseBlock = 4096
+
+// zeroBlock is a block of zeros, for comparing the data with it.
+var zeroBlock [sparseBlock]byte
+
+// Type sparseWriter writes the content of a destination file, which must be
+// empty at first. Blocks of zeros, aligned to the block size, are skipped by
+// seeking past them, so that they become holes. The file must be truncated to
+// its size afterwards, in case it ends with a hole.
+type sparseWriter struct {
+	f     File
+	off   int64 // offset of the next write
+	holes int64 // bytes left as holes
+}
+
+// Method Write writes the data, and seeks past the blocks of zeros. Runs of
+// data blocks, and runs of zero blocks, are handled with a single call each.
+func (s *sparseWriter) Write(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		l := blockLen(s.off, len(b)-n)
+		zero := isZero(b[n : n+l])
+		end := n + l
+		for end < len(b) {
+			l = blockLen(s.off+int64(end-n), len(b)-end)
+			if isZero(b[end:end+l]) != zero {
+				break
+			}
+			end += l
+		}
+		if zero {
+			if _, err := s.f.Seek(int64(end-n), io.SeekCurrent); err != nil {
+				return n, err
+			}
+			s.holes += int64(end - n)
+		} else if w, err := s.f.Write(b[n:end]); err != nil {
+			s.off += int64(w)
+			return n + w, err
+		}
+		s.off += int64(end - n)
+		n = end
+	}
+	return n, nil
+}
+
+// Function blockLen returns the length of the data at the offset off of the
+// file up to the next block boundary, at most rest.
+func blockLen(off int64, rest int) int {
+	l := sparseBlock - int(off%sparseBlock)
+	if l > rest {
+		l = rest
+	}
+	return l
+}
+
+// Function isZero tells whether the data is a whole block of zeros.
+func isZero(b []byte) bool {
+	return len(b) == sparseBlock && bytes.Equal(b, zeroBlock[:])
+}
@@ -0,0 +1,137 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Type spiller is the overflow of the work list on disk, the Spill of the
+// work pool: an append-only log of directory paths separated by NUL
+// characters. The directories are read back in the order they were written,
+// when the work list in memory runs empty. Only the paths are kept, the file information of a spilled
+// directory is looked up again when it is finished. A spiller with only its
+// engine set is an empty overflow, whose file is created when it is first
+// needed.
+type spiller struct {
+	e      *engine // engine of the run
+	w      *bufio.Writer
+	r      *bufio.Reader
+	wfd    *os.File
+	rfd    *os.File
+	n      int  // number of directories in the file, not read back yet
+	failed bool // the file could not be written, the work list stays in memory
+}
+
+// Method open creates the temporary file of the overflow, and removes its
+// name right away, so that it vanishes when psync ends, by whatever means.
+func (s *spiller) open() error {
+	wfd, err := ioutil.TempFile("", "psync-spill-")
+	if err != nil {
+		return err
+	}
+	rfd, err := os.Open(wfd.Name())
+	os.Remove(wfd.Name())
+	if err != nil {
+		wfd.Close()
+		return err
+	}
+	s.wfd, s.rfd = wfd, rfd
+	s.w, s.r = bufio.NewWriter(wfd), bufio.NewReader(rfd)
+	return nil
+}
+
+// Method Store spills half of the work list, the directories which would be
+// handed out last, and returns the rest. Directories of the file list, which
+// select some of their entries, stay in memory, like the list itself.
+func (s *spiller) Store(worklist []interface{}) []interface{} {
+	if s.failed {
+		return worklist
+	}
+	if s.w == nil {
+		if err := s.open(); err != nil {
+			s.fail(err)
+			return worklist
+		}
+	}
+	half := len(worklist) / 2
+	out, keep := worklist[:half], worklist[half:]
+	if s.e.breadthFirst {
+		keep, out = worklist[:len(worklist)-half], worklist[len(worklist)-half:]
+	}
+
+	rest := make([]interface{}, 0, len(worklist))
+	if s.e.breadthFirst {
+		rest = append(rest, keep...)
+	}
+	n := 0
+	for _, item := range out {
+		j := item.(job)
+		if j.names != nil {
+			rest = append(rest, j)
+			continue
+		}
+		s.w.WriteString(j.dir)
+		s.w.WriteByte(0)
+		n++
+	}
+	if err := s.w.Flush(); err != nil {
+		s.fail(err)
+		return worklist
+	}
+	if !s.e.breadthFirst {
+		rest = append(rest, keep...)
+	}
+	s.n += n
+	if s.e.verbose >= 3 {
+		fmt.Fprintf(s.e.stdout, "Spilled %d directories of the work list, %d on disk\n", n, s.n)
+	}
+	return rest
+}
+
+// Method fail reports that the overflow cannot be written, and keeps the
+// work list in memory from now on. The directories written before are still
+// read back.
+func (s *spiller) fail(err error) {
+	s.failed = true
+	if !s.e.quiet {
+		fmt.Fprintf(s.e.stderr, "WARNING - cannot spill the work list to disk, keeping it in memory: %s\n", err)
+	}
+}
+
+// Method Load appends up to n spilled directories to the work list.
+func (s *spiller) Load(worklist []interface{}, n int) []interface{} {
+	for ; n > 0 && s.n > 0; n-- {
+		dir, err := s.r.ReadString(0)
+		if err != nil {
+			// the directories are lost, so the tree needs another run
+			s.e.warning("", "could not read back %d spilled directories: %s", s.n, err)
+			for ; s.n > 0; s.n-- {
+				s.e.wg.Done()
+			}
+			break
+		}
+		worklist = append(worklist, job{dir: strings.TrimSuffix(dir, "\x00")})
+		s.n--
+	}
+	return worklist
+}
+
+// Method Len returns the number of directories in the overflow.
+func (s *spiller) Len() int {
+	return s.n
+}
+
+// Method Close removes the overflow.
+func (s *spiller) Close() {
+	if s.wfd != nil {
+		s.wfd.Close()
+		s.rfd.Close()
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Type stateData is the state of the last successful run, which is kept in
+// the state file. Only the source must match in the next run, which may copy
+// to another destination.
+type stateData struct {
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Start       time.Time `json:"start"` // start time of the last successful run
+}
+
+// Method readState reads the state file. Without a state file, e.g. on the
+// first run, nothing is skipped.
+func (e *engine) readState() error {
+	var st stateData
+	b, err := ioutil.ReadFile(e.stateFile)
+	if os.IsNotExist(err) {
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "No state file %s, copying all files\n", e.stateFile)
+		}
+		return nil
+	}
+	if err == nil {
+		err = json.Unmarshal(b, &st)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read state file %s: %s", e.stateFile, err)
+	}
+	if st.Source != e.src {
+		// the destination may change, e.g. with rotating backups
+		return fmt.Errorf("state file %s was written for copying from %s", e.stateFile, st.Source)
+	}
+	e.lastRun = st.Start
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Skipping files older than the last run at %s\n", e.lastRun.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Method writeState records the start time of a successful run in the state
+// file. Runs with errors are not recorded, so that the next run copies the
+// failed files again.
+func (e *engine) writeState() {
+	if atomic.LoadUint64(&e.total.errors) > 0 {
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "Not updating state file %s, since there were errors\n", e.stateFile)
+		}
+		return
+	}
+	b, _ := json.MarshalIndent(stateData{e.src, e.dest, e.start}, "", "\t")
+	tmp := e.stateFile + ".tmp"
+	err := ioutil.WriteFile(tmp, b, os.FileMode(0644))
+	if err == nil {
+		err = os.Rename(tmp, e.stateFile)
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - could not write state file %s: %s\n", e.stateFile, err)
+	}
+}
+
+// Method olderThanLastRun checks whether a source file was neither modified
+// nor changed (e.g. renamed or moved) since the start of the last successful
+// run.
+func (e *engine) olderThanLastRun(f os.FileInfo) bool {
+	if e.lastRun.IsZero() {
+		return false
+	}
+	t := f.ModTime()
+	if stat, ok := f.Sys().(*sysstat.Stat); ok {
+		_, _, ctime := sysstat.Times(stat)
+		if c := time.Unix(0, ctime); c.After(t) {
+			t = c
+		}
+	}
+	return t.Before(e.lastRun)
+}
@@ -0,0 +1,259 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Type counters holds the statistics of a (partial) run. The fields are
+// updated atomically, since they are shared between the copy threads.
+type counters struct {
+	dirs   uint64 // number of directories handled
+	files  uint64 // number of regular files copied
+	links  uint64 // number of symbolic links copied
+	bytes  uint64 // number of bytes copied
+	errors uint64 // number of errors and warnings
+
+	relinks uint64 // number of existing links retargeted (total only)
+	diffs   uint64 // number of entries which differ, in verify mode
+
+	conflicts uint64 // number of entries changed in both trees, in two-way mode (total only)
+}
+
+// Type workerStats holds the statistics of a single copy thread. The fields
+// are updated atomically, since they are read by the status signal handler.
+type workerStats struct {
+	dirs  uint64 // number of directories handled
+	files uint64 // number of files and links copied
+	bytes uint64 // number of bytes copied
+	busy  uint64 // time spent working on directories, in nanoseconds
+}
+
+// Method countQueue records the number of directories waiting in the work
+// list. It is only called by the dispatcher of the work pool, the values are
+// read atomically by the progress output and the reports.
+func (e *engine) countQueue(n int64) {
+	atomic.StoreInt64(&e.queueLen, n)
+	if n > atomic.LoadInt64(&e.queuePeak) {
+		atomic.StoreInt64(&e.queuePeak, n)
+	}
+}
+
+// Method group returns the counters for the immediate child of the source
+// directory the (relative) path belongs to. The source directory itself is
+// collected in the group ".".
+func (e *engine) group(path string) *counters {
+	name := strings.TrimPrefix(path, "/")
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		name = "."
+	}
+
+	e.groupMu.Lock()
+	defer e.groupMu.Unlock()
+	c, ok := e.groups[name]
+	if !ok {
+		c = &counters{}
+		e.groups[name] = c
+	}
+	return c
+}
+
+// Method countDir counts a handled directory.
+func (e *engine) countDir(id uint, dir string) {
+	atomic.AddUint64(&e.workers[id].dirs, 1)
+	atomic.AddUint64(&e.total.dirs, 1)
+	atomic.AddUint64(&e.group(dir).dirs, 1)
+}
+
+// Method countFile counts a copied regular file and its size.
+func (e *engine) countFile(id uint, file string, size int64) {
+	atomic.AddUint64(&e.workers[id].files, 1)
+	atomic.AddUint64(&e.workers[id].bytes, uint64(size))
+	for _, c := range []*counters{&e.total, e.group(file)} {
+		atomic.AddUint64(&c.files, 1)
+		atomic.AddUint64(&c.bytes, uint64(size))
+	}
+}
+
+// Method countLink counts a copied symbolic link.
+func (e *engine) countLink(id uint, file string) {
+	atomic.AddUint64(&e.workers[id].files, 1)
+	atomic.AddUint64(&e.total.links, 1)
+	atomic.AddUint64(&e.group(file).links, 1)
+}
+
+// Method countError counts an error or warning that occurred on a path. It
+// returns the total number of errors.
+func (e *engine) countError(path string) uint64 {
+	atomic.AddUint64(&e.group(path).errors, 1)
+	return atomic.AddUint64(&e.total.errors, 1)
+}
+
+// Method warning counts an error on the (relative) path, writes it to the
+// event stream, and prints the message to STDERR, unless quiet mode is requested.
+func (e *engine) warning(path string, format string, a ...interface{}) {
+	e.warn(newError(path, fmt.Sprintf(format, a...), a))
+}
+
+// Method metaWarning counts a failed change of the metadata of an entry,
+// like warning does, in the error category ErrMetadata.
+func (e *engine) metaWarning(path string, format string, a ...interface{}) {
+	err := newError(path, fmt.Sprintf(format, a...), a)
+	err.Err = &metadataError{err.Err}
+	e.warn(err)
+}
+
+// Method warn counts an error, writes it to the event stream, and prints
+// it to STDERR, unless quiet mode is requested. In strict mode, the first
+// error stops the run, otherwise exceeding the -max-errors limit does.
+func (e *engine) warn(err *Error) {
+	n := e.countError(err.Path)
+	e.countCategory(err)
+	e.emit("error", err.Path, 0, time.Time{}, err)
+	if e.onError != nil {
+		e.onErrorMu.Lock()
+		e.onError(err)
+		e.onErrorMu.Unlock()
+	}
+	e.reportError(err)
+	if e.strict {
+		// only the first error is reported, it aborts the run
+		if n == 1 {
+			fmt.Fprintf(e.stderr, "ERROR - %s\nStrict mode, stopping after the files in progress.\n", err)
+			e.halt()
+		}
+		return
+	}
+	if !e.quiet {
+		fmt.Fprintf(e.stderr, "WARNING - %s\n", err)
+	}
+	if e.maxErrors > 0 && n == e.maxErrors+1 {
+		fmt.Fprintf(e.stderr, "ERROR - more than %d errors, stopping after the files in progress.\n", e.maxErrors)
+		e.halt()
+	}
+}
+
+// Method report prints the statistics of the run to the given writer, broken
+// down by the immediate children of the source directory.
+func (e *engine) report(w io.Writer) {
+	names := make([]string, 0, len(e.groups))
+	for name := range e.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch {
+	case e.verifyMode:
+		fmt.Fprintf(w, "\nCompared %s with %s in %s, %d differences\n\n", e.src, e.dest, e.elapsed().Round(time.Millisecond), e.total.diffs)
+	case e.twoWay:
+		fmt.Fprintf(w, "\nSynchronized %s and %s in %s\n\n", e.src, e.dest, e.elapsed().Round(time.Millisecond))
+	default:
+		fmt.Fprintf(w, "\nCopied %s to %s in %s\n\n", e.src, e.dest, e.elapsed().Round(time.Millisecond))
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Dirs\tFiles\tLinks\tBytes\tErrors\t\tDirectory")
+	for _, name := range names {
+		printCounters(tw, e.groups[name], name)
+	}
+	printCounters(tw, &e.total, "TOTAL")
+	tw.Flush()
+	if e.total.relinks > 0 {
+		fmt.Fprintf(w, "\nRetargeted %d existing links\n", e.total.relinks)
+	}
+	if e.total.conflicts > 0 {
+		fmt.Fprintf(w, "\nFound %d conflicting changes\n", e.total.conflicts)
+	}
+	if e.total.errors > 0 {
+		fmt.Fprintf(w, "\nErrors by category: %s\n", e.errorSummary())
+	}
+
+	fmt.Fprintln(w)
+	e.reportWorkers(w)
+}
+
+// Method categoryCounts returns the number of errors of each category, see
+// categoryName. Errors without a category are counted as "other".
+func (e *engine) categoryCounts() map[string]uint64 {
+	e.categoryMu.Lock()
+	defer e.categoryMu.Unlock()
+	counts := make(map[string]uint64, len(e.categories))
+	for name, n := range e.categories {
+		counts[name] = n
+	}
+	return counts
+}
+
+// Method errorSummary returns the number of errors of each category as a
+// single line, the most frequent category first, like "3000 permission, 1 io".
+func (e *engine) errorSummary() string {
+	return summarize(e.categoryCounts())
+}
+
+// Function summarize formats the number of errors of each category, see
+// errorSummary.
+func summarize(counts map[string]uint64) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Function printCounters prints a line of counters to the tabwriter.
+func printCounters(tw *tabwriter.Writer, c *counters, name string) {
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t\t%s\n", c.dirs, c.files, c.links, c.bytes, c.errors, name)
+}
+
+// Method elapsed returns the duration of the run, up to now if it is still
+// running.
+func (e *engine) elapsed() time.Duration {
+	if e.end.IsZero() {
+		return time.Since(e.start)
+	}
+	return e.end.Sub(e.start)
+}
+
+// Method countBusy adds the time a copy thread spent on a directory.
+func (e *engine) countBusy(id uint, d time.Duration) {
+	atomic.AddUint64(&e.workers[id].busy, uint64(d))
+}
+
+// Method reportWorkers prints the statistics of each copy thread, including
+// the time it was busy or waiting for work, to the given writer.
+func (e *engine) reportWorkers(w io.Writer) {
+	elapsed := e.elapsed()
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Thread\tDirs\tFiles\tBytes\tBusy\tIdle\t")
+	for i := range e.workers {
+		ws := &e.workers[i]
+		busy := time.Duration(atomic.LoadUint64(&ws.busy))
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s\t\n", i,
+			atomic.LoadUint64(&ws.dirs), atomic.LoadUint64(&ws.files), atomic.LoadUint64(&ws.bytes),
+			busy.Round(time.Millisecond), (elapsed - busy).Round(time.Millisecond))
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nWork list: %d directories waiting, at most %d, %d handed out\n",
+		atomic.LoadInt64(&e.queueLen), atomic.LoadInt64(&e.queuePeak), atomic.LoadUint64(&e.queueTotal))
+}
@@ -0,0 +1,162 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Type statsFile is the summary of a run written by -stats-out.
+type statsFile struct {
+	Sources     []string                 `json:"sources"`
+	Destination string                   `json:"destination"`
+	Start       time.Time                `json:"start"`
+	End         time.Time                `json:"end"`
+	Duration    float64                  `json:"duration"` // in seconds
+	Interrupted bool                     `json:"interrupted"`
+	Total       statsCounters            `json:"total"`
+	Groups      map[string]statsCounters `json:"groups"`             // by top level directory
+	Categories  map[string]uint64        `json:"errors_by_category"` // vanished, permission, full, conflict, timeout, mismatch, unstable, io, unsupported, metadata or other
+	Threads     []statsThread            `json:"threads"`
+	WorkList    statsWorkList            `json:"work_list"`
+	Settings    map[string]interface{}   `json:"settings"` // options of the run, see settings
+}
+
+// Type statsCounters holds the counters of a run, or of a top level
+// directory, in the statistics file.
+type statsCounters struct {
+	Dirs      uint64 `json:"dirs"`
+	Files     uint64 `json:"files"`
+	Links     uint64 `json:"links"`
+	Bytes     uint64 `json:"bytes"`
+	Errors    uint64 `json:"errors"`
+	Relinks   uint64 `json:"relinks,omitempty"`
+	Diffs     uint64 `json:"diffs,omitempty"`
+	Conflicts uint64 `json:"conflicts,omitempty"`
+}
+
+// Type statsThread holds the statistics of a copy thread in the statistics
+// file. Busy and idle times are in seconds.
+type statsThread struct {
+	Dirs  uint64  `json:"dirs"`
+	Files uint64  `json:"files"`
+	Bytes uint64  `json:"bytes"`
+	Busy  float64 `json:"busy"`
+	Idle  float64 `json:"idle"`
+}
+
+// Type statsWorkList holds the statistics of the work list in the statistics
+// file.
+type statsWorkList struct {
+	Peak   int64  `json:"peak"`
+	Handed uint64 `json:"handed"`
+}
+
+// Method countCategory counts an error by its category.
+func (e *engine) countCategory(err *Error) {
+	name := categoryName(err)
+	if name == "" {
+		name = "other"
+	}
+	e.categoryMu.Lock()
+	e.categories[name]++
+	e.categoryMu.Unlock()
+}
+
+// Function newStatsCounters takes the values of the counters.
+func newStatsCounters(c *counters) statsCounters {
+	return statsCounters{
+		Dirs:      atomic.LoadUint64(&c.dirs),
+		Files:     atomic.LoadUint64(&c.files),
+		Links:     atomic.LoadUint64(&c.links),
+		Bytes:     atomic.LoadUint64(&c.bytes),
+		Errors:    atomic.LoadUint64(&c.errors),
+		Relinks:   atomic.LoadUint64(&c.relinks),
+		Diffs:     atomic.LoadUint64(&c.diffs),
+		Conflicts: atomic.LoadUint64(&c.conflicts),
+	}
+}
+
+// Method writeStatsOut writes the summary of the run to the statistics
+// file, as indented JSON. The file is written for interrupted runs, too.
+func (e *engine) writeStatsOut(o Options, interrupted bool) {
+	b, err := json.MarshalIndent(e.newStatsFile(o, interrupted), "", "\t")
+	if err == nil {
+		err = ioutil.WriteFile(e.statsOut, append(b, '\n'), os.FileMode(0644))
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "ERROR - could not write statistics %s: %s\n", e.statsOut, err)
+	}
+}
+
+// Method newStatsFile takes the summary of the run.
+func (e *engine) newStatsFile(o Options, interrupted bool) statsFile {
+	elapsed := e.elapsed()
+	sf := statsFile{
+		Sources:     e.srcs,
+		Destination: e.dest,
+		Start:       e.start,
+		End:         e.end,
+		Duration:    elapsed.Seconds(),
+		Interrupted: interrupted,
+		Total:       newStatsCounters(&e.total),
+		Groups:      make(map[string]statsCounters),
+		Categories:  e.categoryCounts(),
+		WorkList:    statsWorkList{atomic.LoadInt64(&e.queuePeak), atomic.LoadUint64(&e.queueTotal)},
+		Settings:    settings(o),
+	}
+	e.groupMu.Lock()
+	for name, c := range e.groups {
+		sf.Groups[name] = newStatsCounters(c)
+	}
+	e.groupMu.Unlock()
+	for i := range e.workers {
+		ws := &e.workers[i]
+		busy := time.Duration(atomic.LoadUint64(&ws.busy))
+		sf.Threads = append(sf.Threads, statsThread{
+			Dirs:  atomic.LoadUint64(&ws.dirs),
+			Files: atomic.LoadUint64(&ws.files),
+			Bytes: atomic.LoadUint64(&ws.bytes),
+			Busy:  busy.Seconds(),
+			Idle:  (elapsed - busy).Seconds(),
+		})
+	}
+	return sf
+}
+
+// Function settings returns the options of the run which differ from their
+// zero values, by field name. Durations are given as strings like "1m30s".
+// The backends, writers and transforms are left out.
+func settings(o Options) map[string]interface{} {
+	m := make(map[string]interface{})
+	v := reflect.ValueOf(o)
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Func, reflect.Chan:
+			continue
+		case reflect.Slice:
+			if f.Len() == 0 || f.Type().Elem().Kind() == reflect.Interface {
+				continue
+			}
+		default:
+			if f.Interface() == reflect.Zero(f.Type()).Interface() {
+				continue
+			}
+		}
+		if d, ok := f.Interface().(time.Duration); ok {
+			m[v.Type().Field(i).Name] = d.String()
+		} else {
+			m[v.Type().Field(i).Name] = f.Interface()
+		}
+	}
+	return m
+}
@@ -0,0 +1,96 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stealMin is the number of remaining entries from which a copy thread offers
+// the rest of its directory to idle copy threads.
+const stealMin = 100
+
+// Type sharedDir is the list of entries of a directory, which is handled by
+// the copy thread which read it, and by the copy threads helping with it.
+type sharedDir struct {
+	e      *engine // engine of the run
+	d      *dirState
+	files  []os.FileInfo
+	rules  []*rule
+	next   int64      // index of the next entry to handle (atomic)
+	seenMu sync.Mutex // guards seen
+	seen   map[string]string
+}
+
+// Method startStealing prepares the steal channel of a phase. A single
+// copy thread has nobody to share with.
+func (e *engine) startStealing() {
+	e.sch = nil
+	if e.threads > 1 {
+		e.sch = make(chan interface{})
+	}
+}
+
+// Method work handles the entries of the directory, until none is left or
+// psync is interrupted. The owner, the copy thread which read the directory,
+// offers the rest to idle copy threads while enough entries are left.
+func (s *sharedDir) work(id uint, owner bool) {
+	n := int64(len(s.files))
+	for {
+		if s.e.stopping() {
+			if atomic.LoadInt64(&s.next) < n {
+				// interrupted, the directory is resumed as a whole
+				atomic.StoreInt32(&s.d.partial, 1)
+			}
+			return
+		}
+		i := atomic.AddInt64(&s.next, 1) - 1
+		if i >= n {
+			return
+		}
+		if owner && n-i > stealMin {
+			s.offer()
+		}
+		s.e.handleEntry(id, s, s.files[i])
+	}
+}
+
+// Method offer hands the directory over to an idle copy thread, if there is
+// one. The helper holds the directory open, like the owner does, so it is
+// counted before the offer.
+func (s *sharedDir) offer() {
+	atomic.AddInt32(&s.d.pending, 1)
+	select {
+	case s.e.sch <- s:
+	default:
+		atomic.AddInt32(&s.d.pending, -1)
+	}
+}
+
+// Method collision checks an entry for a case collision with the entries
+// seen so far, see caseCollision.
+func (s *sharedDir) collision(dir, name string) bool {
+	s.seenMu.Lock()
+	defer s.seenMu.Unlock()
+	return s.e.caseCollision(dir, name, s.seen)
+}
+
+// Method helpDir handles entries of a directory offered by another copy
+// thread.
+func (e *engine) helpDir(id uint, s *sharedDir) {
+	begin := time.Now()
+	e.setBusy(id, e.src+s.d.j.dir)
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Helping with directory %s%s\n", id, e.src, s.d.j.dir)
+	}
+	s.work(id, false)
+	e.setBusy(id, "")
+	e.countBusy(id, time.Since(begin))
+	s.d.release(id)
+}
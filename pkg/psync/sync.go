@@ -0,0 +1,55 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+)
+
+// Method existingDir checks whether the destination path is an existing
+// directory.
+func (e *engine) existingDir(name string) bool {
+	e.op()
+	stat, err := e.destination.Lstat(name)
+	return err == nil && stat.IsDir()
+}
+
+// Method mergeDirs checks whether existing destination directories are
+// descended into, instead of being reported as conflict: in sync mode, when
+// merging further sources, with a state database, when skipping the files
+// older than the last run, when replaying the changes of a watched source,
+// and in two-way mode.
+func (e *engine) mergeDirs() bool {
+	return e.syncMode || e.phase > 0 || e.stateDB != "" || e.sinceLast || e.watch != nil || e.twoWay
+}
+
+// Method unchanged checks whether the destination file is up to date with
+// the source file. This is the case if it is a regular file with the same size
+// and modification time (in seconds), or only the same size with -size-only.
+// With -ignore-times, no file is up to date. The sizes of transformed files
+// differ, only their modification times are compared.
+func (e *engine) unchanged(f os.FileInfo, name string) bool {
+	if e.ignoreTimes {
+		return false
+	}
+	e.op()
+	stat, err := e.destination.Lstat(name)
+	if err != nil || !stat.Mode().IsRegular() {
+		return false
+	}
+	return (stat.Size() == f.Size() || len(e.transforms) > 0) && (e.sizeOnly || stat.ModTime().Unix() == f.ModTime().Unix())
+}
+
+// Method newer checks whether the destination file is newer than the source
+// file, i.e. it is a regular file with a later modification time (in
+// seconds). Files of the same time are not newer, even if their sizes differ.
+func (e *engine) newer(f os.FileInfo, name string) bool {
+	e.op()
+	stat, err := e.destination.Lstat(name)
+	if err != nil || !stat.Mode().IsRegular() {
+		return false
+	}
+	return stat.ModTime().Unix() > f.ModTime().Unix()
+}
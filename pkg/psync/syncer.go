@@ -0,0 +1,881 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package psync copies directory trees in parallel. It is the engine of the
+// psync command, and can be used by other programs to run a copy without
+// starting a separate process:
+//
+//	s := psync.New(psync.Options{Source: "/data", Destination: "/backup", Times: true})
+//	if err := s.Run(ctx); err != nil {
+//		...
+//	}
+//	st := s.Stats()
+//
+// A Syncer can be run several times. Each run has its own state, so several
+// Syncers can run in parallel in a process, e.g. to copy different trees at
+// the same time. The runs of a single Syncer are executed one after the
+// other, and its methods Stats, Report, ReportErrors and ReportWorkers return
+// the counters of its current or last run.
+package psync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInterrupted is returned by Run when the run was stopped before all
+// directories were handled, by cancelling the context, in strict mode, after
+// too many errors, or when the lease on the destination was lost. The
+// remaining directories are written to the checkpoint file. If errors
+// occurred as well, it is wrapped by a RunError.
+var ErrInterrupted = errors.New("run interrupted, checkpoint written")
+
+// Type Options holds the settings of a run. The fields correspond to the
+// command line options of psync; zero values select the defaults of the
+// command, except for Stall and FileProgress, which are disabled by zero.
+type Options struct {
+	Source      string // source directory or local file, nfs://host/path, [user@]host:path or bucket URL
+	Destination string // destination directory, [user@]host:path or bucket URL
+
+	Sources   []string // further source trees, merged into the destination after Source
+	Conflicts string   // handling of entries in several sources: first (default), last or error
+
+	Threads  uint // number of copy threads (default 16, at most 1024)
+	Verbose  int  // verbosity level (0..3)
+	Quiet    bool // do not print warnings
+	Times    bool // preserve time stamps
+	Owner    bool // preserve user and group ownership
+	NoPerms  bool // do not apply the permissions of the source to existing entries, reduce those of new entries by the umask
+	Create   bool // create the destination directory, if needed
+	Force    bool // copy a source which lies inside the destination
+	Slash    bool // copy source directories without a trailing slash into the destination, like rsync
+	Sync     bool // copy only entries which are missing or differ in size or mtime
+	Strict   bool // stop on the first error
+	Progress bool // print progress and throughput periodically
+
+	DataThreads   uint   // number of threads copying the files, 0 if the copy threads copy them (at most 1024)
+	Prefetch      uint   // number of scanners reading directories ahead of the copy threads, 0 to disable
+	Traversal     string // order of the traversal: dfs (depth-first, default) or bfs (breadth-first)
+	MaxQueue      uint   // number of directories waiting in the work list, before the copy threads descend themselves, 0 for no limit
+	Spill         uint   // length of the work list from which parts of it are moved to a temporary file, 0 to keep it in memory
+	Order         string // order of the files of a directory: small or large files first, default directory order
+	Deterministic bool   // handle the directories and files one by one, sorted by name, for reproducible runs
+	Fsync         bool   // sync each destination file after copying it
+	FsyncDir      bool   // sync each destination directory after creating its entries
+	Preallocate   bool   // allocate the blocks of destination files before copying (Linux only)
+	CopyRange     bool   // copy the content of local files in the kernel, with copy_file_range (Linux only)
+	Sparse        bool   // leave blocks of zeros as holes in the destination files (local destinations)
+	CopyDevices   bool   // copy the content of block devices to regular files
+	DropCache     string // drop the copied files from the page cache: source, dest or both
+	Interactive   bool   // ask on the terminal before overwriting differing files, implies Sync
+	Update        bool   // keep destination files which are newer than their source files, implies Sync
+	SizeOnly      bool   // files of the same size are up to date, regardless of their modification times, implies Sync
+	IgnoreTimes   bool   // copy all files, even if they look up to date in sync mode or by the state database, do not compare times in verify mode
+	Estimate      bool   // scan the source trees first, for the percentage done and the time left in the progress output
+
+	Chmod string // rules changing the permissions of the destination entries, like rsync --chmod (e.g. "D2775,F664")
+	Chown string // owner of the destination entries, "user", "user:group" or ":group"
+
+	NumericIDs bool   // keep the owner IDs of remote sources, instead of matching the user and group names
+	FakeSuper  bool   // keep the owner, device numbers and special bits in attributes, like rsync --fake-super
+	UserMap    string // file mapping source user IDs or names to destination IDs
+	GroupMap   string // file mapping source group IDs or names to destination IDs
+
+	Events    string // file for the NDJSON event stream ("-" for Stdout)
+	EventsFd  uint   // open file descriptor for the NDJSON event stream
+	ErrorsTo  string // file for the report of failed paths
+	StatsOut  string // file for the JSON summary of the run
+	AuditLog  string // file to which a record of each change of the destination is appended
+	NotifyURL string // URL the summary of the run is posted to as JSON, when it ends
+	NotifyCmd string // shell command the summary of the run is passed to on STDIN, when it ends
+	PreCmd    string // shell command run before the copy, which fails the run if it fails
+	PostCmd   string // shell command run after the copy, also if it failed
+	FileCmd   string // shell command run for each completed entry
+
+	Stall        time.Duration // warn when no progress was made for this duration
+	FileProgress uint          // report the progress of files larger than this number of MB
+	FileTimeout  time.Duration // abandon file copies without progress for this duration
+
+	ShardTemplate string // template for sharded destination names
+	ShardHash     string // hash function for sharding (default md5)
+	ShardManifest string // shard manifest (default <destination>/.psync-manifest)
+
+	FilterFrom    []string // files with rsync filter rules
+	ExcludeFrom   []string // files with rsync exclude patterns
+	FilesFrom     string   // file with the list of paths to copy ("-" for STDIN)
+	From0         bool     // the paths of FilesFrom are separated by NUL characters
+	ExistingLinks string   // handling of existing links: warn (default), skip or replace
+
+	IOPS         uint          // maximum number of metadata operations per second
+	Retries      uint          // number of retries on transient errors
+	RetryDelay   time.Duration // initial delay between retries (default 1s)
+	MaxErrors    uint64        // stop after more than this number of errors
+	ChunkJournal uint          // chunk size in MB of the journal for resuming large files
+	PartialDir   string        // directory in the destination root where files are written while they are copied (e.g. .psync-partial)
+	TempDir      string        // directory in the destination root for the temporary files of atomic replacements
+
+	Checkpoint     string        // checkpoint file (default <destination>/.psync-checkpoint)
+	Resume         bool          // resume an interrupted run from the checkpoint file
+	SinceLast      bool          // skip files older than the last successful run
+	Watch          bool          // follow the changes of a local source after the copy
+	Interval       time.Duration // repeat the sync after this duration, until cancelled
+	StateDB        string        // database of the synced entries, to skip unchanged entries of the source
+	StateFile      string        // state file (default <destination>/.psync-state)
+	KeepAtime      bool          // restore the access times of the source directories
+	LinkDest       []string      // reference directories to hard-link unchanged files from
+	Dedup          bool          // hard-link files with identical content on the destination
+	CaseCollisions string        // handling of source names differing only in case on case-insensitive destinations: skip (default) or rename
+	Normalize      string        // matching of names in other Unicode normalization forms: match, nfc or nfd
+	TwoWay         bool          // propagate the changes of both trees since the last two-way run
+	Resolve        string        // handling of conflicting changes in two-way mode: newer (default), rename or skip
+	OnlyType       string        // entry types to copy, like "f,l": f, d, l, b, c, p or s (default all)
+	SkipSymlinks   bool          // do not copy symbolic links
+
+	Lock        bool          // take a lease on the destination directory
+	LockTimeout time.Duration // take over leases without heartbeat for this duration (default 2m)
+	NFSConns    uint          // number of connections to an nfs:// source (default 4)
+	SSH         string        // ssh command for remote trees, with options (default "ssh")
+	SFTPConns   uint          // number of SFTP sessions to a remote tree (default 4)
+	S3Endpoint  string        // URL of an S3 compatible service (default AWS)
+	SecretFile  string        // file with the shared secret for psync daemons (default $PSYNC_SECRET)
+	Delta       bool          // transfer only the changed blocks of changed files to a psync daemon
+	WholeFile   bool          // copy changed files as a whole, also to destinations supporting delta transfers
+	Compress    bool          // compress the traffic to remote trees and psync daemons
+	Verify      bool          // compare the trees, and report the differences without copying
+	VerifyAfter bool          // read copied files back, and compare their hash with the source
+	Unstable    string        // handling of source files changed while copying: skip or retry, "" for no check
+	Checksum    string        // hash function for checksums: sha256 (default), sha512, blake3, xxh3, sha1, md5 or crc32c
+	Transforms  []Transform   // filters applied to the content of regular files while copying, in order
+	OnError     func(*Error)  // function called for each error on an entry, one call at a time
+
+	SourceFS      FS // backend of the source tree (default local, nfs:// or remote source)
+	DestinationFS FS // backend of the destination tree (default local or remote destination)
+
+	Stdout io.Writer // output of verbose messages (default os.Stdout)
+	Stderr io.Writer // output of warnings and progress (default os.Stderr)
+}
+
+// Type Stats holds the counters of a run.
+type Stats struct {
+	Dirs      uint64        // number of directories handled
+	Files     uint64        // number of regular files copied
+	Links     uint64        // number of symbolic links copied
+	Bytes     uint64        // number of bytes copied
+	Errors    uint64        // number of errors and warnings
+	Relinks   uint64        // number of existing links retargeted
+	Conflicts uint64        // number of entries changed in both trees, in two-way mode
+	Diffs     uint64        // number of entries which differ, in verify mode
+	Duration  time.Duration // duration of the run
+
+	Categories map[string]uint64 // number of errors by category: vanished, permission, full, conflict, timeout, mismatch, unstable, io, unsupported, metadata or other
+
+	Queued    int64  // number of directories waiting in the work list
+	QueuePeak int64  // highest number of directories waiting in the work list
+	Handed    uint64 // number of directories handed out to the copy threads
+}
+
+// Type Syncer copies a directory tree with the given options. Each run has
+// its own state, so several Syncers can run at the same time. The runs of a
+// single Syncer are executed one after the other.
+type Syncer struct {
+	opts  Options
+	runMu sync.Mutex // serializes the runs of the Syncer
+	mu    sync.Mutex // protects e
+	e     *engine    // state of the current or last run, nil before the first run
+}
+
+// Function New creates a Syncer for the given options.
+func New(opts Options) *Syncer {
+	return &Syncer{opts: opts}
+}
+
+// Method Run copies the source to the destination directory. Cancelling the
+// context stops the run after the files in progress, like SIGINT does for
+// the psync command. Errors on single entries do not stop the run; they are
+// counted, passed to OnError, and returned as a RunError at the end. Run
+// returns another error if the run could not be started, or ErrInterrupted
+// if it was stopped.
+func (s *Syncer) Run(ctx context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	e := new(engine)
+	if err := e.setup(s.opts); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.e = e
+	s.mu.Unlock()
+
+	var err error
+	if e.preCmd != "" {
+		err = e.preHook()
+	}
+	if err == nil {
+		err = e.run(ctx, s.opts)
+		if e.postCmd != "" {
+			err = e.postHook(err)
+		}
+	}
+	if e.notifyURL != "" || e.notifyCmd != "" {
+		e.notify(s.opts, err)
+	}
+	return e.runError(err)
+}
+
+// Method run copies the source to the destination directory with the
+// options o, see Syncer.Run.
+func (e *engine) run(ctx context.Context, o Options) error {
+	defer close(e.finished)
+
+	// cancelling the context stops the run
+	go func(stop chan struct{}, once *sync.Once, done chan struct{}) {
+		select {
+		case <-ctx.Done():
+			once.Do(func() { close(stop) })
+		case <-done:
+		}
+	}(e.stop, e.stopOnce, e.finished)
+
+	// connect to the trees, and check or create the destination directory
+	defer e.closeTrees()
+	if err := e.openDestination(); err != nil {
+		return err
+	}
+	if err := e.prepareDestDir(); err != nil {
+		return err
+	}
+	if _, ok := e.destination.(archiveFS); ok && e.normalize != "" {
+		return errors.New("option -normalize is not supported for archive destinations")
+	}
+	if _, ok := e.destination.(localFS); e.fakeSuper && !ok {
+		return errors.New("option -fake-super is only supported for local destinations")
+	}
+	if _, ok := e.destination.(localFS); e.sparse && !ok {
+		if !e.quiet {
+			fmt.Fprintf(e.stderr, "WARNING - option -sparse has no effect, destination %s is not local\n", e.dest)
+		}
+		e.sparse = false
+	}
+	e.caseFold = !e.verifyMode && e.probeCase()
+	if e.caseFold && e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Destination %s is case-insensitive\n", e.dest)
+	}
+	if e.normalize != "" || (e.caseFold && e.caseCollisions == "rename" && !e.verifyMode && !e.twoWay) {
+		e.destination = e.newNormFS(e.destination, e.normalize)
+	}
+	if _, ok := e.destination.(archiveFS); ok && (e.verifyMode || e.verifyAfter || e.unstable != "") {
+		return errors.New("options -verify, -verify-after and -unstable are not supported for archive destinations")
+	}
+	if _, ok := e.destination.(archiveFS); ok && (e.partialDir != "" || e.tempDir != "") {
+		return errors.New("options -partial-dir and -temp-dir are not supported for archive destinations")
+	}
+	if e.tempDir != "" && !e.verifyMode {
+		if err := e.destination.MkdirAll("/"+e.tempDir, os.FileMode(0700)); err != nil {
+			return fmt.Errorf("cannot create temporary directory %s: %s", e.dest+"/"+e.tempDir, err)
+		}
+	}
+	if len(e.linkDest) > 0 {
+		if err := e.initLinkDest(); err != nil {
+			return err
+		}
+	}
+	if _, ok := e.destination.(localFS); e.dedupMode && !ok {
+		return errors.New("option -dedup is only supported for local destinations")
+	}
+	if _, ok := e.destination.(deltaFS); e.deltaMode && !ok && !e.quiet {
+		fmt.Fprintf(e.stderr, "WARNING - option -delta has no effect, destination %s does not support delta transfers\n", e.dest)
+	}
+	if _, ok := e.destination.(deltaFS); ok && !o.WholeFile && len(e.transforms) == 0 {
+		// remote destinations get delta transfers by default, like with rsync
+		e.deltaMode = true
+	}
+	if err := e.openSources(o.SourceFS); err != nil {
+		return err
+	}
+	if e.watchMode {
+		root, ok := e.source.(localFS)
+		if !ok {
+			return errors.New("option -watch is only supported for local sources")
+		}
+		var err error
+		if e.watch, err = e.newWatcher(string(root)); err != nil {
+			return err
+		}
+		defer e.watch.close()
+	}
+	if e.lock {
+		defer e.releaseLock()
+		if err := e.acquireLock(); err != nil {
+			return err
+		}
+	}
+
+	// open the event stream and the report of failed entries
+	var err error
+	if e.events != "" {
+		err = e.openEvents(e.events)
+	} else if e.eventsFdNum > 0 {
+		err = e.openEventsFd(e.eventsFdNum)
+	}
+	if err != nil {
+		return err
+	}
+	defer e.closeEvents()
+	if e.errorsTo != "" {
+		if err := e.openErrors(e.errorsTo); err != nil {
+			return err
+		}
+	}
+	defer e.closeErrors()
+	if e.auditLog != "" {
+		if err := e.openAudit(e.auditLog); err != nil {
+			return err
+		}
+	}
+	defer e.closeAudit()
+	if e.interactive {
+		if err := e.openTTY(); err != nil {
+			return err
+		}
+		defer e.closeTTY()
+	}
+
+	// read filter rules, the state of the last run and the directories to copy
+	if err := e.initFilters(); err != nil {
+		return err
+	}
+	if e.sinceLast {
+		if err := e.readState(); err != nil {
+			return err
+		}
+	}
+	if e.stateDB != "" {
+		if err := e.readDB(); err != nil {
+			return err
+		}
+	}
+	var dirs, list []string
+	if e.resume {
+		if dirs, err = e.readCheckpoint(); err != nil {
+			return err
+		}
+	} else if e.filesFrom != "" {
+		if list, err = e.readFileList(e.filesFrom); err != nil {
+			return err
+		}
+	}
+
+	// initialize sharding of destination names
+	if e.shardTemplate != "" {
+		if err := e.initShard(); err != nil {
+			return err
+		}
+	}
+	defer e.closeShard()
+
+	// initialize buffers
+	n := e.threads + e.dataThreads
+	e.busy = make([]string, n)
+	e.busySince = make([]time.Time, n)
+	e.fileSize = make([]int64, n)
+	e.fileSums = make([][]byte, n)
+	e.copies = make([]*fileCopy, n)
+	e.workers = make([]workerStats, n)
+
+	// copy the top level directory, or the directories left over from an
+	// interrupted run, of each source in turn
+	e.start = time.Now()
+	go e.monitor(e.finished)
+	if e.twoWay {
+		if !e.localTrees() {
+			return errors.New("option -two-way is only supported for local trees")
+		}
+		if e.nested != "" {
+			return errors.New("option -two-way is not supported for nested trees")
+		}
+		if err := e.syncTwoWay(); err != nil {
+			return err
+		}
+	} else if err := e.syncSources(dirs, list); err != nil {
+		return err
+	}
+
+	// the watch and the repeated syncs end normally with the cancelling of
+	// the context
+	interrupted := e.stopping()
+	if e.watch != nil && !interrupted {
+		e.watchSource()
+		interrupted = ctx.Err() == nil || len(e.pending) > 0
+	}
+	if e.interval > 0 && !interrupted {
+		if err := e.repeatSync(list); err != nil {
+			return err
+		}
+		interrupted = ctx.Err() == nil || len(e.pending) > 0
+	}
+	e.end = time.Now()
+	if e.statsOut != "" {
+		e.writeStatsOut(o, interrupted)
+	}
+
+	// complete the archive, the entries still waiting for their metadata
+	// are written now
+	if a, ok := e.destination.(archiveFS); ok {
+		if err := a.Close(); err != nil {
+			return fmt.Errorf("cannot write archive %s: %s", e.dest, err)
+		}
+	}
+
+	if e.stateDB != "" {
+		// interrupted runs keep the entries they did not visit
+		e.dbDirty = e.dbDirty || interrupted
+		e.writeDB()
+	}
+	if interrupted {
+		if e.verifyMode || e.twoWay {
+			// nothing to resume, two-way runs start over
+		} else if len(e.sources) == 1 {
+			e.writeCheckpoint()
+		} else if !e.quiet {
+			fmt.Fprintln(e.stderr, "WARNING - no checkpoint is written for runs with several sources")
+		}
+		return ErrInterrupted
+	}
+	if e.resume {
+		os.Remove(e.checkpoint)
+	}
+	if e.partialDir != "" && !e.verifyMode {
+		e.removePartialDirs("/" + e.partialDir)
+	}
+	if e.tempDir != "" && !e.verifyMode {
+		// files left over from aborted runs keep the directory
+		e.destination.Remove("/" + e.tempDir)
+	}
+	if e.sinceLast && !e.verifyMode {
+		e.writeState()
+	}
+	if e.times && !e.verifyMode && (e.resume || e.partialDir != "" || e.tempDir != "" || e.sinceLast) {
+		e.retouchRoot()
+	}
+	return nil
+}
+
+// Method setup checks the options o, and initializes the state of the
+// engine for a new run.
+func (e *engine) setup(o Options) error {
+	if o.Source == "" || o.Destination == "" {
+		return errors.New("source and destination directory must be given")
+	}
+	if o.Threads > 1024 {
+		return fmt.Errorf("at most 1024 threads are supported, not %d", o.Threads)
+	}
+	if o.DataThreads > 1024 {
+		return fmt.Errorf("at most 1024 data threads are supported, not %d", o.DataThreads)
+	}
+	if o.Prefetch > 1024 {
+		return fmt.Errorf("at most 1024 scanners are supported, not %d", o.Prefetch)
+	}
+	if o.Events != "" && o.EventsFd > 0 {
+		return errors.New("an event stream file and file descriptor cannot be given both")
+	}
+	if o.ExistingLinks == "" && o.TwoWay {
+		// changed links are propagated like files
+		o.ExistingLinks = "replace"
+	}
+	if o.ExistingLinks == "" {
+		o.ExistingLinks = "warn"
+	}
+	if o.ExistingLinks != "warn" && o.ExistingLinks != "skip" && o.ExistingLinks != "replace" {
+		return fmt.Errorf("unknown handling of existing links %s", o.ExistingLinks)
+	}
+	if o.Conflicts == "" {
+		o.Conflicts = "first"
+	}
+	if o.Conflicts != "first" && o.Conflicts != "last" && o.Conflicts != "error" {
+		return fmt.Errorf("unknown handling of conflicts %s", o.Conflicts)
+	}
+	if o.Interval < 0 {
+		return fmt.Errorf("invalid interval %s", o.Interval)
+	}
+	if o.Estimate && (o.Verify || o.TwoWay || o.Watch || o.Resume || o.FilesFrom != "") {
+		return errors.New("option -estimate is not supported with -verify, -two-way, -watch, -resume and -files-from")
+	}
+	if o.Deterministic && (o.DataThreads > 0 || o.TwoWay || o.Watch) {
+		return errors.New("option -deterministic is not supported with -data-threads, -two-way and -watch")
+	}
+	if o.PartialDir != "" && (strings.Contains(o.PartialDir, "/") || o.PartialDir == "." || o.PartialDir == "..") {
+		return fmt.Errorf("partial directory %s must be a name in the destination directory", o.PartialDir)
+	}
+	if o.TempDir != "" && (strings.Contains(o.TempDir, "/") || o.TempDir == "." || o.TempDir == "..") {
+		return fmt.Errorf("temporary directory %s must be a name in the destination directory", o.TempDir)
+	}
+	if o.Sparse && o.Preallocate {
+		return errors.New("options -sparse and -preallocate exclude each other")
+	}
+	if o.IgnoreTimes && (o.SizeOnly || o.SinceLast) {
+		return errors.New("option -ignore-times is not supported with -size-only and -since-last")
+	}
+	if o.Delta && o.WholeFile {
+		return errors.New("options -delta and -whole-file exclude each other")
+	}
+	if len(o.Transforms) > 0 {
+		// the destination files differ from the source files
+		switch {
+		case o.Verify || o.VerifyAfter || o.TwoWay:
+			return errors.New("transforms are not supported with -verify, -verify-after and -two-way")
+		case o.Delta || o.ChunkJournal > 0 || o.Preallocate:
+			return errors.New("transforms are not supported with -delta, -chunk-journal and -preallocate")
+		case o.SizeOnly || len(o.LinkDest) > 0 || o.Dedup:
+			return errors.New("transforms are not supported with -size-only, -link-dest and -dedup")
+		}
+	}
+	if o.Interval > 0 && (o.Watch || o.Verify) {
+		return errors.New("option -interval is not supported with -watch and -verify")
+	}
+	if len(o.LinkDest) > 0 && o.ShardTemplate != "" {
+		return errors.New("option -link-dest is not supported for sharded destinations")
+	}
+	if o.Dedup && (o.ShardTemplate != "" || o.Verify) {
+		return errors.New("option -dedup is not supported with sharded destinations and -verify")
+	}
+	if o.CaseCollisions == "" {
+		o.CaseCollisions = "skip"
+	}
+	if o.CaseCollisions != "skip" && o.CaseCollisions != "rename" {
+		return fmt.Errorf("unknown handling of case collisions %s", o.CaseCollisions)
+	}
+	if o.CaseCollisions == "rename" && (len(o.LinkDest) > 0 || o.Dedup || o.TwoWay) {
+		return errors.New("option -case-collisions rename is not supported with -link-dest, -dedup and -two-way")
+	}
+	if o.Normalize != "" {
+		switch {
+		case o.Normalize != "match" && o.Normalize != "nfc" && o.Normalize != "nfd":
+			return fmt.Errorf("unknown normalization %s", o.Normalize)
+		case o.Verify || o.TwoWay:
+			return errors.New("option -normalize is not supported with -verify and -two-way")
+		case len(o.LinkDest) > 0 || o.Dedup:
+			return errors.New("option -normalize is not supported with -link-dest and -dedup")
+		case o.ShardTemplate != "":
+			return errors.New("option -normalize is not supported for sharded destinations")
+		}
+	}
+	var rules []chmodRule
+	if o.Chmod != "" {
+		var err error
+		if rules, err = parseChmod(o.Chmod); err != nil {
+			return err
+		}
+	}
+	uid, gid := -1, -1
+	if o.Chown != "" {
+		var err error
+		if uid, gid, err = parseChown(o.Chown); err != nil {
+			return err
+		}
+	}
+	var umap, gmap idMap
+	if o.UserMap != "" {
+		var err error
+		if umap, err = readIDMap(o.UserMap, false); err != nil {
+			return err
+		}
+	}
+	if o.GroupMap != "" {
+		var err error
+		if gmap, err = readIDMap(o.GroupMap, true); err != nil {
+			return err
+		}
+	}
+	mapped := o.UserMap != "" || o.GroupMap != ""
+	if o.FakeSuper && runtime.GOOS != "linux" {
+		return errors.New("option -fake-super is only supported on Linux")
+	}
+	fake := o.FakeSuper && os.Geteuid() != 0
+	if o.Traversal != "" && o.Traversal != "dfs" && o.Traversal != "bfs" {
+		return fmt.Errorf("unknown traversal %s", o.Traversal)
+	}
+	if o.Order != "" && o.Order != "small" && o.Order != "large" {
+		return fmt.Errorf("unknown order of files %s", o.Order)
+	}
+	if o.DropCache != "" && o.DropCache != "source" && o.DropCache != "dest" && o.DropCache != "both" {
+		return fmt.Errorf("unknown page cache handling %s", o.DropCache)
+	}
+	types, ok := parseTypes(o.OnlyType)
+	if !ok {
+		return fmt.Errorf("unknown entry types %s", o.OnlyType)
+	}
+	if o.Unstable != "" && o.Unstable != "skip" && o.Unstable != "retry" {
+		return fmt.Errorf("unknown handling of changing files %s", o.Unstable)
+	}
+	if o.Resolve == "" {
+		o.Resolve = "newer"
+	}
+	if o.Resolve != "newer" && o.Resolve != "rename" && o.Resolve != "skip" {
+		return fmt.Errorf("unknown handling of conflicting changes %s", o.Resolve)
+	}
+	if o.TwoWay {
+		switch {
+		case o.Verify || o.Watch || o.Interval > 0:
+			return errors.New("option -two-way is not supported with -verify, -watch and -interval")
+		case len(o.Sources) > 0:
+			return errors.New("option -two-way is not supported with several sources")
+		case o.ShardTemplate != "":
+			return errors.New("option -two-way is not supported for sharded destinations")
+		case o.Resume || o.FilesFrom != "" || o.StateDB != "" || o.SinceLast:
+			return errors.New("option -two-way is not supported with -resume, -files-from, -state-db and -since-last")
+		case len(o.LinkDest) > 0 || o.Dedup:
+			return errors.New("option -two-way is not supported with -link-dest and -dedup")
+		case o.Interactive || o.Update:
+			return errors.New("option -two-way is not supported with -interactive and -update")
+		}
+	}
+	if o.Watch {
+		switch {
+		case o.Verify:
+			return errors.New("option -watch is not supported with -verify")
+		case len(o.Sources) > 0:
+			return errors.New("option -watch is not supported with several sources")
+		case o.ShardTemplate != "":
+			return errors.New("option -watch is not supported for sharded destinations")
+		case o.Resume || o.FilesFrom != "":
+			return errors.New("option -watch is not supported with -resume and -files-from")
+		}
+	}
+	if o.Verify {
+		switch {
+		case o.StateDB != "":
+			return errors.New("option -verify is not supported with -state-db")
+		case len(o.Sources) > 0:
+			return errors.New("option -verify is not supported with several sources")
+		case o.ShardTemplate != "":
+			return errors.New("option -verify is not supported for sharded destinations")
+		case o.Resume:
+			return errors.New("option -verify is not supported with -resume")
+		case o.Create:
+			return errors.New("option -verify cannot create the destination")
+		}
+	}
+	if len(o.Sources) > 0 {
+		switch {
+		case o.SourceFS != nil:
+			return errors.New("a source backend cannot be given with several sources")
+		case o.Resume:
+			return errors.New("option -resume is not supported with several sources")
+		case o.StateDB != "":
+			return errors.New("option -state-db is not supported with several sources")
+		case o.FilesFrom != "":
+			return errors.New("option -files-from is not supported with several sources")
+		}
+		for _, s := range o.Sources {
+			if s == "" {
+				return errors.New("source directory must not be empty")
+			}
+		}
+	}
+	if _, ok := checksums[o.Checksum]; !ok && o.Checksum != "" {
+		return fmt.Errorf("unknown checksum %s", o.Checksum)
+	}
+	if o.LockTimeout < 0 {
+		return fmt.Errorf("invalid lock timeout %s", o.LockTimeout)
+	}
+
+	// options with defaults
+	if o.Threads == 0 {
+		o.Threads = 16
+	}
+	if o.Deterministic {
+		o.Threads, o.Prefetch = 1, 0
+	}
+	if o.Checksum == "" {
+		o.Checksum = "sha256"
+	}
+	if o.Dedup && (o.Checksum == "crc32c" || o.Checksum == "xxh3") {
+		return fmt.Errorf("option -dedup needs a cryptographic checksum, not %s", o.Checksum)
+	}
+	if o.ShardHash == "" {
+		o.ShardHash = "md5"
+	}
+	if o.RetryDelay == 0 {
+		o.RetryDelay = time.Second
+	}
+	if o.LockTimeout == 0 {
+		o.LockTimeout = 2 * time.Minute
+	}
+	if o.NFSConns == 0 {
+		o.NFSConns = 4
+	}
+	if o.SFTPConns == 0 {
+		o.SFTPConns = 4
+	}
+	if o.SSH == "" {
+		o.SSH = "ssh"
+	}
+	ctlDir := o.Destination + "/"
+	if remote(o.Destination) {
+		// control files are local, use the working directory
+		ctlDir = ""
+	}
+	if o.Checkpoint == "" {
+		o.Checkpoint = ctlDir + ".psync-checkpoint"
+	}
+	if o.StateFile == "" {
+		o.StateFile = ctlDir + ".psync-state"
+	}
+	if o.ShardManifest == "" {
+		o.ShardManifest = ctlDir + ".psync-manifest"
+	}
+	if o.Stdout == nil && o.Destination == "tar:-" {
+		// the archive is written to Stdout
+		o.Stdout = os.Stderr
+	}
+	if o.Stdout == nil {
+		o.Stdout = os.Stdout
+	}
+	if o.Stderr == nil {
+		o.Stderr = os.Stderr
+	}
+
+	e.src, e.dest = o.Source, o.Destination
+	e.threads, e.dataThreads, e.scanners = o.Threads, o.DataThreads, o.Prefetch
+	e.verbose, e.quiet = o.Verbose, o.Quiet
+	e.order, e.deterministic = o.Order, o.Deterministic
+	e.breadthFirst, e.maxQueue, e.spillAt = o.Traversal == "bfs", int64(o.MaxQueue), int(o.Spill)
+	e.preallocate, e.sparse, e.fsyncFiles, e.fsyncDirs = o.Preallocate, o.Sparse, o.Fsync, o.FsyncDir
+	e.copyRange = o.CopyRange
+	e.copyDevices, e.update, e.sizeOnly, e.ignoreTimes = o.CopyDevices, o.Update, o.SizeOnly, o.IgnoreTimes
+	e.dropSource, e.dropDest = o.DropCache == "source" || o.DropCache == "both", o.DropCache == "dest" || o.DropCache == "both"
+	e.times, e.owner, e.create, e.force = o.Times, o.Owner || mapped || o.Chown != "" || fake, o.Create, o.Force
+	e.sourceOwner, e.chownUID, e.chownGID = o.Owner || mapped, uid, gid
+	e.userMap, e.groupMap = umap, gmap
+	e.fakeSuper, e.fakeSource = fake, o.FakeSuper
+	e.numericIDs, e.userIDs, e.groupIDs = o.NumericIDs, make(map[string]int), make(map[string]int)
+	e.perms, e.chmodRules = !o.NoPerms, rules
+	e.syncMode, e.interactive, e.strict, e.progress = o.Sync || o.Interactive || o.SizeOnly || o.Update, o.Interactive, o.Strict, o.Progress
+	e.events, e.eventsFdNum, e.errorsTo, e.statsOut, e.auditLog = o.Events, o.EventsFd, o.ErrorsTo, o.StatsOut, o.AuditLog
+	e.notifyURL, e.notifyCmd = o.NotifyURL, o.NotifyCmd
+	e.preCmd, e.postCmd, e.fileCmd = o.PreCmd, o.PostCmd, o.FileCmd
+	e.stall, e.fileProgress, e.fileTimeout = o.Stall, o.FileProgress, o.FileTimeout
+	e.shardTemplate, e.shardHash, e.shardManifest = o.ShardTemplate, o.ShardHash, o.ShardManifest
+	e.filterFrom, e.excludeFrom = o.FilterFrom, o.ExcludeFrom
+	e.filesFrom, e.from0, e.existingLinks = o.FilesFrom, o.From0, o.ExistingLinks
+	e.iops, e.retries, e.retryDelay = o.IOPS, o.Retries, o.RetryDelay
+	e.maxErrors, e.chunkSize, e.partialDir, e.tempDir = o.MaxErrors, o.ChunkJournal, o.PartialDir, o.TempDir
+	e.checkpoint, e.resume, e.keepAtime = o.Checkpoint, o.Resume, o.KeepAtime
+	e.sinceLast, e.stateFile = o.SinceLast, o.StateFile
+	e.stateDB, e.dbDirty = o.StateDB, o.Resume || o.FilesFrom != ""
+	e.watchMode, e.interval = o.Watch, o.Interval
+	e.linkDest = o.LinkDest
+	e.dedupMode, e.dedupSizes, e.dedupFiles = o.Dedup, make(map[int64]bool), make(map[dedupKey]string)
+	e.normalize, e.caseCollisions = o.Normalize, o.CaseCollisions
+	e.twoWay, e.resolve, e.snapshotFile = o.TwoWay, o.Resolve, ctlDir+".psync-twoway"
+	e.lock, e.lockTimeout, e.nfsConns = o.Lock, o.LockTimeout, o.NFSConns
+	e.sshCommand, e.sftpConns, e.compress = strings.Fields(o.SSH), o.SFTPConns, o.Compress
+	e.s3Endpoint, e.secretFile, e.deltaMode = o.S3Endpoint, o.SecretFile, o.Delta
+	e.verifyMode, e.verifyAfter, e.unstable = o.Verify, o.VerifyAfter, o.Unstable
+	e.transforms, e.onlyTypes, e.skipSymlinks = o.Transforms, types, o.SkipSymlinks
+	e.onError = o.OnError
+	e.checksum, e.checksumNew = o.Checksum, checksums[o.Checksum]
+	e.stdout, e.stderr = &syncWriter{w: o.Stdout}, &syncWriter{w: o.Stderr}
+
+	// initial state of the run
+	e.session, e.umask = newSession(), readUmask()
+	if e.iops > 0 {
+		e.opLimit = newLimiter(e.iops)
+	}
+	e.finished = make(chan struct{})
+	e.stop = make(chan struct{})
+	e.stopOnce = new(sync.Once)
+	e.atimeOnce = new(sync.Once)
+	e.partials, e.openDirs = make(map[string]bool), make(map[string]*openDir)
+	e.groups, e.categories = make(map[string]*counters), make(map[string]uint64)
+	e.estimate, e.dirRules = o.Estimate, make(map[string][]*rule)
+	e.source, e.destination = o.SourceFS, o.DestinationFS
+	e.srcs, e.conflicts = append([]string{o.Source}, o.Sources...), o.Conflicts
+	e.srcNames = make([]string, len(e.srcs))
+	for i := range e.srcs {
+		if o.Slash && (i > 0 || o.SourceFS == nil) {
+			e.srcs[i], e.srcNames[i] = splitSource(e.srcs[i])
+		}
+	}
+	e.src, e.srcName = e.srcs[0], e.srcNames[0]
+	return nil
+}
+
+// Method last returns the state of the current or last run, or nil before
+// the first run.
+func (s *Syncer) last() *engine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.e
+}
+
+// Method Stats returns the counters of the current or last run.
+func (s *Syncer) Stats() Stats {
+	e := s.last()
+	if e == nil {
+		return Stats{}
+	}
+	return Stats{
+		Dirs:      atomic.LoadUint64(&e.total.dirs),
+		Files:     atomic.LoadUint64(&e.total.files),
+		Links:     atomic.LoadUint64(&e.total.links),
+		Bytes:     atomic.LoadUint64(&e.total.bytes),
+		Errors:    atomic.LoadUint64(&e.total.errors),
+		Relinks:   atomic.LoadUint64(&e.total.relinks),
+		Diffs:     atomic.LoadUint64(&e.total.diffs),
+		Conflicts: atomic.LoadUint64(&e.total.conflicts),
+		Duration:  e.elapsed(),
+
+		Categories: e.categoryCounts(),
+
+		Queued:    atomic.LoadInt64(&e.queueLen),
+		QueuePeak: atomic.LoadInt64(&e.queuePeak),
+		Handed:    atomic.LoadUint64(&e.queueTotal),
+	}
+}
+
+// Method Report prints the statistics of the last run, grouped by top level
+// directory and per thread, to the given writer.
+func (s *Syncer) Report(w io.Writer) {
+	if e := s.last(); e != nil {
+		e.report(w)
+	}
+}
+
+// Method ReportErrors prints the number of errors of the last run by category
+// to the given writer, if there were errors.
+func (s *Syncer) ReportErrors(w io.Writer) {
+	if e := s.last(); e != nil && atomic.LoadUint64(&e.total.errors) > 0 {
+		fmt.Fprintf(w, "%d errors: %s\n", atomic.LoadUint64(&e.total.errors), e.errorSummary())
+	}
+}
+
+// Method ReportWorkers prints the statistics of the copy threads of the
+// current run to the given writer.
+func (s *Syncer) ReportWorkers(w io.Writer) {
+	if e := s.last(); e != nil {
+		e.reportWorkers(w)
+	}
+}
+
+// Type syncWriter serializes the writes of the copy threads to an output.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Method Write writes to the underlying writer.
+func (s *syncWriter) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(b)
+}
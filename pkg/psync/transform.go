@@ -0,0 +1,67 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"io"
+	"os"
+)
+
+// Type Transform is a filter applied to the content of regular files while
+// they are copied, like a compression or an encryption. Tools built on the
+// engine register their filters in Options.Transforms. The names of the files
+// are not changed; tools which need other names can map them in their own
+// DestinationFS.
+type Transform interface {
+	// Method Transform returns a writer which transforms the data written
+	// to it, and writes the result to w. The file is given by its path
+	// relative to the source directory, and the metadata of the source
+	// file. The Close method of the writer must write any buffered data,
+	// but not close w.
+	Transform(w io.Writer, name string, f os.FileInfo) (io.WriteCloser, error)
+}
+
+// Type TransformFunc is an adapter to use an ordinary function as Transform.
+type TransformFunc func(w io.Writer, name string, f os.FileInfo) (io.WriteCloser, error)
+
+// Method Transform calls the function.
+func (t TransformFunc) Transform(w io.Writer, name string, f os.FileInfo) (io.WriteCloser, error) {
+	return t(w, name, f)
+}
+
+// Type transformWriter writes the data of a file through the filters of
+// transforms.
+type transformWriter struct {
+	io.Writer
+	chain []io.WriteCloser // writers of the filters, the first one is written to
+}
+
+// Method newTransformWriter sets up the filters for the file with the
+// metadata f, which write their result to w.
+func (e *engine) newTransformWriter(w io.Writer, file string, f os.FileInfo) (*transformWriter, error) {
+	t := &transformWriter{Writer: w}
+	for i := len(e.transforms) - 1; i >= 0; i-- {
+		tw, err := e.transforms[i].Transform(t.Writer, file, f)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+		t.chain = append([]io.WriteCloser{tw}, t.chain...)
+		t.Writer = tw
+	}
+	return t, nil
+}
+
+// Method Close closes the writers of the filters, the first one first, so that
+// each one writes its buffered data to the next one.
+func (t *transformWriter) Close() error {
+	var err error
+	for _, tw := range t.chain {
+		if cerr := tw.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,469 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/workpool"
+)
+
+// Type twoWayState is the snapshot of both trees after the last two-way run.
+// The changes of each tree are found by comparing it with its snapshot.
+type twoWayState struct {
+	A map[string]dbEntry // entries of the first tree (the source)
+	B map[string]dbEntry // entries of the second tree (the destination)
+}
+
+// Type twoWayPlan collects the actions of a two-way run.
+type twoWayPlan struct {
+	e          *engine            // engine of the run
+	toB, toA   []string           // entries copied to the destination or the source
+	delB, delA []string           // entries deleted from the destination or the source
+	keep       map[string]bool    // entries whose old snapshot is kept, e.g. skipped conflicts
+	scanA      map[string]dbEntry // current entries of the source
+	scanB      map[string]dbEntry // current entries of the destination
+}
+
+// Method syncTwoWay propagates the changes of both trees since the last run
+// to the other tree. Entries changed in one tree only are copied or deleted in
+// the other one, entries changed in both trees are conflicts resolved by the
+// -resolve policy. Without a snapshot, e.g. on the first run, all entries are
+// treated as new, so that the trees are merged.
+func (e *engine) syncTwoWay() error {
+	old, err := e.readTwoWayState()
+	if err != nil {
+		return err
+	}
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Scanning %s and %s\n", e.src, e.dest)
+	}
+	var scanA, scanB map[string]dbEntry
+	var errA, errB error
+	var scanWg sync.WaitGroup
+	scanWg.Add(2)
+	go func() { scanA, errA = e.scanTree(e.source, e.src); scanWg.Done() }()
+	go func() { scanB, errB = e.scanTree(e.destination, e.dest); scanWg.Done() }()
+	scanWg.Wait()
+	if errA != nil {
+		return errA
+	}
+	if errB != nil {
+		return errB
+	}
+	if e.stopping() {
+		return nil
+	}
+
+	p := &twoWayPlan{
+		e:     e,
+		keep:  make(map[string]bool),
+		scanA: scanA,
+		scanB: scanB,
+	}
+	names := make(map[string]bool, len(scanA))
+	for name := range scanA {
+		names[name] = true
+	}
+	for name := range scanB {
+		names[name] = true
+	}
+	for name := range old.A {
+		names[name] = true
+	}
+	for name := range old.B {
+		names[name] = true
+	}
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	for _, name := range list {
+		p.decide(name, old)
+	}
+
+	// copy in both directions, then delete children before their parents,
+	// so that directories with entries kept by a conflict are not removed
+	e.propagate(p.toB, false)
+	if !e.stopping() {
+		e.propagate(p.toA, true)
+	}
+	if e.stopping() {
+		return nil
+	}
+	for i := len(p.delB) - 1; i >= 0; i-- {
+		e.deleteEntry(e.destination, e.dest, p.delB[i])
+	}
+	for i := len(p.delA) - 1; i >= 0; i-- {
+		e.deleteEntry(e.source, e.src, p.delA[i])
+	}
+	e.writeTwoWayState(p.snapshot(old))
+	return nil
+}
+
+// Method decide compares an entry of both trees with the snapshot of the last
+// run, and plans its propagation.
+func (p *twoWayPlan) decide(name string, old twoWayState) {
+	a, okA := p.scanA[name]
+	b, okB := p.scanB[name]
+	oa, okOA := old.A[name]
+	ob, okOB := old.B[name]
+	changedA := changedEntry(a, oa, okA, okOA)
+	changedB := changedEntry(b, ob, okB, okOB)
+
+	switch {
+	case !changedA && !changedB:
+		return
+	case changedA && !changedB:
+		p.apply(name, okA, false)
+	case changedB && !changedA:
+		p.apply(name, okB, true)
+	case !okA && !okB:
+		// deleted in both trees
+	case okA && okB && p.e.sameEntry(name, a, b):
+		// changed the same way in both trees
+	default:
+		p.conflict(name, a, b, okA, okB)
+	}
+}
+
+// Method apply plans the propagation of an entry from the source to the
+// destination, or the other way round. A missing entry is deleted.
+func (p *twoWayPlan) apply(name string, present, reverse bool) {
+	from, to := p.scanA, p.scanB
+	if reverse {
+		from, to = p.scanB, p.scanA
+	}
+	f, t := from[name], to[name]
+	switch {
+	case !present && reverse:
+		p.delA = append(p.delA, name)
+	case !present:
+		p.delB = append(p.delB, name)
+	case f.Mode.IsDir() && t.Mode.IsDir():
+		// the entries of existing directories are handled on their own
+	default:
+		if _, ok := to[name]; ok && f.Mode&os.ModeType != t.Mode&os.ModeType {
+			// the entry changed its type, remove the old one first
+			fs := p.e.destination
+			if reverse {
+				fs = p.e.source
+			}
+			p.e.op()
+			if err := removeAll(fs, name); err != nil {
+				p.e.warning(name, "could not replace %s: %s", name, err)
+				p.keep[name] = true
+				return
+			}
+		}
+		if reverse {
+			p.toA = append(p.toA, name)
+		} else {
+			p.toB = append(p.toB, name)
+		}
+	}
+}
+
+// Method conflict resolves an entry changed in both trees by the -resolve
+// policy. With "newer", the entry with the newer modification time wins, or
+// the source on a tie; with "rename", the destination version is renamed and
+// both versions are kept in both trees; with "skip", both trees are left
+// unchanged and the conflict is reported again by the next run. An entry
+// deleted in one tree and changed in the other is kept, unless skipped.
+func (p *twoWayPlan) conflict(name string, a, b dbEntry, okA, okB bool) {
+	atomic.AddUint64(&p.e.total.conflicts, 1)
+	p.e.emit("conflict", name, 0, time.Now(), nil)
+	if p.e.resolve == "skip" {
+		p.keep[name] = true
+		p.e.warning(name, "conflicting changes of %s and %s, skipped", p.e.src+name, p.e.dest+name)
+		return
+	}
+
+	var how string
+	switch {
+	case !okA || !okB:
+		how = "keeping the changed version"
+		p.apply(name, true, !okA)
+	case p.e.resolve == "rename":
+		renamed := fmt.Sprintf("%s.conflict-%s", name, p.e.start.Format("20060102-150405"))
+		p.e.op()
+		if err := p.e.destination.Rename(name, renamed); err != nil {
+			p.keep[name] = true
+			p.e.warning(name, "could not rename %s: %s", p.e.dest+name, err)
+			return
+		}
+		how = "keeping both, " + p.e.dest + name + " renamed to " + path.Base(renamed)
+		delete(p.scanB, name)
+		p.e.op()
+		if f, err := p.e.destination.Lstat(renamed); err == nil {
+			p.scanB[renamed] = newDBEntry(f)
+		}
+		p.toA = append(p.toA, renamed)
+		p.toB = append(p.toB, name)
+	case b.Mtime > a.Mtime:
+		how = "keeping the newer version " + p.e.dest + name
+		p.apply(name, true, true)
+	default:
+		how = "keeping the newer version " + p.e.src + name
+		p.apply(name, true, false)
+	}
+	if !p.e.quiet {
+		fmt.Fprintf(p.e.stderr, "WARNING - conflicting changes of %s and %s, %s\n", p.e.src+name, p.e.dest+name, how)
+	}
+}
+
+// Method snapshot returns the snapshot of both trees after the run. Entries
+// are recorded as scanned, except for the copied and deleted entries of the
+// receiving tree, which are recorded as found now. Changes of the sending tree
+// while copying are propagated by the next run.
+func (p *twoWayPlan) snapshot(old twoWayState) twoWayState {
+	next := twoWayState{A: p.scanA, B: p.scanB}
+	refresh := func(fs FS, entries map[string]dbEntry, list []string) {
+		for _, name := range list {
+			p.e.op()
+			if f, err := fs.Lstat(name); err == nil {
+				entries[name] = newDBEntry(f)
+			} else {
+				delete(entries, name)
+			}
+		}
+	}
+	refresh(p.e.destination, next.B, p.toB)
+	refresh(p.e.destination, next.B, p.delB)
+	refresh(p.e.source, next.A, p.toA)
+	refresh(p.e.source, next.A, p.delA)
+	for name := range p.keep {
+		if e, ok := old.A[name]; ok {
+			next.A[name] = e
+		} else {
+			delete(next.A, name)
+		}
+		if e, ok := old.B[name]; ok {
+			next.B[name] = e
+		} else {
+			delete(next.B, name)
+		}
+	}
+	return next
+}
+
+// Function changedEntry checks whether an entry changed since the last run.
+// Directories only change by being created or removed, their metadata is not
+// propagated.
+func changedEntry(e, old dbEntry, ok, okOld bool) bool {
+	switch {
+	case ok != okOld:
+		return true
+	case !ok:
+		return false
+	case e.Mode.IsDir() && old.Mode.IsDir():
+		return false
+	}
+	return e != old
+}
+
+// Method sameEntry checks whether the entries of both trees are equal,
+// after they were changed the same way. Files must agree in their content,
+// links in their target.
+func (e *engine) sameEntry(name string, a, b dbEntry) bool {
+	switch {
+	case a.Mode&os.ModeType != b.Mode&os.ModeType:
+		return false
+	case a.Mode.IsDir():
+		return true
+	case a.Mode.IsRegular():
+		if a.Size != b.Size {
+			return false
+		}
+		equal, _, err := e.sameContent(0, name)
+		return err == nil && equal
+	case a.Mode&os.ModeSymlink != 0:
+		e.op()
+		la, err := e.source.Readlink(name)
+		if err != nil {
+			return false
+		}
+		e.op()
+		lb, err := e.destination.Readlink(name)
+		return err == nil && la == lb
+	}
+	return false
+}
+
+// Method scanTree reads the entries of a tree, except for the excluded
+// entries and the control files of psync, with a pool of as many workers as
+// copy threads. A directory which cannot be read fails the run, since its
+// entries would be taken as deleted.
+func (e *engine) scanTree(fs FS, root string) (map[string]dbEntry, error) {
+	entries := make(map[string]dbEntry)
+	var mu sync.Mutex
+	var failed error
+	dirs := e.newDirSet(root)
+	e.op()
+	if fi, err := fs.Stat(""); err == nil {
+		dirs.visit("", fi)
+	}
+
+	var pool *workpool.Pool
+	walk := func(id int, item interface{}) {
+		dir := item.(string)
+		if e.stopping() {
+			return
+		}
+		var files []os.FileInfo
+		err := e.retry(dir, func() (err error) {
+			e.op()
+			files, err = fs.ReadDir(dir)
+			return
+		})
+		if err != nil {
+			mu.Lock()
+			if failed == nil {
+				failed = fmt.Errorf("could not read directory %s: %s", root+dir, err)
+			}
+			mu.Unlock()
+			return
+		}
+		var rules []*rule
+		if len(files) > 0 {
+			rules = e.rulesFor(dir)
+		}
+		for _, f := range files {
+			name := dir + "/" + f.Name()
+			if e.controlFile(dir, f.Name()) || e.excluded(rules, name, f.IsDir()) || !e.selected(f.Mode()) {
+				continue
+			}
+			if f.IsDir() && !dirs.visit(name, f) {
+				continue
+			}
+			mu.Lock()
+			entries[name] = newDBEntry(f)
+			mu.Unlock()
+			if f.IsDir() {
+				pool.Submit(name)
+			}
+		}
+	}
+	pool = workpool.New(int(e.threads), false, walk)
+	pool.Submit("")
+	pool.Wait()
+	return entries, failed
+}
+
+// Method propagate copies the given entries with the copy threads, from the
+// source to the destination, or the other way round.
+func (e *engine) propagate(list []string, reverse bool) {
+	if len(list) == 0 {
+		return
+	}
+	if reverse {
+		e.src, e.dest, e.source, e.destination = e.dest, e.src, e.destination, e.source
+		defer func() {
+			e.src, e.dest, e.source, e.destination = e.dest, e.src, e.destination, e.source
+		}()
+	}
+	e.dirMu.Lock()
+	e.dirRules = make(map[string][]*rule)
+	e.dirMu.Unlock()
+	e.runPhase(func() { e.queueFileList(list) })
+}
+
+// Method deleteEntry removes an entry deleted from the other tree. A
+// directory which is not empty, e.g. since it holds an entry kept by a
+// conflict, is left alone.
+func (e *engine) deleteEntry(fs FS, root, name string) {
+	begin := time.Now()
+	before := e.auditBefore(fs, name)
+	e.op()
+	err := fs.Remove(name)
+	if pe, ok := err.(*os.PathError); ok && (pe.Err == syscall.ENOTEMPTY || pe.Err == syscall.EEXIST) {
+		return
+	}
+	if err != nil && !os.IsNotExist(err) {
+		e.warning(name, "could not remove %s: %s", root+name, err)
+		return
+	}
+	e.emit("delete", name, 0, begin, nil)
+	e.audit("delete", fs, root, name, before)
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Deleted %s%s\n", root, name)
+	}
+}
+
+// Method readTwoWayState reads the snapshot of the last two-way run.
+// Without a snapshot, an empty one is returned.
+func (e *engine) readTwoWayState() (twoWayState, error) {
+	st := twoWayState{A: make(map[string]dbEntry), B: make(map[string]dbEntry)}
+	fd, err := os.Open(e.snapshotFile)
+	if os.IsNotExist(err) {
+		if e.verbose >= 1 {
+			fmt.Fprintf(e.stdout, "No snapshot %s, merging the trees\n", e.snapshotFile)
+		}
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("could not read snapshot %s: %s", e.snapshotFile, err)
+	}
+	defer fd.Close()
+
+	var h dbHeader
+	zr, err := gzip.NewReader(bufio.NewReader(fd))
+	if err == nil {
+		dec := gob.NewDecoder(zr)
+		if err = dec.Decode(&h); err == nil {
+			err = dec.Decode(&st)
+		}
+	}
+	if err != nil {
+		return st, fmt.Errorf("could not read snapshot %s: %s", e.snapshotFile, err)
+	}
+	if h.Source != e.src || h.Destination != e.dest {
+		return st, fmt.Errorf("snapshot %s was written for syncing %s and %s", e.snapshotFile, h.Source, h.Destination)
+	}
+	return st, nil
+}
+
+// Method writeTwoWayState writes the snapshot for the next two-way run,
+// under a temporary name which is renamed.
+func (e *engine) writeTwoWayState(st twoWayState) {
+	tmp := e.snapshotFile + ".tmp"
+	fd, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0644))
+	if err == nil {
+		bw := bufio.NewWriter(fd)
+		zw := gzip.NewWriter(bw)
+		enc := gob.NewEncoder(zw)
+		err = enc.Encode(dbHeader{e.src, e.dest, len(st.A) + len(st.B)})
+		if err == nil {
+			err = enc.Encode(st)
+		}
+		if err == nil {
+			err = zw.Close()
+		}
+		if err == nil {
+			err = bw.Flush()
+		}
+		if cerr := fd.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, e.snapshotFile)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		fmt.Fprintf(e.stderr, "ERROR - could not write snapshot %s: %s\n", e.snapshotFile, err)
+	}
+}
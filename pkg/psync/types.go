@@ -0,0 +1,57 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"os"
+	"strings"
+)
+
+// Function typeLetter returns the letter of the type of an entry, like with
+// find -type: f (regular file), d (directory), l (symbolic link), b (block
+// device), c (character device), p (named pipe) or s (socket).
+func typeLetter(mode os.FileMode) byte {
+	switch {
+	case mode.IsDir():
+		return 'd'
+	case mode&os.ModeSymlink != 0:
+		return 'l'
+	case mode&os.ModeNamedPipe != 0:
+		return 'p'
+	case mode&os.ModeSocket != 0:
+		return 's'
+	case mode&os.ModeCharDevice != 0:
+		return 'c'
+	case mode&os.ModeDevice != 0:
+		return 'b'
+	}
+	return 'f'
+}
+
+// Method selected checks whether an entry has one of the types selected by
+// -only-type and -skip-symlinks. Directories are always selected, since the
+// entries in them are looked for.
+func (e *engine) selected(mode os.FileMode) bool {
+	t := typeLetter(mode)
+	switch {
+	case t == 'd':
+		return true
+	case t == 'l' && e.skipSymlinks:
+		return false
+	}
+	return e.onlyTypes == "" || strings.IndexByte(e.onlyTypes, t) >= 0
+}
+
+// Function parseTypes checks the list of entry types of -only-type, letters
+// separated by commas like "f,l", and returns the letters.
+func parseTypes(list string) (string, bool) {
+	letters := strings.Replace(list, ",", "", -1)
+	for _, t := range letters {
+		if !strings.ContainsRune("fdlbcps", t) {
+			return "", false
+		}
+	}
+	return letters, true
+}
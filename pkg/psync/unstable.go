@@ -0,0 +1,39 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+)
+
+// unstableAttempts is the number of copies of a changing file with -unstable
+// retry, before it is skipped.
+const unstableAttempts = 3
+
+// Method changedSource checks whether a source file changed while it was
+// copied, i.e. its size or modification time differs from the ones it had
+// when the copy started. It returns the current metadata of the file, and
+// whether it changed. A file which vanished counts as changed.
+func (e *engine) changedSource(file string, f os.FileInfo) (os.FileInfo, bool) {
+	e.op()
+	nf, err := e.source.Lstat(file)
+	if err != nil {
+		return f, true
+	}
+	return nf, nf.Size() != f.Size() || !nf.ModTime().Equal(f.ModTime())
+}
+
+// Method removeUnstable removes the copy of a source file which changed
+// while it was copied, since it may be torn, so that the next run copies it
+// again, and returns an *Error.
+func (e *engine) removeUnstable(file, target string) error {
+	before := e.auditBefore(e.destination, target)
+	e.op()
+	if e.destination.Remove(target) == nil {
+		e.audit("delete", e.destination, e.dest, target, before)
+	}
+	return &Error{file, fmt.Sprintf("file %s changed while copying, removed %s", e.src+file, e.dest+target), ErrUnstable}
+}
@@ -0,0 +1,276 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Method verifyDir compares a directory of the source with the same
+// directory of the destination. Missing, extra and differing entries are
+// reported, subdirectories present on both sides are queued for comparison.
+func (e *engine) verifyDir(id uint, j job) {
+	dir := j.dir
+	e.setBusy(id, e.src+dir)
+	defer e.wg.Done()
+	if e.verbose >= 3 {
+		fmt.Fprintf(e.stdout, "[%d] Comparing directory %s%s\n", id, e.src, dir)
+	}
+
+	// read both directories, or the selected entries only
+	var files, others []os.FileInfo
+	var err error
+	if j.names != nil {
+		files = e.selectEntries(dir, j.names)
+	} else {
+		err = e.retry(dir, func() (err error) {
+			e.op()
+			files, err = e.source.ReadDir(dir)
+			return
+		})
+	}
+	if err != nil {
+		e.warning(dir, "could not read directory %s: %s", e.src+dir, err)
+		return
+	}
+	err = e.retry(dir, func() (err error) {
+		e.op()
+		others, err = e.destination.ReadDir(dir)
+		return
+	})
+	if err != nil {
+		e.warning(dir, "could not read directory %s: %s", e.dest+dir, err)
+		return
+	}
+	e.countDir(id, dir)
+	if e.deterministic {
+		sortNames(files)
+		sortNames(others)
+	}
+	existing := make(map[string]os.FileInfo, len(others))
+	for _, f := range others {
+		existing[f.Name()] = f
+	}
+
+	var rules []*rule
+	if len(files) > 0 || len(others) > 0 {
+		rules = e.rulesFor(dir)
+	}
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if e.stopping() {
+			return
+		}
+		fname := f.Name()
+		seen[fname] = true
+		if f.IsDir() && dir+"/"+fname == e.nested {
+			continue
+		}
+		if e.excluded(rules, dir+"/"+fname, f.IsDir()) || !e.selected(f.Mode()) {
+			continue
+		}
+		if f.IsDir() && !e.visited.visit(dir+"/"+fname, f) {
+			continue
+		}
+		d, ok := existing[fname]
+		if !ok {
+			e.difference(dir+"/"+fname, "missing in the destination")
+			continue
+		}
+		if f.Mode()&os.ModeType != d.Mode()&os.ModeType {
+			e.difference(dir+"/"+fname, "differs in type")
+			continue
+		}
+		if f.IsDir() {
+			e.submit(id, dir, job{dir: dir + "/" + fname, info: f})
+			continue
+		}
+		e.setBusy(id, e.src+dir+"/"+fname)
+		e.compareEntry(id, dir+"/"+fname, f, d)
+		e.setBusy(id, e.src+dir)
+	}
+
+	// entries of the destination which are not in the source, except for the
+	// control files of psync
+	if j.names != nil {
+		return
+	}
+	for _, d := range others {
+		name := d.Name()
+		if seen[name] || dir+"/"+name == e.nested || e.controlFile(dir, name) {
+			continue
+		}
+		if e.excluded(rules, dir+"/"+name, d.IsDir()) || !e.selected(d.Mode()) {
+			continue
+		}
+		e.difference(dir+"/"+name, "extra in the destination")
+	}
+}
+
+// Method compareEntry compares a file or link of the source with the
+// destination entry of the same type.
+func (e *engine) compareEntry(id uint, file string, f, d os.FileInfo) {
+	switch {
+	case f.Mode()&os.ModeSymlink != 0:
+		var link, other string
+		err := e.retry(file, func() (err error) {
+			e.op()
+			if link, err = e.source.Readlink(file); err != nil {
+				return
+			}
+			other, err = e.destination.Readlink(file)
+			return
+		})
+		if err != nil {
+			e.warning(file, "could not read link %s: %s", e.src+file, err)
+		} else if link != other {
+			e.difference(file, fmt.Sprintf("differs in the link target (%s / %s)", link, other))
+		}
+		e.countLink(id, file)
+
+	case !f.Mode().IsRegular():
+		// special files are not copied by psync
+
+	case f.Size() != d.Size():
+		e.difference(file, fmt.Sprintf("differs in size (%d / %d bytes)", f.Size(), d.Size()))
+		e.countFile(id, file, 0)
+
+	case !e.ignoreTimes && f.ModTime().Unix() != d.ModTime().Unix():
+		e.difference(file, fmt.Sprintf("differs in modification time (%s / %s)",
+			f.ModTime().Format(time.RFC3339), d.ModTime().Format(time.RFC3339)))
+		e.countFile(id, file, 0)
+
+	default:
+		var equal bool
+		var n int64
+		e.setFile(id, f.Size())
+		err := e.retry(file, func() (err error) {
+			equal, n, err = e.sameContent(id, file)
+			return
+		})
+		e.setFile(id, 0)
+		if err != nil {
+			e.warning(file, "could not compare file %s: %s", e.src+file, err)
+		} else if !equal {
+			e.difference(file, "differs in content")
+		}
+		e.countFile(id, file, n)
+	}
+}
+
+// Method sameContent compares the content of a file in the source and the
+// destination. It returns the number of bytes compared.
+func (e *engine) sameContent(id uint, file string) (bool, int64, error) {
+	e.op()
+	rd, err := e.source.Open(file)
+	if err != nil {
+		return false, 0, err
+	}
+	defer rd.Close()
+	e.op()
+	other, err := e.destination.Open(file)
+	if err != nil {
+		return false, 0, err
+	}
+	defer other.Close()
+
+	b := getBuffer()
+	defer putBuffer(b)
+	c := e.newCopy(id, *b)
+	buf := c.buf
+	half := len(buf) / 2
+	var n int64
+	for {
+		nr, err := io.ReadFull(rd, buf[:half])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return false, n, err
+		}
+		no, oerr := io.ReadFull(other, buf[half:half+nr])
+		if oerr != nil && oerr != io.EOF && oerr != io.ErrUnexpectedEOF {
+			return false, n, oerr
+		}
+		if no != nr || !bytes.Equal(buf[:nr], buf[half:half+no]) {
+			return false, n, nil
+		}
+		n += int64(nr)
+		if nr < half {
+			// the end of the source file, the destination must end, too
+			m, oerr := other.Read(buf[half : half+1])
+			if oerr != nil && oerr != io.EOF {
+				return false, n, oerr
+			}
+			return m == 0, n, nil
+		}
+		atomic.AddUint64(&c.done, uint64(nr))
+	}
+}
+
+// Method difference reports an entry which differs between the trees.
+func (e *engine) difference(file, what string) {
+	atomic.AddUint64(&e.total.diffs, 1)
+	atomic.AddUint64(&e.group(file).diffs, 1)
+	e.emit("differ", file, 0, time.Now(), nil)
+	fmt.Fprintf(e.stdout, "%s: %s\n", strings.TrimPrefix(file, "/"), what)
+}
+
+// Method controlFile checks whether an entry of the destination is a
+// control file of psync, like the checkpoint or the journal of a file.
+func (e *engine) controlFile(dir, name string) bool {
+	return (dir == "" && (strings.HasPrefix(name, ".psync-") || name == e.partialDir || name == e.tempDir)) || strings.HasSuffix(name, ".psync-chunks")
+}
+
+// Method verifyCopy reads a copied file from the destination, and compares
+// its hash with the hash sum of the source data. If sum is nil, the source
+// file is read again. On a mismatch, the destination file is removed, so that
+// the next run copies it again, and an *Error is returned.
+func (e *engine) verifyCopy(buf []byte, file, target string, sum []byte) error {
+	if sum == nil {
+		e.op()
+		rd, err := e.source.Open(file)
+		if err != nil {
+			return &Error{file, fmt.Sprintf("file %s could not be verified: %s", e.src+file, err), err}
+		}
+		sum, err = e.hashFile(rd, buf)
+		rd.Close()
+		if err != nil {
+			return &Error{file, fmt.Sprintf("file %s could not be verified: %s", e.src+file, err), err}
+		}
+	}
+
+	e.op()
+	rd, err := e.destination.Open(target)
+	if err != nil {
+		return &Error{file, fmt.Sprintf("file %s could not be verified: %s", e.dest+target, err), err}
+	}
+	other, err := e.hashFile(rd, buf)
+	rd.Close()
+	if err != nil {
+		return &Error{file, fmt.Sprintf("file %s could not be verified: %s", e.dest+target, err), err}
+	}
+	if !bytes.Equal(sum, other) {
+		before := e.auditBefore(e.destination, target)
+		e.op()
+		if e.destination.Remove(target) == nil {
+			e.audit("delete", e.destination, e.dest, target, before)
+		}
+		return &Error{file, fmt.Sprintf("file %s differs from the source after copying, removed", e.dest+target), ErrMismatch}
+	}
+	return nil
+}
+
+// Method hashFile returns the hash of the content of a file.
+func (e *engine) hashFile(rd io.Reader, buf []byte) ([]byte, error) {
+	h := e.checksumNew()
+	if _, err := io.CopyBuffer(h, rd, buf); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
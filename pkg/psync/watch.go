@@ -0,0 +1,111 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchDelay is the time the changes of the source are collected, before
+// they are replayed to the destination.
+const watchDelay = time.Second
+
+// Method watchSource collects the changes of the source, and replays them to
+// the destination, until the run is stopped. The changes are collected until
+// no new change arrived for a moment, so that the files being written are
+// copied once.
+func (e *engine) watchSource() {
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Watching %s for changes\n", e.src)
+	}
+	batch := make(map[string]bool)
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-e.stop:
+			return
+		case p, ok := <-e.watch.changes:
+			if !ok {
+				return
+			}
+			batch[p] = true
+			timer = time.After(watchDelay)
+		case <-e.watch.full:
+			if !e.quiet {
+				fmt.Fprintf(e.stderr, "WARNING - lost changes of %s, copying the whole tree again\n", e.src)
+			}
+			batch = map[string]bool{"": true}
+			timer = time.After(watchDelay)
+		case <-timer:
+			e.replay(batch)
+			batch, timer = make(map[string]bool), nil
+		}
+	}
+}
+
+// Method replay copies the changed entries of the source to the
+// destination through the copy threads, and removes the entries deleted from
+// the source.
+func (e *engine) replay(batch map[string]bool) {
+	list := make([]string, 0, len(batch))
+	for p := range batch {
+		list = append(list, p)
+	}
+	sort.Strings(list)
+
+	var copies []string
+	for _, p := range list {
+		if e.nested != "" && (p == e.nested || strings.HasPrefix(p, e.nested+"/")) {
+			// changes made by the copy to a nested destination
+			continue
+		}
+		parent := path.Dir(p)
+		if parent == "/" || parent == "." {
+			parent = ""
+		}
+		e.op()
+		f, err := e.source.Lstat(p)
+		if os.IsNotExist(err) {
+			e.removeEntry(p, parent)
+			continue
+		}
+		if err != nil {
+			e.warning(p, "file %s could not be read: %s", e.src+p, err)
+			continue
+		}
+		if p != "" && (e.excluded(e.rulesFor(parent), p, f.IsDir()) || !e.selected(f.Mode())) {
+			continue
+		}
+		copies = append(copies, p)
+	}
+	if len(copies) > 0 {
+		e.runPhase(func() { e.queueFileList(copies) })
+	}
+}
+
+// Method removeEntry removes an entry deleted from the source from the
+// destination, unless it is excluded.
+func (e *engine) removeEntry(p, parent string) {
+	e.op()
+	d, err := e.destination.Lstat(p)
+	if err != nil || e.excluded(e.rulesFor(parent), p, d.IsDir()) || !e.selected(d.Mode()) {
+		return
+	}
+	begin := time.Now()
+	if err := removeAll(e.destination, p); err != nil {
+		e.warning(p, "could not remove %s: %s", e.dest+p, err)
+		return
+	}
+	e.emit("delete", p, 0, begin, nil)
+	e.audit("delete", e.destination, e.dest, p, newAuditMeta(d))
+	if e.verbose >= 1 {
+		fmt.Fprintf(e.stdout, "Deleted %s%s\n", e.dest, p)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// watchMask selects the inotify events of the watched directories.
+const watchMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+
+// Type watcher follows the changes of a local source tree with inotify. The
+// copy threads add a watch for each directory before reading it, so that no
+// change after the initial copy is missed.
+type watcher struct {
+	e       *engine          // engine of the run
+	root    string           // local path of the source directory
+	fd      int              // inotify file descriptor
+	file    *os.File         // inotify file descriptor for reading
+	mu      sync.Mutex       // protects dirs
+	dirs    map[int32]string // watched directories by watch descriptor
+	changes chan string      // changed paths, relative to the source directory
+	full    chan struct{}    // signals lost events, after an overflow of the queue
+	once    sync.Once        // reports a failure to add a watch once
+	done    chan struct{}    // closed at the end of the run
+}
+
+// Method newWatcher creates a watcher for a local source directory, and
+// starts reading its events.
+func (e *engine) newWatcher(root string) (*watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("cannot watch source %s: %s", root, err)
+	}
+	w := &watcher{
+		e:       e,
+		root:    root,
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		dirs:    make(map[int32]string),
+		changes: make(chan string, 1000),
+		full:    make(chan struct{}, 1),
+		done:    e.finished,
+	}
+	go w.read()
+	return w, nil
+}
+
+// Method add watches a directory of the source tree.
+func (w *watcher) add(dir string) {
+	wd, err := syscall.InotifyAddWatch(w.fd, w.root+dir, watchMask)
+	if err != nil {
+		if err == syscall.ENOSPC {
+			w.once.Do(func() {
+				w.e.warning(dir, "cannot watch directory %s: too many watches, raise fs.inotify.max_user_watches", w.e.src+dir)
+			})
+		}
+		return
+	}
+	w.mu.Lock()
+	w.dirs[int32(wd)] = dir
+	w.mu.Unlock()
+}
+
+// Method remove stops watching a directory and its subdirectories, e.g. when
+// it was moved away.
+func (w *watcher) remove(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for wd, d := range w.dirs {
+		if d == dir || strings.HasPrefix(d, dir+"/") {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+// Method read decodes the inotify events, and sends the changed paths to the
+// changes channel. Changes of the metadata of directories are ignored, since
+// replaying them would copy the whole directory. The method returns when the
+// watcher is closed.
+func (w *watcher) read() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.EAGAIN {
+			// the file descriptor is not handled by the poller
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		if err != nil || n <= 0 {
+			close(w.changes)
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev, name, size := inotifyEvent(buf[off:n])
+			off += size
+
+			if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				select {
+				case w.full <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			w.mu.Lock()
+			dir, ok := w.dirs[ev.Wd]
+			if ev.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, ev.Wd)
+			}
+			w.mu.Unlock()
+			if !ok || name == "" {
+				continue
+			}
+			if ev.Mask&syscall.IN_ISDIR != 0 {
+				if ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO|syscall.IN_DELETE|syscall.IN_MOVED_FROM) == 0 {
+					continue
+				}
+				if ev.Mask&syscall.IN_MOVED_FROM != 0 {
+					w.remove(dir + "/" + name)
+				}
+			}
+			select {
+			case w.changes <- dir + "/" + name:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// Method close stops watching the source.
+func (w *watcher) close() {
+	w.file.Close()
+}
@@ -0,0 +1,28 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !linux
+// +build !linux
+
+package psync
+
+import "errors"
+
+// Type watcher follows the changes of a local source tree. Without inotify,
+// watching is not supported.
+type watcher struct {
+	changes chan string   // changed paths, relative to the source directory
+	full    chan struct{} // signals lost events
+}
+
+// Method newWatcher fails, since watching needs inotify.
+func (e *engine) newWatcher(root string) (*watcher, error) {
+	return nil, errors.New("option -watch is only supported on Linux")
+}
+
+// Method add watches a directory of the source tree.
+func (w *watcher) add(dir string) {}
+
+// Method close stops watching the source.
+func (w *watcher) close() {}
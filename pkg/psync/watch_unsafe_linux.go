@@ -0,0 +1,21 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Function inotifyEvent decodes the inotify event at the start of buf, as read
+// from the inotify file descriptor. It returns the event, the name of the
+// entry it refers to, and the size of the event in the buffer.
+func inotifyEvent(buf []byte) (ev syscall.InotifyEvent, name string, size int) {
+	ev = *(*syscall.InotifyEvent)(unsafe.Pointer(&buf[0]))
+	size = syscall.SizeofInotifyEvent + int(ev.Len)
+	name = strings.TrimRight(string(buf[syscall.SizeofInotifyEvent:size]), "\x00")
+	return ev, name, size
+}
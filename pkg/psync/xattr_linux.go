@@ -0,0 +1,35 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package psync
+
+import "syscall"
+
+// Function getxattr returns an extended attribute of a file.
+func getxattr(name, attr string) ([]byte, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(name, attr, buf)
+		if err == syscall.ERANGE {
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// Function setxattr sets an extended attribute of a file.
+func setxattr(name, attr string, value []byte) error {
+	return syscall.Setxattr(name, attr, value, 0)
+}
+
+// Function devSplit returns the major and minor number of a device.
+func devSplit(dev uint64) (major, minor uint64) {
+	major = dev>>8&0xfff | dev>>32&^0xfff
+	minor = dev&0xff | dev>>12&^0xff
+	return
+}
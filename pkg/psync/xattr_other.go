@@ -0,0 +1,26 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !linux
+// +build !linux
+
+package psync
+
+import "syscall"
+
+// Function getxattr fails, extended attributes are only supported on Linux.
+func getxattr(name, attr string) ([]byte, error) {
+	return nil, syscall.ENOTSUP
+}
+
+// Function setxattr fails, extended attributes are only supported on Linux.
+func setxattr(name, attr string, value []byte) error {
+	return syscall.ENOTSUP
+}
+
+// Function devSplit returns the major and minor number of a device, in the
+// encoding of Darwin and the BSDs.
+func devSplit(dev uint64) (major, minor uint64) {
+	return dev >> 24 & 0xff, dev & 0xffffff
+}
@@ -0,0 +1,301 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package rsync
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// protocolVersion is the rsync protocol version spoken by the client. Version
+// 29 is supported by all daemons since rsync 2.6.4, and needs neither the
+// negotiation of checksums nor multiplexing in the client to server direction.
+const protocolVersion = 29
+
+// Message tags of the multiplexed stream. Informational messages are
+// dropped.
+const (
+	mplexBase   = 7
+	msgData     = 0
+	msgErrXfer  = 1
+	msgError    = 3
+	msgErrSock  = 5
+	msgErrUTF8  = 8
+	msgErrExit  = 86
+	maxFrameLen = 1<<24 - 1
+)
+
+// errnoSuffix matches the error number at the end of rsync error messages,
+// e.g. "(2)" for ENOENT.
+var errnoSuffix = regexp.MustCompile(`\((\d+)\)\s*$`)
+
+// Type conn is a connection to an rsync daemon, running one transfer. The
+// server to client direction is multiplexed after the setup, the data is
+// read with the Read method.
+type conn struct {
+	c     net.Conn
+	r     *bufio.Reader
+	w     *bufio.Writer
+	seed  int32  // checksum seed of the transfer
+	mplex bool   // the server output is multiplexed
+	left  int    // bytes left of the current data frame
+	msg   string // last error message of the server
+}
+
+// Function dial connects to the daemon listening on addr, and runs the
+// greeting: the protocol versions are exchanged, and the module is selected,
+// with authentication if the daemon requests it. For an empty module, the
+// daemon sends the list of modules, which is returned.
+func dial(addr, module string, opts *Options) (*conn, []string, error) {
+	nc, err := net.DialTimeout("tcp", addr, opts.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &conn{c: nc, r: bufio.NewReaderSize(nc, 64*1024), w: bufio.NewWriterSize(nc, 64*1024)}
+	if opts.Timeout > 0 {
+		nc.SetDeadline(time.Now().Add(opts.Timeout))
+	}
+	lines, err := c.greet(module, opts)
+	if err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
+	return c, lines, nil
+}
+
+// Method greet runs the greeting of the daemon protocol, and returns the
+// lines of text sent by the daemon before it accepted the module (the message
+// of the day, or the list of modules).
+func (c *conn) greet(module string, opts *Options) ([]string, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "@RSYNCD: ") {
+		return nil, fmt.Errorf("unexpected greeting %q", line)
+	}
+	v := strings.Fields(strings.TrimPrefix(line, "@RSYNCD: "))
+	major := 0
+	if len(v) > 0 {
+		major, _ = strconv.Atoi(strings.SplitN(v[0], ".", 2)[0])
+	}
+	if major < protocolVersion {
+		return nil, fmt.Errorf("daemon speaks protocol version %d, at least %d is needed", major, protocolVersion)
+	}
+	fmt.Fprintf(c.w, "@RSYNCD: %d.0\n%s\n", protocolVersion, module)
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case line == "@RSYNCD: OK":
+			return lines, nil
+		case line == "@RSYNCD: EXIT":
+			if module == "" {
+				return lines, nil
+			}
+			return nil, errors.New("daemon closed the connection")
+		case strings.HasPrefix(line, "@ERROR"):
+			return nil, errors.New(strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "@ERROR"), ":")))
+		case strings.HasPrefix(line, "@RSYNCD: AUTHREQD "):
+			if opts.User == "" || opts.Password == "" {
+				return nil, errors.New("module needs authentication, give the user and set RSYNC_PASSWORD")
+			}
+			challenge := strings.TrimPrefix(line, "@RSYNCD: AUTHREQD ")
+			fmt.Fprintf(c.w, "%s %s\n", opts.User, authHash(opts.Password, challenge))
+			if err := c.w.Flush(); err != nil {
+				return nil, err
+			}
+		default:
+			lines = append(lines, line)
+		}
+	}
+}
+
+// Function authHash returns the response to the challenge of the daemon: the
+// MD4 digest of the password and the challenge, with the checksum seed 0 in
+// front, in base64 without padding.
+func authHash(password, challenge string) string {
+	d := newMD4()
+	d.Write([]byte{0, 0, 0, 0})
+	d.Write([]byte(password))
+	d.Write([]byte(challenge))
+	return base64.RawStdEncoding.EncodeToString(d.Sum())
+}
+
+// Method readLine reads a line of the greeting.
+func (c *conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line == "" {
+			err = errors.New("daemon closed the connection")
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Method start sends the arguments of the server process, and an empty
+// filter list, and reads the checksum seed. The output of the server is
+// multiplexed from then on.
+func (c *conn) start(args []string) error {
+	for _, a := range args {
+		if strings.ContainsAny(a, "\n\x00") {
+			return syscall.EINVAL
+		}
+		c.w.WriteString(a + "\n")
+	}
+	c.w.WriteString("\n")
+	c.writeInt(0)
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	seed, err := c.readInt()
+	if err != nil {
+		return err
+	}
+	c.seed, c.mplex = seed, true
+	c.c.SetDeadline(time.Time{})
+	return nil
+}
+
+// Method Read reads data from the multiplexed stream. Messages of the server
+// are taken out; the last error message is kept, and returned as error if
+// the stream ends.
+func (c *conn) Read(b []byte) (int, error) {
+	if !c.mplex {
+		return c.r.Read(b)
+	}
+	for c.left == 0 {
+		var h [4]byte
+		if _, err := io.ReadFull(c.r, h[:]); err != nil {
+			return 0, c.error(err)
+		}
+		v := binary.LittleEndian.Uint32(h[:])
+		tag, n := int(v>>24)-mplexBase, int(v&maxFrameLen)
+		if tag == msgData {
+			c.left = n
+			continue
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.r, payload); err != nil {
+			return 0, c.error(err)
+		}
+		switch tag {
+		case msgErrXfer, msgError, msgErrSock, msgErrUTF8:
+			c.msg = strings.TrimSpace(string(payload))
+		case msgErrExit:
+			return 0, c.error(io.EOF)
+		}
+	}
+	if len(b) > c.left {
+		b = b[:c.left]
+	}
+	n, err := c.r.Read(b)
+	c.left -= n
+	if err != nil {
+		err = c.error(err)
+	}
+	return n, err
+}
+
+// Method error returns the last error message of the server, if any, instead
+// of a read error. An error number at the end of the message is returned as
+// such.
+func (c *conn) error(err error) error {
+	if c.msg == "" {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if m := errnoSuffix.FindStringSubmatch(c.msg); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			return syscall.Errno(n)
+		}
+	}
+	return errors.New(c.msg)
+}
+
+// Method readInt reads a little endian 32 bit integer.
+func (c *conn) readInt() (int32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(c, b[:]); err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b[:])), nil
+}
+
+// Method readLong reads a 64 bit integer, which is sent as 32 bit integer if
+// it fits.
+func (c *conn) readLong() (int64, error) {
+	n, err := c.readInt()
+	if err != nil || n != -1 {
+		return int64(n), err
+	}
+	var b [8]byte
+	if _, err := io.ReadFull(c, b[:]); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b[:])), nil
+}
+
+// Method readByte reads a single byte.
+func (c *conn) readByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(c, b[:])
+	return b[0], err
+}
+
+// Method readBytes reads n bytes.
+func (c *conn) readBytes(n int) ([]byte, error) {
+	if n < 0 || n > maxFrameLen {
+		return nil, fmt.Errorf("invalid length %d", n)
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(c, b)
+	return b, err
+}
+
+// Method writeInt writes a little endian 32 bit integer.
+func (c *conn) writeInt(n int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(n))
+	c.w.Write(b[:])
+}
+
+// Method done announces the end of the three phases of the generator, and
+// the final goodbye. The sender finishes after the files requested before.
+func (c *conn) done() error {
+	for i := 0; i < 4; i++ {
+		c.writeInt(ndxDone)
+	}
+	return c.w.Flush()
+}
+
+// Method finish discards the rest of the output, so that the daemon exits
+// cleanly, and closes the connection.
+func (c *conn) finish() {
+	c.c.SetDeadline(time.Now().Add(5 * time.Second))
+	io.Copy(ioutil.Discard, c.r)
+	c.c.Close()
+}
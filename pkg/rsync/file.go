@@ -0,0 +1,216 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package rsync
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+// Flags of the requests of the generator
+const (
+	itemBasisTypeFollows = 0x0800
+	itemXNameFollows     = 0x1000
+	itemTransfer         = 0x8000
+)
+
+// errChecksum is returned for a file with wrong checksum.
+var errChecksum = errors.New("rsync: checksum of the transferred file does not match")
+
+// Type File is a file transferred from the daemon. The data is received as a
+// stream, so the file can only be read sequentially.
+type File struct {
+	cl     *Client
+	c      *conn
+	name   string
+	off    int64
+	header bool // the header of the transfer was read
+	left   int  // bytes left of the current literal data
+	eof    bool
+	sum    *md4
+}
+
+// Method Open transfers a file. Its data is read with the Read method.
+func (cl *Client) Open(name string) (*File, error) {
+	c, err := cl.transfer("-logtp", cl.path(name))
+	if err == nil {
+		var list []entry
+		if list, err = c.readFileList(); err == nil && len(list) != 1 {
+			err = syscall.EISDIR
+		}
+		if err == nil && list[0].mode&sIFMT != sIFREG {
+			err = syscall.EINVAL
+		}
+		if err != nil {
+			c.c.Close()
+		}
+	}
+	if err == nil {
+		// request the whole file (no checksums of a basis file), and end
+		// the transfer afterwards
+		c.writeInt(0)
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], itemTransfer)
+		c.w.Write(b[:])
+		for i := 0; i < 4; i++ {
+			c.writeInt(0)
+		}
+		if err = c.done(); err != nil {
+			c.c.Close()
+		}
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: cl.url(name), Err: err}
+	}
+	f := &File{cl: cl, c: c, name: cl.url(name), sum: newMD4()}
+	var seed [4]byte
+	binary.LittleEndian.PutUint32(seed[:], uint32(c.seed))
+	f.sum.Write(seed[:])
+	return f, nil
+}
+
+// Method OpenFile opens a file for reading. Writing is not supported.
+func (cl *Client) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: cl.url(name), Err: syscall.EROFS}
+	}
+	return cl.Open(name)
+}
+
+// Method readHeader reads the header of the file transfer: the index and
+// flags of the file, and the checksum header, which must be empty.
+func (f *File) readHeader() error {
+	ndx, err := f.c.readInt()
+	if err != nil {
+		return err
+	}
+	if ndx != 0 {
+		// the sender skipped the file
+		return f.c.error(io.EOF)
+	}
+	var b [2]byte
+	if _, err := io.ReadFull(f.c, b[:]); err != nil {
+		return err
+	}
+	iflags := binary.LittleEndian.Uint16(b[:])
+	if iflags&itemBasisTypeFollows != 0 {
+		if _, err := f.c.readByte(); err != nil {
+			return err
+		}
+	}
+	if iflags&itemXNameFollows != 0 {
+		n, err := f.c.readByte()
+		if err != nil {
+			return err
+		}
+		l := int(n)
+		if n&0x80 != 0 {
+			n2, err := f.c.readByte()
+			if err != nil {
+				return err
+			}
+			l = int(n&0x7f)<<8 | int(n2)
+		}
+		if _, err := f.c.readBytes(l); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < 4; i++ {
+		n, err := f.c.readInt()
+		if err != nil {
+			return err
+		}
+		if i == 0 && n != 0 {
+			return syscall.EPROTO
+		}
+	}
+	f.header = true
+	return nil
+}
+
+// Method Read reads the literal data of the transfer. At the end, the
+// checksum of the file is verified.
+func (f *File) Read(b []byte) (int, error) {
+	if !f.header {
+		if err := f.readHeader(); err != nil {
+			return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+		}
+	}
+	for f.left == 0 {
+		if f.eof {
+			return 0, io.EOF
+		}
+		n, err := f.c.readInt()
+		if err == nil && n < 0 {
+			err = syscall.EPROTO
+		}
+		if err == nil && n == 0 {
+			var sum []byte
+			if sum, err = f.c.readBytes(16); err == nil && !bytes.Equal(sum, f.sum.Sum()) {
+				err = errChecksum
+			}
+			f.eof = true
+		}
+		if err != nil {
+			return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.left = int(n)
+	}
+	if len(b) > f.left {
+		b = b[:f.left]
+	}
+	n, err := f.c.Read(b)
+	f.sum.Write(b[:n])
+	f.left -= n
+	f.off += int64(n)
+	if err != nil {
+		return n, &os.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	return n, nil
+}
+
+// Method Write is not supported.
+func (f *File) Write(b []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EROFS}
+}
+
+// Method Truncate is not supported.
+func (f *File) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.EROFS}
+}
+
+// Method Seek skips forward in the file. Seeking backwards is not supported.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	default:
+		return f.off, &os.PathError{Op: "seek", Path: f.name, Err: errors.New("unsupported whence")}
+	}
+	if offset < f.off {
+		return f.off, &os.PathError{Op: "seek", Path: f.name, Err: syscall.ESPIPE}
+	}
+	if _, err := io.CopyN(ioutil.Discard, f, offset-f.off); err != nil {
+		return f.off, err
+	}
+	return offset, nil
+}
+
+// Method Close ends the transfer. If the file was read completely, the rest
+// of the output of the daemon is drained, so that it exits cleanly.
+func (f *File) Close() error {
+	if f.eof {
+		go f.c.finish()
+	} else {
+		f.c.c.Close()
+	}
+	return nil
+}
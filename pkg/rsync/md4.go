@@ -0,0 +1,98 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package rsync
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Type md4 computes the MD4 message digest (RFC 1320), which rsync uses for
+// the file checksums and the authentication up to protocol version 29.
+type md4 struct {
+	s   [4]uint32
+	buf [64]byte
+	n   int    // bytes in buf
+	len uint64 // total length
+}
+
+// Function newMD4 returns an initialized MD4 digest.
+func newMD4() *md4 {
+	return &md4{s: [4]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476}}
+}
+
+// Method Write adds data to the digest.
+func (d *md4) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.n > 0 {
+		c := copy(d.buf[d.n:], p)
+		d.n += c
+		p = p[c:]
+		if d.n < 64 {
+			return n, nil
+		}
+		d.block(d.buf[:])
+		d.n = 0
+	}
+	for len(p) >= 64 {
+		d.block(p[:64])
+		p = p[64:]
+	}
+	d.n = copy(d.buf[:], p)
+	return n, nil
+}
+
+// Method Sum returns the digest of the data written.
+func (d *md4) Sum() []byte {
+	length := d.len
+	var pad [72]byte
+	pad[0] = 0x80
+	if d.n < 56 {
+		d.Write(pad[:56-d.n])
+	} else {
+		d.Write(pad[:64+56-d.n])
+	}
+	binary.LittleEndian.PutUint64(pad[:8], length<<3)
+	d.Write(pad[:8])
+	out := make([]byte, 16)
+	for i, v := range d.s {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return out
+}
+
+// Method block processes a block of 64 bytes.
+func (d *md4) block(p []byte) {
+	var x [16]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(p[4*i:])
+	}
+	a, b, c, e := d.s[0], d.s[1], d.s[2], d.s[3]
+
+	// round 1
+	for _, i := range [16]uint{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15} {
+		s := [4]int{3, 7, 11, 19}[i%4]
+		t := a + (b&c | ^b&e) + x[i]
+		a, b, c, e = e, bits.RotateLeft32(t, s), b, c
+	}
+	// round 2
+	for n, i := range [16]uint{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15} {
+		s := [4]int{3, 5, 9, 13}[n%4]
+		t := a + (b&c | b&e | c&e) + x[i] + 0x5a827999
+		a, b, c, e = e, bits.RotateLeft32(t, s), b, c
+	}
+	// round 3
+	for n, i := range [16]uint{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15} {
+		s := [4]int{3, 9, 11, 15}[n%4]
+		t := a + (b ^ c ^ e) + x[i] + 0x6ed9eba1
+		a, b, c, e = e, bits.RotateLeft32(t, s), b, c
+	}
+
+	d.s[0] += a
+	d.s[1] += b
+	d.s[2] += c
+	d.s[3] += e
+}
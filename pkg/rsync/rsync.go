@@ -0,0 +1,443 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package rsync implements a read-only client for rsync daemons (rsyncd),
+// speaking rsync protocol version 29. It lists the modules of a daemon, and
+// reads directory trees of a module without needing anything but the daemon
+// on the server side.
+//
+// Unlike the rsync program, which transfers a whole tree over a single
+// connection, every directory listing and every file is a transfer of its own
+// on a separate connection, so that many of them can run in parallel. Files
+// are always transferred whole; their MD4 checksum is verified at the end.
+package rsync
+
+import (
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// DefaultPort is the TCP port of rsync daemons.
+const DefaultPort = 873
+
+// ndxDone is the index which ends a phase of the transfer.
+const ndxDone = -1
+
+// Flags of the file list entries
+const (
+	xmitSameMode      = 0x02
+	xmitExtendedFlags = 0x04
+	xmitSameUID       = 0x08
+	xmitSameGID       = 0x10
+	xmitSameName      = 0x20
+	xmitLongName      = 0x40
+	xmitSameTime      = 0x80
+)
+
+// Unix file types
+const (
+	sIFMT  = 0170000
+	sIFDIR = 0040000
+	sIFLNK = 0120000
+	sIFREG = 0100000
+)
+
+// Type Options configures an rsync client.
+type Options struct {
+	User     string        // user for modules with authentication, default $USER
+	Password string        // password of the user, default $RSYNC_PASSWORD
+	Timeout  time.Duration // timeout of the connection setup, 0 for none
+}
+
+// Type Module is a module offered by a daemon.
+type Module struct {
+	Name    string
+	Comment string
+}
+
+// Function withPort adds the default port to an address without port.
+func withPort(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return net.JoinHostPort(strings.Trim(addr, "[]"), strconv.Itoa(DefaultPort))
+	}
+	return addr
+}
+
+// Function ListModules returns the modules offered by the daemon listening on
+// addr, which has the form host[:port].
+func ListModules(addr string, opts Options) ([]Module, error) {
+	c, lines, err := dial(withPort(addr), "", &opts)
+	if err != nil {
+		return nil, err
+	}
+	c.c.Close()
+	var list []Module
+	for _, l := range lines {
+		f := strings.SplitN(l, "\t", 2)
+		m := Module{Name: strings.TrimSpace(f[0])}
+		if len(f) > 1 {
+			m.Comment = strings.TrimSpace(f[1])
+		}
+		if m.Name != "" {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+// Type Client reads a tree in a module of an rsync daemon. The names are
+// relative to the directory given to Dial, with a leading "/" ("" for the
+// directory itself). The attributes of the entries of listed directories are
+// kept, so that Lstat needs no further transfer.
+type Client struct {
+	addr   string
+	module string
+	root   string
+	opts   Options
+
+	mu    sync.Mutex
+	infos map[string]*fileInfo
+}
+
+// Function Dial connects to the module of the daemon listening on addr, which
+// has the form host[:port]. The root is the directory within the module the
+// names are relative to. Dial reads the root directory, to check the access.
+func Dial(addr, module, root string, opts Options) (*Client, error) {
+	if opts.User == "" {
+		opts.User = os.Getenv("USER")
+	}
+	if opts.Password == "" {
+		opts.Password = os.Getenv("RSYNC_PASSWORD")
+	}
+	cl := &Client{
+		addr:   withPort(addr),
+		module: module,
+		root:   strings.Trim(path.Clean("/"+root), "/"),
+		opts:   opts,
+		infos:  make(map[string]*fileInfo),
+	}
+	if _, err := cl.ReadDir(""); err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+// Method Close releases the client. The connections are closed after each
+// transfer already.
+func (cl *Client) Close() error {
+	return nil
+}
+
+// Method path returns the path of a name as argument for the daemon, with the
+// wildcard characters escaped.
+func (cl *Client) path(name string) string {
+	p := path.Join(cl.module, cl.root, name)
+	var b strings.Builder
+	for _, r := range p {
+		if strings.ContainsRune(`\*?[`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Method url returns the name as rsync:// URL, for error messages.
+func (cl *Client) url(name string) string {
+	return "rsync://" + cl.addr + "/" + path.Join(cl.module, cl.root, name)
+}
+
+// Method transfer connects to the module, and starts a transfer of the
+// sender with the given options and path.
+func (cl *Client) transfer(flags, p string) (*conn, error) {
+	c, _, err := dial(cl.addr, cl.module, &cl.opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.start([]string{"--server", "--sender", flags, "--numeric-ids", ".", p}); err != nil {
+		c.c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Type entry is an entry of the file list.
+type entry struct {
+	name  string
+	size  int64
+	mtime int32
+	mode  uint32
+	uid   uint32
+	gid   uint32
+	link  string
+}
+
+// Method readFileList reads the file list of a transfer, with the owners and
+// the targets of symbolic links.
+func (c *conn) readFileList() ([]entry, error) {
+	var list []entry
+	var last entry
+	for {
+		b, err := c.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			break
+		}
+		flags := int(b)
+		if flags&xmitExtendedFlags != 0 {
+			b, err := c.readByte()
+			if err != nil {
+				return nil, err
+			}
+			flags |= int(b) << 8
+		}
+
+		var l1, l2 int
+		if flags&xmitSameName != 0 {
+			b, err := c.readByte()
+			if err != nil {
+				return nil, err
+			}
+			l1 = int(b)
+		}
+		if flags&xmitLongName != 0 {
+			n, err := c.readInt()
+			if err != nil {
+				return nil, err
+			}
+			l2 = int(n)
+		} else {
+			b, err := c.readByte()
+			if err != nil {
+				return nil, err
+			}
+			l2 = int(b)
+		}
+		if l1 > len(last.name) {
+			return nil, syscall.EPROTO
+		}
+		name, err := c.readBytes(l2)
+		if err != nil {
+			return nil, err
+		}
+
+		e := last
+		e.name = last.name[:l1] + string(name)
+		e.link = ""
+		if e.size, err = c.readLong(); err != nil {
+			return nil, err
+		}
+		var n int32
+		if flags&xmitSameTime == 0 {
+			if e.mtime, err = c.readInt(); err != nil {
+				return nil, err
+			}
+		}
+		if flags&xmitSameMode == 0 {
+			if n, err = c.readInt(); err != nil {
+				return nil, err
+			}
+			e.mode = uint32(n)
+		}
+		if flags&xmitSameUID == 0 {
+			if n, err = c.readInt(); err != nil {
+				return nil, err
+			}
+			e.uid = uint32(n)
+		}
+		if flags&xmitSameGID == 0 {
+			if n, err = c.readInt(); err != nil {
+				return nil, err
+			}
+			e.gid = uint32(n)
+		}
+		if e.mode&sIFMT == sIFLNK {
+			if n, err = c.readInt(); err != nil {
+				return nil, err
+			}
+			link, err := c.readBytes(int(n))
+			if err != nil {
+				return nil, err
+			}
+			e.link = string(link)
+		}
+		list = append(list, e)
+		last = e
+	}
+	// the I/O error flag of the sender, e.g. for a missing path
+	ioErr, err := c.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if ioErr != 0 && len(list) == 0 {
+		if c.msg == "" {
+			return nil, syscall.EIO
+		}
+		return nil, c.error(nil)
+	}
+	return list, nil
+}
+
+// Method ReadDir lists a directory with a transfer of the file list only,
+// and returns the entries sorted by name, like ioutil.ReadDir.
+func (cl *Client) ReadDir(name string) ([]os.FileInfo, error) {
+	c, err := cl.transfer("-logtpd", cl.path(name)+"/")
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: cl.url(name), Err: err}
+	}
+	entries, err := c.readFileList()
+	if err == nil {
+		err = c.done()
+	}
+	if err != nil {
+		c.c.Close()
+		return nil, &os.PathError{Op: "readdir", Path: cl.url(name), Err: err}
+	}
+	go c.finish()
+
+	var list []os.FileInfo
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	for i := range entries {
+		e := &entries[i]
+		if e.name == "." {
+			cl.infos[name] = &fileInfo{path.Base("/" + path.Join(cl.root, name)), e}
+			continue
+		}
+		if strings.Contains(e.name, "/") {
+			continue
+		}
+		fi := &fileInfo{e.name, e}
+		cl.infos[name+"/"+e.name] = fi
+		list = append(list, fi)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+// Method Lstat returns the attributes of a file. They are taken from the
+// listing of the parent directory, which is read if needed.
+func (cl *Client) Lstat(name string) (os.FileInfo, error) {
+	cl.mu.Lock()
+	fi, ok := cl.infos[name]
+	cl.mu.Unlock()
+	if ok {
+		return fi, nil
+	}
+	parent := name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		parent = name[:i]
+	}
+	if _, err := cl.ReadDir(parent); err != nil {
+		return nil, err
+	}
+	cl.mu.Lock()
+	fi, ok = cl.infos[name]
+	cl.mu.Unlock()
+	if !ok {
+		return nil, &os.PathError{Op: "lstat", Path: cl.url(name), Err: syscall.ENOENT}
+	}
+	return fi, nil
+}
+
+// Method Stat returns the attributes of a file, following symbolic links
+// with relative targets within the tree.
+func (cl *Client) Stat(name string) (os.FileInfo, error) {
+	for i := 0; i < 40; i++ {
+		fi, err := cl.Lstat(name)
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			return fi, err
+		}
+		target := fi.(*fileInfo).e.link
+		if path.IsAbs(target) {
+			return nil, &os.PathError{Op: "stat", Path: cl.url(name), Err: syscall.EXDEV}
+		}
+		name = path.Join("/", path.Dir("/"+name), target)
+		if name == "/" {
+			name = ""
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: cl.url(name), Err: syscall.ELOOP}
+}
+
+// Method Readlink returns the target of a symbolic link.
+func (cl *Client) Readlink(name string) (string, error) {
+	fi, err := cl.Lstat(name)
+	if err != nil {
+		return "", err
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return "", &os.PathError{Op: "readlink", Path: cl.url(name), Err: syscall.EINVAL}
+	}
+	return fi.(*fileInfo).e.link, nil
+}
+
+// Type fileInfo implements os.FileInfo for the entries of a file list. The
+// method Sys returns a *sysstat.Stat with the owner and the modification
+// time, like for local files.
+type fileInfo struct {
+	name string
+	e    *entry
+}
+
+// Method Name returns the base name of the file.
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+// Method Size returns the size of the file in bytes.
+func (fi *fileInfo) Size() int64 {
+	return fi.e.size
+}
+
+// Method Mode returns the file mode bits.
+func (fi *fileInfo) Mode() os.FileMode {
+	m := os.FileMode(fi.e.mode & 0777)
+	switch fi.e.mode & sIFMT {
+	case sIFDIR:
+		m |= os.ModeDir
+	case sIFLNK:
+		m |= os.ModeSymlink
+	case sIFREG:
+	default:
+		m |= os.ModeIrregular
+	}
+	if fi.e.mode&04000 != 0 {
+		m |= os.ModeSetuid
+	}
+	if fi.e.mode&02000 != 0 {
+		m |= os.ModeSetgid
+	}
+	if fi.e.mode&01000 != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
+
+// Method ModTime returns the modification time, in seconds.
+func (fi *fileInfo) ModTime() time.Time {
+	return time.Unix(int64(fi.e.mtime), 0)
+}
+
+// Method IsDir checks whether the file is a directory.
+func (fi *fileInfo) IsDir() bool {
+	return fi.e.mode&sIFMT == sIFDIR
+}
+
+// Method Sys returns the owner and modification time as *sysstat.Stat.
+func (fi *fileInfo) Sys() interface{} {
+	t := int64(fi.e.mtime) * int64(time.Second)
+	return sysstat.New(sysstat.Attr{Uid: fi.e.uid, Gid: fi.e.gid, Size: fi.e.size, Atime: t, Mtime: t, Ctime: t})
+}
@@ -0,0 +1,302 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package s3
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Type fileInfo implements os.FileInfo for objects and key prefixes.
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	dir   bool
+}
+
+// Method Name returns the base name of the object.
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+// Method Size returns the size of the object in bytes.
+func (fi *fileInfo) Size() int64 {
+	return fi.size
+}
+
+// Method Mode returns the permissions kept in the metadata, or 0644 for
+// objects and 0755 for directories.
+func (fi *fileInfo) Mode() os.FileMode {
+	m := fi.mode
+	if m == 0 {
+		m = 0644
+		if fi.dir {
+			m = 0755
+		}
+	}
+	if fi.dir {
+		m |= os.ModeDir
+	}
+	return m
+}
+
+// Method ModTime returns the modification time kept in the metadata, or the
+// time of the upload.
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.mtime
+}
+
+// Method IsDir checks whether the entry is a directory (key prefix).
+func (fi *fileInfo) IsDir() bool {
+	return fi.dir
+}
+
+// Method Sys returns nil, there are no system specific attributes.
+func (fi *fileInfo) Sys() interface{} {
+	return nil
+}
+
+// Function sortInfos sorts directory entries by name.
+func sortInfos(list []os.FileInfo) {
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+}
+
+// Type part is an uploaded part of a multipart upload.
+type part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// Type File is an object opened for reading or writing. Reading streams the
+// object, seeking restarts the download at the new offset. Writing buffers
+// the data, and uploads it in parts of PartSize bytes, several in parallel;
+// small objects are uploaded with a single request on Close. Written objects
+// can neither be read nor seeked.
+type File struct {
+	cl    *Client
+	name  string
+	key   string
+	write bool
+	off   int64 // offset of the next byte read or written
+
+	// reading
+	body io.ReadCloser
+
+	// writing
+	perm   os.FileMode
+	buf    []byte
+	upload string // id of the multipart upload
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	mu     sync.Mutex // protects parts and err
+	parts  []part
+	err    error
+}
+
+// Method Open opens an object for reading.
+func (cl *Client) Open(name string) (*File, error) {
+	return &File{cl: cl, name: name, key: cl.key(name)}, nil
+}
+
+// Method OpenFile opens an object for reading or writing. Writing replaces
+// the object, appending is not supported.
+func (cl *Client) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return cl.Open(name)
+	}
+	if flag&(os.O_RDWR|os.O_APPEND) != 0 {
+		return nil, &os.PathError{Op: "open", Path: cl.url(name), Err: syscall.ENOTSUP}
+	}
+	return &File{
+		cl:    cl,
+		name:  name,
+		key:   cl.key(name),
+		write: true,
+		perm:  perm.Perm(),
+		sem:   make(chan struct{}, cl.opts.Uploads),
+	}, nil
+}
+
+// Method Read reads from the object, starting the download if needed.
+func (f *File) Read(b []byte) (int, error) {
+	if f.write {
+		return 0, &os.PathError{Op: "read", Path: f.cl.url(f.name), Err: syscall.EBADF}
+	}
+	if f.body == nil {
+		var hdr http.Header
+		if f.off > 0 {
+			hdr = http.Header{"Range": {"bytes=" + strconv.FormatInt(f.off, 10) + "-"}}
+		}
+		resp, err := f.cl.request("GET", f.key, nil, hdr, nil)
+		if e, ok := err.(*Error); ok && e.Status == http.StatusRequestedRangeNotSatisfiable {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, &os.PathError{Op: "read", Path: f.cl.url(f.name), Err: errno(err)}
+		}
+		f.body = resp.Body
+	}
+	n, err := f.body.Read(b)
+	f.off += int64(n)
+	if err != nil && err != io.EOF {
+		err = &os.PathError{Op: "read", Path: f.cl.url(f.name), Err: err}
+	}
+	return n, err
+}
+
+// Method Write buffers the data, and starts the upload of full parts.
+func (f *File) Write(b []byte) (int, error) {
+	if !f.write {
+		return 0, &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: syscall.EBADF}
+	}
+	if err := f.error(); err != nil {
+		return 0, err
+	}
+	f.buf = append(f.buf, b...)
+	f.off += int64(len(b))
+	for int64(len(f.buf)) >= f.cl.opts.PartSize {
+		if err := f.sendPart(f.buf[:f.cl.opts.PartSize]); err != nil {
+			return 0, err
+		}
+		f.buf = append([]byte{}, f.buf[f.cl.opts.PartSize:]...)
+	}
+	return len(b), nil
+}
+
+// Method error returns the first error of the part uploads.
+func (f *File) error() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Method sendPart starts the upload of a part in the background. The first
+// part starts the multipart upload.
+func (f *File) sendPart(data []byte) error {
+	if f.upload == "" {
+		var r struct {
+			UploadID string `xml:"UploadId"`
+		}
+		hdr := http.Header{"X-Amz-Meta-Mode": {strconv.FormatUint(uint64(f.perm), 8)}}
+		if _, err := f.cl.do("POST", f.key, url.Values{"uploads": {""}}, hdr, nil, &r); err != nil {
+			return &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+		}
+		f.upload = r.UploadID
+	}
+
+	f.mu.Lock()
+	n := len(f.parts) + 1
+	f.parts = append(f.parts, part{PartNumber: n})
+	f.mu.Unlock()
+
+	f.sem <- struct{}{}
+	f.wg.Add(1)
+	go func(n int, data []byte) {
+		defer func() {
+			<-f.sem
+			f.wg.Done()
+		}()
+		q := url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {f.upload}}
+		hdr, err := f.cl.do("PUT", f.key, q, nil, data, nil)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if err != nil {
+			if f.err == nil {
+				f.err = &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+			}
+			return
+		}
+		f.parts[n-1].ETag = hdr.Get("ETag")
+	}(n, append([]byte{}, data...))
+	return nil
+}
+
+// Method Seek sets the offset for the next Read. Written objects only
+// support seeking to the current offset.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	default:
+		return f.off, &os.PathError{Op: "seek", Path: f.cl.url(f.name), Err: errors.New("unsupported whence")}
+	}
+	if offset == f.off {
+		return offset, nil
+	}
+	if f.write || offset < 0 {
+		return f.off, &os.PathError{Op: "seek", Path: f.cl.url(f.name), Err: syscall.EINVAL}
+	}
+	if f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.off = offset
+	return offset, nil
+}
+
+// Method Truncate is only supported for the size already written.
+func (f *File) Truncate(size int64) error {
+	if !f.write || size != f.off {
+		return &os.PathError{Op: "truncate", Path: f.cl.url(f.name), Err: syscall.ENOTSUP}
+	}
+	return nil
+}
+
+// Method Close finishes the download or upload.
+func (f *File) Close() error {
+	if !f.write {
+		if f.body != nil {
+			f.body.Close()
+			f.body = nil
+		}
+		return nil
+	}
+
+	// small objects are uploaded at once
+	if f.upload == "" {
+		hdr := http.Header{"X-Amz-Meta-Mode": {strconv.FormatUint(uint64(f.perm), 8)}}
+		if _, err := f.cl.do("PUT", f.key, nil, hdr, f.buf, nil); err != nil {
+			return &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+		}
+		return nil
+	}
+
+	// upload the last part, and complete the multipart upload
+	var err error
+	if len(f.buf) > 0 {
+		err = f.sendPart(f.buf)
+	}
+	f.wg.Wait()
+	if err == nil {
+		err = f.error()
+	}
+	q := url.Values{"uploadId": {f.upload}}
+	if err == nil {
+		body, _ := xml.Marshal(struct {
+			XMLName xml.Name `xml:"CompleteMultipartUpload"`
+			Parts   []part   `xml:"Part"`
+		}{Parts: f.parts})
+		if _, err = f.cl.do("POST", f.key, q, nil, body, nil); err != nil {
+			err = &os.PathError{Op: "write", Path: f.cl.url(f.name), Err: errno(err)}
+		}
+	}
+	if err != nil {
+		f.cl.do("DELETE", f.key, q, nil, nil, nil)
+	}
+	f.buf = nil
+	return err
+}
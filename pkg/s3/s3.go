@@ -0,0 +1,476 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package s3 is a minimal client for Amazon S3 and S3 compatible object
+// stores (MinIO, Ceph RGW, the XML API of Google Cloud Storage), which
+// presents a bucket and key prefix as directory tree. Directories are key
+// prefixes, and are created as empty marker objects with a trailing slash, so
+// that empty directories survive. Large files are uploaded in parts, which are
+// sent in parallel. Requests are signed with AWS signature version 4, or carry
+// an OAuth2 bearer token.
+package s3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Type Options configures an S3 client. Empty fields are taken from the
+// usual AWS environment variables.
+type Options struct {
+	Endpoint     string // URL of the service (AWS_ENDPOINT_URL), default AWS
+	Region       string // region (AWS_REGION, AWS_DEFAULT_REGION), default us-east-1
+	AccessKey    string // access key (AWS_ACCESS_KEY_ID), anonymous if empty
+	SecretKey    string // secret key (AWS_SECRET_ACCESS_KEY)
+	SessionToken string // session token (AWS_SESSION_TOKEN)
+	Token        string // OAuth2 bearer token, used instead of signing if given
+	PathStyle    bool   // address the bucket in the path, default for custom endpoints
+	PartSize     int64  // size of the parts of multipart uploads, default 8 MiB
+	Uploads      int    // number of parts uploaded in parallel per file, default 4
+	Conns        int    // maximum number of idle HTTP connections, default 64
+}
+
+// Type Client is a bucket and key prefix of an object store. Names are
+// relative to the prefix, with a leading "/" ("" for the prefix itself).
+type Client struct {
+	bucket string
+	prefix string
+	opts   Options
+	base   *url.URL
+	hc     *http.Client
+}
+
+// Function New returns a client for the bucket. The prefix is the key prefix
+// the names are relative to.
+func New(bucket, prefix string, opts Options) (*Client, error) {
+	env := func(v *string, names ...string) {
+		for _, n := range names {
+			if *v == "" {
+				*v = os.Getenv(n)
+			}
+		}
+	}
+	env(&opts.Endpoint, "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	env(&opts.Region, "AWS_REGION", "AWS_DEFAULT_REGION")
+	env(&opts.AccessKey, "AWS_ACCESS_KEY_ID")
+	env(&opts.SecretKey, "AWS_SECRET_ACCESS_KEY")
+	env(&opts.SessionToken, "AWS_SESSION_TOKEN")
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	} else {
+		opts.PathStyle = true
+	}
+	if opts.PartSize < 5<<20 {
+		opts.PartSize = 8 << 20
+	}
+	if opts.Uploads <= 0 {
+		opts.Uploads = 4
+	}
+	if opts.Conns <= 0 {
+		opts.Conns = 64
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("s3: no bucket given")
+	}
+	base, err := url.Parse(opts.Endpoint)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %s", opts.Endpoint)
+	}
+
+	return &Client{
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+		opts:   opts,
+		base:   base,
+		hc: &http.Client{Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+			MaxIdleConns:          opts.Conns,
+			MaxIdleConnsPerHost:   opts.Conns,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: 2 * time.Minute,
+		}},
+	}, nil
+}
+
+// Method Close releases the idle connections of the client.
+func (cl *Client) Close() error {
+	if t, ok := cl.hc.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Method key returns the object key of a name.
+func (cl *Client) key(name string) string {
+	return strings.TrimPrefix(path.Join(cl.prefix, name), "/")
+}
+
+// Function dirKey returns the key prefix of the entries of a directory.
+func dirKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+// Type Error is an error response of the object store.
+type Error struct {
+	Status  int    // HTTP status code
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// Method Error returns the error message.
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: HTTP status %d", e.Status)
+	}
+	return fmt.Sprintf("s3: %s: %s", e.Code, e.Message)
+}
+
+// Function errno maps an error response to the corresponding system error,
+// so that os.IsNotExist and friends work.
+func errno(err error) error {
+	if e, ok := err.(*Error); ok {
+		switch e.Status {
+		case http.StatusNotFound:
+			return syscall.ENOENT
+		case http.StatusForbidden:
+			return syscall.EACCES
+		case http.StatusPreconditionFailed:
+			return syscall.EEXIST
+		}
+	}
+	return err
+}
+
+// Method request sends a signed request for an object key, and returns the
+// response, which has a successful status. The caller closes the body.
+func (cl *Client) request(method, key string, query url.Values, hdr http.Header, body []byte) (*http.Response, error) {
+	u := *cl.base
+	p := "/" + key
+	if cl.opts.PathStyle {
+		p = "/" + cl.bucket + p
+	} else {
+		u.Host = cl.bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(cl.base.Path, "/") + p
+	u.RawPath = uriEncode(u.Path, true)
+	u.RawQuery = canonicalQuery(query)
+
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u.String(), rd)
+	if err != nil {
+		return nil, err
+	}
+	req.URL = &u
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+	if cl.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cl.opts.Token)
+	} else if cl.opts.AccessKey != "" {
+		hash := emptyHash
+		if len(body) > 0 {
+			sum := sha256.Sum256(body)
+			hash = hex.EncodeToString(sum[:])
+		}
+		cl.sign(req, hash, time.Now())
+	}
+
+	resp, err := cl.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		e := &Error{Status: resp.StatusCode}
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		xml.Unmarshal(b, e)
+		return nil, e
+	}
+	return resp, nil
+}
+
+// Method do sends a request, and decodes the XML response into v, if it is
+// not nil.
+func (cl *Client) do(method, key string, query url.Values, hdr http.Header, body []byte, v interface{}) (http.Header, error) {
+	resp, err := cl.request(method, key, query, hdr, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// some operations report errors with status 200
+	if bytes.Contains(b[:min(len(b), 256)], []byte("<Error>")) {
+		e := &Error{Status: resp.StatusCode}
+		xml.Unmarshal(b, e)
+		return nil, e
+	}
+	if v != nil {
+		if err := xml.Unmarshal(b, v); err != nil {
+			return nil, fmt.Errorf("s3: invalid response: %s", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// Function min returns the smaller of two integers.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Type listResult is the response of ListObjectsV2.
+type listResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// Method list lists the keys with the given prefix, up to the next slash.
+// It stops after max entries, if max is positive.
+func (cl *Client) list(prefix string, max int) ([]os.FileInfo, error) {
+	q := url.Values{"list-type": {"2"}, "prefix": {prefix}, "delimiter": {"/"}}
+	if max > 0 {
+		q.Set("max-keys", strconv.Itoa(max))
+	}
+	var list []os.FileInfo
+	for {
+		var r listResult
+		if _, err := cl.do("GET", "", q, nil, nil, &r); err != nil {
+			return nil, err
+		}
+		for _, p := range r.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(p.Prefix, prefix), "/")
+			if name != "" {
+				list = append(list, &fileInfo{name: name, dir: true})
+			}
+		}
+		for _, c := range r.Contents {
+			name := strings.TrimPrefix(c.Key, prefix)
+			if name == "" {
+				// the directory marker
+				continue
+			}
+			list = append(list, &fileInfo{name: name, size: c.Size, mtime: c.LastModified})
+		}
+		if !r.IsTruncated || r.NextContinuationToken == "" || (max > 0 && len(list) >= max) {
+			break
+		}
+		q.Set("continuation-token", r.NextContinuationToken)
+	}
+	return list, nil
+}
+
+// Method ReadDir lists a directory, and returns the entries sorted by name.
+// The modification times are those of the uploads; the file times kept in the
+// metadata are only returned by Stat and Lstat.
+func (cl *Client) ReadDir(name string) ([]os.FileInfo, error) {
+	list, err := cl.list(dirKey(cl.key(name)), 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: cl.url(name), Err: errno(err)}
+	}
+	sortInfos(list)
+	return list, nil
+}
+
+// Method head returns the attributes of an object.
+func (cl *Client) head(key string) (*fileInfo, error) {
+	hdr, err := cl.do("HEAD", key, nil, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	fi := &fileInfo{name: path.Base(key), mode: 0644}
+	fi.size, _ = strconv.ParseInt(hdr.Get("Content-Length"), 10, 64)
+	fi.mtime, _ = http.ParseTime(hdr.Get("Last-Modified"))
+	if s, err := strconv.ParseInt(hdr.Get("X-Amz-Meta-Mtime"), 10, 64); err == nil {
+		fi.mtime = time.Unix(s, 0)
+	}
+	if m, err := strconv.ParseUint(hdr.Get("X-Amz-Meta-Mode"), 8, 32); err == nil {
+		fi.mode = os.FileMode(m).Perm()
+	}
+	return fi, nil
+}
+
+// Method Lstat returns the attributes of a file or directory. A directory
+// exists, if its marker object or any key below it exists.
+func (cl *Client) Lstat(name string) (os.FileInfo, error) {
+	key := cl.key(name)
+	if name == "" || key == "" {
+		return &fileInfo{name: path.Base("/" + key), dir: true}, nil
+	}
+	fi, err := cl.head(key)
+	if err == nil {
+		return fi, nil
+	}
+	if errno(err) == syscall.ENOENT {
+		if fi, err = cl.head(key + "/"); err == nil {
+			fi.name, fi.dir = path.Base(key), true
+			return fi, nil
+		}
+	}
+	if errno(err) == syscall.ENOENT {
+		var list []os.FileInfo
+		if list, err = cl.list(key+"/", 1); err == nil {
+			if len(list) > 0 {
+				return &fileInfo{name: path.Base(key), dir: true}, nil
+			}
+			err = &Error{Status: http.StatusNotFound}
+		}
+	}
+	return nil, &os.PathError{Op: "lstat", Path: cl.url(name), Err: errno(err)}
+}
+
+// Method Stat is the same as Lstat, since there are no symbolic links.
+func (cl *Client) Stat(name string) (os.FileInfo, error) {
+	return cl.Lstat(name)
+}
+
+// Method Readlink fails, since there are no symbolic links.
+func (cl *Client) Readlink(name string) (string, error) {
+	return "", &os.PathError{Op: "readlink", Path: cl.url(name), Err: syscall.EINVAL}
+}
+
+// Method Symlink fails, since symbolic links cannot be stored.
+func (cl *Client) Symlink(oldname, newname string) error {
+	return &os.PathError{Op: "symlink", Path: cl.url(newname), Err: syscall.ENOTSUP}
+}
+
+// Method Mkdir creates the marker object of a directory. If the store
+// supports conditional writes, an existing directory is reported with
+// EEXIST.
+func (cl *Client) Mkdir(name string, perm os.FileMode) error {
+	key := cl.key(name)
+	if key == "" {
+		return &os.PathError{Op: "mkdir", Path: cl.url(name), Err: syscall.EEXIST}
+	}
+	hdr := http.Header{
+		"If-None-Match":   {"*"},
+		"X-Amz-Meta-Mode": {strconv.FormatUint(uint64(perm.Perm()), 8)},
+	}
+	if _, err := cl.do("PUT", key+"/", nil, hdr, []byte{}, nil); err != nil {
+		return &os.PathError{Op: "mkdir", Path: cl.url(name), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method MkdirAll creates the marker object of a directory, if it does not
+// exist. The parent directories are implied by the key.
+func (cl *Client) MkdirAll(name string, perm os.FileMode) error {
+	if cl.key(name) == "" {
+		return nil
+	}
+	err := cl.Mkdir(name, perm)
+	if os.IsExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Method Rename copies an object to the new key, and deletes the old one.
+// Directories cannot be renamed.
+func (cl *Client) Rename(oldname, newname string) error {
+	src := cl.bucket + "/" + cl.key(oldname)
+	hdr := http.Header{"X-Amz-Copy-Source": {uriEncode(src, true)}}
+	_, err := cl.do("PUT", cl.key(newname), nil, hdr, nil, nil)
+	if err == nil {
+		_, err = cl.do("DELETE", cl.key(oldname), nil, nil, nil, nil)
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: cl.url(oldname), New: cl.url(newname), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method Remove deletes an object, or the marker object of a directory.
+func (cl *Client) Remove(name string) error {
+	key := cl.key(name)
+	fi, err := cl.Lstat(name)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		key += "/"
+	}
+	if _, err := cl.do("DELETE", key, nil, nil, nil, nil); err != nil {
+		return &os.PathError{Op: "remove", Path: cl.url(name), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method Chown fails, since objects have no owner.
+func (cl *Client) Chown(name string, uid, gid int) error {
+	return &os.PathError{Op: "chown", Path: cl.url(name), Err: syscall.ENOTSUP}
+}
+
+// Method Lchown fails, since objects have no owner.
+func (cl *Client) Lchown(name string, uid, gid int) error {
+	return &os.PathError{Op: "lchown", Path: cl.url(name), Err: syscall.ENOTSUP}
+}
+
+// Method Chtimes stores the modification time of a file in its metadata, by
+// copying the object onto itself. Directories are ignored. Objects larger
+// than 5 GB cannot be copied this way.
+func (cl *Client) Chtimes(name string, atime, mtime time.Time) error {
+	key := cl.key(name)
+	fi, err := cl.head(key)
+	if errno(err) == syscall.ENOENT {
+		if fi, err := cl.Lstat(name); err == nil && fi.IsDir() {
+			return nil
+		}
+	}
+	if err != nil {
+		return &os.PathError{Op: "chtimes", Path: cl.url(name), Err: errno(err)}
+	}
+	hdr := http.Header{
+		"X-Amz-Copy-Source":        {uriEncode(cl.bucket+"/"+key, true)},
+		"X-Amz-Metadata-Directive": {"REPLACE"},
+		"X-Amz-Meta-Mtime":         {strconv.FormatInt(mtime.Unix(), 10)},
+		"X-Amz-Meta-Mode":          {strconv.FormatUint(uint64(fi.mode), 8)},
+	}
+	if _, err := cl.do("PUT", key, nil, hdr, nil, nil); err != nil {
+		return &os.PathError{Op: "chtimes", Path: cl.url(name), Err: errno(err)}
+	}
+	return nil
+}
+
+// Method url returns the s3:// URL of a name, for error messages.
+func (cl *Client) url(name string) string {
+	return "s3://" + cl.bucket + "/" + cl.key(name)
+}
@@ -0,0 +1,158 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package sftp
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Attribute flags
+const (
+	attrSize        = 0x00000001
+	attrUIDGID      = 0x00000002
+	attrPermissions = 0x00000004
+	attrACModTime   = 0x00000008
+	attrExtended    = 0x80000000
+)
+
+// Type attr holds the file attributes of an SFTP file. The permissions
+// include the file type bits, like st_mode.
+type attr struct {
+	flags uint32
+	size  uint64
+	uid   uint32
+	gid   uint32
+	mode  uint32
+	atime uint32
+	mtime uint32
+}
+
+// Function decodeAttr decodes the file attributes.
+func decodeAttr(d *decoder) attr {
+	var a attr
+	a.flags = d.uint32()
+	if a.flags&attrSize != 0 {
+		a.size = d.uint64()
+	}
+	if a.flags&attrUIDGID != 0 {
+		a.uid = d.uint32()
+		a.gid = d.uint32()
+	}
+	if a.flags&attrPermissions != 0 {
+		a.mode = d.uint32()
+	}
+	if a.flags&attrACModTime != 0 {
+		a.atime = d.uint32()
+		a.mtime = d.uint32()
+	}
+	if a.flags&attrExtended != 0 {
+		for n := d.uint32(); n > 0 && d.err == nil; n-- {
+			d.string() // type
+			d.string() // data
+		}
+	}
+	return a
+}
+
+// Method encode appends the attributes to a request.
+func (a attr) encode(e *encoder) {
+	e.uint32(a.flags)
+	if a.flags&attrSize != 0 {
+		e.uint64(a.size)
+	}
+	if a.flags&attrUIDGID != 0 {
+		e.uint32(a.uid)
+		e.uint32(a.gid)
+	}
+	if a.flags&attrPermissions != 0 {
+		e.uint32(a.mode)
+	}
+	if a.flags&attrACModTime != 0 {
+		e.uint32(a.atime)
+		e.uint32(a.mtime)
+	}
+}
+
+// Type fileInfo implements os.FileInfo for SFTP files. The method Sys returns
+// a *sysstat.Stat with the owner and time stamps, like for local files.
+// The names of the owner are known for the entries of a directory listing.
+type fileInfo struct {
+	name        string
+	a           attr
+	user, group string
+}
+
+// Method Owner returns the names of the user and group owning the file on the
+// server, or empty strings if they are unknown.
+func (fi *fileInfo) Owner() (user, group string) {
+	return fi.user, fi.group
+}
+
+// Method Name returns the base name of the file.
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+// Method Size returns the size of the file in bytes.
+func (fi *fileInfo) Size() int64 {
+	return int64(fi.a.size)
+}
+
+// Method Mode returns the file mode bits.
+func (fi *fileInfo) Mode() os.FileMode {
+	m := os.FileMode(fi.a.mode & 0777)
+	if fi.a.mode&syscall.S_ISUID != 0 {
+		m |= os.ModeSetuid
+	}
+	if fi.a.mode&syscall.S_ISGID != 0 {
+		m |= os.ModeSetgid
+	}
+	if fi.a.mode&syscall.S_ISVTX != 0 {
+		m |= os.ModeSticky
+	}
+	switch fi.a.mode & syscall.S_IFMT {
+	case syscall.S_IFDIR:
+		m |= os.ModeDir
+	case syscall.S_IFLNK:
+		m |= os.ModeSymlink
+	case syscall.S_IFBLK:
+		m |= os.ModeDevice
+	case syscall.S_IFCHR:
+		m |= os.ModeDevice | os.ModeCharDevice
+	case syscall.S_IFSOCK:
+		m |= os.ModeSocket
+	case syscall.S_IFIFO:
+		m |= os.ModeNamedPipe
+	}
+	return m
+}
+
+// Method ModTime returns the modification time. SFTP version 3 transfers it
+// in seconds.
+func (fi *fileInfo) ModTime() time.Time {
+	return time.Unix(int64(fi.a.mtime), 0)
+}
+
+// Method IsDir checks whether the file is a directory.
+func (fi *fileInfo) IsDir() bool {
+	return fi.a.mode&syscall.S_IFMT == syscall.S_IFDIR
+}
+
+// Method Sys returns the attributes as *sysstat.Stat. Only the fields which
+// have the same type on all supported platforms are filled.
+func (fi *fileInfo) Sys() interface{} {
+	return sysstat.New(sysstat.Attr{
+		Uid:   fi.a.uid,
+		Gid:   fi.a.gid,
+		Size:  int64(fi.a.size),
+		Mode:  fi.a.mode,
+		Atime: int64(fi.a.atime) * 1e9,
+		Mtime: int64(fi.a.mtime) * 1e9,
+	})
+}
@@ -0,0 +1,279 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package sftp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// maxPacket is the upper limit for the size of a received packet.
+const maxPacket = 1 << 18
+
+// errClosed is returned for requests on a closed or broken connection.
+var errClosed = errors.New("sftp: connection closed")
+
+// Type reply is the response to a request.
+type reply struct {
+	typ byte
+	d   *decoder
+	err error
+}
+
+// Type conn is an SFTP session, running over the standard input and output of
+// an ssh process. Requests are pipelined: many requests can be in flight at
+// the same time, and the responses are matched to the requests by their id.
+type conn struct {
+	cmd     *exec.Cmd
+	w       io.WriteCloser
+	r       *bufio.Reader
+	exts    map[string]string // extensions announced by the server
+	wmu     sync.Mutex        // serializes the writing of packets
+	mu      sync.Mutex        // protects pending and err
+	pending map[uint32]chan reply
+	err     error
+}
+
+// Function dial starts the ssh command, which runs the sftp subsystem on the
+// server, and negotiates the protocol version.
+func dial(argv []string, timeout time.Duration) (*conn, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stderr = os.Stderr
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &conn{
+		cmd:     cmd,
+		w:       w,
+		r:       bufio.NewReaderSize(r, 64*1024),
+		exts:    make(map[string]string),
+		pending: make(map[uint32]chan reply),
+	}
+
+	// the version exchange has no request id
+	done := make(chan error, 1)
+	go func() {
+		var e encoder
+		e.uint32(protocolVersion)
+		if err := c.send(typeInit, e.b); err != nil {
+			done <- err
+			return
+		}
+		typ, d, err := c.readPacket()
+		if err == nil && typ != typeVersion {
+			err = fmt.Errorf("sftp: unexpected packet type %d instead of version", typ)
+		}
+		if err == nil {
+			if v := d.uint32(); v != protocolVersion {
+				err = fmt.Errorf("sftp: server speaks protocol version %d", v)
+			}
+			for d.err == nil && len(d.b) > 0 {
+				name := d.string()
+				c.exts[name] = d.string()
+			}
+		}
+		done <- err
+	}()
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		select {
+		case err = <-done:
+			t.Stop()
+		case <-t.C:
+			err = syscall.ETIMEDOUT
+		}
+	} else {
+		err = <-done
+	}
+	if err != nil {
+		c.fail(err)
+		return nil, err
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// Method send writes a packet.
+func (c *conn) send(typ byte, payload []byte) error {
+	p := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(p, uint32(1+len(payload)))
+	p[4] = typ
+	p = append(p, payload...)
+	c.wmu.Lock()
+	_, err := c.w.Write(p)
+	c.wmu.Unlock()
+	return err
+}
+
+// Method readPacket reads a packet, and returns its type and payload.
+func (c *conn) readPacket() (byte, *decoder, error) {
+	var h [5]byte
+	if _, err := io.ReadFull(c.r, h[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(h[:4])
+	if n < 1 || n > maxPacket {
+		return 0, nil, fmt.Errorf("sftp: packet of %d bytes is invalid", n)
+	}
+	b := make([]byte, n-1)
+	if _, err := io.ReadFull(c.r, b); err != nil {
+		return 0, nil, err
+	}
+	return h[4], &decoder{b: b}, nil
+}
+
+// Method start sends a request with the given id, and returns the channel on
+// which the response is delivered. The payload starts with the id.
+func (c *conn) start(id uint32, typ byte, payload []byte) chan reply {
+	ch := make(chan reply, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		ch <- reply{err: c.err}
+		return ch
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(typ, payload); err != nil {
+		c.fail(err)
+	}
+	return ch
+}
+
+// Method forget drops a pending request, e.g. after a timeout.
+func (c *conn) forget(id uint32) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Method fail closes the session and delivers the error to all pending
+// requests.
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	for id, ch := range c.pending {
+		ch <- reply{err: err}
+		delete(c.pending, id)
+	}
+	c.w.Close()
+	if err != errClosed {
+		c.cmd.Process.Kill()
+	}
+	go c.cmd.Wait()
+}
+
+// Method readLoop reads the responses and hands them to the waiting
+// requests.
+func (c *conn) readLoop() {
+	for {
+		typ, d, err := c.readPacket()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		id := d.uint32()
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ok {
+			ch <- reply{typ: typ, d: d, err: d.err}
+		}
+	}
+}
+
+// Type request is a request in flight.
+type request struct {
+	cl *Client
+	c  *conn
+	id uint32
+	ch chan reply
+}
+
+// Method start sends a request with the encoded arguments over the next
+// session. The response is collected with the wait method, so that many
+// requests can be in flight at the same time.
+func (cl *Client) start(typ byte, args []byte) *request {
+	return cl.startOn(cl.conn(), typ, args)
+}
+
+// Method conn returns the next session, round robin.
+func (cl *Client) conn() *conn {
+	return cl.conns[atomic.AddUint32(&cl.next, 1)%uint32(len(cl.conns))]
+}
+
+// Method startOn sends a request over the given session. Requests on a file
+// handle must use the session which opened it.
+func (cl *Client) startOn(c *conn, typ byte, args []byte) *request {
+	id := atomic.AddUint32(&cl.id, 1)
+	var e encoder
+	e.uint32(id)
+	e.b = append(e.b, args...)
+	return &request{cl, c, id, c.start(id, typ, e.b)}
+}
+
+// Method wait waits for the response of a request. A request without
+// response within the timeout fails with ETIMEDOUT.
+func (rq *request) wait() (byte, *decoder, error) {
+	var r reply
+	if rq.cl.opts.Timeout > 0 {
+		t := time.NewTimer(rq.cl.opts.Timeout)
+		select {
+		case r = <-rq.ch:
+			t.Stop()
+		case <-t.C:
+			rq.c.forget(rq.id)
+			return 0, nil, syscall.ETIMEDOUT
+		}
+	} else {
+		r = <-rq.ch
+	}
+	return r.typ, r.d, r.err
+}
+
+// Method status waits for a request answered by a status response, and
+// returns the error of the status.
+func (rq *request) status() error {
+	typ, d, err := rq.wait()
+	if err != nil {
+		return err
+	}
+	return expect(typ, d, typeStatus)
+}
+
+// Function expect checks the type of a response. A status response is turned
+// into its error.
+func expect(typ byte, d *decoder, want byte) error {
+	if typ == typeStatus {
+		return statusError(d)
+	}
+	if typ != want {
+		return fmt.Errorf("sftp: unexpected packet type %d", typ)
+	}
+	return d.err
+}
@@ -0,0 +1,197 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package sftp
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Type pendingRead is a READ request in flight.
+type pendingRead struct {
+	off   uint64
+	count uint32
+	rq    *request
+}
+
+// Type File is an open SFTP file. Reads and writes are pipelined: up to
+// ReadAhead requests are in flight, so that the throughput is not limited by
+// the round trip time of a single request. Errors of writes are reported by
+// a later Write, or by Close.
+type File struct {
+	cl     *Client
+	c      *conn // session of the handle
+	name   string
+	h      []byte
+	off    uint64        // offset of the next byte read or written
+	next   uint64        // offset of the next READ request
+	reads  []pendingRead // READ requests in flight, in offset order
+	buf    []byte        // data received but not yet returned
+	eof    bool          // the server reported the end of the file
+	writes []*request    // WRITE requests in flight
+}
+
+// Method fill starts READ requests until ReadAhead requests are in flight.
+func (f *File) fill() {
+	for !f.eof && len(f.reads) < f.cl.opts.ReadAhead {
+		var e encoder
+		e.bytes(f.h)
+		e.uint64(f.next)
+		e.uint32(chunk)
+		f.reads = append(f.reads, pendingRead{f.next, chunk, f.cl.startOn(f.c, typeRead, e.b)})
+		f.next += chunk
+	}
+}
+
+// Method reset drops the READ requests in flight and the buffered data. The
+// responses of the dropped requests are discarded when they arrive.
+func (f *File) reset(off uint64) {
+	f.reads = nil
+	f.buf = nil
+	f.off = off
+	f.next = off
+	f.eof = false
+}
+
+// Method Read reads up to len(b) bytes from the file.
+func (f *File) Read(b []byte) (int, error) {
+	if err := f.flush(); err != nil {
+		return 0, err
+	}
+	for len(f.buf) == 0 {
+		if f.eof && len(f.reads) == 0 {
+			return 0, io.EOF
+		}
+		f.fill()
+		p := f.reads[0]
+		f.reads = f.reads[1:]
+
+		typ, d, err := p.rq.wait()
+		if err == nil && typ == typeStatus {
+			if err = statusError(d); err == errEOF {
+				// the requests behind the end of the file are not needed
+				f.reads = nil
+				f.eof = true
+				continue
+			}
+			if err == nil {
+				err = errors.New("sftp: unexpected status response")
+			}
+		}
+		if err == nil {
+			err = expect(typ, d, typeData)
+		}
+		var data []byte
+		if err == nil {
+			data = d.bytes()
+			if err = d.err; err == nil && uint32(len(data)) > p.count {
+				err = errors.New("sftp: invalid READ response")
+			}
+		}
+		if err != nil {
+			f.reset(f.off)
+			return 0, &os.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.buf = data
+		if uint32(len(data)) < p.count {
+			// short read, continue after the data received
+			f.reads = nil
+			f.next = p.off + uint64(len(data))
+		}
+	}
+	n := copy(b, f.buf)
+	f.buf = f.buf[n:]
+	f.off += uint64(n)
+	return n, nil
+}
+
+// Method Write writes b to the file. The data is sent in chunks, without
+// waiting for the responses, unless ReadAhead requests are in flight.
+func (f *File) Write(b []byte) (int, error) {
+	if len(f.reads) > 0 || len(f.buf) > 0 {
+		f.reset(f.off)
+	}
+	n := 0
+	for n < len(b) {
+		m := len(b) - n
+		if m > chunk {
+			m = chunk
+		}
+		if len(f.writes) >= f.cl.opts.ReadAhead {
+			rq := f.writes[0]
+			f.writes = f.writes[1:]
+			if err := rq.status(); err != nil {
+				f.flush()
+				return n, &os.PathError{Op: "write", Path: f.name, Err: err}
+			}
+		}
+		var e encoder
+		e.bytes(f.h)
+		e.uint64(f.off)
+		e.bytes(b[n : n+m])
+		f.writes = append(f.writes, f.cl.startOn(f.c, typeWrite, e.b))
+		f.off += uint64(m)
+		n += m
+	}
+	return n, nil
+}
+
+// Method flush waits for the WRITE requests in flight, and returns the first
+// error.
+func (f *File) flush() error {
+	var first error
+	for _, rq := range f.writes {
+		if err := rq.status(); err != nil && first == nil {
+			first = &os.PathError{Op: "write", Path: f.name, Err: err}
+		}
+	}
+	f.writes = nil
+	return first
+}
+
+// Method Seek sets the offset for the next Read or Write. Seeking relative to
+// the end of the file is not supported.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if err := f.flush(); err != nil {
+		return int64(f.off), err
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(f.off)
+	default:
+		return int64(f.off), &os.PathError{Op: "seek", Path: f.name, Err: errors.New("unsupported whence")}
+	}
+	if offset < 0 {
+		return int64(f.off), &os.PathError{Op: "seek", Path: f.name, Err: errors.New("negative offset")}
+	}
+	f.reset(uint64(offset))
+	return offset, nil
+}
+
+// Method Truncate changes the size of the file.
+func (f *File) Truncate(size int64) error {
+	if err := f.flush(); err != nil {
+		return err
+	}
+	var e encoder
+	e.bytes(f.h)
+	attr{flags: attrSize, size: uint64(size)}.encode(&e)
+	if err := f.cl.startOn(f.c, typeFsetstat, e.b).status(); err != nil {
+		return &os.PathError{Op: "truncate", Path: f.name, Err: err}
+	}
+	return nil
+}
+
+// Method Close waits for the pending writes, and closes the file.
+func (f *File) Close() error {
+	err := f.flush()
+	f.reset(f.off)
+	if cerr := f.cl.closeHandle(f.c, f.h); err == nil && cerr != nil {
+		err = &os.PathError{Op: "close", Path: f.name, Err: cerr}
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package sftp
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errShort is returned when a packet is shorter than its encoding requires.
+var errShort = errors.New("sftp: short packet")
+
+// Type encoder builds the payload of an SFTP packet, in the SSH wire
+// encoding (RFC 4251).
+type encoder struct {
+	b []byte
+}
+
+// Method byte appends a single byte.
+func (e *encoder) byte(v byte) {
+	e.b = append(e.b, v)
+}
+
+// Method uint32 appends an unsigned 32 bit integer.
+func (e *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.b = append(e.b, b[:]...)
+}
+
+// Method uint64 appends an unsigned 64 bit integer.
+func (e *encoder) uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.b = append(e.b, b[:]...)
+}
+
+// Method bytes appends a string of arbitrary bytes.
+func (e *encoder) bytes(b []byte) {
+	e.uint32(uint32(len(b)))
+	e.b = append(e.b, b...)
+}
+
+// Method string appends a string.
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.b = append(e.b, s...)
+}
+
+// Type decoder reads the payload of an SFTP packet. The first error is
+// remembered, and all further reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// Method next returns the next n bytes of the packet.
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = errShort
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+// Method byte reads a single byte.
+func (d *decoder) byte() byte {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// Method uint32 reads an unsigned 32 bit integer.
+func (d *decoder) uint32() uint32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// Method uint64 reads an unsigned 64 bit integer.
+func (d *decoder) uint64() uint64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// Method bytes reads a string of arbitrary bytes.
+func (d *decoder) bytes() []byte {
+	return d.next(int(d.uint32()))
+}
+
+// Method string reads a string.
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
@@ -0,0 +1,516 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package sftp is a client for the SSH file transfer protocol, version 3
+// (draft-ietf-secsh-filexfer-02), as implemented by OpenSSH. Like sshfs, it
+// runs the ssh command with the sftp subsystem, and talks the protocol over
+// its standard input and output, so that the SSH configuration, keys and
+// agent of the user apply. The client uses several sessions, and keeps many
+// requests in flight, so that the latency of the link is hidden.
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Protocol version, packet types and open flags
+const (
+	protocolVersion = 3
+
+	typeInit          = 1
+	typeVersion       = 2
+	typeOpen          = 3
+	typeClose         = 4
+	typeRead          = 5
+	typeWrite         = 6
+	typeLstat         = 7
+	typeFstat         = 8
+	typeSetstat       = 9
+	typeFsetstat      = 10
+	typeOpendir       = 11
+	typeReaddir       = 12
+	typeRemove        = 13
+	typeMkdir         = 14
+	typeRmdir         = 15
+	typeStat          = 17
+	typeRename        = 18
+	typeReadlink      = 19
+	typeSymlink       = 20
+	typeStatus        = 101
+	typeHandle        = 102
+	typeData          = 103
+	typeName          = 104
+	typeAttrs         = 105
+	typeExtended      = 200
+	typeExtendedReply = 201
+
+	flagRead   = 0x01
+	flagWrite  = 0x02
+	flagAppend = 0x04
+	flagCreat  = 0x08
+	flagTrunc  = 0x10
+	flagExcl   = 0x20
+)
+
+// Status codes
+const (
+	statusOK               = 0
+	statusEOF              = 1
+	statusNoSuchFile       = 2
+	statusPermissionDenied = 3
+	statusFailure          = 4
+	statusBadMessage       = 5
+	statusNoConnection     = 6
+	statusConnectionLost   = 7
+	statusOpUnsupported    = 8
+)
+
+// chunk is the number of bytes read or written by a single request. It is
+// the limit all servers must support.
+const chunk = 32 * 1024
+
+// Type Options configures an SFTP client.
+type Options struct {
+	Command   []string      // ssh command and options, default "ssh"
+	Port      int           // port of the SSH server, 0 for the default
+	Conns     int           // number of SFTP sessions, default 4
+	ReadAhead int           // number of requests in flight per open file, default 16
+	Timeout   time.Duration // timeout of session setup and requests, 0 for none
+	Compress  bool          // let ssh compress the traffic
+}
+
+// Type Client is a connection to an SFTP server. The names are relative to
+// the root directory given to Dial.
+type Client struct {
+	root  string
+	opts  Options
+	conns []*conn
+	next  uint32 // session for the next request
+	id    uint32 // last request id
+}
+
+// Function Dial connects to the SFTP server on host, which may have the form
+// user@host. The root is the directory the names are relative to; a relative
+// root is relative to the home directory of the user on the server.
+func Dial(host, root string, opts Options) (*Client, error) {
+	if len(opts.Command) == 0 {
+		opts.Command = []string{"ssh"}
+	}
+	if opts.Conns <= 0 {
+		opts.Conns = 4
+	}
+	if opts.ReadAhead <= 0 {
+		opts.ReadAhead = 16
+	}
+	if root == "" {
+		root = "."
+	}
+
+	argv := append([]string{}, opts.Command...)
+	argv = append(argv, "-x", "-a")
+	if opts.Compress {
+		argv = append(argv, "-C")
+	}
+	if opts.Port > 0 {
+		argv = append(argv, "-p", strconv.Itoa(opts.Port))
+	}
+	argv = append(argv, "-s", host, "sftp")
+
+	cl := &Client{root: root, opts: opts}
+	for i := 0; i < opts.Conns; i++ {
+		c, err := dial(argv, opts.Timeout)
+		if err != nil {
+			cl.Close()
+			return nil, fmt.Errorf("sftp: cannot connect to %s: %s", host, err)
+		}
+		cl.conns = append(cl.conns, c)
+	}
+	return cl, nil
+}
+
+// Method Close closes all sessions of the client.
+func (cl *Client) Close() error {
+	for _, c := range cl.conns {
+		c.fail(errClosed)
+	}
+	return nil
+}
+
+// Method path returns the path of a name on the server.
+func (cl *Client) path(name string) string {
+	return path.Join(cl.root, name)
+}
+
+// Method extension checks whether the server supports a protocol extension.
+func (cl *Client) extension(name string) bool {
+	_, ok := cl.conns[0].exts[name]
+	return ok
+}
+
+// Method call sends a request with a path argument, and waits for a status
+// response.
+func (cl *Client) call(op string, typ byte, name string, rest func(e *encoder)) error {
+	var e encoder
+	e.string(cl.path(name))
+	if rest != nil {
+		rest(&e)
+	}
+	if err := cl.start(typ, e.b).status(); err != nil {
+		return &os.PathError{Op: op, Path: cl.path(name), Err: err}
+	}
+	return nil
+}
+
+// Method stat sends a request for the attributes of a file.
+func (cl *Client) stat(op string, typ byte, name string) (os.FileInfo, error) {
+	var e encoder
+	e.string(cl.path(name))
+	typ, d, err := cl.start(typ, e.b).wait()
+	if err == nil {
+		err = expect(typ, d, typeAttrs)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: op, Path: cl.path(name), Err: err}
+	}
+	a := decodeAttr(d)
+	if d.err != nil {
+		return nil, &os.PathError{Op: op, Path: cl.path(name), Err: d.err}
+	}
+	return &fileInfo{name: path.Base(cl.path(name)), a: a}, nil
+}
+
+// Method Lstat returns the attributes of a file. Symbolic links are not
+// followed.
+func (cl *Client) Lstat(name string) (os.FileInfo, error) {
+	return cl.stat("lstat", typeLstat, name)
+}
+
+// Method Stat returns the attributes of a file, following symbolic links.
+func (cl *Client) Stat(name string) (os.FileInfo, error) {
+	return cl.stat("stat", typeStat, name)
+}
+
+// Method Readlink returns the target of a symbolic link.
+func (cl *Client) Readlink(name string) (string, error) {
+	var e encoder
+	e.string(cl.path(name))
+	typ, d, err := cl.start(typeReadlink, e.b).wait()
+	if err == nil {
+		err = expect(typ, d, typeName)
+	}
+	var target string
+	if err == nil {
+		if d.uint32() != 1 {
+			err = errors.New("sftp: invalid readlink response")
+		}
+		target = d.string()
+	}
+	if err == nil {
+		err = d.err
+	}
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: cl.path(name), Err: err}
+	}
+	return target, nil
+}
+
+// Method ReadDir reads a directory, and returns the entries sorted by name,
+// like ioutil.ReadDir.
+func (cl *Client) ReadDir(name string) ([]os.FileInfo, error) {
+	var e encoder
+	e.string(cl.path(name))
+	c := cl.conn()
+	h, err := cl.handle(c, typeOpendir, e.b)
+	if err != nil {
+		return nil, &os.PathError{Op: "readdir", Path: cl.path(name), Err: err}
+	}
+	defer cl.closeHandle(c, h)
+
+	var list []os.FileInfo
+	for {
+		e = encoder{}
+		e.bytes(h)
+		typ, d, err := cl.startOn(c, typeReaddir, e.b).wait()
+		if err == nil && typ == typeStatus {
+			if err = statusError(d); err == nil {
+				err = errors.New("sftp: unexpected status response")
+			}
+			if err == errEOF {
+				break
+			}
+		}
+		if err == nil {
+			err = expect(typ, d, typeName)
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: cl.path(name), Err: err}
+		}
+		for n := d.uint32(); n > 0 && d.err == nil; n-- {
+			ename := d.string()
+			long := d.string()
+			a := decodeAttr(d)
+			if ename != "." && ename != ".." {
+				fi := &fileInfo{name: ename, a: a}
+				// the long name is formatted like "ls -l", with the names
+				// of the owner
+				if f := strings.Fields(long); len(f) > 4 && a.flags&attrUIDGID != 0 {
+					fi.user, fi.group = f[2], f[3]
+				}
+				list = append(list, fi)
+			}
+		}
+		if d.err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: cl.path(name), Err: d.err}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+// Method handle sends a request which returns a file handle.
+func (cl *Client) handle(c *conn, typ byte, args []byte) ([]byte, error) {
+	typ, d, err := cl.startOn(c, typ, args).wait()
+	if err == nil {
+		err = expect(typ, d, typeHandle)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h := d.bytes()
+	return h, d.err
+}
+
+// Method closeHandle closes a file or directory handle.
+func (cl *Client) closeHandle(c *conn, h []byte) error {
+	var e encoder
+	e.bytes(h)
+	return cl.startOn(c, typeClose, e.b).status()
+}
+
+// Method Open opens a file for reading.
+func (cl *Client) Open(name string) (*File, error) {
+	return cl.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Method OpenFile opens a file with the flags of os.OpenFile. The permissions
+// are applied to new files.
+func (cl *Client) OpenFile(name string, flag int, perm os.FileMode) (*File, error) {
+	var pflags uint32
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_RDONLY:
+		pflags = flagRead
+	case os.O_WRONLY:
+		pflags = flagWrite
+	case os.O_RDWR:
+		pflags = flagRead | flagWrite
+	}
+	if flag&os.O_APPEND != 0 {
+		pflags |= flagAppend
+	}
+	if flag&os.O_CREATE != 0 {
+		pflags |= flagCreat
+	}
+	if flag&os.O_TRUNC != 0 {
+		pflags |= flagTrunc
+	}
+	if flag&os.O_EXCL != 0 {
+		pflags |= flagExcl
+	}
+
+	var e encoder
+	e.string(cl.path(name))
+	e.uint32(pflags)
+	attr{flags: attrPermissions, mode: uint32(perm.Perm())}.encode(&e)
+	c := cl.conn()
+	h, err := cl.handle(c, typeOpen, e.b)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: cl.path(name), Err: err}
+	}
+	return &File{cl: cl, c: c, name: cl.path(name), h: h}, nil
+}
+
+// Method Mkdir creates a directory.
+func (cl *Client) Mkdir(name string, perm os.FileMode) error {
+	err := cl.call("mkdir", typeMkdir, name, func(e *encoder) {
+		attr{flags: attrPermissions, mode: uint32(perm.Perm())}.encode(e)
+	})
+	return cl.exists(name, err)
+}
+
+// Method MkdirAll creates a directory and its parents, if they do not exist.
+func (cl *Client) MkdirAll(name string, perm os.FileMode) error {
+	if f, err := cl.Stat(name); err == nil {
+		if f.IsDir() {
+			return nil
+		}
+		return &os.PathError{Op: "mkdir", Path: cl.path(name), Err: syscall.ENOTDIR}
+	}
+	if dir := path.Dir(name); dir != name && dir != "." && dir != "/" {
+		if err := cl.MkdirAll(dir, perm); err != nil {
+			return err
+		}
+	}
+	err := cl.Mkdir(name, perm)
+	if err != nil && os.IsExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Method Symlink creates the symbolic link newname pointing to oldname. The
+// link target oldname is not relative to the root. OpenSSH expects the
+// arguments in the opposite order of the protocol draft, which is the order
+// used here.
+func (cl *Client) Symlink(oldname, newname string) error {
+	var e encoder
+	e.string(oldname)
+	e.string(cl.path(newname))
+	err := cl.start(typeSymlink, e.b).status()
+	if err != nil {
+		err = &os.PathError{Op: "symlink", Path: cl.path(newname), Err: err}
+	}
+	return cl.exists(newname, err)
+}
+
+// Method exists turns a generic failure of a create operation into EEXIST,
+// if the name exists. Servers report an existing target as failure.
+func (cl *Client) exists(name string, err error) error {
+	if pe, ok := err.(*os.PathError); ok && pe.Err == errFailure {
+		if _, serr := cl.Lstat(name); serr == nil {
+			pe.Err = syscall.EEXIST
+		}
+	}
+	return err
+}
+
+// Method Rename renames a file, replacing the target if it exists. The
+// replacement is atomic, if the server supports the posix-rename extension.
+func (cl *Client) Rename(oldname, newname string) error {
+	var e encoder
+	if cl.extension("posix-rename@openssh.com") {
+		e.string("posix-rename@openssh.com")
+		e.string(cl.path(oldname))
+		e.string(cl.path(newname))
+		if err := cl.start(typeExtended, e.b).status(); err != nil {
+			return &os.LinkError{Op: "rename", Old: cl.path(oldname), New: cl.path(newname), Err: err}
+		}
+		return nil
+	}
+	e.string(cl.path(oldname))
+	e.string(cl.path(newname))
+	err := cl.start(typeRename, e.b).status()
+	if err == errFailure {
+		// the target exists
+		if rerr := cl.Remove(newname); rerr == nil {
+			err = cl.start(typeRename, e.b).status()
+		}
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: cl.path(oldname), New: cl.path(newname), Err: err}
+	}
+	return nil
+}
+
+// Method Remove removes a file or an empty directory.
+func (cl *Client) Remove(name string) error {
+	err := cl.call("remove", typeRemove, name, nil)
+	if err == nil {
+		return nil
+	}
+	if f, serr := cl.Lstat(name); serr == nil && f.IsDir() {
+		return cl.call("remove", typeRmdir, name, nil)
+	}
+	return err
+}
+
+// Method Chown changes the owner of a file.
+func (cl *Client) Chown(name string, uid, gid int) error {
+	return cl.call("chown", typeSetstat, name, func(e *encoder) {
+		attr{flags: attrUIDGID, uid: uint32(uid), gid: uint32(gid)}.encode(e)
+	})
+}
+
+// Method Lchown changes the owner of a file, without following symbolic
+// links. It needs the lsetstat extension of OpenSSH.
+func (cl *Client) Lchown(name string, uid, gid int) error {
+	if !cl.extension("lsetstat@openssh.com") {
+		return &os.PathError{Op: "lchown", Path: cl.path(name), Err: syscall.ENOTSUP}
+	}
+	var e encoder
+	e.string("lsetstat@openssh.com")
+	e.string(cl.path(name))
+	attr{flags: attrUIDGID, uid: uint32(uid), gid: uint32(gid)}.encode(&e)
+	if err := cl.start(typeExtended, e.b).status(); err != nil {
+		return &os.PathError{Op: "lchown", Path: cl.path(name), Err: err}
+	}
+	return nil
+}
+
+// Method Chtimes changes the access and modification time of a file, in
+// seconds.
+func (cl *Client) Chtimes(name string, atime, mtime time.Time) error {
+	return cl.call("chtimes", typeSetstat, name, func(e *encoder) {
+		attr{flags: attrACModTime, atime: uint32(atime.Unix()), mtime: uint32(mtime.Unix())}.encode(e)
+	})
+}
+
+// Method Chmod changes the permissions of a file, including the setuid,
+// setgid and sticky bits.
+func (cl *Client) Chmod(name string, mode os.FileMode) error {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= 01000
+	}
+	return cl.call("chmod", typeSetstat, name, func(e *encoder) {
+		attr{flags: attrPermissions, mode: m}.encode(e)
+	})
+}
+
+// Errors of status responses without an errno equivalent
+var (
+	errEOF     = errors.New("sftp: end of file")
+	errFailure = errors.New("sftp: failure")
+)
+
+// Function statusError translates a status response into an error, nil for
+// success.
+func statusError(d *decoder) error {
+	code := d.uint32()
+	msg := d.string()
+	switch code {
+	case statusOK:
+		return nil
+	case statusEOF:
+		return errEOF
+	case statusNoSuchFile:
+		return syscall.ENOENT
+	case statusPermissionDenied:
+		return syscall.EACCES
+	case statusFailure:
+		return errFailure
+	case statusNoConnection, statusConnectionLost:
+		return syscall.ECONNRESET
+	case statusOpUnsupported:
+		return syscall.ENOTSUP
+	default:
+		if msg != "" {
+			return fmt.Errorf("sftp: %s", msg)
+		}
+		return fmt.Errorf("sftp: status %d", code)
+	}
+}
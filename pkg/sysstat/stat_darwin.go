@@ -0,0 +1,27 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package sysstat
+
+import "syscall"
+
+// Function New returns the attributes as *Stat.
+func New(a Attr) *Stat {
+	return &syscall.Stat_t{
+		Ino:       a.Ino,
+		Uid:       a.Uid,
+		Gid:       a.Gid,
+		Size:      a.Size,
+		Mode:      uint16(a.Mode),
+		Atimespec: syscall.NsecToTimespec(a.Atime),
+		Mtimespec: syscall.NsecToTimespec(a.Mtime),
+		Ctimespec: syscall.NsecToTimespec(a.Ctime),
+	}
+}
+
+// Function Times returns the access, modification and change time of a
+// *Stat in nanoseconds.
+func Times(st *Stat) (atime, mtime, ctime int64) {
+	return syscall.TimespecToNsec(st.Atimespec), syscall.TimespecToNsec(st.Mtimespec), syscall.TimespecToNsec(st.Ctimespec)
+}
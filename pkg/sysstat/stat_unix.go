@@ -0,0 +1,14 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !windows
+// +build !windows
+
+package sysstat
+
+import "syscall"
+
+// Type Stat is the *syscall.Stat_t of the system, which the local file system
+// returns from the Sys method of its file information.
+type Stat = syscall.Stat_t
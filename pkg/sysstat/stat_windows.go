@@ -0,0 +1,43 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package sysstat
+
+// Type Stat holds the attributes of a file of a backend, with the fields of a
+// *syscall.Stat_t on Linux. The local file system of Windows returns a
+// *syscall.Win32FileAttributeData instead, so local files have no owner and
+// no inode number.
+type Stat struct {
+	Dev   uint64
+	Ino   uint64
+	Nlink uint64
+	Mode  uint32
+	Uid   uint32
+	Gid   uint32
+	Rdev  uint64
+	Size  int64
+	Atim  int64 // access time in nanoseconds
+	Mtim  int64 // modification time in nanoseconds
+	Ctim  int64 // change time in nanoseconds
+}
+
+// Function New returns the attributes as *Stat.
+func New(a Attr) *Stat {
+	return &Stat{
+		Ino:  a.Ino,
+		Uid:  a.Uid,
+		Gid:  a.Gid,
+		Size: a.Size,
+		Mode: a.Mode,
+		Atim: a.Atime,
+		Mtim: a.Mtime,
+		Ctim: a.Ctime,
+	}
+}
+
+// Function Times returns the access, modification and change time of a
+// *Stat in nanoseconds.
+func Times(st *Stat) (atime, mtime, ctime int64) {
+	return st.Atim, st.Mtim, st.Ctim
+}
@@ -0,0 +1,26 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package sysstat converts between file attributes and *syscall.Stat_t, whose
+// fields differ between the supported operating systems. The backends of
+// psync return a *syscall.Stat_t from the Sys method of their file
+// information, like the local file system does, so that the owner and time
+// stamps of remote files are handled the same way. The type is available as
+// Stat, which is a struct of its own on Windows, where package syscall has
+// no Stat_t.
+package sysstat
+
+// Type Attr holds the attributes of a file which are kept in a *Stat. Times
+// are in nanoseconds since the epoch, the mode holds the file type and
+// permission bits in the format of stat(2).
+type Attr struct {
+	Ino   uint64
+	Uid   uint32
+	Gid   uint32
+	Size  int64
+	Mode  uint32
+	Atime int64
+	Mtime int64
+	Ctime int64
+}
@@ -0,0 +1,168 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package treetest
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hweidner/psync/pkg/psync"
+)
+
+// DefaultSizes is the default size distribution of the synthetic tree.
+const DefaultSizes = "90:4k,9:64k,1:4m"
+
+// Type Setting is a setting of the engine or of the scheduler, which the
+// benchmarks compare. The same setting is given as command line flags to the
+// psync processes of "psync bench", and as options to the benchmarks of the
+// package psync.
+type Setting struct {
+	Name  string                      // name of the setting
+	Args  func(threads uint) []string // command line flags for the number of threads
+	Apply func(o *psync.Options)      // options, with the number of threads set
+}
+
+// Engines are the ways the data of the files is copied. The classic engine
+// reads and writes the files with the copy threads, the data threads copy
+// them in a separate pool while the copy threads read the directories, and
+// copy-range has the kernel copy them with copy_file_range. There is no
+// io_uring engine.
+var Engines = []Setting{
+	{"classic", func(uint) []string { return nil }, func(*psync.Options) {}},
+	{"data-threads", func(n uint) []string { return []string{"-data-threads", strconv.Itoa(int(n))} },
+		func(o *psync.Options) { o.DataThreads = o.Threads }},
+	{"copy-range", func(uint) []string { return []string{"-copy-range"} },
+		func(o *psync.Options) { o.CopyRange = true }},
+}
+
+// Schedulers are the orders in which the directories and files are handed
+// out to the copy threads.
+var Schedulers = []Setting{
+	{"dfs", func(uint) []string { return nil }, func(*psync.Options) {}},
+	{"bfs", func(uint) []string { return []string{"-traversal", "bfs"} },
+		func(o *psync.Options) { o.Traversal = "bfs" }},
+	{"prefetch", func(n uint) []string { return []string{"-prefetch", strconv.Itoa(int(n))} },
+		func(o *psync.Options) { o.Prefetch = o.Threads }},
+	{"small-first", func(uint) []string { return []string{"-order", "small"} },
+		func(o *psync.Options) { o.Order = "small" }},
+	{"large-first", func(uint) []string { return []string{"-order", "large"} },
+		func(o *psync.Options) { o.Order = "large" }},
+}
+
+// Type SizeClass is a file size of the synthetic tree, with its share of the
+// files in percent.
+type SizeClass struct {
+	Percent int
+	Size    int64
+}
+
+// Function ParseSizes parses a size distribution like "90:4k,9:64k,1:4m".
+// The sizes take the suffixes k, m and g for KiB, MiB and GiB, the shares
+// must add up to 100 percent.
+func ParseSizes(s string) ([]SizeClass, error) {
+	var sizes []SizeClass
+	sum := 0
+	for _, f := range strings.Split(s, ",") {
+		i := strings.IndexByte(f, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid size class %q", f)
+		}
+		pct, err := strconv.Atoi(strings.TrimSpace(f[:i]))
+		if err != nil || pct < 0 {
+			return nil, fmt.Errorf("invalid share %q", f[:i])
+		}
+		num, mult := strings.ToLower(strings.TrimSpace(f[i+1:])), int64(1)
+		if num != "" {
+			switch num[len(num)-1] {
+			case 'k':
+				mult = 1 << 10
+			case 'm':
+				mult = 1 << 20
+			case 'g':
+				mult = 1 << 30
+			}
+			if mult > 1 {
+				num = num[:len(num)-1]
+			}
+		}
+		size, err := strconv.ParseInt(num, 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid size %q", f[i+1:])
+		}
+		sizes = append(sizes, SizeClass{pct, size * mult})
+		sum += pct
+	}
+	if sum != 100 {
+		return nil, fmt.Errorf("the shares of the sizes %s add up to %d%%, not 100%%", s, sum)
+	}
+	return sizes, nil
+}
+
+// Function Synthetic creates a synthetic tree in root: a directory tree of
+// the given depth with width subdirectories per directory, and the files
+// spread evenly over all directories. The file sizes are drawn from the
+// distribution, the contents are random, so that they cannot be compressed.
+// The same arguments always generate the same tree.
+func Synthetic(root string, files int, sizes []SizeClass, depth, width int) error {
+	dirs := []string{root}
+	level := []string{root}
+	for d := 0; d < depth; d++ {
+		var next []string
+		for _, dir := range level {
+			for w := 0; w < width; w++ {
+				sub := filepath.Join(dir, fmt.Sprintf("d%d", w))
+				if err := os.Mkdir(sub, 0755); err != nil {
+					return err
+				}
+				next = append(next, sub)
+			}
+		}
+		dirs, level = append(dirs, next...), next
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	data := make([]byte, 1<<20)
+	rnd.Read(data)
+	for i := 0; i < files; i++ {
+		p, size := rnd.Intn(100), int64(0)
+		for _, c := range sizes {
+			if p < c.Percent {
+				size = c.Size
+				break
+			}
+			p -= c.Percent
+		}
+		name := filepath.Join(dirs[i%len(dirs)], fmt.Sprintf("f%d", i))
+		if err := writeFile(name, data, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function writeFile creates a file of the given size, filled by repeating
+// the data.
+func writeFile(name string, data []byte, size int64) error {
+	fd, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	for size > 0 && err == nil {
+		n := int64(len(data))
+		if n > size {
+			n = size
+		}
+		_, err = fd.Write(data[:n])
+		size -= n
+	}
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
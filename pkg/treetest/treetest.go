@@ -0,0 +1,304 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package treetest generates random trees for tests of the copy engine, and
+// compares the copies with their sources. It also generates the synthetic
+// trees of the benchmarks, and lists the settings of the engine compared by
+// them. It is used by the tests and benchmarks of package psync, and by the
+// subcommands "psync selftest" and "psync bench".
+package treetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hweidner/psync/pkg/psync"
+	"github.com/hweidner/psync/pkg/sysstat"
+)
+
+// Function Run generates a random tree in src, copies it with the engine to
+// dst, and compares the trees. A second run in sync mode must find nothing to
+// copy. It returns the statistics of the first run, and the differences
+// found. The owners are set and compared only when run as root.
+func Run(src, dst string, seed int64, entries int, threads uint) (psync.Stats, []string, error) {
+	var st psync.Stats
+	if err := Random(src, rand.New(rand.NewSource(seed)), entries); err != nil {
+		return st, nil, fmt.Errorf("cannot create source tree: %s", err)
+	}
+	opts := psync.Options{
+		Source:      src,
+		Destination: dst,
+		Threads:     threads,
+		Times:       true,
+		Owner:       os.Getuid() == 0,
+		Create:      true,
+		Stdout:      ioutil.Discard,
+	}
+
+	// the first run copies the tree
+	s := psync.New(opts)
+	if err := s.Run(context.Background()); err != nil {
+		return st, nil, fmt.Errorf("copy failed: %s", err)
+	}
+	st = s.Stats()
+	if st.Errors > 0 {
+		return st, nil, fmt.Errorf("copy reported %d errors", st.Errors)
+	}
+	diffs := Compare(src, dst, opts.Owner)
+
+	// the second run finds everything up to date
+	opts.Sync = true
+	s = psync.New(opts)
+	if err := s.Run(context.Background()); err != nil {
+		return st, nil, fmt.Errorf("sync failed: %s", err)
+	}
+	if st := s.Stats(); st.Files > 0 || st.Errors > 0 {
+		diffs = append(diffs, fmt.Sprintf("sync run copied %d files, with %d errors, instead of none", st.Files, st.Errors))
+	}
+	return st, diffs, nil
+}
+
+// Names of the random tree, %d is replaced by a unique number
+var names = []string{
+	"file%d", "with space %d", "ünïcødé-%d", "日本語%d", "line\nbreak%d", "-dash%d",
+	"#hash%d", "back\\slash%d", "quote'\"%d", ".hidden%d", "%d..", "tab\t%d",
+	"Case%d", "case%d", "emoji-\U0001F600-%d", strings.Repeat("long", 60) + "%d",
+}
+
+// Modes of the random files and directories
+var (
+	fileModes = []os.FileMode{0644, 0600, 0755, 0700, 0444, 0640, 0400, 0755 | os.ModeSetuid}
+	dirModes  = []os.FileMode{0755, 0700, 0555, 0750, 0755 | os.ModeSetgid, 0777 | os.ModeSticky}
+)
+
+// The setuid, setgid and sticky bits are not preserved, see -fake-super
+const specialBits = os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// Function Random creates a random tree in root. The modes and timestamps
+// are set at the end, deepest entries first, so that the directories stay
+// writable while the tree is created.
+func Random(root string, rnd *rand.Rand, entries int) error {
+	if err := os.Mkdir(root, 0755); err != nil {
+		return err
+	}
+	dirs := []string{root}
+	var all []string
+	data := make([]byte, 1<<20)
+	rnd.Read(data)
+
+	for i := 0; i < entries; i++ {
+		name := fmt.Sprintf(names[rnd.Intn(len(names))], i)
+		path := filepath.Join(dirs[rnd.Intn(len(dirs))], name)
+		var err error
+		switch p := rnd.Intn(100); {
+		case p < 15: // directory
+			err = os.Mkdir(path, 0755)
+			dirs = append(dirs, path)
+		case p < 70: // file with random content, up to 1 MB
+			size := int64(rnd.ExpFloat64() * 32768)
+			if size > int64(len(data)) {
+				size = int64(len(data))
+			}
+			off := rnd.Intn(len(data) - int(size) + 1)
+			err = ioutil.WriteFile(path, data[off:off+int(size)], 0644)
+		case p < 75: // empty file
+			err = ioutil.WriteFile(path, nil, 0644)
+		case p < 80: // sparse file with a few blocks of data
+			err = sparseFile(path, rnd, data)
+		default: // symbolic link, relative, absolute or dangling
+			var target string
+			switch {
+			case len(all) > 0 && rnd.Intn(3) > 0:
+				target, _ = filepath.Rel(filepath.Dir(path), all[rnd.Intn(len(all))])
+			case rnd.Intn(2) == 0:
+				target = "/nonexistent/target"
+			default:
+				target = "missing/target"
+			}
+			err = os.Symlink(target, path)
+		}
+		if err != nil {
+			return err
+		}
+		all = append(all, path)
+	}
+
+	// set owners, modes and timestamps
+	for i := len(all) - 1; i >= -1; i-- {
+		path := root
+		if i >= 0 {
+			path = all[i]
+		}
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		if os.Getuid() == 0 {
+			ids := []int{0, 1000, 12345, 65534}
+			if err := os.Lchown(path, ids[rnd.Intn(len(ids))], ids[rnd.Intn(len(ids))]); err != nil {
+				return err
+			}
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		mode := fileModes[rnd.Intn(len(fileModes))]
+		if fi.IsDir() {
+			mode = dirModes[rnd.Intn(len(dirModes))]
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+		mtime := time.Unix(rnd.Int63n(300000000)+1300000000, rnd.Int63n(1e9))
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function sparseFile creates a file of up to 8 MB with holes, and a few
+// blocks of data.
+func sparseFile(path string, rnd *rand.Rand, data []byte) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	size := rnd.Int63n(8<<20) + 1
+	if err = fd.Truncate(size); err == nil {
+		for n := rnd.Intn(4); n > 0 && err == nil; n-- {
+			off := rnd.Int63n(size)
+			len := rnd.Intn(8192) + 1
+			if off+int64(len) > size {
+				len = int(size - off)
+			}
+			_, err = fd.WriteAt(data[:len], off)
+		}
+	}
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Function Compare compares the destination with the source tree, and
+// returns the differences: missing and extra entries, and entries differing
+// in type, permission bits, content, link target, modification time (except for
+// symbolic links) or owner.
+func Compare(src, dst string, owner bool) []string {
+	var diffs []string
+	differ := func(rel, format string, a ...interface{}) {
+		diffs = append(diffs, fmt.Sprintf("%q: %s", rel, fmt.Sprintf(format, a...)))
+	}
+
+	filepath.Walk(src, func(path string, s os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(src, path)
+		if err != nil {
+			differ(rel, "cannot read source: %s", err)
+			return nil
+		}
+		d, err := os.Lstat(filepath.Join(dst, rel))
+		if err != nil {
+			differ(rel, "missing in the destination")
+			if s.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if s.Mode()&^specialBits != d.Mode()&^specialBits {
+			differ(rel, "mode %s, copied as %s", s.Mode(), d.Mode())
+			return nil
+		}
+		if owner {
+			ss, ds := s.Sys().(*sysstat.Stat), d.Sys().(*sysstat.Stat)
+			if ss.Uid != ds.Uid || ss.Gid != ds.Gid {
+				differ(rel, "owner %d:%d, copied as %d:%d", ss.Uid, ss.Gid, ds.Uid, ds.Gid)
+			}
+		}
+		switch {
+		case s.Mode()&os.ModeSymlink != 0:
+			st, _ := os.Readlink(path)
+			dt, _ := os.Readlink(filepath.Join(dst, rel))
+			if st != dt {
+				differ(rel, "link target %q, copied as %q", st, dt)
+			}
+			return nil
+		case s.Mode().IsRegular():
+			if s.Size() != d.Size() {
+				differ(rel, "size %d, copied as %d", s.Size(), d.Size())
+			} else if !sameFile(path, filepath.Join(dst, rel)) {
+				differ(rel, "content differs")
+			}
+		}
+		if !s.ModTime().Equal(d.ModTime()) {
+			differ(rel, "modification time %s, copied as %s", s.ModTime(), d.ModTime())
+		}
+		return nil
+	})
+
+	filepath.Walk(dst, func(path string, d os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(dst, path)
+		if err != nil {
+			return nil
+		}
+		if _, err := os.Lstat(filepath.Join(src, rel)); err != nil {
+			differ(rel, "extra entry in the destination")
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	return diffs
+}
+
+// Function sameFile compares the contents of two files.
+func sameFile(a, b string) bool {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false
+	}
+	defer fb.Close()
+	ba, bb := make([]byte, 64*1024), make([]byte, 64*1024)
+	for {
+		na, erra := io.ReadFull(fa, ba)
+		nb, errb := io.ReadFull(fb, bb)
+		if na != nb || !bytes.Equal(ba[:na], bb[:nb]) {
+			return false
+		}
+		if erra != nil || errb != nil {
+			return atEnd(erra) && atEnd(errb)
+		}
+	}
+}
+
+// Function atEnd tells whether an error of io.ReadFull means the end of the
+// file.
+func atEnd(err error) bool {
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// Function Remove removes a tree, after making its directories writable.
+func Remove(root string) {
+	filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err == nil && fi.IsDir() {
+			os.Chmod(path, 0700)
+		}
+		return nil
+	})
+	os.RemoveAll(root)
+}
@@ -0,0 +1,316 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package workpool implements a pool of workers handling the items of a work
+// list of arbitrary size, like the copy threads of psync handle directories.
+// The workers may submit further items while they handle one, e.g. the
+// subdirectories of a directory, without ever blocking, since the work list
+// grows as needed. A dispatcher goroutine hands the items of the work list
+// to the idle workers.
+//
+// With Options, a long work list can be moved out of memory in parts, the
+// pool can be stopped with the remaining items passed to a function, e.g. for
+// a checkpoint, and idle workers can take side work, like parts of a huge
+// directory offered by a busy worker.
+package workpool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Type Pool is a pool of workers with its work list.
+type Pool struct {
+	queued  int64  // items waiting in the work list, in memory (atomic)
+	spilled int64  // items waiting in the storage of Spill (atomic)
+	peak    int64  // highest number of items waiting (atomic)
+	handed  uint64 // items handed out to the workers (atomic)
+
+	handle  func(id int, item interface{})
+	o       Options
+	in      chan interface{} // dispatcher channel - get items into the work list
+	out     chan interface{} // worker channel - get items from the work list to a worker
+	items   sync.WaitGroup   // items submitted, but not handled yet
+	workers sync.WaitGroup   // running workers
+
+	mu      sync.Mutex // guards size, running and next
+	size    int        // requested number of workers
+	running int        // number of running workers
+	next    int        // id of the next worker started
+}
+
+// Type Options holds the settings of a pool.
+type Options struct {
+	Workers int  // number of workers, at least 1
+	FIFO    bool // hand out the oldest item first, instead of the newest
+
+	SpillAt int   // length of the work list from which Spill takes a part of it, 0 for never
+	Spill   Spill // storage of the items moved out of memory
+
+	Stop <-chan struct{}        // closing it stops handing out items
+	Drop func(item interface{}) // called for the items not handed out after Stop was closed
+
+	Side   <-chan interface{}             // side work, taken by idle workers
+	Helper func(id int, item interface{}) // handles the side work
+
+	Queue func(n int64) // called with the length of the work list, including the spilled items, when it changed
+}
+
+// Type Spill stores a part of a long work list outside of memory, e.g. in a
+// temporary file. It is used by the dispatcher only.
+type Spill interface {
+	Store(list []interface{}) []interface{}       // moves a part of the list to the storage, and returns the rest
+	Load(list []interface{}, n int) []interface{} // appends up to n stored items to the list, and removes them from the storage
+	Len() int                                     // number of items in the storage
+	Close()                                       // releases the storage
+}
+
+// Type Stats holds the statistics of a pool.
+type Stats struct {
+	Workers int    // number of running workers
+	Queued  int64  // number of items waiting in the work list, in memory
+	Spilled int64  // number of items waiting in the storage of Spill
+	Peak    int64  // highest number of items waiting in the work list, including the spilled ones
+	Handed  uint64 // number of items handed out to the workers
+}
+
+// Function New starts a pool of n workers, which call handle for each item
+// submitted, with the id of the worker. The ids start at 0, and are not
+// reused when the pool is resized. By default, the work list is treated
+// last-in-first-out, so that a tree is traversed depth-first, which keeps the
+// work list short. With fifo, it is treated first-in-first-out, so that a
+// tree is traversed breadth-first.
+func New(n int, fifo bool, handle func(id int, item interface{})) *Pool {
+	return NewWith(Options{Workers: n, FIFO: fifo}, handle)
+}
+
+// Function NewWith starts a pool like New, with the given options.
+//
+// With SpillAt and Spill, the dispatcher moves a part of the work list to the
+// storage of Spill when it grows longer than SpillAt items, and loads them
+// back when the work list in memory runs empty. Items which Spill cannot load
+// any more count as handled.
+//
+// When Stop is closed, the workers get no further items. The items of the
+// work list, the spilled ones, and the items submitted afterwards are passed
+// to Drop, one at a time, and count as handled; Wait still has to be called.
+//
+// Idle workers take items from Side as well, and handle them with Helper.
+// Side work is not part of the work list, Wait does not wait for it.
+func NewWith(o Options, handle func(id int, item interface{})) *Pool {
+	p := &Pool{
+		handle: handle,
+		o:      o,
+		in:     make(chan interface{}),
+		out:    make(chan interface{}),
+	}
+	go p.dispatch()
+	p.Resize(o.Workers)
+	return p
+}
+
+// Method Submit inserts an item into the work list. It may be called by the
+// workers, and returns without waiting for a worker to take the item.
+func (p *Pool) Submit(item interface{}) {
+	p.items.Add(1)
+	p.in <- item
+}
+
+// Method Wait waits until all items submitted are handled, including the
+// items submitted by the workers meanwhile, and ends the workers. The pool
+// cannot be used afterwards.
+func (p *Pool) Wait() {
+	p.items.Wait()
+	close(p.in)
+	p.workers.Wait()
+}
+
+// Method Resize changes the number of workers, which is at least 1. New
+// workers are started at once. Surplus workers end after the item they are
+// handling, or after the next one if they are idle.
+func (p *Pool) Resize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = n
+	for p.running < p.size {
+		p.running++
+		p.workers.Add(1)
+		go p.work(p.next)
+		p.next++
+	}
+}
+
+// Method Stats returns the statistics of the pool.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	running := p.running
+	p.mu.Unlock()
+	return Stats{
+		Workers: running,
+		Queued:  atomic.LoadInt64(&p.queued),
+		Spilled: atomic.LoadInt64(&p.spilled),
+		Peak:    atomic.LoadInt64(&p.peak),
+		Handed:  atomic.LoadUint64(&p.handed),
+	}
+}
+
+// Method Queued returns the number of items waiting in the work list, in
+// memory. Unlike Stats, it does not lock the pool.
+func (p *Pool) Queued() int64 {
+	return atomic.LoadInt64(&p.queued)
+}
+
+// Method dispatch maintains the work list. Incoming items are stored in the
+// work list, and handed out to the workers as they get idle. A long work list
+// is spilled, and loaded back when the work list runs empty. After Stop was
+// closed, all items are dropped. The dispatcher ends when the dispatcher
+// channel is closed, and closes the worker channel.
+func (p *Pool) dispatch() {
+	defer close(p.out)
+	var list []interface{}
+	spill := p.o.Spill
+	if p.o.SpillAt <= 0 {
+		spill = nil
+	}
+	if spill != nil {
+		defer spill.Close()
+	}
+	last := int64(-1)
+	for {
+		stored := 0
+		if spill != nil {
+			if len(list) > p.o.SpillAt {
+				list = spill.Store(list)
+			} else if len(list) == 0 && spill.Len() > 0 {
+				list = p.load(spill, list, p.o.SpillAt/2+1)
+			}
+			stored = spill.Len()
+		}
+		atomic.StoreInt64(&p.queued, int64(len(list)))
+		atomic.StoreInt64(&p.spilled, int64(stored))
+		n := int64(len(list) + stored)
+		if n > atomic.LoadInt64(&p.peak) {
+			atomic.StoreInt64(&p.peak, n)
+		}
+		if n != last && p.o.Queue != nil {
+			p.o.Queue(n)
+		}
+		last = n
+
+		if p.stopped() {
+			if spill != nil {
+				list = p.load(spill, list, stored)
+			}
+			for _, item := range list {
+				p.drop(item)
+			}
+			list = nil
+			atomic.StoreInt64(&p.queued, 0)
+			atomic.StoreInt64(&p.spilled, 0)
+			if p.o.Queue != nil {
+				p.o.Queue(0)
+			}
+			for item := range p.in {
+				p.drop(item)
+			}
+			return
+		}
+
+		if len(list) == 0 {
+			select {
+			case item, ok := <-p.in:
+				if !ok {
+					return
+				}
+				list = append(list, item)
+			case <-p.o.Stop:
+			}
+			continue
+		}
+		next := len(list) - 1
+		if p.o.FIFO {
+			next = 0
+		}
+		select {
+		case item := <-p.in:
+			list = append(list, item)
+		case p.out <- list[next]:
+			list[next] = nil
+			if p.o.FIFO {
+				list = list[1:]
+			} else {
+				list = list[:next]
+			}
+			atomic.AddUint64(&p.handed, 1)
+		case <-p.o.Stop:
+		}
+	}
+}
+
+// Method load appends up to n spilled items to the work list. The items
+// Spill could not load count as handled.
+func (p *Pool) load(spill Spill, list []interface{}, n int) []interface{} {
+	stored, have := spill.Len(), len(list)
+	list = spill.Load(list, n)
+	if lost := stored - spill.Len() - (len(list) - have); lost > 0 {
+		p.items.Add(-lost)
+	}
+	return list
+}
+
+// Method stopped checks whether the pool was stopped.
+func (p *Pool) stopped() bool {
+	select {
+	case <-p.o.Stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Method drop passes an item, which is not handed out after the pool was
+// stopped, to Drop.
+func (p *Pool) drop(item interface{}) {
+	if p.o.Drop != nil {
+		p.o.Drop(item)
+	}
+	p.items.Done()
+}
+
+// Method work receives items on the worker channel and handles them, or
+// takes side work while idle. The worker ends when the worker channel is
+// closed, or when the pool was shrunk.
+func (p *Pool) work(id int) {
+	defer p.workers.Done()
+	for {
+		select {
+		case item := <-p.o.Side:
+			p.o.Helper(id, item)
+		case item, ok := <-p.out:
+			if !ok {
+				return
+			}
+			p.handle(id, item)
+			p.items.Done()
+			if p.retire() {
+				return
+			}
+		}
+	}
+}
+
+// Method retire checks whether the pool has more workers than requested, and
+// counts the calling worker as ended if so.
+func (p *Pool) retire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running > p.size {
+		p.running--
+		return true
+	}
+	return false
+}
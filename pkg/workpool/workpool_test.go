@@ -0,0 +1,325 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package workpool
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, and fails the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestSubmitWait walks a tree of items, whose handlers submit the children,
+// and checks that Wait returns after all of them were handled.
+func TestSubmitWait(t *testing.T) {
+	const depth, fanout = 5, 4
+	for _, fifo := range []bool{false, true} {
+		var handled int64
+		var p *Pool
+		p = New(8, fifo, func(id int, item interface{}) {
+			atomic.AddInt64(&handled, 1)
+			if level := item.(int); level < depth {
+				for i := 0; i < fanout; i++ {
+					p.Submit(level + 1)
+				}
+			}
+		})
+		p.Submit(0)
+		p.Wait()
+
+		// 1 + 4 + 16 + 64 + 256 + 1024 items
+		want := int64(1365)
+		if handled != want {
+			t.Errorf("fifo %v: handled %d items, want %d", fifo, handled, want)
+		}
+		s := p.Stats()
+		if s.Handed != uint64(want) || s.Queued != 0 {
+			t.Errorf("fifo %v: stats %+v, want %d handed and none queued", fifo, s, want)
+		}
+	}
+}
+
+// TestOrder checks the order in which a single worker gets the items
+// submitted while it was busy.
+func TestOrder(t *testing.T) {
+	for _, c := range []struct {
+		fifo bool
+		want []int
+	}{
+		{false, []int{0, 3, 2, 1}},
+		{true, []int{0, 1, 2, 3}},
+	} {
+		var order []int
+		var p *Pool
+		p = New(1, c.fifo, func(id int, item interface{}) {
+			order = append(order, item.(int))
+			if item.(int) == 0 {
+				p.Submit(1)
+				p.Submit(2)
+				p.Submit(3)
+			}
+		})
+		p.Submit(0)
+		p.Wait()
+		if !reflect.DeepEqual(order, c.want) {
+			t.Errorf("fifo %v: order %v, want %v", c.fifo, order, c.want)
+		}
+	}
+}
+
+// TestResizeUp checks that new workers take items at once.
+func TestResizeUp(t *testing.T) {
+	const n = 4
+	var running int64
+	all := make(chan struct{})
+	p := New(1, false, func(id int, item interface{}) {
+		if atomic.AddInt64(&running, 1) == n {
+			close(all)
+		}
+		<-all
+	})
+	p.Resize(n)
+	if w := p.Stats().Workers; w != n {
+		t.Errorf("%d workers after resizing to %d", w, n)
+	}
+	for i := 0; i < n; i++ {
+		p.Submit(i)
+	}
+	select {
+	case <-all:
+	case <-time.After(time.Second):
+		t.Fatalf("only %d of %d items handled in parallel", atomic.LoadInt64(&running), n)
+	}
+	p.Wait()
+}
+
+// TestResizeDown shrinks the pool while all workers are busy. The surplus
+// workers end after their current item, and the remaining items are handled
+// by the one worker left.
+func TestResizeDown(t *testing.T) {
+	const n = 4
+	var running, peak int64
+	var mu sync.Mutex
+	started := make(chan struct{}, n)
+	release := make(chan struct{})
+	p := New(n, false, func(id int, item interface{}) {
+		r := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		if item.(int) < n {
+			started <- struct{}{}
+			<-release
+			return
+		}
+		mu.Lock()
+		if r > peak {
+			peak = r
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+	})
+	for i := 0; i < n; i++ {
+		p.Submit(i)
+	}
+	for i := 0; i < n; i++ {
+		<-started
+	}
+
+	p.Resize(0)
+	if w := p.Stats().Workers; w != n {
+		t.Errorf("%d workers while busy, want %d until they end", w, n)
+	}
+	close(release)
+	waitFor(t, "the surplus workers to end", func() bool { return p.Stats().Workers == 1 })
+
+	for i := n; i < 3*n; i++ {
+		p.Submit(i)
+	}
+	p.Wait()
+	if peak != 1 {
+		t.Errorf("%d items handled in parallel after shrinking to 1 worker", peak)
+	}
+}
+
+// TestStats checks the counters of the work list.
+func TestStats(t *testing.T) {
+	const n = 5
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p := New(1, false, func(id int, item interface{}) {
+		if item.(int) == 0 {
+			close(started)
+			<-release
+		}
+	})
+	p.Submit(0)
+	<-started
+	for i := 1; i <= n; i++ {
+		p.Submit(i)
+	}
+	waitFor(t, "the items to be queued", func() bool { return p.Stats().Queued == n })
+	s := p.Stats()
+	if s.Workers != 1 || s.Peak != n || s.Handed != 1 {
+		t.Errorf("stats while busy %+v, want 1 worker, peak %d and 1 handed", s, n)
+	}
+
+	close(release)
+	p.Wait()
+	s = p.Stats()
+	if s.Queued != 0 || s.Peak != n || s.Handed != n+1 {
+		t.Errorf("stats at the end %+v, want none queued, peak %d and %d handed", s, n, n+1)
+	}
+}
+
+// Type memSpill is a Spill in memory, which moves the first half of the work
+// list, and may lose the items it holds.
+type memSpill struct {
+	items  []interface{}
+	stores int
+	lose   bool
+}
+
+// Method Store moves the first half of the list.
+func (s *memSpill) Store(list []interface{}) []interface{} {
+	half := len(list) / 2
+	s.items = append(s.items, list[:half]...)
+	s.stores++
+	return append([]interface{}(nil), list[half:]...)
+}
+
+// Method Load appends up to n items, or loses all of them.
+func (s *memSpill) Load(list []interface{}, n int) []interface{} {
+	if s.lose {
+		s.items = nil
+		return list
+	}
+	if n > len(s.items) {
+		n = len(s.items)
+	}
+	list = append(list, s.items[:n]...)
+	s.items = s.items[n:]
+	return list
+}
+
+// Method Len returns the number of items held.
+func (s *memSpill) Len() int {
+	return len(s.items)
+}
+
+// Method Close does nothing.
+func (s *memSpill) Close() {}
+
+// TestSpill queues many items while the single worker is busy, so that the
+// work list is spilled, and checks that all items are handled, or counted as
+// handled when the spill loses them.
+func TestSpill(t *testing.T) {
+	const n = 100
+	for _, lose := range []bool{false, true} {
+		sp := &memSpill{lose: lose}
+		var handled, queued int64
+		started := make(chan struct{})
+		release := make(chan struct{})
+		p := NewWith(Options{Workers: 1, SpillAt: 10, Spill: sp, Queue: func(n int64) { atomic.StoreInt64(&queued, n) }},
+			func(id int, item interface{}) {
+				atomic.AddInt64(&handled, 1)
+				if item.(int) == 0 {
+					close(started)
+					<-release
+				}
+			})
+		p.Submit(0)
+		<-started
+		for i := 1; i <= n; i++ {
+			p.Submit(i)
+		}
+		waitFor(t, "the items to be queued", func() bool { return atomic.LoadInt64(&queued) == n })
+		if s := p.Stats(); s.Queued > 10 || s.Spilled == 0 || s.Queued+s.Spilled != n {
+			t.Errorf("lose %v: stats %+v, want at most 10 items in memory, the rest of %d spilled", lose, s, n)
+		}
+		close(release)
+		p.Wait()
+
+		if lose && handled >= n+1 {
+			t.Errorf("lose %v: %d items handled, want less than %d", lose, handled, n+1)
+		} else if !lose && handled != n+1 {
+			t.Errorf("lose %v: %d items handled, want %d", lose, handled, n+1)
+		}
+		if sp.stores == 0 {
+			t.Errorf("lose %v: the work list was never spilled", lose)
+		}
+	}
+}
+
+// TestStop stops the pool while items are queued. The queued items, and the
+// items submitted afterwards, are dropped instead of handled.
+func TestStop(t *testing.T) {
+	const n = 10
+	stop := make(chan struct{})
+	started := make(chan struct{})
+	var handled, dropped []int
+	var mu sync.Mutex
+	var p *Pool
+	p = NewWith(Options{Workers: 1, Stop: stop, Drop: func(item interface{}) {
+		mu.Lock()
+		dropped = append(dropped, item.(int))
+		mu.Unlock()
+	}}, func(id int, item interface{}) {
+		mu.Lock()
+		handled = append(handled, item.(int))
+		mu.Unlock()
+		if item.(int) == 0 {
+			close(started)
+			<-stop
+			p.Submit(n + 1)
+		}
+	})
+	p.Submit(0)
+	<-started
+	for i := 1; i <= n; i++ {
+		p.Submit(i)
+	}
+	close(stop)
+	p.Wait()
+
+	if !reflect.DeepEqual(handled, []int{0}) {
+		t.Errorf("handled %v, want only the item in progress", handled)
+	}
+	if len(dropped) != n+1 {
+		t.Errorf("dropped %v, want the %d items queued and the one submitted afterwards", dropped, n)
+	}
+}
+
+// TestSide offers side work while all workers are idle, and checks that it
+// is handled by the helper.
+func TestSide(t *testing.T) {
+	side := make(chan interface{})
+	done := make(chan int, 1)
+	p := NewWith(Options{Workers: 2, Side: side, Helper: func(id int, item interface{}) {
+		done <- item.(int)
+	}}, func(id int, item interface{}) {})
+	side <- 42
+	select {
+	case v := <-done:
+		if v != 42 {
+			t.Errorf("helper got %d, want 42", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("side work was not taken")
+	}
+	p.Submit(0)
+	p.Wait()
+}
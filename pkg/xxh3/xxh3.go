@@ -0,0 +1,326 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+// Package xxh3 implements the 64 bit variant of the XXH3 hash function of the
+// xxHash family, with the default secret and seed 0. It is not a cryptographic
+// hash function, but much faster than those, and detects accidental changes
+// of data as well. The sums are written in the canonical big endian form, as
+// printed by xxhsum -H3.
+//
+// The implementation is portable Go, without assembly or unsafe code.
+package xxh3
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Size is the size of an XXH3 checksum in bytes.
+const Size = 8
+
+const (
+	stripeLen     = 64  // bytes consumed per accumulation
+	consumeRate   = 8   // bytes the secret advances per stripe
+	midSizeMax    = 240 // longest input hashed without accumulators
+	secretSize    = 192 // size of the default secret
+	bufferSize    = 256 // size of the buffer of the streaming hash
+	stripesBlock  = (secretSize - stripeLen) / consumeRate
+	mergeStart    = 11 // offset of the secret for merging the accumulators
+	lastAccStart  = 7  // offset of the secret for the last stripe, from its end
+	secretSizeMin = 136
+
+	prime32_1 = 0x9E3779B1
+	prime32_2 = 0x85EBCA77
+	prime32_3 = 0xC2B2AE3D
+	prime64_1 = 0x9E3779B185EBCA87
+	prime64_2 = 0xC2B2AE3D27D4EB4F
+	prime64_3 = 0x165667B19E3779F9
+	prime64_4 = 0x85EBCA77C2B2AE63
+	prime64_5 = 0x27D4EB2F165667C5
+)
+
+// secret is the default secret of XXH3.
+var secret = [secretSize]byte{
+	0xb8, 0xfe, 0x6c, 0x39, 0x23, 0xa4, 0x4b, 0xbe, 0x7c, 0x01, 0x81, 0x2c, 0xf7, 0x21, 0xad, 0x1c,
+	0xde, 0xd4, 0x6d, 0xe9, 0x83, 0x90, 0x97, 0xdb, 0x72, 0x40, 0xa4, 0xa4, 0xb7, 0xb3, 0x67, 0x1f,
+	0xcb, 0x79, 0xe6, 0x4e, 0xcc, 0xc0, 0xe5, 0x78, 0x82, 0x5a, 0xd0, 0x7d, 0xcc, 0xff, 0x72, 0x21,
+	0xb8, 0x08, 0x46, 0x74, 0xf7, 0x43, 0x24, 0x8e, 0xe0, 0x35, 0x90, 0xe6, 0x81, 0x3a, 0x26, 0x4c,
+	0x3c, 0x28, 0x52, 0xbb, 0x91, 0xc3, 0x00, 0xcb, 0x88, 0xd0, 0x65, 0x8b, 0x1b, 0x53, 0x2e, 0xa3,
+	0x71, 0x64, 0x48, 0x97, 0xa2, 0x0d, 0xf9, 0x4e, 0x38, 0x19, 0xef, 0x46, 0xa9, 0xde, 0xac, 0xd8,
+	0xa8, 0xfa, 0x76, 0x3f, 0xe3, 0x9c, 0x34, 0x3f, 0xf9, 0xdc, 0xbb, 0xc7, 0xc7, 0x0b, 0x4f, 0x1d,
+	0x8a, 0x51, 0xe0, 0x4b, 0xcd, 0xb4, 0x59, 0x31, 0xc8, 0x9f, 0x7e, 0xc9, 0xd9, 0x78, 0x73, 0x64,
+	0xea, 0xc5, 0xac, 0x83, 0x34, 0xd3, 0xeb, 0xc3, 0xc5, 0x81, 0xa0, 0xff, 0xfa, 0x13, 0x63, 0xeb,
+	0x17, 0x0d, 0xdd, 0x51, 0xb7, 0xf0, 0xda, 0x49, 0xd3, 0x16, 0x55, 0x26, 0x29, 0xd4, 0x68, 0x9e,
+	0x2b, 0x16, 0xbe, 0x58, 0x7d, 0x47, 0xa1, 0xfc, 0x8f, 0xf8, 0xb8, 0xd1, 0x7a, 0xd0, 0x31, 0xce,
+	0x45, 0xcb, 0x3a, 0x8f, 0x95, 0x16, 0x04, 0x28, 0xaf, 0xd7, 0xfb, 0xca, 0xbb, 0x4b, 0x40, 0x7e,
+}
+
+// initAcc is the initial state of the accumulators.
+var initAcc = [8]uint64{prime32_3, prime64_1, prime64_2, prime64_3, prime64_4, prime32_2, prime64_5, prime32_1}
+
+// Type digest is the state of a streaming XXH3 hash. Inputs up to 240 bytes
+// are hashed by dedicated functions, so the first bytes are kept in the buffer
+// until the input is known to be longer.
+type digest struct {
+	acc     [8]uint64        // accumulators
+	buf     [bufferSize]byte // input not consumed yet
+	n       int              // number of bytes in buf
+	stripes int              // stripes consumed in the current block
+	total   uint64           // length of the input
+}
+
+// Function New returns a new hash.Hash64 computing the XXH3 checksum.
+func New() hash.Hash64 {
+	d := new(digest)
+	d.Reset()
+	return d
+}
+
+// Function Sum64 returns the XXH3 checksum of the data.
+func Sum64(b []byte) uint64 {
+	return hash64(b)
+}
+
+// Method Reset resets the hash to its initial state.
+func (d *digest) Reset() {
+	d.acc = initAcc
+	d.n, d.stripes, d.total = 0, 0, 0
+}
+
+// Method Size returns the number of bytes Sum will append.
+func (d *digest) Size() int {
+	return Size
+}
+
+// Method BlockSize returns the size of a stripe, which the hash consumes at
+// once.
+func (d *digest) BlockSize() int {
+	return stripeLen
+}
+
+// Method Write adds data to the hash. It never returns an error.
+func (d *digest) Write(p []byte) (int, error) {
+	size := len(p)
+	d.total += uint64(size)
+	if d.n+len(p) <= bufferSize {
+		d.n += copy(d.buf[d.n:], p)
+		return size, nil
+	}
+	if d.n > 0 {
+		fill := copy(d.buf[d.n:], p)
+		p = p[fill:]
+		d.consume(d.buf[:], bufferSize/stripeLen)
+		d.n = 0
+	}
+	if len(p) > bufferSize {
+		i := 0
+		for len(p)-i > bufferSize {
+			d.consume(p[i:i+bufferSize], bufferSize/stripeLen)
+			i += bufferSize
+		}
+		// keep the last stripe consumed, for the final stripe of a short
+		// remainder
+		copy(d.buf[bufferSize-stripeLen:], p[i-stripeLen:i])
+		p = p[i:]
+	}
+	d.n = copy(d.buf[:], p)
+	return size, nil
+}
+
+// Method consume accumulates stripes of the input, and scrambles the
+// accumulators at the end of each block.
+func (d *digest) consume(p []byte, stripes int) {
+	if stripesBlock-d.stripes <= stripes {
+		toEnd := stripesBlock - d.stripes
+		accumulate(&d.acc, p, secret[d.stripes*consumeRate:], toEnd)
+		scramble(&d.acc, secret[secretSize-stripeLen:])
+		accumulate(&d.acc, p[toEnd*stripeLen:], secret[:], stripes-toEnd)
+		d.stripes = stripes - toEnd
+	} else {
+		accumulate(&d.acc, p, secret[d.stripes*consumeRate:], stripes)
+		d.stripes += stripes
+	}
+}
+
+// Method Sum64 returns the checksum of the data written so far.
+func (d *digest) Sum64() uint64 {
+	if d.total <= midSizeMax {
+		return hash64(d.buf[:d.n])
+	}
+	acc := *d
+	if d.n >= stripeLen {
+		acc.consume(d.buf[:d.n], (d.n-1)/stripeLen)
+		accumulate512(&acc.acc, d.buf[d.n-stripeLen:d.n], secret[secretSize-stripeLen-lastAccStart:])
+	} else {
+		var last [stripeLen]byte
+		catchup := stripeLen - d.n
+		copy(last[:], d.buf[bufferSize-catchup:])
+		copy(last[catchup:], d.buf[:d.n])
+		accumulate512(&acc.acc, last[:], secret[secretSize-stripeLen-lastAccStart:])
+	}
+	return mergeAccs(&acc.acc, secret[mergeStart:], d.total*prime64_1)
+}
+
+// Method Sum appends the checksum in big endian byte order to b.
+func (d *digest) Sum(b []byte) []byte {
+	var s [Size]byte
+	binary.BigEndian.PutUint64(s[:], d.Sum64())
+	return append(b, s[:]...)
+}
+
+// Function hash64 computes the checksum of a whole input.
+func hash64(b []byte) uint64 {
+	switch n := len(b); {
+	case n == 0:
+		return avalanche64(u64(secret[56:]) ^ u64(secret[64:]))
+	case n <= 3:
+		combo := uint32(b[0])<<16 | uint32(b[n>>1])<<24 | uint32(b[n-1]) | uint32(n)<<8
+		flip := uint64(u32(secret[0:]) ^ u32(secret[4:]))
+		return avalanche64(uint64(combo) ^ flip)
+	case n <= 8:
+		flip := u64(secret[8:]) ^ u64(secret[16:])
+		v := uint64(u32(b[n-4:])) + uint64(u32(b))<<32
+		return rrmxmx(v^flip, uint64(n))
+	case n <= 16:
+		lo := u64(b) ^ (u64(secret[24:]) ^ u64(secret[32:]))
+		hi := u64(b[n-8:]) ^ (u64(secret[40:]) ^ u64(secret[48:]))
+		return avalanche(uint64(n) + bits.ReverseBytes64(lo) + hi + mulFold64(lo, hi))
+	case n <= 128:
+		acc := uint64(n) * prime64_1
+		if n > 32 {
+			if n > 64 {
+				if n > 96 {
+					acc += mix16(b[48:], secret[96:])
+					acc += mix16(b[n-64:], secret[112:])
+				}
+				acc += mix16(b[32:], secret[64:])
+				acc += mix16(b[n-48:], secret[80:])
+			}
+			acc += mix16(b[16:], secret[32:])
+			acc += mix16(b[n-32:], secret[48:])
+		}
+		acc += mix16(b, secret[0:])
+		acc += mix16(b[n-16:], secret[16:])
+		return avalanche(acc)
+	case n <= midSizeMax:
+		acc := uint64(n) * prime64_1
+		for i := 0; i < 8; i++ {
+			acc += mix16(b[16*i:], secret[16*i:])
+		}
+		acc = avalanche(acc)
+		for i := 8; i < n/16; i++ {
+			acc += mix16(b[16*i:], secret[16*(i-8)+3:])
+		}
+		acc += mix16(b[n-16:], secret[secretSizeMin-17:])
+		return avalanche(acc)
+	}
+	return hashLong(b)
+}
+
+// Function hashLong computes the checksum of an input longer than 240 bytes
+// with the accumulators.
+func hashLong(b []byte) uint64 {
+	acc := initAcc
+	stripes := (secretSize - stripeLen) / consumeRate
+	block := stripeLen * stripes
+	blocks := (len(b) - 1) / block
+	for i := 0; i < blocks; i++ {
+		accumulate(&acc, b[i*block:], secret[:], stripes)
+		scramble(&acc, secret[secretSize-stripeLen:])
+	}
+	stripes = (len(b) - 1 - block*blocks) / stripeLen
+	accumulate(&acc, b[blocks*block:], secret[:], stripes)
+	accumulate512(&acc, b[len(b)-stripeLen:], secret[secretSize-stripeLen-lastAccStart:])
+	return mergeAccs(&acc, secret[mergeStart:], uint64(len(b))*prime64_1)
+}
+
+// Function accumulate accumulates consecutive stripes of the input.
+func accumulate(acc *[8]uint64, b, sec []byte, stripes int) {
+	for i := 0; i < stripes; i++ {
+		accumulate512(acc, b[i*stripeLen:], sec[i*consumeRate:])
+	}
+}
+
+// Function accumulate512 accumulates one stripe of the input.
+func accumulate512(acc *[8]uint64, b, sec []byte) {
+	for i := 0; i < 8; i++ {
+		v := u64(b[8*i:])
+		k := v ^ u64(sec[8*i:])
+		acc[i^1] += v
+		acc[i] += (k & 0xFFFFFFFF) * (k >> 32)
+	}
+}
+
+// Function scramble mixes the accumulators at the end of a block.
+func scramble(acc *[8]uint64, sec []byte) {
+	for i := 0; i < 8; i++ {
+		a := acc[i]
+		a ^= a >> 47
+		a ^= u64(sec[8*i:])
+		acc[i] = a * prime32_1
+	}
+}
+
+// Function mergeAccs merges the accumulators into the checksum.
+func mergeAccs(acc *[8]uint64, sec []byte, start uint64) uint64 {
+	r := start
+	for i := 0; i < 4; i++ {
+		r += mulFold64(acc[2*i]^u64(sec[16*i:]), acc[2*i+1]^u64(sec[16*i+8:]))
+	}
+	return avalanche(r)
+}
+
+// Function mix16 mixes 16 bytes of the input with 16 bytes of the secret.
+func mix16(b, sec []byte) uint64 {
+	return mulFold64(u64(b)^u64(sec), u64(b[8:])^u64(sec[8:]))
+}
+
+// Function mulFold64 multiplies two 64 bit values to 128 bits, and folds the
+// product into 64 bits.
+func mulFold64(a, b uint64) uint64 {
+	// bits.Mul64 is not available before Go 1.12
+	a0, a1 := a&0xFFFFFFFF, a>>32
+	b0, b1 := b&0xFFFFFFFF, b>>32
+	w0 := a0 * b0
+	t := a1*b0 + w0>>32
+	w1, w2 := t&0xFFFFFFFF, t>>32
+	w1 += a0 * b1
+	return (a1*b1 + w2 + w1>>32) ^ a*b
+}
+
+// Function avalanche is the final mix of XXH3.
+func avalanche(h uint64) uint64 {
+	h ^= h >> 37
+	h *= 0x165667919E3779F9
+	return h ^ h>>32
+}
+
+// Function avalanche64 is the final mix of XXH64, used for short inputs.
+func avalanche64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= prime64_2
+	h ^= h >> 29
+	h *= prime64_3
+	return h ^ h>>32
+}
+
+// Function rrmxmx is the strong final mix for inputs of 4 to 8 bytes.
+func rrmxmx(h, n uint64) uint64 {
+	h ^= bits.RotateLeft64(h, 49) ^ bits.RotateLeft64(h, 24)
+	h *= 0x9FB21C651E98DF25
+	h ^= (h >> 35) + n
+	h *= 0x9FB21C651E98DF25
+	return h ^ h>>28
+}
+
+// Function u32 reads a little endian 32 bit value.
+func u32(b []byte) uint32 {
+	return binary.LittleEndian.Uint32(b)
+}
+
+// Function u64 reads a little endian 64 bit value.
+func u64(b []byte) uint64 {
+	return binary.LittleEndian.Uint64(b)
+}
@@ -0,0 +1,78 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package xxh3
+
+import (
+	"testing"
+)
+
+// input returns the bytes 0 to 250, repeated.
+func input(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+// vectors are checksums computed with the reference implementation, one for
+// each input length class.
+var vectors = []struct {
+	n   int
+	sum uint64
+}{
+	{0, 0x2d06800538d394c2},
+	{1, 0xc44bdff4074eecdb},
+	{3, 0x5f4299fc161c9cbb},
+	{4, 0x60dab036a58211f2},
+	{8, 0x3a1c2d7c85af88f8},
+	{9, 0xe9612598145bb9dc},
+	{16, 0x8355e3a6f61770db},
+	{17, 0x9ef341a99de37328},
+	{128, 0x85c6174c7ff4c46b},
+	{129, 0xec7642b431ba3e5a},
+	{240, 0x375a384d957fe865},
+	{241, 0x02e8cd95421c6d02},
+	{1024, 0xe5d78bafa45b2aa5},
+	{1025, 0xe95c42288f28186e},
+	{2048, 0x25339063db861586},
+	{100000, 0x42c23aeead96750d},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		if got := Sum64(input(v.n)); got != v.sum {
+			t.Errorf("Sum64 of %d bytes = %016x, want %016x", v.n, got, v.sum)
+		}
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	for _, v := range vectors {
+		b := input(v.n)
+		for _, step := range []int{1, 7, 64, 100, 256, 257, 1000} {
+			h := New()
+			for i := 0; i < v.n; i += step {
+				j := i + step
+				if j > v.n {
+					j = v.n
+				}
+				h.Write(b[i:j])
+			}
+			if got := h.Sum64(); got != v.sum {
+				t.Errorf("%d bytes in writes of %d bytes: got %016x, want %016x", v.n, step, got, v.sum)
+			}
+		}
+	}
+}
+
+func BenchmarkWrite(b *testing.B) {
+	buf := input(1 << 20)
+	b.SetBytes(int64(len(buf)))
+	h := New()
+	for i := 0; i < b.N; i++ {
+		h.Write(buf)
+	}
+}
@@ -1,335 +1,261 @@
-// Copyright 2018 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
 // Use of this source code is governed by the GNU General Public License
 // Version 3 that can be found in the LICENSE.txt file.
 
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
-	"sync"
+	"os/signal"
+	"strings"
 	"syscall"
 	"time"
-)
-
-// BUFSIZE defines the size of the buffer used for copying. It is currently 64kB.
-const BUFSIZE = 64 * 1024
-
-// Buffer, Channels and Synchronization
-var (
-	buffer [][BUFSIZE]byte
-	dch    = make(chan string, 100) // dispatcher channel - get work into work queue
-	wch    = make(chan string, 100) // worker channel - get work from work queue to copy thread
-	wg     sync.WaitGroup           // waitgroup for work queue length
-)
 
-// Commandline Flags
-var (
-	threads        uint   // number of threads
-	src, dest      string // source and destination directory
-	verbose, quiet bool   // verbose and quiet flags
-	times, owner   bool   // preserve timestamps and owner flag
-	create         bool   // create destination directory flag
+	"github.com/hweidner/psync/pkg/psync"
 )
 
 func main() {
-	// parse commandline flags
-	flags()
-
-	// check or create the destination directory
-	prepareDestDir()
-
-	// clear umask, so that it does not interfere with explicite permissions
-	// used in os.FileOpen()
-	syscall.Umask(0000)
-
-	// initialize buffers
-	buffer = make([][BUFSIZE]byte, threads)
-
-	// Start dispatcher and copy threads
-	go dispatcher()
-	for i := uint(0); i < threads; i++ {
-		go copyDir(i)
+	// run subcommands
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		bench(os.Args[2:])
+		return
 	}
-
-	// start copying top level directory
-	wg.Add(1)
-	dch <- ""
-
-	// wait for work queue to get empty
-	wg.Wait()
-}
-
-// Function flags parses the command line flags and checks them for sanity.
-func flags() {
-	flag.UintVar(&threads, "threads", 16, "Number of threads to run in parallel")
-	flag.BoolVar(&verbose, "verbose", false, "Verbose mode")
-	flag.BoolVar(&quiet, "quiet", false, "Quiet mode")
-	flag.BoolVar(&times, "times", false, "Preserve time stamps")
-	flag.BoolVar(&owner, "owner", false, "Preserve user/group ownership (root only)")
-	flag.BoolVar(&create, "create", false, "Create destination directory, if needed (with standard permissions)")
-	flag.Parse()
-
-	if flag.NArg() != 2 || flag.Arg(0) == "" || flag.Arg(1) == "" || threads > 1024 {
-		usage()
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		selftest(os.Args[2:])
+		return
 	}
-
-	if threads == 0 {
-		threads = 16
+	if len(os.Args) > 1 && (os.Args[1] == "-daemon" || os.Args[1] == "--daemon") {
+		runDaemon(os.Args[2:])
+		return
 	}
-	src = flag.Arg(0)
-	dest = flag.Arg(1)
-}
-
-// Function usage prints a message about how to use psync, and exits.
-func usage() {
-	fmt.Println("Usage: psync [options] source destination")
-	flag.Usage()
-	os.Exit(1)
-}
 
-// Function prepareDestDir checks for the existence of the destination,
-// or creates it if the flag '-create' is set.
-func prepareDestDir() {
-	if create {
-		// create destination directory
-		err := os.MkdirAll(dest, os.FileMode(0777))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR - unable to create destination dir %s: %s\n", dest, err)
-			os.Exit(1)
-		}
-	} else {
-		// test the existence of destination directory prior to syncing
-		stat, err := os.Stat(dest)
-		if os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "ERROR - destination directory %s does not exist: %s.\nUse '-create' to create it.\n", dest, err)
-			os.Exit(1)
-		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR - cannot stat() destination directory %s: %s.\n", dest, err)
-			os.Exit(1)
-		}
-		if !stat.IsDir() {
-			fmt.Fprintf(os.Stderr, "ERROR - destination %s exists, but is not a directory\n", dest)
-			os.Exit(1)
-		}
+	// parse commandline flags
+	opts, stats := flags()
+	s := psync.New(opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go interrupts(cancel)
+	go statusSignal(s)
+
+	// errors on single entries are reported below, and do not change the exit
+	// status
+	err := s.Run(ctx)
+	if re, ok := err.(*psync.RunError); ok {
+		err = re.Err
+	}
+	if err != nil && err != psync.ErrInterrupted {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\n", err)
+		os.Exit(1)
 	}
-}
 
-// Function dispatcher maintains a work list of potentially arbitrary size.
-// Incoming directories (over the dispather channel) will be forwarded to a
-// copy thread through the worker channel, or stored in the work list if no
-// copy thread is available. For easier memory handling, the work list is
-// treated last-in-first-out.
-func dispatcher() {
-	worklist := make([]string, 0, 1000)
-	var dir string
-	for {
-		if len(worklist) == 0 {
-			dir = <-dch
-			worklist = append(worklist, dir)
+	// print statistics, to STDERR if the archive is written to STDOUT
+	if stats {
+		if opts.Destination == "tar:-" {
+			s.Report(os.Stderr)
 		} else {
-			select {
-			case dir = <-dch:
-				worklist = append(worklist, dir)
-			case wch <- worklist[len(worklist)-1]:
-				worklist = worklist[:len(worklist)-1]
-			}
+			s.Report(os.Stdout)
 		}
+	} else if !opts.Quiet {
+		s.ReportErrors(os.Stderr)
+	}
+	if err != nil || s.Stats().Diffs > 0 {
+		os.Exit(1)
 	}
 }
 
-// Function copyDir receives a directory on the worker channel and copies its
-// content from src to dest. Files are copied sequentially. If a subdirectory
-// is discovered, it is created on the destination side, and then inserted into
-// the work queue through the dispatcher channel.
-func copyDir(id uint) {
-	for {
-		// read next directory to handle
-		dir := <-wch
-		if verbose {
-			fmt.Printf("[%d] Handling directory %s%s\n", id, src, dir)
-		}
-
-		// read directory content
-		files, err := ioutil.ReadDir(src + dir)
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - could not read directory %s: %s\n", src+dir, err)
-			}
-			wg.Done()
-			continue
-		}
-
-		for _, f := range files {
-			fname := f.Name()
-			if fname == "." || fname == ".." {
-				continue
-			}
-
-			if f.IsDir() {
-				// create directory on destination side
-				perm := f.Mode().Perm()
-				err := os.Mkdir(dest+dir+"/"+fname, perm)
-				if err != nil {
-					if !quiet {
-						fmt.Fprintf(os.Stderr, "WARNING - could not create directory %s: %s\n",
-							dest+dir+"/"+fname, err)
-					}
-					continue
-				}
+// Function flags parses the command line flags and checks them for sanity.
+// It returns the options of the run, and whether statistics are requested.
+func flags() (psync.Options, bool) {
+	var o psync.Options
+	var stats bool
+	var filterFrom, excludeFrom, linkDest stringList
+	flag.UintVar(&o.Threads, "threads", 16, "Number of threads to run in parallel")
+	flag.UintVar(&o.DataThreads, "data-threads", 0, "Number of separate threads copying the files, 0 to copy them by the directory threads")
+	var v1, v2, v3, vfull, perms bool
+	flag.BoolVar(&v1, "v", false, "Verbose mode, print created and updated entries")
+	flag.BoolVar(&v2, "vv", false, "More verbose mode, print also skipped entries and metadata operations")
+	flag.BoolVar(&v3, "vvv", false, "Most verbose mode, print also per thread scheduling details")
+	flag.BoolVar(&vfull, "verbose", false, "Verbose mode, same as -vvv")
+	flag.BoolVar(&o.Quiet, "quiet", false, "Quiet mode")
+	flag.BoolVar(&o.Times, "times", false, "Preserve time stamps")
+	flag.BoolVar(&o.Owner, "owner", false, "Preserve user/group ownership (root only)")
+	flag.BoolVar(&perms, "perms", true, "Apply the permissions of the source to the destination entries")
+	flag.BoolVar(&o.NoPerms, "no-perms", false, "Keep the permissions of existing entries, and apply the umask to new ones")
+	flag.BoolVar(&o.NumericIDs, "numeric-ids", false, "Keep the owner IDs of remote sources, instead of matching the user and group names")
+	flag.BoolVar(&o.CopyDevices, "copy-devices", false, "Copy the content of block devices to regular files")
+	flag.BoolVar(&o.FakeSuper, "fake-super", false, "Keep the owner, device numbers and special bits in user.rsync.%stat attributes when not root, like rsync")
+	flag.StringVar(&o.UserMap, "usermap", "", "Map the source user IDs or names to destination IDs by the rules of this file")
+	flag.StringVar(&o.GroupMap, "groupmap", "", "Map the source group IDs or names to destination IDs by the rules of this file")
+	flag.StringVar(&o.Chown, "chown", "", "Set the owner of the destination entries, 'user', 'user:group' or ':group' (root only)")
+	flag.StringVar(&o.Chmod, "chmod", "", "Change the permissions of the destination entries by rsync style rules, e.g. 'D2775,F664'")
+	flag.BoolVar(&o.Create, "create", false, "Create destination directory, if needed (with standard permissions)")
+	flag.BoolVar(&o.Force, "force", false, "Copy even if the source directory lies inside the destination")
+	flag.BoolVar(&o.Slash, "rsync-slash", false, "Copy source directories without a trailing slash into the destination, like rsync")
+	flag.BoolVar(&stats, "stats", false, "Print statistics, grouped by top level directory and per thread, at the end")
+	flag.StringVar(&o.StatsOut, "stats-out", "", "Write a JSON summary of the run to the given file")
+	flag.StringVar(&o.AuditLog, "audit-log", "", "Append a record of each change of the destination, with the metadata before and after it, to the given file")
+	flag.StringVar(&o.NotifyURL, "notify-url", "", "Post the summary of the run as JSON to this URL, when it ends or aborts")
+	flag.StringVar(&o.NotifyCmd, "notify-cmd", "", "Run this command with the summary of the run as JSON on STDIN, when it ends or aborts")
+	flag.StringVar(&o.PreCmd, "pre-cmd", "", "Run this command before the copy, and fail the run if it fails")
+	flag.StringVar(&o.PostCmd, "post-cmd", "", "Run this command after the copy, also if the copy failed")
+	flag.StringVar(&o.FileCmd, "file-cmd", "", "Run this command for each copied, linked, created or deleted entry")
+	flag.StringVar(&o.Events, "events", "", "Write an NDJSON event per completed or failed entry to the given file ('-' for STDOUT)")
+	flag.UintVar(&o.EventsFd, "events-fd", 0, "Write the NDJSON event stream to the given open file descriptor (e.g. 3)")
+	flag.BoolVar(&o.Progress, "progress", false, "Print progress and throughput periodically")
+	flag.UintVar(&o.ChunkJournal, "chunk-journal", 0, "Journal the hashes of chunks of this number of MB for resuming large files (0 to disable)")
+	flag.StringVar(&o.PartialDir, "partial-dir", "", "Write files to this directory in the destination while they are copied, e.g. .psync-partial")
+	flag.StringVar(&o.TempDir, "temp-dir", "", "Write the temporary files of atomic replacements to this directory in the destination")
+	flag.UintVar(&o.FileProgress, "file-progress", 1024, "Report the progress of files larger than this number of MB (0 to disable)")
+	flag.DurationVar(&o.FileTimeout, "file-timeout", 0, "Abandon the copy of a file which made no progress for this duration (0 to disable)")
+	flag.DurationVar(&o.Stall, "stall", time.Minute, "Warn when no progress was made for this duration (0 to disable)")
+	flag.StringVar(&o.ShardTemplate, "shard", "", "Shard destination names by a template, e.g. '{hash:2}/{hash:2:2}/{path}'")
+	flag.StringVar(&o.ShardHash, "shard-hash", "md5", "Hash function for sharding (md5, sha1, sha256, fnv)")
+	flag.StringVar(&o.ShardManifest, "shard-manifest", "", "Path of the shard manifest (default <destination>/.psync-manifest)")
+	flag.Var(&filterFrom, "filter-from", "Read rsync filter rules from the given file (may be repeated)")
+	flag.Var(&excludeFrom, "exclude-from", "Read rsync exclude patterns from the given file (may be repeated)")
+	flag.StringVar(&o.OnlyType, "only-type", "", "Copy only entries of these types, like with find -type: f, d, l, b, c, p or s, separated by commas")
+	flag.BoolVar(&o.SkipSymlinks, "skip-symlinks", false, "Do not copy symbolic links")
+	flag.StringVar(&o.Traversal, "traversal", "dfs", "Traverse the tree depth-first (dfs) or breadth-first (bfs)")
+	flag.StringVar(&o.Order, "order", "", "Copy the files of each directory by size: small or large first")
+	flag.BoolVar(&o.Deterministic, "deterministic", false, "Copy with a single thread, in the order of the names, for reproducible runs")
+	flag.BoolVar(&o.Fsync, "fsync", false, "Sync each destination file to the storage after copying it")
+	flag.BoolVar(&o.FsyncDir, "fsync-dir", false, "Sync each destination directory to the storage after creating its entries")
+	flag.BoolVar(&o.Preallocate, "preallocate", false, "Allocate the blocks of destination files before copying (Linux only)")
+	flag.BoolVar(&o.CopyRange, "copy-range", false, "Copy the content of local files in the kernel, with copy_file_range (Linux only)")
+	flag.BoolVar(&o.Sparse, "sparse", false, "Leave blocks of zeros as holes in the destination files")
+	flag.UintVar(&o.Prefetch, "prefetch", 0, "Number of scanners reading directories ahead of the copy threads, 0 to disable")
+	flag.UintVar(&o.MaxQueue, "max-queue", 0, "Maximum number of directories waiting in the work list, 0 for no limit")
+	flag.UintVar(&o.Spill, "spill", 0, "Move parts of the work list to a temporary file beyond this many directories, 0 to disable")
+	flag.StringVar(&o.DropCache, "drop-cache", "", "Drop the copied files from the page cache: source, dest or both")
+	flag.BoolVar(&o.SizeOnly, "size-only", false, "Copy only files which are missing or differ in size, implies -sync")
+	flag.BoolVar(&o.IgnoreTimes, "ignore-times", false, "Copy all files, also those which look up to date; with -verify, do not compare the modification times")
+	flag.BoolVar(&o.Update, "update", false, "Keep destination files which are newer than their source files, implies -sync")
+	flag.BoolVar(&o.Interactive, "interactive", false, "Ask before overwriting destination files which differ, implies -sync")
+	flag.BoolVar(&o.Estimate, "estimate", false, "Scan the source first, for the percentage done and the time left in the progress output")
+	flag.BoolVar(&o.Sync, "sync", false, "Sync mode, copy only entries which are missing or differ in size or mtime")
+	flag.UintVar(&o.IOPS, "iops", 0, "Maximum number of metadata operations (open, create, stat, ...) per second, 0 for no limit")
+	flag.StringVar(&o.Checkpoint, "checkpoint", "", "Checkpoint file written on SIGINT/SIGTERM (default <destination>/.psync-checkpoint)")
+	flag.BoolVar(&o.SinceLast, "since-last", false, "Skip files older than the start of the last successful run")
+	flag.StringVar(&o.StateFile, "state", "", "State file of the last successful run (default <destination>/.psync-state)")
+	flag.StringVar(&o.StateDB, "state-db", "", "Record the synced entries in this database, and skip unchanged source entries in the next run")
+	flag.BoolVar(&o.Watch, "watch", false, "Keep running after the copy, and replay the changes of a local source to the destination")
+	flag.DurationVar(&o.Interval, "interval", 0, "Repeat the sync after this duration (e.g. 15m) until interrupted, copying only changed entries")
+	flag.StringVar(&o.CaseCollisions, "case-collisions", "skip", "Handling of source names differing only in case on case-insensitive destinations: skip or rename")
+	flag.StringVar(&o.Normalize, "normalize", "", "Match names in other Unicode normalization forms (match), and write new names as nfc or nfd")
+	flag.BoolVar(&o.TwoWay, "two-way", false, "Propagate the changes of both trees since the last two-way run to the other tree")
+	flag.StringVar(&o.Resolve, "resolve", "newer", "Handling of entries changed in both trees with -two-way: newer, rename or skip")
+	flag.BoolVar(&o.Dedup, "dedup", false, "Hard-link files with identical content on the destination instead of copying them again")
+	flag.Var(&linkDest, "link-dest", "Hard-link unchanged files from this reference directory, relative to the destination (may be repeated)")
+	flag.BoolVar(&o.Resume, "resume", false, "Resume an interrupted run from the checkpoint file")
+	flag.BoolVar(&o.KeepAtime, "keep-atime", false, "Restore the access times of the source directories after reading them")
+	flag.UintVar(&o.Retries, "retries", 0, "Number of retries on transient errors (ESTALE, EIO, ETIMEDOUT, ...)")
+	flag.DurationVar(&o.RetryDelay, "retry-delay", time.Second, "Initial delay between retries, doubled with each attempt")
+	flag.StringVar(&o.ErrorsTo, "errors-to", "", "Write the failed paths with their errors to this file")
+	flag.StringVar(&o.FilesFrom, "files-from", "", "Copy only the paths listed in this file, - for STDIN")
+	flag.BoolVar(&o.From0, "from0", false, "The paths of -files-from are separated by NUL characters, like the output of find -print0")
+	flag.BoolVar(&o.Lock, "lock", false, "Take a lease on the destination directory, so that concurrent runs wait")
+	flag.DurationVar(&o.LockTimeout, "lock-timeout", 2*time.Minute, "Take over a lease without heartbeat for this duration")
+	flag.UintVar(&o.NFSConns, "nfs-conns", 4, "Number of TCP connections to an nfs:// source")
+	flag.StringVar(&o.SSH, "ssh", "ssh", "ssh command with options for remote trees, e.g. 'ssh -i key -l user'")
+	flag.UintVar(&o.SFTPConns, "sftp-conns", 4, "Number of SFTP sessions to a remote tree")
+	flag.StringVar(&o.S3Endpoint, "s3-endpoint", "", "URL of an S3 compatible service for s3:// trees, e.g. http://minio:9000")
+	flag.BoolVar(&o.Compress, "compress", false, "Compress the traffic to remote trees and host::module trees")
+	flag.BoolVar(&o.Delta, "delta", false, "Transfer only the changed blocks of changed files to host::module destinations")
+	flag.BoolVar(&o.Delta, "no-whole-file", false, "Same as -delta")
+	flag.BoolVar(&o.WholeFile, "whole-file", false, "Copy changed files as a whole, also to host::module destinations")
+	flag.StringVar(&o.SecretFile, "secret-file", "", "File with the shared secret for host::module trees (default: $PSYNC_SECRET)")
+	flag.BoolVar(&o.Strict, "strict", false, "Abort the run on the first error")
+	flag.Uint64Var(&o.MaxErrors, "max-errors", 0, "Abort after more than this number of errors, 0 for no limit")
+	flag.StringVar(&o.ExistingLinks, "existing-links", "warn", "Handling of existing links pointing elsewhere: warn, skip or replace")
+	flag.BoolVar(&o.Verify, "verify", false, "Compare the trees, and report missing, extra and differing entries without copying")
+	flag.BoolVar(&o.VerifyAfter, "verify-after", false, "Read each copied file back, and compare its checksum with the source")
+	flag.StringVar(&o.Unstable, "unstable", "", "Check whether source files changed while copying them, and skip or retry such files")
+	flag.StringVar(&o.Checksum, "checksum-choice", "sha256", "Hash function for checksums of files and chunks: sha256, sha512, blake3, xxh3, sha1, md5, crc32c")
+	flag.StringVar(&o.Conflicts, "conflicts", "first", "Handling of entries in several sources: first, last or error")
+	flag.Parse()
 
-				// submit directory to work queue
-				wg.Add(1)
-				dch <- dir + "/" + fname
-			} else {
-				// copy file sequentially
-				if verbose {
-					fmt.Printf("[%d] Copying %s%s/%s to %s%s/%s\n",
-						id, src, dir, fname, dest, dir, fname)
-				}
-				copyFile(id, dir+"/"+fname, f)
-			}
-		}
-		finfo, err := os.Stat(src + dir)
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - could not read fileinfo of directory %s: %s\n",
-					dest+dir, err)
-			}
-		} else {
-			// preserve user and group of the destination directory
-			if owner {
-				preserveOwner(dest+dir, finfo, "directory")
-			}
-			// setting the timestamps of the destination directory
-			if times {
-				preserveTimes(dest+dir, finfo, "directory")
-			}
-		}
-		if verbose {
-			fmt.Printf("[%d] Finished directory %s%s\n", id, src, dir)
+	if flag.NArg() < 2 || o.Threads > 1024 || o.DataThreads > 1024 || o.Prefetch > 1024 {
+		usage()
+	}
+	for _, arg := range flag.Args() {
+		if arg == "" {
+			usage()
 		}
-		wg.Done()
 	}
-}
-
-// Function copyFile copies a file from the source to the destination directory.
-func copyFile(id uint, file string, f os.FileInfo) {
-	mode := f.Mode()
+	if o.Events != "" && o.EventsFd > 0 {
+		usage()
+	}
+	if o.ExistingLinks != "warn" && o.ExistingLinks != "skip" && o.ExistingLinks != "replace" {
+		usage()
+	}
+	if o.Conflicts != "first" && o.Conflicts != "last" && o.Conflicts != "error" {
+		usage()
+	}
+	if o.Traversal != "dfs" && o.Traversal != "bfs" {
+		usage()
+	}
+	if o.Order != "" && o.Order != "small" && o.Order != "large" {
+		usage()
+	}
+	if o.DropCache != "" && o.DropCache != "source" && o.DropCache != "dest" && o.DropCache != "both" {
+		usage()
+	}
+	if o.Lock && o.LockTimeout <= 0 {
+		usage()
+	}
 	switch {
-
-	case mode&os.ModeSymlink != 0: // symbolic link
-		// read link
-		link, err := os.Readlink(src + file)
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - link %s disappeared while copying %s\n", src+file, err)
-			}
-			return
-		}
-
-		// write link to destination
-		err = os.Symlink(link, dest+file)
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - link %s could not be created: %s\n", dest+file, err)
-			}
-			return
-		}
-
-		// preserve owner of symbolic link
-		if owner {
-			preserveOwner(dest+file, f, "link")
-		}
-		// preserving the timestamps of links seems not be supported in Go
-		// TODO: it should be possible by using the futimesat system call,
-		// see https://github.com/golang/go/issues/3951
-		//if times {
-		//	preserveTimes(dest+file, f, "link")
-		//}
-
-	case mode&(os.ModeDevice|os.ModeNamedPipe|os.ModeSocket) != 0: // special files
-		// TODO: not yet implemented
-		fmt.Fprintf(os.Stderr, "WARNING - %s: syncing of UNIX special files is not implemented yet.\n", src+file)
-
-	default:
-		// copy regular file
-		// open source file for reading
-		rd, err := os.Open(src + file)
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - file %s disappeared while copying: %s\n", src+file, err)
-			}
-			return
-		}
-		defer rd.Close()
-
-		// open destination file for writing
-		perm := mode.Perm()
-		wr, err := os.OpenFile(dest+file, os.O_WRONLY|os.O_CREATE, perm)
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - file %s could not be created: %s\n", dest+file, err)
-			}
-			return
-		}
-		defer wr.Close()
-
-		// copy data
-		_, err = io.CopyBuffer(wr, rd, buffer[id][:])
-		if err != nil {
-			if !quiet {
-				fmt.Fprintf(os.Stderr, "WARNING - file %s could not be created: %s\n", dest+file, err)
-			}
-			return
-		}
-
-		if owner {
-			preserveOwner(dest+file, f, "file")
-		}
-		if times {
-			preserveTimes(dest+file, f, "file")
-		}
-
+	case v3 || vfull:
+		o.Verbose = 3
+	case v2:
+		o.Verbose = 2
+	case v1:
+		o.Verbose = 1
 	}
+	o.NoPerms = o.NoPerms || !perms
+	o.FilterFrom = filterFrom
+	o.ExcludeFrom = excludeFrom
+	o.LinkDest = linkDest
+	n := flag.NArg()
+	o.Source = flag.Arg(0)
+	o.Sources = flag.Args()[1 : n-1]
+	o.Destination = flag.Arg(n - 1)
+	return o, stats
 }
 
-// Function preserveOwner transfers the ownership information from the source to
-// the destination file/directory.
-func preserveOwner(name string, f os.FileInfo, ftype string) {
-	if stat, ok := f.Sys().(*syscall.Stat_t); ok {
-		uid := int(stat.Uid)
-		gid := int(stat.Gid)
+// Type stringList is a command line flag which can be given several times.
+type stringList []string
 
-		var err error
-		if ftype == "link" {
-			err = syscall.Lchown(name, uid, gid)
-		} else {
-			err = os.Chown(name, uid, gid)
-		}
+// Method String returns the values of the flag, separated by commas.
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
 
-		if err != nil && !quiet {
-			fmt.Fprintf(os.Stderr, "WARNING - could not change ownership of %s %s: %s\n",
-				ftype, name, err)
-		}
-	}
+// Method Set adds a value to the flag.
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
 }
 
-// Function preserveTimes transfers the access and modification timestamp from
-// the source to the destination file/directory.
-func preserveTimes(name string, f os.FileInfo, ftype string) {
-	mtime := f.ModTime()
-	atime := mtime
-	if stat, ok := f.Sys().(*syscall.Stat_t); ok {
-		atime = time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
-	}
-	err := os.Chtimes(name, atime, mtime)
-	if err != nil && !quiet {
-		fmt.Fprintf(os.Stderr, "WARNING - could not change timestamps for %s %s: %s\n",
-			ftype, name, err)
-	}
+// Function usage prints a message about how to use psync, and exits.
+func usage() {
+	fmt.Println("Usage: psync [options] source [source ...] destination")
+	flag.Usage()
+	os.Exit(1)
+}
+
+// Function interrupts waits for SIGINT or SIGTERM. On the first signal, no
+// more directories are handed out to the copy threads, but the files in
+// progress are finished. On the second signal, psync exits immediately.
+func interrupts(cancel context.CancelFunc) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigs
+	fmt.Fprintf(os.Stderr, "Received %s, finishing the files in progress. Repeat to abort immediately.\n", sig)
+	cancel()
+	sig = <-sigs
+	fmt.Fprintf(os.Stderr, "ERROR - received %s, aborting.\n", sig)
+	os.Exit(1)
 }
@@ -0,0 +1,71 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hweidner/psync/pkg/treetest"
+)
+
+// Function selftest implements the subcommand "psync selftest", an end to
+// end test of the copy engine with the tree generator of package treetest,
+// which the tests of package psync use as well. It generates a random source
+// tree with directories, files, sparse files, symbolic links, unusual names,
+// modes, timestamps and, when run as root, owners, copies it with the engine,
+// and compares the destination with the source byte for byte and attribute
+// for attribute, like cp -a and rsync -a would leave it. A second run in sync
+// mode must find nothing to copy. The trees are removed if the test passes,
+// and kept for inspection otherwise.
+func selftest(args []string) {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	seed := fs.Int64("seed", 0, "Seed of the random tree, to repeat a failed test (default: current time)")
+	entries := fs.Uint("entries", 2000, "Number of entries of the random tree")
+	threads := fs.Uint("threads", 16, "Number of threads of the copy")
+	tmp := fs.String("temp", "", "Directory for the trees (default: system temp directory)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: psync selftest [options]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 || *threads == 0 || *threads > 1024 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	root, err := ioutil.TempDir(*tmp, "psync-selftest")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - cannot create test directory: %s\n", err)
+		os.Exit(1)
+	}
+	src, dst := filepath.Join(root, "src"), filepath.Join(root, "dst")
+	fmt.Printf("Testing in %s with seed %d\n", root, *seed)
+
+	st, diffs, err := treetest.Run(src, dst, *seed, int(*entries), *threads)
+	if st.Dirs > 0 {
+		fmt.Printf("Copied %d directories, %d files and %d links\n", st.Dirs, st.Files, st.Links)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR - %s\nThe trees are kept in %s.\n", err, root)
+		os.Exit(1)
+	}
+	if len(diffs) > 0 {
+		for _, d := range diffs {
+			fmt.Println(d)
+		}
+		fmt.Printf("FAILED - %d differences, the trees are kept in %s.\n", len(diffs), root)
+		os.Exit(1)
+	}
+	treetest.Remove(root)
+	fmt.Println("PASSED")
+}
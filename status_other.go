@@ -0,0 +1,26 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hweidner/psync/pkg/psync"
+)
+
+// Function statusSignal prints the statistics of the copy threads to STDERR
+// each time the process receives SIGUSR1.
+func statusSignal(s *psync.Syncer) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	for range sigs {
+		s.ReportWorkers(os.Stderr)
+	}
+}
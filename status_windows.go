@@ -0,0 +1,10 @@
+// Copyright 2018-2020 by Harald Weidner <hweidner@gmx.net>. All rights reserved.
+// Use of this source code is governed by the GNU General Public License
+// Version 3 that can be found in the LICENSE.txt file.
+
+package main
+
+import "github.com/hweidner/psync/pkg/psync"
+
+// Function statusSignal does nothing, Windows has no SIGUSR1.
+func statusSignal(s *psync.Syncer) {}